@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"assetio/config"
+	"assetio/internal/adapters/repository"
+	"assetio/internal/domain"
+	stockSrv "assetio/internal/usecase/stock"
+)
+
+const (
+	CONFIG_FILE_PATH = `../../config/yaml/`
+	CONFIG_FILE_NAME = `app_config`
+	CONFIG_FILE_TYPE = `yaml`
+)
+
+// main checks inventories, ledgers and transactions against each other and prints a reconciliation report.
+// It exits with status 1 when an issue is found, so it can run from cron or CI.
+//
+//	check [-config dir] [-account id] [-json]
+func main() {
+	configPath := flag.String("config", CONFIG_FILE_PATH, "directory holding app_config.yaml")
+	accountId := flag.Int("account", 0, "check only this account; every account when zero")
+	asJson := flag.Bool("json", false, "print the report as JSON")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: check [-config dir] [-account id] [-json]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Load the same configuration the server uses so the command targets the same database.
+	appConfigIns, err := config.StartConfig(*configPath, config.File{
+		Name: CONFIG_FILE_NAME,
+		Ext:  CONFIG_FILE_TYPE,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	repo, err := repository.New(appConfigIns)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	report, err := stockSrv.Check(context.Background(), repo, domain.ClientStockConsistencyRequest{AccountId: *accountId})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *asJson {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		for _, issue := range report.Issues {
+			fmt.Printf("%-20s account %d stock %d inventory %d ledger %d transaction %d: %s\n",
+				issue.Type, issue.AccountId, issue.StockId, issue.InventoryId, issue.LedgerId, issue.TransactionId, issue.Message)
+		}
+		fmt.Printf("checked %d inventories, %d ledger entries, %d transactions: %d issues\n",
+			report.InventoriesChecked, report.LedgersChecked, report.TransactionsChecked, len(report.Issues))
+	}
+
+	if !report.Consistent {
+		os.Exit(1)
+	}
+}
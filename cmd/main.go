@@ -7,15 +7,22 @@ import (
 	"assetio/internal/port"
 	"context"
 	"log"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
 
 	"assetio/internal/adapters/handler/validator"
 	"assetio/internal/adapters/middleware"
 
+	cacheLru "assetio/internal/adapters/cache/lru"
 	cipherAes "assetio/internal/adapters/cipher/aes"
 	handler "assetio/internal/adapters/handler/http/v1"
 	loggerZap "assetio/internal/adapters/logger/zapLogger"
-	repositoryMysql "assetio/internal/adapters/repository/mysql"
+	"assetio/internal/adapters/repository"
+	repositoryCached "assetio/internal/adapters/repository/cached"
 	routerGin "assetio/internal/adapters/router/gin"
+	searchInverted "assetio/internal/adapters/search/inverted"
 	tokenEngineJwt "assetio/internal/adapters/tokenEngine/jwt"
 
 	accountSrv "assetio/internal/usecase/account"
@@ -27,6 +34,8 @@ const (
 	CONFIG_FILE_PATH = `../config/yaml/`
 	CONFIG_FILE_NAME = `app_config`
 	CONFIG_FILE_TYPE = `yaml`
+
+	CACHE_STATS_INTERVAL = time.Minute
 )
 
 // main function is the entry point of the application.
@@ -62,21 +71,40 @@ func main() {
 	validatorIns := validator.New()
 
 	// Get a database instance and initialize it with the app's database configuration.
-	mysqlIns, err := getDatabase(appConfigIns)
+	mysqlIns, err := repository.New(appConfigIns)
 	if err != nil {
 		// If there is an error getting the database instance, log the error and stop the execution.
 		log.Println(err)
 		return
 	}
-	// Automatically migrate the database schema if needed.
-	mysqlIns.AutoMigrate()
+
+	// Refuse to serve against a schema older than this binary expects; migrations are applied with cmd/migrate.
+	schemaVersion, expectedVersion, err := mysqlIns.SchemaVersion(context.Background())
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if schemaVersion < expectedVersion {
+		log.Printf("database schema is at version %d but version %d is required; run `migrate up` first", schemaVersion, expectedVersion)
+		return
+	}
+
+	// Serve security master reads from the in-process cache when it is enabled in the config.
+	if enabled, maxCapacity, expiry := appConfigIns.GetStoreCacheHeapProperties(); enabled {
+		cacheIns := cacheLru.New(maxCapacity, time.Duration(expiry)*time.Second)
+		mysqlIns = repositoryCached.New(mysqlIns, cacheIns)
+		go logCacheStats(appLoggerIns, cacheIns)
+	}
+
+	// Rank security searches from an in-process index over the security master.
+	searcherIns := searchInverted.New(mysqlIns, time.Duration(appConfigIns.GetStoreSearchRefresh())*time.Second)
 
 	marketerIns := yahoo.New(appConfigIns.GetYahooExchangeHash())
 
 	// Create instances of different services (Account, Security, Stock).
 	accountSrvIns := accountSrv.New(appLoggerIns, mysqlIns)
-	securitySrvIns := securitySrv.New(appLoggerIns, mysqlIns)
-	stockSrvIns := stockSrv.New(appLoggerIns, mysqlIns, marketerIns)
+	securitySrvIns := securitySrv.New(appLoggerIns, mysqlIns, searcherIns)
+	stockSrvIns := stockSrv.New(appLoggerIns, mysqlIns, marketerIns, getChargeRates(appConfigIns))
 
 	// Create a service list that contains all the service instances for easy access.
 	svcList := domain.List{
@@ -121,39 +149,36 @@ func getLogger(logConfigIns config.Logger) (port.Logger, error) {
 	return loggerZap.New(loggerConfig)
 }
 
-// getDatabase is a helper function to set up and return a database instance.
-func getDatabase(appConfigIns config.App) (port.RepositoryStore, error) {
-	// Retrieve the crypto key used for decryption from the configuration.
-	cipherCryptoKey := appConfigIns.GetCipherCryptoKey()
-	// Initialize the AES cipher instance for decryption.
-	cipherIns := cipherAes.New(cipherCryptoKey)
-
-	// Retrieve the encrypted database connection properties.
-	encryptDbHost, encryptDbPort, encryptDbUsename, encryptDbPasword, dbName, prefix := appConfigIns.GetStoreDatabaseProperties()
-
-	// Decrypt each property using the cipher instance.
-	decryptDbHost, decryptErr := cipherIns.Decrypt(encryptDbHost)
-	if decryptErr != nil {
-		return nil, decryptErr
-	}
-
-	decryptdbPort, decryptErr := cipherIns.Decrypt(encryptDbPort)
-	if decryptErr != nil {
-		return nil, decryptErr
-	}
-
-	decryptDbUsename, decryptErr := cipherIns.Decrypt(encryptDbUsename)
-	if decryptErr != nil {
-		return nil, decryptErr
-	}
+// getChargeRates converts the configured trade charge rate tables into the decimals the stock service works with.
+func getChargeRates(appConfigIns config.App) map[string]domain.ChargeRates {
+	chargeRates := map[string]domain.ChargeRates{}
+	for exchange, rates := range appConfigIns.GetChargeRates() {
+		chargeRates[strings.ToLower(exchange)] = domain.ChargeRates{
+			BrokeragePercent: decimal.NewFromFloat(rates.BrokeragePercent),
+			BrokerageMax:     decimal.NewFromFloat(rates.BrokerageMax),
+			SttBuyPercent:    decimal.NewFromFloat(rates.SttBuyPercent),
+			SttSellPercent:   decimal.NewFromFloat(rates.SttSellPercent),
+			ExchangePercent:  decimal.NewFromFloat(rates.ExchangePercent),
+			SebiPercent:      decimal.NewFromFloat(rates.SebiPercent),
+			StampBuyPercent:  decimal.NewFromFloat(rates.StampBuyPercent),
+			GstPercent:       decimal.NewFromFloat(rates.GstPercent),
+		}
+	}
+	return chargeRates
+}
 
-	decryptDbPasword, decryptErr := cipherIns.Decrypt(encryptDbPasword)
-	if decryptErr != nil {
-		return nil, decryptErr
+// logCacheStats writes the cache counters to the app log every CACHE_STATS_INTERVAL for as long as the app runs.
+func logCacheStats(appLoggerIns port.Logger, cacheIns port.Cache) {
+	for range time.Tick(CACHE_STATS_INTERVAL) {
+		stats := cacheIns.Stats()
+		appLoggerIns.Infow(context.Background(), "cache stats",
+			"hits", stats.Hits,
+			"misses", stats.Misses,
+			"evictions", stats.Evictions,
+			"expirations", stats.Expirations,
+			"entries", stats.Entries,
+		)
 	}
-
-	// Return the database instance after successful decryption and initialization.
-	return repositoryMysql.New(decryptDbHost, decryptdbPort, decryptDbUsename, decryptDbPasword, dbName, prefix)
 }
 
 // getRouter is a helper function to create and configure the router for handling HTTP requests.
@@ -325,6 +350,12 @@ func updateStockRouters(generalGr port.RouterGroup, accessTokenGr port.RouterGro
 		accessTokenGr.RegisterRoute(apiMethod, apiRoute, handlerIns.StockSummary)
 	}
 
+	// Register route for fetching the stock portfolio totals if enabled in the config.
+	if apiConfigIns.GetStockSummaryTotalsEnabled() {
+		apiMethod, apiRoute := apiConfigIns.GetStockSummaryTotalsProperties()
+		accessTokenGr.RegisterRoute(apiMethod, apiRoute, handlerIns.StockSummaryTotals)
+	}
+
 	// Register route for fetching stock inventories if enabled in the config.
 	if apiConfigIns.GetStockInventorieslEnabled() {
 		apiMethod, apiRoute := apiConfigIns.GetStockInventoriesProperties()
@@ -336,4 +367,95 @@ func updateStockRouters(generalGr port.RouterGroup, accessTokenGr port.RouterGro
 		apiMethod, apiRoute := apiConfigIns.GetStockInventoryLedgersProperties()
 		accessTokenGr.RegisterRoute(apiMethod, apiRoute, handlerIns.StockInventoryLedgers)
 	}
+
+	// Register route for voiding a buy or sell if enabled in the config.
+	if apiConfigIns.GetTransactionVoidEnabled() {
+		apiMethod, apiRoute := apiConfigIns.GetTransactionVoidProperties()
+		accessTokenGr.RegisterRoute(apiMethod, apiRoute, handlerIns.TransactionVoid)
+	}
+
+	// Register route for editing a buy, sell or dividend if enabled in the config.
+	if apiConfigIns.GetTransactionUpdateEnabled() {
+		apiMethod, apiRoute := apiConfigIns.GetTransactionUpdateProperties()
+		accessTokenGr.RegisterRoute(apiMethod, apiRoute, handlerIns.TransactionUpdate)
+	}
+
+	// Register route for the realized profit and loss report if enabled in the config.
+	if apiConfigIns.GetStockRealizedEnabled() {
+		apiMethod, apiRoute := apiConfigIns.GetStockRealizedProperties()
+		accessTokenGr.RegisterRoute(apiMethod, apiRoute, handlerIns.StockRealized)
+	}
+
+	// Register route for the capital gains report if enabled in the config.
+	if apiConfigIns.GetStockCapitalGainsEnabled() {
+		apiMethod, apiRoute := apiConfigIns.GetStockCapitalGainsProperties()
+		accessTokenGr.RegisterRoute(apiMethod, apiRoute, handlerIns.StockCapitalGains)
+	}
+
+	// Register route for the XIRR and CAGR returns report if enabled in the config.
+	if apiConfigIns.GetStockReturnsEnabled() {
+		apiMethod, apiRoute := apiConfigIns.GetStockReturnsProperties()
+		accessTokenGr.RegisterRoute(apiMethod, apiRoute, handlerIns.StockReturns)
+	}
+
+	// Register route for the holdings report if enabled in the config.
+	if apiConfigIns.GetStockHoldingsEnabled() {
+		apiMethod, apiRoute := apiConfigIns.GetStockHoldingsProperties()
+		accessTokenGr.RegisterRoute(apiMethod, apiRoute, handlerIns.StockHoldings)
+	}
+
+	// Register route for the charges calculator if enabled in the config.
+	if apiConfigIns.GetStockChargesCalculateEnabled() {
+		apiMethod, apiRoute := apiConfigIns.GetStockChargesCalculateProperties()
+		accessTokenGr.RegisterRoute(apiMethod, apiRoute, handlerIns.StockChargesCalculate)
+	}
+
+	// Register route for the charges report if enabled in the config.
+	if apiConfigIns.GetStockChargesEnabled() {
+		apiMethod, apiRoute := apiConfigIns.GetStockChargesProperties()
+		accessTokenGr.RegisterRoute(apiMethod, apiRoute, handlerIns.StockCharges)
+	}
+
+	// Register route for the rights entitlement API if enabled in the config.
+	if apiConfigIns.GetStockRightsEntitleEnabled() {
+		apiMethod, apiRoute := apiConfigIns.GetStockRightsEntitleProperties()
+		accessTokenGr.RegisterRoute(apiMethod, apiRoute, handlerIns.StockRightsEntitle)
+	}
+
+	// Register route for the rights renunciation API if enabled in the config.
+	if apiConfigIns.GetStockRightsRenounceEnabled() {
+		apiMethod, apiRoute := apiConfigIns.GetStockRightsRenounceProperties()
+		accessTokenGr.RegisterRoute(apiMethod, apiRoute, handlerIns.StockRightsRenounce)
+	}
+
+	// Register route for the rights subscription API if enabled in the config.
+	if apiConfigIns.GetStockRightsSubscribeEnabled() {
+		apiMethod, apiRoute := apiConfigIns.GetStockRightsSubscribeProperties()
+		accessTokenGr.RegisterRoute(apiMethod, apiRoute, handlerIns.StockRightsSubscribe)
+	}
+
+	// Register route for the rights call API if enabled in the config.
+	if apiConfigIns.GetStockRightsCallEnabled() {
+		apiMethod, apiRoute := apiConfigIns.GetStockRightsCallProperties()
+		accessTokenGr.RegisterRoute(apiMethod, apiRoute, handlerIns.StockRightsCall)
+	}
+
+	// Register route for the buyback API if enabled in the config.
+	if apiConfigIns.GetStockBuybackEnabled() {
+		apiMethod, apiRoute := apiConfigIns.GetStockBuybackProperties()
+		accessTokenGr.RegisterRoute(apiMethod, apiRoute, handlerIns.StockBuyback)
+	}
+
+	// Register the admin route for rebuilding inventories from their ledgers if enabled in the config.
+	// It spans accounts, so it is guarded by the API key rather than a user's access token.
+	if apiConfigIns.GetStockRebuildEnabled() {
+		apiMethod, apiRoute := apiConfigIns.GetStockRebuildProperties()
+		generalGr.RegisterRoute(apiMethod, apiRoute, handlerIns.StockRebuild)
+	}
+
+	// Register the admin route for the consistency report if enabled in the config.
+	if apiConfigIns.GetStockConsistencyEnabled() {
+		apiMethod, apiRoute := apiConfigIns.GetStockConsistencyProperties()
+		generalGr.RegisterRoute(apiMethod, apiRoute, handlerIns.StockConsistency)
+	}
 }
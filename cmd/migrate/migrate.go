@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"assetio/config"
+	"assetio/internal/adapters/repository"
+)
+
+const (
+	CONFIG_FILE_PATH = `../../config/yaml/`
+	CONFIG_FILE_NAME = `app_config`
+	CONFIG_FILE_TYPE = `yaml`
+)
+
+// main applies, reverts or lists the schema migrations of the configured database.
+//
+//	migrate [-config dir] up      apply every pending migration
+//	migrate [-config dir] down    revert the most recently applied migration
+//	migrate [-config dir] status  list every migration and whether it is applied
+func main() {
+	configPath := flag.String("config", CONFIG_FILE_PATH, "directory holding app_config.yaml")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: migrate [-config dir] up|down|status")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Load the same configuration the server uses so the command targets the same database.
+	appConfigIns, err := config.StartConfig(*configPath, config.File{
+		Name: CONFIG_FILE_NAME,
+		Ext:  CONFIG_FILE_TYPE,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	repo, err := repository.New(appConfigIns)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := context.Background()
+	switch flag.Arg(0) {
+	case "up":
+		applied, err := repo.MigrateUp(ctx)
+		for _, migration := range applied {
+			fmt.Printf("applied %04d %s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+	case "down":
+		reverted, err := repo.MigrateDown(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("reverted %04d %s\n", reverted.Version, reverted.Name)
+	case "status":
+		migrations, err := repo.MigrationStatus(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, migration := range migrations {
+			state := "pending"
+			if migration.Applied {
+				state = "applied"
+				if !migration.AppliedAt.IsZero() {
+					state += " " + migration.AppliedAt.Format("2006-01-02 15:04:05")
+				}
+			}
+			fmt.Printf("%04d %-30s %s\n", migration.Version, migration.Name, state)
+		}
+		current, expected, err := repo.SchemaVersion(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("schema version %d, binary expects %d\n", current, expected)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"assetio/config"
+	"assetio/internal/adapters/repository"
+	"assetio/internal/domain"
+	stockSrv "assetio/internal/usecase/stock"
+)
+
+const (
+	CONFIG_FILE_PATH = `../../config/yaml/`
+	CONFIG_FILE_NAME = `app_config`
+	CONFIG_FILE_TYPE = `yaml`
+)
+
+// main rebuilds inventories from their ledgers for an account, a stock, or both.
+//
+//	rebuild [-config dir] [-account id] [-stock id]          list the inventories that would change
+//	rebuild [-config dir] [-account id] [-stock id] -apply   write the rebuilt totals
+func main() {
+	configPath := flag.String("config", CONFIG_FILE_PATH, "directory holding app_config.yaml")
+	accountId := flag.Int("account", 0, "rebuild the inventories of this account")
+	stockId := flag.Int("stock", 0, "rebuild the inventories of this stock")
+	apply := flag.Bool("apply", false, "write the rebuilt totals instead of only reporting them")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: rebuild [-config dir] [-account id] [-stock id] [-apply]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 0 || (*accountId == 0 && *stockId == 0) {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Load the same configuration the server uses so the command targets the same database.
+	appConfigIns, err := config.StartConfig(*configPath, config.File{
+		Name: CONFIG_FILE_NAME,
+		Ext:  CONFIG_FILE_TYPE,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	repo, err := repository.New(appConfigIns)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := context.Background()
+	result, err := stockSrv.Rebuild(ctx, repo, domain.ClientStockRebuildRequest{
+		AccountId: *accountId,
+		StockId:   *stockId,
+		Apply:     *apply,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, inventory := range result.Inventories {
+		fmt.Printf("inventory %d (account %d, stock %d)\n", inventory.InventoryId, inventory.AccountId, inventory.StockId)
+		fmt.Printf("  quantity       %s -> %s\n", inventory.Stored.Quantity, inventory.Rebuilt.Quantity)
+		fmt.Printf("  average price  %s -> %s\n", inventory.Stored.AveragePrice, inventory.Rebuilt.AveragePrice)
+		fmt.Printf("  total value    %s -> %s\n", inventory.Stored.TotalValue, inventory.Rebuilt.TotalValue)
+	}
+
+	action := "would change"
+	if result.Applied {
+		action = "changed"
+	}
+	fmt.Printf("checked %d inventories, %d %s\n", result.Checked, len(result.Inventories), action)
+}
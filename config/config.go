@@ -25,11 +25,32 @@ type App interface {
 	// GetMiddlewareApiKeys returns the list of API keys used by the middleware for authorization.
 	GetMiddlewareApiKeys() []string
 
+	// GetStoreDatabaseDriver returns the configured database driver name.
+	GetStoreDatabaseDriver() string
+
 	// GetStoreDatabaseProperties returns the database connection details (host, port, username, password, database name, and prefix).
 	GetStoreDatabaseProperties() (string, string, string, string, string, string)
 
-	// GetStoreCacheHeapProperties returns cache heap properties (enabled status and expiry time).
-	GetStoreCacheHeapProperties() (bool, int)
+	// GetStoreDatabaseSslMode returns the sslmode used when connecting to postgres.
+	GetStoreDatabaseSslMode() string
+
+	// GetStoreDatabaseMysqlOptions returns the mysql DSN options (TLS mode, timezone, and dial, read and write timeouts in seconds).
+	GetStoreDatabaseMysqlOptions() (string, string, int, int, int)
+
+	// GetStoreDatabasePoolProperties returns the connection pool limits (max open, max idle, max lifetime and max idle time in seconds).
+	GetStoreDatabasePoolProperties() (int, int, int, int)
+
+	// GetStoreDatabaseReplicas returns the encrypted addresses of the database read replicas.
+	GetStoreDatabaseReplicas() []Replica
+
+	// GetStoreDatabaseSqliteProperties returns the sqlite database file path and table prefix.
+	GetStoreDatabaseSqliteProperties() (string, string)
+
+	// GetStoreCacheHeapProperties returns cache heap properties (enabled status, maximum capacity and expiry time).
+	GetStoreCacheHeapProperties() (bool, int, int)
+
+	// GetStoreSearchRefresh returns the number of seconds after which the security search index is reloaded.
+	GetStoreSearchRefresh() int
 
 	// GetAppLog returns the logger used for general application logging.
 	GetAppLog() Logger
@@ -41,6 +62,9 @@ type App interface {
 	GetApi() Api
 
 	GetYahooExchangeHash() map[string]string
+
+	// GetChargeRates returns the trade charge rate tables keyed by lower case exchange name.
+	GetChargeRates() map[string]ChargeRates
 }
 
 // StartConfig reads and processes the configuration file and returns an App instance or an error.
@@ -108,12 +132,62 @@ func (a app) GetStoreDatabaseProperties() (string, string, string, string, strin
 	return database.Host, database.Port, database.Username, database.Password, database.Name, database.Prefix
 }
 
-// GetStoreCacheHeapProperties returns the cache heap properties, such as whether the heap cache is enabled and its expiry time.
-func (a app) GetStoreCacheHeapProperties() (bool, int) {
+// GetStoreDatabaseDriver returns the configured database driver, falling back to mysql when none is set.
+func (a app) GetStoreDatabaseDriver() string {
+	if a.Store.Database.Driver == "" {
+		return "mysql"
+	}
+	return a.Store.Database.Driver
+}
+
+// GetStoreDatabaseSslMode returns the sslmode configured for postgres connections.
+func (a app) GetStoreDatabaseSslMode() string {
+	return a.Store.Database.SslMode
+}
+
+// GetStoreDatabaseMysqlOptions returns the TLS mode, timezone and the dial, read and write timeouts used in the mysql DSN.
+func (a app) GetStoreDatabaseMysqlOptions() (string, string, int, int, int) {
+	database := a.Store.Database // Retrieves the database configuration from the app's store settings
+
+	// Returns the DSN options, timeouts in seconds
+	return database.Tls, database.Timezone, database.Timeout, database.ReadTimeout, database.WriteTimeout
+}
+
+// GetStoreDatabasePoolProperties returns the connection pool limits: max open and idle connections, and the
+// maximum lifetime and idle time of a connection.
+func (a app) GetStoreDatabasePoolProperties() (int, int, int, int) {
+	pool := a.Store.Database.Pool // Retrieves the pool configuration from the database settings
+
+	// Returns the pool limits, durations in seconds
+	return pool.MaxOpenConns, pool.MaxIdleConns, pool.ConnMaxLifetime, pool.ConnMaxIdleTime
+}
+
+// GetStoreDatabaseReplicas returns the encrypted host and port of every configured read replica.
+func (a app) GetStoreDatabaseReplicas() []Replica {
+	return a.Store.Database.Replicas
+}
+
+// GetStoreDatabaseSqliteProperties returns the sqlite file path and the prefix used in table names.
+func (a app) GetStoreDatabaseSqliteProperties() (string, string) {
+	database := a.Store.Database // Retrieves the database configuration from the app's store settings
+
+	// Returns the sqlite file path and table prefix
+	return database.Path, database.Prefix
+}
+
+// GetStoreCacheHeapProperties returns the cache heap properties, such as whether the heap cache is enabled, the maximum
+// number of entries it holds and its expiry time.
+func (a app) GetStoreCacheHeapProperties() (bool, int, int) {
 	heapCache := a.Store.Cache.Heap // Retrieves the heap cache configuration from the app's store settings
 
-	// Returns whether the heap cache is enabled and the expiry time in seconds
-	return heapCache.Enabled, heapCache.Expiry
+	// Returns whether the heap cache is enabled, its maximum capacity and the expiry time in seconds
+	return heapCache.Enabled, heapCache.MaxCapacity, heapCache.Expiry
+}
+
+// GetStoreSearchRefresh returns the number of seconds after which the security search index is reloaded, so
+// securities written through other instances become searchable.
+func (a app) GetStoreSearchRefresh() int {
+	return a.Store.Search.Refresh
 }
 
 // GetApi returns the API configuration for the application.
@@ -124,3 +198,8 @@ func (a app) GetApi() Api {
 func (a app) GetYahooExchangeHash() map[string]string {
 	return a.Yahoo.ExchangeHash
 }
+
+// GetChargeRates returns the trade charge rate tables keyed by lower case exchange name.
+func (a app) GetChargeRates() map[string]ChargeRates {
+	return a.Charges
+}
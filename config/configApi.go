@@ -124,6 +124,12 @@ type Api interface {
 	// Returns the HTTP method and route for fetching stock summaries
 	GetStockSummaryProperties() (string, string)
 
+	// Returns whether the stock portfolio totals are enabled
+	GetStockSummaryTotalsEnabled() bool
+
+	// Returns the HTTP method and route for fetching the stock portfolio totals
+	GetStockSummaryTotalsProperties() (string, string)
+
 	// Returns whether the stock inventories feature is enabled
 	GetStockInventorieslEnabled() bool
 
@@ -135,6 +141,96 @@ type Api interface {
 
 	// Returns the HTTP method and route for fetching stock inventory ledgers
 	GetStockInventoryLedgersProperties() (string, string)
+
+	// Returns whether the stock inventory rebuild feature is enabled
+	GetStockRebuildEnabled() bool
+
+	// Returns the HTTP method and route for rebuilding stock inventories from their ledgers
+	GetStockRebuildProperties() (string, string)
+
+	// Returns whether the stock consistency report feature is enabled
+	GetStockConsistencyEnabled() bool
+
+	// Returns the HTTP method and route for the stock consistency report
+	GetStockConsistencyProperties() (string, string)
+
+	// Returns whether voiding transactions is enabled
+	GetTransactionVoidEnabled() bool
+
+	// Returns the HTTP method and route for voiding a transaction
+	GetTransactionVoidProperties() (string, string)
+
+	// Returns whether editing transactions is enabled
+	GetTransactionUpdateEnabled() bool
+
+	// Returns the HTTP method and route for editing a transaction
+	GetTransactionUpdateProperties() (string, string)
+
+	// Returns whether the realized profit and loss report is enabled
+	GetStockRealizedEnabled() bool
+
+	// Returns the HTTP method and route for the realized profit and loss report
+	GetStockRealizedProperties() (string, string)
+
+	// Returns whether the capital gains report is enabled
+	GetStockCapitalGainsEnabled() bool
+
+	// Returns the HTTP method and route for the capital gains report
+	GetStockCapitalGainsProperties() (string, string)
+
+	// Returns whether the returns report is enabled
+	GetStockReturnsEnabled() bool
+
+	// Returns the HTTP method and route for the returns report
+	GetStockReturnsProperties() (string, string)
+
+	// Returns whether the holdings report is enabled
+	GetStockHoldingsEnabled() bool
+
+	// Returns the HTTP method and route for the holdings report
+	GetStockHoldingsProperties() (string, string)
+
+	// Returns whether the charges calculator is enabled
+	GetStockChargesCalculateEnabled() bool
+
+	// Returns the HTTP method and route for the charges calculator
+	GetStockChargesCalculateProperties() (string, string)
+
+	// Returns whether the charges report is enabled
+	GetStockChargesEnabled() bool
+
+	// Returns the HTTP method and route for the charges report
+	GetStockChargesProperties() (string, string)
+
+	// Returns whether the rights entitlement API is enabled
+	GetStockRightsEntitleEnabled() bool
+
+	// Returns the HTTP method and route for the rights entitlement API
+	GetStockRightsEntitleProperties() (string, string)
+
+	// Returns whether the rights renunciation API is enabled
+	GetStockRightsRenounceEnabled() bool
+
+	// Returns the HTTP method and route for the rights renunciation API
+	GetStockRightsRenounceProperties() (string, string)
+
+	// Returns whether the rights subscription API is enabled
+	GetStockRightsSubscribeEnabled() bool
+
+	// Returns the HTTP method and route for the rights subscription API
+	GetStockRightsSubscribeProperties() (string, string)
+
+	// Returns whether the rights call API is enabled
+	GetStockRightsCallEnabled() bool
+
+	// Returns the HTTP method and route for the rights call API
+	GetStockRightsCallProperties() (string, string)
+
+	// Returns whether the buyback API is enabled
+	GetStockBuybackEnabled() bool
+
+	// Returns the HTTP method and route for the buyback API
+	GetStockBuybackProperties() (string, string)
 }
 
 // GetAccountCreateEnabled checks if account creation is enabled and returns a boolean.
@@ -357,6 +453,17 @@ func (a api) GetStockSummaryProperties() (string, string) {
 	return apiData.Method, apiData.Route
 }
 
+// GetStockSummaryTotalsEnabled checks if fetching the stock portfolio totals is enabled and returns a boolean.
+func (a api) GetStockSummaryTotalsEnabled() bool {
+	return a.StockSummaryTotals.Enabled
+}
+
+// GetStockSummaryTotalsProperties returns the HTTP method and route for fetching the stock portfolio totals.
+func (a api) GetStockSummaryTotalsProperties() (string, string) {
+	apiData := a.StockSummaryTotals
+	return apiData.Method, apiData.Route
+}
+
 // GetStockInventorieslEnabled checks if fetching stock inventories is enabled and returns a boolean.
 func (a api) GetStockInventorieslEnabled() bool {
 	return a.StockInventories.Enabled
@@ -378,3 +485,168 @@ func (a api) GetStockInventoryLedgersProperties() (string, string) {
 	apiData := a.StockInventoryLedgers
 	return apiData.Method, apiData.Route
 }
+
+// GetStockRebuildEnabled checks if rebuilding stock inventories is enabled and returns a boolean.
+func (a api) GetStockRebuildEnabled() bool {
+	return a.StockRebuild.Enabled
+}
+
+// GetStockRebuildProperties returns the HTTP method and route for rebuilding stock inventories.
+func (a api) GetStockRebuildProperties() (string, string) {
+	apiData := a.StockRebuild
+	return apiData.Method, apiData.Route
+}
+
+// GetStockConsistencyEnabled checks if the stock consistency report is enabled and returns a boolean.
+func (a api) GetStockConsistencyEnabled() bool {
+	return a.StockConsistency.Enabled
+}
+
+// GetStockConsistencyProperties returns the HTTP method and route for the stock consistency report.
+func (a api) GetStockConsistencyProperties() (string, string) {
+	apiData := a.StockConsistency
+	return apiData.Method, apiData.Route
+}
+
+// GetTransactionVoidEnabled checks if voiding transactions is enabled and returns a boolean.
+func (a api) GetTransactionVoidEnabled() bool {
+	return a.TransactionVoid.Enabled
+}
+
+// GetTransactionVoidProperties returns the HTTP method and route for voiding a transaction.
+func (a api) GetTransactionVoidProperties() (string, string) {
+	apiData := a.TransactionVoid
+	return apiData.Method, apiData.Route
+}
+
+// GetTransactionUpdateEnabled checks if editing transactions is enabled and returns a boolean.
+func (a api) GetTransactionUpdateEnabled() bool {
+	return a.TransactionUpdate.Enabled
+}
+
+// GetTransactionUpdateProperties returns the HTTP method and route for editing a transaction.
+func (a api) GetTransactionUpdateProperties() (string, string) {
+	apiData := a.TransactionUpdate
+	return apiData.Method, apiData.Route
+}
+
+// GetStockRealizedEnabled checks if the realized profit and loss report is enabled and returns a boolean.
+func (a api) GetStockRealizedEnabled() bool {
+	return a.StockRealized.Enabled
+}
+
+// GetStockRealizedProperties returns the HTTP method and route for the realized profit and loss report.
+func (a api) GetStockRealizedProperties() (string, string) {
+	apiData := a.StockRealized
+	return apiData.Method, apiData.Route
+}
+
+// GetStockCapitalGainsEnabled checks if the capital gains report is enabled and returns a boolean.
+func (a api) GetStockCapitalGainsEnabled() bool {
+	return a.StockCapitalGains.Enabled
+}
+
+// GetStockCapitalGainsProperties returns the HTTP method and route for the capital gains report.
+func (a api) GetStockCapitalGainsProperties() (string, string) {
+	apiData := a.StockCapitalGains
+	return apiData.Method, apiData.Route
+}
+
+// GetStockReturnsEnabled checks if the returns report is enabled and returns a boolean.
+func (a api) GetStockReturnsEnabled() bool {
+	return a.StockReturns.Enabled
+}
+
+// GetStockReturnsProperties returns the HTTP method and route for the returns report.
+func (a api) GetStockReturnsProperties() (string, string) {
+	apiData := a.StockReturns
+	return apiData.Method, apiData.Route
+}
+
+// GetStockHoldingsEnabled checks if the holdings report is enabled and returns a boolean.
+func (a api) GetStockHoldingsEnabled() bool {
+	return a.StockHoldings.Enabled
+}
+
+// GetStockHoldingsProperties returns the HTTP method and route for the holdings report.
+func (a api) GetStockHoldingsProperties() (string, string) {
+	apiData := a.StockHoldings
+	return apiData.Method, apiData.Route
+}
+
+// GetStockChargesCalculateEnabled checks if the charges calculator is enabled and returns a boolean.
+func (a api) GetStockChargesCalculateEnabled() bool {
+	return a.StockChargesCalculate.Enabled
+}
+
+// GetStockChargesCalculateProperties returns the HTTP method and route for the charges calculator.
+func (a api) GetStockChargesCalculateProperties() (string, string) {
+	apiData := a.StockChargesCalculate
+	return apiData.Method, apiData.Route
+}
+
+// GetStockChargesEnabled checks if the charges report is enabled and returns a boolean.
+func (a api) GetStockChargesEnabled() bool {
+	return a.StockCharges.Enabled
+}
+
+// GetStockChargesProperties returns the HTTP method and route for the charges report.
+func (a api) GetStockChargesProperties() (string, string) {
+	apiData := a.StockCharges
+	return apiData.Method, apiData.Route
+}
+
+// GetStockRightsEntitleEnabled checks if the rights entitlement API is enabled and returns a boolean.
+func (a api) GetStockRightsEntitleEnabled() bool {
+	return a.StockRightsEntitle.Enabled
+}
+
+// GetStockRightsEntitleProperties returns the HTTP method and route for the rights entitlement API.
+func (a api) GetStockRightsEntitleProperties() (string, string) {
+	apiData := a.StockRightsEntitle
+	return apiData.Method, apiData.Route
+}
+
+// GetStockRightsRenounceEnabled checks if the rights renunciation API is enabled and returns a boolean.
+func (a api) GetStockRightsRenounceEnabled() bool {
+	return a.StockRightsRenounce.Enabled
+}
+
+// GetStockRightsRenounceProperties returns the HTTP method and route for the rights renunciation API.
+func (a api) GetStockRightsRenounceProperties() (string, string) {
+	apiData := a.StockRightsRenounce
+	return apiData.Method, apiData.Route
+}
+
+// GetStockRightsSubscribeEnabled checks if the rights subscription API is enabled and returns a boolean.
+func (a api) GetStockRightsSubscribeEnabled() bool {
+	return a.StockRightsSubscribe.Enabled
+}
+
+// GetStockRightsSubscribeProperties returns the HTTP method and route for the rights subscription API.
+func (a api) GetStockRightsSubscribeProperties() (string, string) {
+	apiData := a.StockRightsSubscribe
+	return apiData.Method, apiData.Route
+}
+
+// GetStockRightsCallEnabled checks if the rights call API is enabled and returns a boolean.
+func (a api) GetStockRightsCallEnabled() bool {
+	return a.StockRightsCall.Enabled
+}
+
+// GetStockRightsCallProperties returns the HTTP method and route for the rights call API.
+func (a api) GetStockRightsCallProperties() (string, string) {
+	apiData := a.StockRightsCall
+	return apiData.Method, apiData.Route
+}
+
+// GetStockBuybackEnabled checks if the buyback API is enabled and returns a boolean.
+func (a api) GetStockBuybackEnabled() bool {
+	return a.StockBuyback.Enabled
+}
+
+// GetStockBuybackProperties returns the HTTP method and route for the buyback API.
+func (a api) GetStockBuybackProperties() (string, string) {
+	apiData := a.StockBuyback
+	return apiData.Method, apiData.Route
+}
@@ -27,12 +27,31 @@ type app struct {
 	Store struct {
 		// Database contains the properties for connecting to a database.
 		Database struct {
+			Driver   string `mapstructure:"driver"`   // Database driver ("mysql", "postgres", "sqlite" or "memory"); defaults to mysql.
+			Path     string `mapstructure:"path"`     // Database file path, used by the sqlite driver.
 			Host     string `mapstructure:"host"`     // Database host address.
 			Port     string `mapstructure:"port"`     // Database port.
 			Username string `mapstructure:"username"` // Database username.
 			Password string `mapstructure:"password"` // Database password.
 			Name     string `mapstructure:"name"`     // Database name.
 			Prefix   string `mapstructure:"prefix"`   // Prefix used in database tables.
+			SslMode  string `mapstructure:"sslmode"`  // SSL mode used by the postgres driver (defaults to "disable").
+
+			// The options below are used by the mysql driver.
+			Tls          string    `mapstructure:"tls"`           // TLS mode ("true", "false", "skip-verify" or "preferred").
+			Timezone     string    `mapstructure:"timezone"`      // IANA timezone of DATETIME values (defaults to the server's local zone).
+			Timeout      int       `mapstructure:"timeout"`       // Dial timeout in seconds.
+			ReadTimeout  int       `mapstructure:"read_timeout"`  // I/O read timeout in seconds.
+			WriteTimeout int       `mapstructure:"write_timeout"` // I/O write timeout in seconds.
+			Replicas     []Replica `mapstructure:"replicas"`      // Read replicas sharing the primary's credentials and database.
+
+			// Pool contains the connection pool limits; zero keeps the driver default.
+			Pool struct {
+				MaxOpenConns    int `mapstructure:"max_open_conns"`     // Maximum number of open connections.
+				MaxIdleConns    int `mapstructure:"max_idle_conns"`     // Maximum number of idle connections.
+				ConnMaxLifetime int `mapstructure:"conn_max_lifetime"`  // Maximum lifetime of a connection in seconds.
+				ConnMaxIdleTime int `mapstructure:"conn_max_idle_time"` // Maximum idle time of a connection in seconds.
+			} `mapstructure:"pool"`
 		} `mapstructure:"database"`
 
 		// Cache contains the configuration for caching.
@@ -43,6 +62,11 @@ type app struct {
 				Expiry      int  `mapstructure:"expiry"`       // Cache expiry time in seconds.
 			} `mapstructure:"heap"`
 		} `mapstructure:"cache"`
+
+		// Search contains the configuration of the in-process security search index.
+		Search struct {
+			Refresh int `mapstructure:"refresh"` // Seconds after which the index is reloaded from the database; 0 reloads only on local writes.
+		} `mapstructure:"search"`
 	} `mapstructure:"store"`
 
 	// Api contains the API configuration for different services.
@@ -50,6 +74,27 @@ type app struct {
 	Yahoo struct {
 		ExchangeHash map[string]string `mapstructure:"exchange_hash"`
 	} `mapstructure:"yahoo"`
+
+	// Charges contains the trade charge rate tables keyed by lower case exchange name (e.g. "nse").
+	Charges map[string]ChargeRates `mapstructure:"charges"`
+}
+
+// ChargeRates holds the rate table of one exchange. Rates are percentages of the trade value.
+type ChargeRates struct {
+	BrokeragePercent float64 `mapstructure:"brokerage_percent"` // Brokerage charged on the trade value.
+	BrokerageMax     float64 `mapstructure:"brokerage_max"`     // Cap on the brokerage of one trade in rupees; 0 for no cap.
+	SttBuyPercent    float64 `mapstructure:"stt_buy_percent"`   // Securities transaction tax on buys.
+	SttSellPercent   float64 `mapstructure:"stt_sell_percent"`  // Securities transaction tax on sells.
+	ExchangePercent  float64 `mapstructure:"exchange_percent"`  // Exchange turnover fee.
+	SebiPercent      float64 `mapstructure:"sebi_percent"`      // SEBI turnover fee.
+	StampBuyPercent  float64 `mapstructure:"stamp_buy_percent"` // Stamp duty on buys.
+	GstPercent       float64 `mapstructure:"gst_percent"`       // GST on brokerage, exchange and SEBI fees.
+}
+
+// Replica holds the encrypted address of a database read replica.
+type Replica struct {
+	Host string `mapstructure:"host"` // Replica host address.
+	Port string `mapstructure:"port"` // Replica port.
 }
 
 // logger struct defines the logging configuration for the application, including log level,
@@ -91,8 +136,24 @@ type api struct {
 	StockDividendAdd      apiData `mapstructure:"stockDividendAdd"`      // Add stock dividend API.
 	StockDividends        apiData `mapstructure:"stockDividends"`        // get stock dividend API.
 	StockSummary          apiData `mapstructure:"stockSummary"`          // Get stock summary API.
+	StockSummaryTotals    apiData `mapstructure:"stockSummaryTotals"`    // Get stock portfolio totals API.
 	StockInventories      apiData `mapstructure:"stockInventories"`      // Get stock inventories API.
 	StockInventoryLedgers apiData `mapstructure:"stockInventiryLedgers"` // Get stock inventory ledgers API.
+	StockRebuild          apiData `mapstructure:"stockRebuild"`          // Rebuild stock inventories from ledgers API (admin).
+	StockConsistency      apiData `mapstructure:"stockConsistency"`      // Stock consistency report API (admin).
+	TransactionVoid       apiData `mapstructure:"transactionVoid"`       // Void a buy or sell API.
+	TransactionUpdate     apiData `mapstructure:"transactionUpdate"`     // Edit a buy, sell or dividend API.
+	StockRealized         apiData `mapstructure:"stockRealized"`         // Get realized profit and loss API.
+	StockCapitalGains     apiData `mapstructure:"stockCapitalGains"`     // Get capital gains for a financial year API.
+	StockReturns          apiData `mapstructure:"stockReturns"`          // Get XIRR and CAGR returns API.
+	StockHoldings         apiData `mapstructure:"stockHoldings"`         // Get holdings as of a past date API.
+	StockChargesCalculate apiData `mapstructure:"stockChargesCalculate"` // Calculate the charges of a trade API.
+	StockCharges          apiData `mapstructure:"stockCharges"`          // Get the charges paid per financial year API.
+	StockRightsEntitle    apiData `mapstructure:"stockRightsEntitle"`    // API for recording rights entitlements
+	StockRightsRenounce   apiData `mapstructure:"stockRightsRenounce"`   // API for renouncing rights entitlements
+	StockRightsSubscribe  apiData `mapstructure:"stockRightsSubscribe"`  // API for subscribing rights shares
+	StockRightsCall       apiData `mapstructure:"stockRightsCall"`       // API for paying calls on partly paid rights shares
+	StockBuyback          apiData `mapstructure:"stockBuyback"`          // API for recording buybacks and tender offers
 }
 
 // apiData struct defines the configuration for a single API endpoint, including whether
@@ -3,11 +3,14 @@ package yahoo
 import (
 	"assetio/internal/port"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Yahoo struct
@@ -60,6 +63,55 @@ func (y *yahoo) Query(symbol, exchange string) (port.MarketerData, error) {
 	return &data, nil
 }
 
+// QueryClose fetches the daily closes of the week up to date from Yahoo Finance API and returns the close of the
+// last trading day on or before date
+func (y *yahoo) QueryClose(symbol, exchange string, date time.Time) (float64, error) {
+	symbolSign := y.getSymbolSign(symbol, exchange)
+
+	// Ask for a week of daily bars so a date on a weekend or a holiday still finds the previous close
+	end := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+	url := "https://query1.finance.yahoo.com/v8/finance/chart/" + symbolSign +
+		"?interval=1d&period1=" + strconv.FormatInt(end.AddDate(0, 0, -7).Unix(), 10) +
+		"&period2=" + strconv.FormatInt(end.Unix(), 10)
+
+	// Make the HTTP GET request
+	resp, err := http.Get(url)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Read the response body
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	// Parse the JSON response into chartData struct
+	var data chartData
+	err = json.Unmarshal(body, &data)
+	if err != nil {
+		return 0, err
+	}
+	return data.getClose(end)
+}
+
+// getClose returns the last close in the chartData before end. Days without a close are reported as null and skipped
+func (y *chartData) getClose(end time.Time) (float64, error) {
+	if len(y.Chart.Result) == 0 || len(y.Chart.Result[0].Indicators.Quote) == 0 {
+		return 0, errors.New("no close price")
+	}
+
+	result := y.Chart.Result[0]
+	closes := result.Indicators.Quote[0].Close
+	for i := len(result.Timestamp) - 1; i >= 0; i-- {
+		if i < len(closes) && closes[i] > 0 && int64(result.Timestamp[i]) < end.Unix() {
+			return closes[i], nil
+		}
+	}
+	return 0, errors.New("no close price")
+}
+
 // GetMarketPrice retrieves the market price from the chartData
 func (y *chartData) GetMarketPrice() float64 {
 	if len(y.Chart.Result) == 0 {
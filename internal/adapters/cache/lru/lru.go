@@ -0,0 +1,118 @@
+package lru
+
+import (
+	"assetio/internal/domain"
+	"assetio/internal/port"
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	key       string
+	value     any
+	expiresAt time.Time
+}
+
+type lru struct {
+	mu          sync.Mutex
+	maxCapacity int
+	expiry      time.Duration
+	now         func() time.Time
+	order       *list.List // Most recently used entry at the front
+	entries     map[string]*list.Element
+	stats       domain.CacheStats
+}
+
+// New creates an in-process cache holding at most maxCapacity entries, each kept for expiry.
+// A maxCapacity of zero or less keeps every entry, and an expiry of zero or less never expires them.
+// The cache is safe for concurrent use.
+func New(maxCapacity int, expiry time.Duration) port.Cache {
+	return &lru{
+		maxCapacity: maxCapacity,
+		expiry:      expiry,
+		now:         time.Now,
+		order:       list.New(),
+		entries:     map[string]*list.Element{},
+	}
+}
+
+// Get returns the value stored under key and marks it as recently used. Expired entries are removed
+// and reported as a miss.
+func (c *lru) Get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+
+	item := element.Value.(*entry)
+	if !item.expiresAt.IsZero() && !c.now().Before(item.expiresAt) {
+		c.remove(element)
+		c.stats.Expirations++
+		c.stats.Misses++
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	c.stats.Hits++
+	return item.value, true
+}
+
+// Set stores value under key, replacing any previous value, and evicts the least recently used
+// entry when the cache is over capacity.
+func (c *lru) Set(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expiresAt time.Time
+	if c.expiry > 0 {
+		expiresAt = c.now().Add(c.expiry)
+	}
+
+	if element, ok := c.entries[key]; ok {
+		item := element.Value.(*entry)
+		item.value = value
+		item.expiresAt = expiresAt
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&entry{key: key, value: value, expiresAt: expiresAt})
+	if c.maxCapacity > 0 && c.order.Len() > c.maxCapacity {
+		c.remove(c.order.Back())
+		c.stats.Evictions++
+	}
+}
+
+// DeletePrefix removes every entry whose key starts with prefix.
+func (c *lru) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, element := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(element)
+		}
+	}
+}
+
+// Stats returns a copy of the usage counters.
+func (c *lru) Stats() domain.CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.order.Len()
+	return stats
+}
+
+// remove drops element from both the recency list and the key index. The caller holds the lock.
+func (c *lru) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*entry).key)
+}
@@ -0,0 +1,66 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := New(2, 0)
+	cache.Set("a", 1)
+	cache.Set("b", 2)
+	cache.Get("a") // "b" is now the least recently used
+	cache.Set("c", 3)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Fatal("b survived eviction")
+	}
+	if value, ok := cache.Get("a"); !ok || value != 1 {
+		t.Fatalf("a = %v, %v; want 1, true", value, ok)
+	}
+	if value, ok := cache.Get("c"); !ok || value != 3 {
+		t.Fatalf("c = %v, %v; want 3, true", value, ok)
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 3 || stats.Misses != 1 || stats.Evictions != 1 || stats.Entries != 2 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	cache := New(10, time.Minute).(*lru)
+	cache.now = func() time.Time { return now }
+
+	cache.Set("a", 1)
+	now = now.Add(59 * time.Second)
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatal("a expired early")
+	}
+	now = now.Add(time.Second)
+	if _, ok := cache.Get("a"); ok {
+		t.Fatal("a outlived its expiry")
+	}
+
+	stats := cache.Stats()
+	if stats.Expirations != 1 || stats.Entries != 0 {
+		t.Fatalf("stats = %+v", stats)
+	}
+}
+
+func TestDeletePrefix(t *testing.T) {
+	cache := New(0, 0)
+	cache.Set("security:id:1", 1)
+	cache.Set("security:search:x", 2)
+	cache.Set("account:1", 3)
+
+	cache.DeletePrefix("security:")
+
+	if stats := cache.Stats(); stats.Entries != 1 {
+		t.Fatalf("%d entries left, want 1", stats.Entries)
+	}
+	if _, ok := cache.Get("account:1"); !ok {
+		t.Fatal("unrelated entry was removed")
+	}
+}
@@ -364,6 +364,50 @@ func (h *handler) StockSummary(w http.ResponseWriter, r *http.Request) {
 	resData.Send(w)
 }
 
+// StockSummaryTotals handles the request to get the totals of a user's stock portfolio
+func (h *handler) StockSummaryTotals(w http.ResponseWriter, r *http.Request) {
+	var request domain.ClientStockSummaryRequest
+	res := response.New()
+
+	// Decode request based on HTTP method (POST or GET)
+	if r.Method == http.MethodPost {
+		decoder := json.NewDecoder(r.Body)
+		err := decoder.Decode(&request)
+		if err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	} else {
+		var decoder = schema.NewDecoder()
+		decoder.IgnoreUnknownKeys(true)
+		if err := decoder.Decode(&request, r.URL.Query()); err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	}
+
+	// Assign user ID from URL query to the request
+	userid, _ := strconv.Atoi(r.URL.Query().Get("uid"))
+	request.UserId = userid
+
+	// The totals take the same request as the summary
+	err := h.validator.StockSummary(request)
+	if err != nil {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+		res.Send(w)
+		return
+	}
+
+	// Call the usecase to get the portfolio totals
+	resData := h.usecases.Stock.StockSummaryTotals(request)
+	resData.Send(w)
+}
+
 // StockInventories handles the request to get the list of stock inventories for a user
 func (h *handler) StockInventories(w http.ResponseWriter, r *http.Request) {
 	var request domain.ClientStockInventoriesRequest
@@ -494,3 +538,655 @@ func (h *handler) StockDividends(w http.ResponseWriter, r *http.Request) {
 	resData := h.usecases.Stock.StockDividends(request)
 	resData.Send(w)
 }
+
+// StockRebuild handles the admin request to rebuild inventories from their ledgers
+func (h *handler) StockRebuild(w http.ResponseWriter, r *http.Request) {
+	var request domain.ClientStockRebuildRequest
+	res := response.New()
+
+	// Decode request based on HTTP method (POST or GET)
+	if r.Method == http.MethodPost {
+		decoder := json.NewDecoder(r.Body)
+		err := decoder.Decode(&request)
+		if err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	} else {
+		var decoder = schema.NewDecoder()
+		decoder.IgnoreUnknownKeys(true)
+		if err := decoder.Decode(&request, r.URL.Query()); err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	}
+
+	// Validate the rebuild request
+	err := h.validator.StockRebuild(request)
+	if err != nil {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+		res.Send(w)
+		return
+	}
+
+	// Call the usecase to rebuild (or preview the rebuild of) the selected inventories
+	resData := h.usecases.Stock.StockRebuild(request)
+	resData.Send(w)
+}
+
+// StockConsistency handles the admin request for a consistency report of inventories, ledgers and transactions
+func (h *handler) StockConsistency(w http.ResponseWriter, r *http.Request) {
+	var request domain.ClientStockConsistencyRequest
+	res := response.New()
+
+	// Decode request based on HTTP method (POST or GET)
+	if r.Method == http.MethodPost {
+		decoder := json.NewDecoder(r.Body)
+		err := decoder.Decode(&request)
+		if err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	} else {
+		var decoder = schema.NewDecoder()
+		decoder.IgnoreUnknownKeys(true)
+		if err := decoder.Decode(&request, r.URL.Query()); err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	}
+
+	// Validate the consistency request
+	err := h.validator.StockConsistency(request)
+	if err != nil {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+		res.Send(w)
+		return
+	}
+
+	// Call the usecase to build the consistency report
+	resData := h.usecases.Stock.StockConsistency(request)
+	resData.Send(w)
+}
+
+// TransactionVoid handles the request to void a recorded buy, sell or buyback
+func (h *handler) TransactionVoid(w http.ResponseWriter, r *http.Request) {
+	var request domain.ClientTransactionVoidRequest
+	res := response.New()
+
+	// Decode request based on HTTP method (POST or GET)
+	if r.Method == http.MethodPost {
+		decoder := json.NewDecoder(r.Body)
+		err := decoder.Decode(&request)
+		if err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	} else {
+		var decoder = schema.NewDecoder()
+		decoder.IgnoreUnknownKeys(true)
+		if err := decoder.Decode(&request, r.URL.Query()); err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	}
+
+	// Assign user ID from URL query to the request
+	userid, _ := strconv.Atoi(r.URL.Query().Get("uid"))
+	request.UserId = userid
+
+	// Validate the void request
+	err := h.validator.TransactionVoid(request)
+	if err != nil {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+		res.Send(w)
+		return
+	}
+
+	// Call the usecase to void the transaction
+	resData := h.usecases.Stock.TransactionVoid(request)
+	resData.Send(w)
+}
+
+// TransactionUpdate handles the request to edit a recorded buy, sell or dividend
+func (h *handler) TransactionUpdate(w http.ResponseWriter, r *http.Request) {
+	var request domain.ClientTransactionUpdateRequest
+	res := response.New()
+
+	// Decode request based on HTTP method (POST or GET)
+	if r.Method == http.MethodPost {
+		decoder := json.NewDecoder(r.Body)
+		err := decoder.Decode(&request)
+		if err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	} else {
+		var decoder = schema.NewDecoder()
+		decoder.IgnoreUnknownKeys(true)
+		if err := decoder.Decode(&request, r.URL.Query()); err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	}
+
+	// Assign user ID from URL query to the request
+	userid, _ := strconv.Atoi(r.URL.Query().Get("uid"))
+	request.UserId = userid
+
+	// Validate the update request
+	err := h.validator.TransactionUpdate(request)
+	if err != nil {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+		res.Send(w)
+		return
+	}
+
+	// Call the usecase to edit the transaction
+	resData := h.usecases.Stock.TransactionUpdate(request)
+	resData.Send(w)
+}
+
+// StockRealized handles the request for the realized profit and loss of an account
+func (h *handler) StockRealized(w http.ResponseWriter, r *http.Request) {
+	var request domain.ClientStockRealizedRequest
+	res := response.New()
+
+	// Decode request based on HTTP method (POST or GET)
+	if r.Method == http.MethodPost {
+		decoder := json.NewDecoder(r.Body)
+		err := decoder.Decode(&request)
+		if err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	} else {
+		var decoder = schema.NewDecoder()
+		decoder.IgnoreUnknownKeys(true)
+		if err := decoder.Decode(&request, r.URL.Query()); err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	}
+
+	// Assign user ID from URL query to the request
+	userid, _ := strconv.Atoi(r.URL.Query().Get("uid"))
+	request.UserId = userid
+
+	// Validate the realized request
+	err := h.validator.StockRealized(request)
+	if err != nil {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+		res.Send(w)
+		return
+	}
+
+	// Call the usecase to report the realized profit and loss
+	resData := h.usecases.Stock.StockRealized(request)
+	resData.Send(w)
+}
+
+// StockCapitalGains handles the request for the capital gains of an account in a financial year
+func (h *handler) StockCapitalGains(w http.ResponseWriter, r *http.Request) {
+	var request domain.ClientStockCapitalGainsRequest
+	res := response.New()
+
+	// Decode request based on HTTP method (POST or GET)
+	if r.Method == http.MethodPost {
+		decoder := json.NewDecoder(r.Body)
+		err := decoder.Decode(&request)
+		if err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	} else {
+		var decoder = schema.NewDecoder()
+		decoder.IgnoreUnknownKeys(true)
+		if err := decoder.Decode(&request, r.URL.Query()); err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	}
+
+	// Assign user ID from URL query to the request
+	userid, _ := strconv.Atoi(r.URL.Query().Get("uid"))
+	request.UserId = userid
+
+	// Validate the capital gains request
+	err := h.validator.StockCapitalGains(request)
+	if err != nil {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+		res.Send(w)
+		return
+	}
+
+	// Call the usecase to report the capital gains
+	resData := h.usecases.Stock.StockCapitalGains(request)
+	resData.Send(w)
+}
+
+// StockReturns handles the request for the XIRR and CAGR returns of a user's stocks
+func (h *handler) StockReturns(w http.ResponseWriter, r *http.Request) {
+	var request domain.ClientStockReturnsRequest
+	res := response.New()
+
+	// Decode request based on HTTP method (POST or GET)
+	if r.Method == http.MethodPost {
+		decoder := json.NewDecoder(r.Body)
+		err := decoder.Decode(&request)
+		if err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	} else {
+		var decoder = schema.NewDecoder()
+		decoder.IgnoreUnknownKeys(true)
+		if err := decoder.Decode(&request, r.URL.Query()); err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	}
+
+	// Assign user ID from URL query to the request
+	userid, _ := strconv.Atoi(r.URL.Query().Get("uid"))
+	request.UserId = userid
+
+	// Validate the returns request
+	err := h.validator.StockReturns(request)
+	if err != nil {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+		res.Send(w)
+		return
+	}
+
+	// Call the usecase to report the returns
+	resData := h.usecases.Stock.StockReturns(request)
+	resData.Send(w)
+}
+
+// StockHoldings handles the request for the holdings of an account at the end of a past day
+func (h *handler) StockHoldings(w http.ResponseWriter, r *http.Request) {
+	var request domain.ClientStockHoldingsRequest
+	res := response.New()
+
+	// Decode request based on HTTP method (POST or GET)
+	if r.Method == http.MethodPost {
+		decoder := json.NewDecoder(r.Body)
+		err := decoder.Decode(&request)
+		if err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	} else {
+		var decoder = schema.NewDecoder()
+		decoder.IgnoreUnknownKeys(true)
+		if err := decoder.Decode(&request, r.URL.Query()); err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	}
+
+	// Assign user ID from URL query to the request
+	userid, _ := strconv.Atoi(r.URL.Query().Get("uid"))
+	request.UserId = userid
+
+	// Validate the holdings report request
+	err := h.validator.StockHoldings(request)
+	if err != nil {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+		res.Send(w)
+		return
+	}
+
+	// Call the usecase for the holdings report
+	resData := h.usecases.Stock.StockHoldings(request)
+	resData.Send(w)
+}
+
+// StockChargesCalculate handles the request for the itemised charges of a prospective trade
+func (h *handler) StockChargesCalculate(w http.ResponseWriter, r *http.Request) {
+	var request domain.ClientStockChargesCalculateRequest
+	res := response.New()
+
+	// Decode request based on HTTP method (POST or GET)
+	if r.Method == http.MethodPost {
+		decoder := json.NewDecoder(r.Body)
+		err := decoder.Decode(&request)
+		if err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	} else {
+		var decoder = schema.NewDecoder()
+		decoder.IgnoreUnknownKeys(true)
+		if err := decoder.Decode(&request, r.URL.Query()); err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	}
+
+	// Assign user ID from URL query to the request
+	userid, _ := strconv.Atoi(r.URL.Query().Get("uid"))
+	request.UserId = userid
+
+	// Validate the charges calculator request
+	err := h.validator.StockChargesCalculate(request)
+	if err != nil {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+		res.Send(w)
+		return
+	}
+
+	// Call the usecase for the charges calculator
+	resData := h.usecases.Stock.StockChargesCalculate(request)
+	resData.Send(w)
+}
+
+// StockCharges handles the request for the charges an account paid per financial year
+func (h *handler) StockCharges(w http.ResponseWriter, r *http.Request) {
+	var request domain.ClientStockChargesRequest
+	res := response.New()
+
+	// Decode request based on HTTP method (POST or GET)
+	if r.Method == http.MethodPost {
+		decoder := json.NewDecoder(r.Body)
+		err := decoder.Decode(&request)
+		if err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	} else {
+		var decoder = schema.NewDecoder()
+		decoder.IgnoreUnknownKeys(true)
+		if err := decoder.Decode(&request, r.URL.Query()); err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	}
+
+	// Assign user ID from URL query to the request
+	userid, _ := strconv.Atoi(r.URL.Query().Get("uid"))
+	request.UserId = userid
+
+	// Validate the charges report request
+	err := h.validator.StockCharges(request)
+	if err != nil {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+		res.Send(w)
+		return
+	}
+
+	// Call the usecase for the charges report
+	resData := h.usecases.Stock.StockCharges(request)
+	resData.Send(w)
+}
+
+// StockRightsEntitle handles the request for the rights entitlements of a holding on a record date
+func (h *handler) StockRightsEntitle(w http.ResponseWriter, r *http.Request) {
+	var request domain.ClientStockRightsEntitleRequest
+	res := response.New()
+
+	// Decode request based on HTTP method (POST or GET)
+	if r.Method == http.MethodPost {
+		decoder := json.NewDecoder(r.Body)
+		err := decoder.Decode(&request)
+		if err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	} else {
+		var decoder = schema.NewDecoder()
+		decoder.IgnoreUnknownKeys(true)
+		if err := decoder.Decode(&request, r.URL.Query()); err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	}
+
+	// Assign user ID from URL query to the request
+	userid, _ := strconv.Atoi(r.URL.Query().Get("uid"))
+	request.UserId = userid
+
+	// Validate the rights entitlement API request
+	err := h.validator.StockRightsEntitle(request)
+	if err != nil {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+		res.Send(w)
+		return
+	}
+
+	// Call the usecase for the rights entitlement API
+	resData := h.usecases.Stock.StockRightsEntitle(request)
+	resData.Send(w)
+}
+
+// StockRightsRenounce handles the request for the sale or renunciation of rights entitlements
+func (h *handler) StockRightsRenounce(w http.ResponseWriter, r *http.Request) {
+	var request domain.ClientStockRightsRenounceRequest
+	res := response.New()
+
+	// Decode request based on HTTP method (POST or GET)
+	if r.Method == http.MethodPost {
+		decoder := json.NewDecoder(r.Body)
+		err := decoder.Decode(&request)
+		if err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	} else {
+		var decoder = schema.NewDecoder()
+		decoder.IgnoreUnknownKeys(true)
+		if err := decoder.Decode(&request, r.URL.Query()); err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	}
+
+	// Assign user ID from URL query to the request
+	userid, _ := strconv.Atoi(r.URL.Query().Get("uid"))
+	request.UserId = userid
+
+	// Validate the rights renunciation API request
+	err := h.validator.StockRightsRenounce(request)
+	if err != nil {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+		res.Send(w)
+		return
+	}
+
+	// Call the usecase for the rights renunciation API
+	resData := h.usecases.Stock.StockRightsRenounce(request)
+	resData.Send(w)
+}
+
+// StockRightsSubscribe handles the request for the subscription of rights shares
+func (h *handler) StockRightsSubscribe(w http.ResponseWriter, r *http.Request) {
+	var request domain.ClientStockRightsSubscribeRequest
+	res := response.New()
+
+	// Decode request based on HTTP method (POST or GET)
+	if r.Method == http.MethodPost {
+		decoder := json.NewDecoder(r.Body)
+		err := decoder.Decode(&request)
+		if err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	} else {
+		var decoder = schema.NewDecoder()
+		decoder.IgnoreUnknownKeys(true)
+		if err := decoder.Decode(&request, r.URL.Query()); err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	}
+
+	// Assign user ID from URL query to the request
+	userid, _ := strconv.Atoi(r.URL.Query().Get("uid"))
+	request.UserId = userid
+
+	// Validate the rights subscription API request
+	err := h.validator.StockRightsSubscribe(request)
+	if err != nil {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+		res.Send(w)
+		return
+	}
+
+	// Call the usecase for the rights subscription API
+	resData := h.usecases.Stock.StockRightsSubscribe(request)
+	resData.Send(w)
+}
+
+// StockRightsCall handles the request for a call-money payment on partly paid rights shares
+func (h *handler) StockRightsCall(w http.ResponseWriter, r *http.Request) {
+	var request domain.ClientStockRightsCallRequest
+	res := response.New()
+
+	// Decode request based on HTTP method (POST or GET)
+	if r.Method == http.MethodPost {
+		decoder := json.NewDecoder(r.Body)
+		err := decoder.Decode(&request)
+		if err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	} else {
+		var decoder = schema.NewDecoder()
+		decoder.IgnoreUnknownKeys(true)
+		if err := decoder.Decode(&request, r.URL.Query()); err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	}
+
+	// Assign user ID from URL query to the request
+	userid, _ := strconv.Atoi(r.URL.Query().Get("uid"))
+	request.UserId = userid
+
+	// Validate the rights call API request
+	err := h.validator.StockRightsCall(request)
+	if err != nil {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+		res.Send(w)
+		return
+	}
+
+	// Call the usecase for the rights call API
+	resData := h.usecases.Stock.StockRightsCall(request)
+	resData.Send(w)
+}
+
+// StockBuyback handles the request for a buyback or tender offer
+func (h *handler) StockBuyback(w http.ResponseWriter, r *http.Request) {
+	var request domain.ClientStockBuybackRequest
+	res := response.New()
+
+	// Decode request based on HTTP method (POST or GET)
+	if r.Method == http.MethodPost {
+		decoder := json.NewDecoder(r.Body)
+		err := decoder.Decode(&request)
+		if err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	} else {
+		var decoder = schema.NewDecoder()
+		decoder.IgnoreUnknownKeys(true)
+		if err := decoder.Decode(&request, r.URL.Query()); err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+			res.Send(w)
+			return
+		}
+	}
+
+	// Assign user ID from URL query to the request
+	userid, _ := strconv.Atoi(r.URL.Query().Get("uid"))
+	request.UserId = userid
+
+	// Validate the buyback API request
+	err := h.validator.StockBuyback(request)
+	if err != nil {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(ERROR_CODE_REQUEST_INVALID, err.Error())
+		res.Send(w)
+		return
+	}
+
+	// Call the usecase for the buyback API
+	resData := h.usecases.Stock.StockBuyback(request)
+	resData.Send(w)
+}
@@ -6,7 +6,7 @@ import (
 )
 
 // AccountCreate validates the fields in the ClientAccountCreateRequest object before creating an account.
-// It checks if the required fields (Name, UserId) are valid (non-zero or non-empty).
+// It checks if the required fields (Name, UserId) are valid (non-zero or non-empty) and the lot method, if given, is supported.
 func (v validation) AccountCreate(request domain.ClientAccountCreateRequest) error {
 	if request.Name == "" {
 		return errors.New("invalid name") // Name must be non-empty
@@ -15,6 +15,9 @@ func (v validation) AccountCreate(request domain.ClientAccountCreateRequest) err
 	if request.UserId == 0 {
 		return errors.New("invalid user id") // UserId must be non-zero
 	}
+	if request.LotMethod != "" && !request.LotMethod.IsValid() {
+		return errors.New("invalid lot method") // LotMethod, when given, must be a supported method
+	}
 	return nil // Return nil if all validations pass
 }
 
@@ -44,7 +47,7 @@ func (v validation) AccountGet(request domain.ClientAccountGetRequest) error {
 }
 
 // AccountUpdate validates the fields in the ClientAccountUpdateRequest object before updating account details.
-// It checks if the required fields (AccountId, UserId) are valid (non-zero).
+// It checks if the required fields (AccountId, UserId) are valid (non-zero) and the lot method, if given, is supported.
 func (v validation) AccountUpdate(request domain.ClientAccountUpdateRequest) error {
 	if request.AccountId == 0 {
 		return errors.New("invalid account id") // AccountId must be non-zero
@@ -52,6 +55,9 @@ func (v validation) AccountUpdate(request domain.ClientAccountUpdateRequest) err
 	if request.UserId == 0 {
 		return errors.New("invalid user id") // UserId must be non-zero
 	}
+	if request.LotMethod != "" && !request.LotMethod.IsValid() {
+		return errors.New("invalid lot method") // LotMethod, when given, must be a supported method
+	}
 	return nil // Return nil if all validations pass
 }
 
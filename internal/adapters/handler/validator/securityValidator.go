@@ -73,15 +73,8 @@ func (v validation) SecurityGet(request domain.ClientSecurityGetRequest) error {
 }
 
 // SecuritySearch validates the fields in the ClientSecuritySearchRequest object before searching for securities.
-// It checks if the required fields (Exchange, Type, Search) are valid (non-empty) and ensures the search keyword has at least 2 characters.
+// It ensures the search keyword has at least 2 characters and the page is in range; Exchange and Type are optional filters.
 func (v validation) SecuritySearch(request domain.ClientSecuritySearchRequest) error {
-	if request.Exchange == "" {
-		return errors.New("invalid exchange") // Exchange must be non-empty
-	}
-	if request.Type == "" {
-		return errors.New("invalid type") // Type must be non-empty
-	}
-
 	if request.Search == "" {
 		return errors.New("invalid search keyword") // Search keyword must be non-empty
 	}
@@ -90,5 +83,13 @@ func (v validation) SecuritySearch(request domain.ClientSecuritySearchRequest) e
 		return errors.New("search keyword should be minimum 2 letters") // Search keyword should have at least 2 characters
 	}
 
+	if request.Limit < 0 || request.Limit > 100 {
+		return errors.New("limit should be between 1 and 100") // Zero falls back to the default page size
+	}
+
+	if request.Offset < 0 {
+		return errors.New("invalid offset") // Offset cannot be negative
+	}
+
 	return nil // Return nil if all validations pass
 }
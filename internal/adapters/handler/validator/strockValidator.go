@@ -3,10 +3,14 @@ package validator
 import (
 	"assetio/internal/domain"
 	"errors"
+	"strings"
+
+	"github.com/shopspring/decimal"
 )
 
 // StockBuy validates the fields in the ClientStockBuyRequest object before proceeding with a stock purchase.
-// It checks if the required fields (AccountId, UserId, StockId, Quantity, AveragePrice) are valid (non-zero).
+// It checks if the required fields (AccountId, UserId, StockId) are valid (non-zero) and Quantity and AveragePrice
+// are positive.
 func (v validation) StockBuy(request domain.ClientStockBuyRequest) error {
 	if request.AccountId == 0 {
 		return errors.New("invalid account id") // AccountId must be non-zero
@@ -19,19 +23,24 @@ func (v validation) StockBuy(request domain.ClientStockBuyRequest) error {
 		return errors.New("invalid stock id") // StockId must be non-zero
 	}
 
-	if request.Quantity == 0 {
+	if !request.Quantity.IsPositive() {
 		return errors.New("invalid quantity") // Quantity must be greater than 0
 	}
 
-	if request.AveragePrice == 0 {
+	if !request.AveragePrice.IsPositive() {
 		return errors.New("invalid amount per quantity") // AveragePrice must be greater than 0
 	}
 
+	if err := validateCharges(request.Charges); err != nil {
+		return err
+	}
+
 	return nil // Return nil if all validations pass
 }
 
 // StockSell validates the fields in the ClientStockSellRequest object before proceeding with a stock sale.
-// It checks if the required fields (AccountId, UserId, StockId, Quantity, AveragePrice) are valid (non-zero).
+// It checks if the required fields (AccountId, UserId, StockId) are valid (non-zero) and Quantity and AveragePrice
+// are positive.
 func (v validation) StockSell(request domain.ClientStockSellRequest) error {
 	if request.AccountId == 0 {
 		return errors.New("invalid account id") // AccountId must be non-zero
@@ -44,19 +53,24 @@ func (v validation) StockSell(request domain.ClientStockSellRequest) error {
 		return errors.New("invalid stock id") // StockId must be non-zero
 	}
 
-	if request.Quantity == 0 {
+	if !request.Quantity.IsPositive() {
 		return errors.New("invalid quantity") // Quantity must be greater than 0
 	}
 
-	if request.AveragePrice == 0 {
+	if !request.AveragePrice.IsPositive() {
 		return errors.New("invalid amount per quantity") // AveragePrice must be greater than 0
 	}
 
+	if err := validateCharges(request.Charges); err != nil {
+		return err
+	}
+
 	return nil // Return nil if all validations pass
 }
 
 // StockSplit validates the fields in the ClientStockSplitRequest object before proceeding with a stock split.
-// It checks if the required fields (AccountId, UserId, StockId, Quantity) are valid (non-zero).
+// It checks if the required fields (AccountId, UserId, StockId) are valid (non-zero) and that the split is given
+// either as a ratio or face values that increase the shares, or as a positive quantity.
 func (v validation) StockSplit(request domain.ClientStockSplitRequest) error {
 	if request.AccountId == 0 {
 		return errors.New("invalid account id") // AccountId must be non-zero
@@ -69,7 +83,16 @@ func (v validation) StockSplit(request domain.ClientStockSplitRequest) error {
 		return errors.New("invalid stock id") // StockId must be non-zero
 	}
 
-	if request.Quantity == 0 {
+	switch {
+	case !request.RatioFrom.IsZero() || !request.RatioTo.IsZero():
+		if !request.RatioFrom.IsPositive() || !request.RatioTo.GreaterThan(request.RatioFrom) {
+			return errors.New("invalid split ratio") // The ratio must turn each share into more shares
+		}
+	case !request.FaceValueFrom.IsZero() || !request.FaceValueTo.IsZero():
+		if !request.FaceValueTo.IsPositive() || !request.FaceValueFrom.GreaterThan(request.FaceValueTo) {
+			return errors.New("invalid face value") // The face value must fall
+		}
+	case !request.Quantity.IsPositive():
 		return errors.New("invalid quantity") // Quantity must be greater than 0
 	}
 
@@ -77,7 +100,8 @@ func (v validation) StockSplit(request domain.ClientStockSplitRequest) error {
 }
 
 // StockBonus validates the fields in the ClientStockBonusRequest object before proceeding with a stock bonus.
-// It checks if the required fields (AccountId, UserId, StockId, Quantity) are valid (non-zero).
+// It checks if the required fields (AccountId, UserId, StockId) are valid (non-zero) and that the bonus is given
+// either as a positive "X for Y" ratio or as a positive quantity.
 func (v validation) StockBonus(request domain.ClientStockBonusRequest) error {
 	if request.AccountId == 0 {
 		return errors.New("invalid account id") // AccountId must be non-zero
@@ -90,7 +114,11 @@ func (v validation) StockBonus(request domain.ClientStockBonusRequest) error {
 		return errors.New("invalid stock id") // StockId must be non-zero
 	}
 
-	if request.Quantity == 0 {
+	if !request.RatioBonus.IsZero() || !request.RatioHeld.IsZero() {
+		if !request.RatioBonus.IsPositive() || !request.RatioHeld.IsPositive() {
+			return errors.New("invalid bonus ratio") // Both sides of the ratio must be greater than 0
+		}
+	} else if !request.Quantity.IsPositive() {
 		return errors.New("invalid quantity") // Quantity must be greater than 0
 	}
 
@@ -111,7 +139,7 @@ func (v validation) StockDividendAdd(request domain.ClientStockDividendAddReques
 		return errors.New("invalid stock id") // StockId must be non-zero
 	}
 
-	if request.AmountPerQuantity == 0 {
+	if request.AmountPerQuantity.IsZero() {
 		return errors.New("invalid amount per quantity") // AmountPerQuantity must be greater than 0
 	}
 
@@ -203,15 +231,15 @@ func (v validation) StockDemerge(request domain.ClientStockDemergeRequest) error
 		return errors.New("invalid new stock id") // ParentStockId must be non-zero
 	}
 
-	if request.Quantity == 0 {
+	if request.Quantity.IsZero() {
 		return errors.New("invalid quantity") // Quantity must be greater than 0
 	}
 
-	if request.ListingPrice == 0 {
+	if request.ListingPrice.IsZero() {
 		return errors.New("invalid listing price") //  listing price must be greater than 0
 	}
 
-	if request.ParentStockPrice == 0 {
+	if request.ParentStockPrice.IsZero() {
 		return errors.New("invalid parent stock price") //  listing parent stock price must be greater than 0
 	}
 
@@ -237,9 +265,348 @@ func (v validation) StockMerge(request domain.ClientStockMergeRequest) error {
 		return errors.New("invalid new stock id") // ParentStockId must be non-zero
 	}
 
-	if request.Quantity == 0 {
+	if request.Quantity.IsZero() {
+		return errors.New("invalid quantity") // Quantity must be greater than 0
+	}
+
+	return nil // Return nil if all validations pass
+}
+
+// StockRebuild validates the fields in the ClientStockRebuildRequest.
+// It checks that the rebuild is scoped to an account, a stock or both, so it never runs over every inventory.
+func (v validation) StockRebuild(request domain.ClientStockRebuildRequest) error {
+	if request.AccountId == 0 && request.StockId == 0 {
+		return errors.New("account id or stock id is required") // At least one scope must be non-zero
+	}
+
+	return nil // Return nil if all validations pass
+}
+
+// StockConsistency validates the fields in the ClientStockConsistencyRequest.
+// An account id of zero is allowed and checks every account.
+func (v validation) StockConsistency(request domain.ClientStockConsistencyRequest) error {
+	if request.AccountId < 0 {
+		return errors.New("invalid account id") // AccountId must not be negative
+	}
+
+	return nil // Return nil if all validations pass
+}
+
+// TransactionVoid validates the fields in the ClientTransactionVoidRequest object before voiding a transaction.
+// It checks if the required fields (AccountId, UserId, TransactionId) are valid (non-zero).
+func (v validation) TransactionVoid(request domain.ClientTransactionVoidRequest) error {
+	if request.AccountId == 0 {
+		return errors.New("invalid account id") // AccountId must be non-zero
+	}
+	if request.UserId == 0 {
+		return errors.New("invalid user id") // UserId must be non-zero
+	}
+
+	if request.TransactionId == 0 {
+		return errors.New("invalid transaction id") // TransactionId must be non-zero
+	}
+
+	return nil // Return nil if all validations pass
+}
+
+// TransactionUpdate validates the fields in the ClientTransactionUpdateRequest object before editing a transaction.
+// It checks the required fields (AccountId, UserId, TransactionId) are non-zero and that at least one value is
+// edited, with a positive quantity and price and a fee that is not negative.
+func (v validation) TransactionUpdate(request domain.ClientTransactionUpdateRequest) error {
+	if request.AccountId == 0 {
+		return errors.New("invalid account id") // AccountId must be non-zero
+	}
+	if request.UserId == 0 {
+		return errors.New("invalid user id") // UserId must be non-zero
+	}
+
+	if request.TransactionId == 0 {
+		return errors.New("invalid transaction id") // TransactionId must be non-zero
+	}
+
+	if request.Quantity == nil && request.AveragePrice == nil && request.FeeAmount == nil && request.Date == "" {
+		return errors.New("nothing to update") // At least one value must be edited
+	}
+
+	if request.Quantity != nil && !request.Quantity.IsPositive() {
+		return errors.New("invalid quantity") // Quantity must be greater than 0
+	}
+
+	if request.AveragePrice != nil && !request.AveragePrice.IsPositive() {
+		return errors.New("invalid amount per quantity") // AveragePrice must be greater than 0
+	}
+
+	if request.FeeAmount != nil && request.FeeAmount.IsNegative() {
+		return errors.New("invalid fee amount") // FeeAmount must not be negative
+	}
+
+	return nil // Return nil if all validations pass
+}
+
+// StockRealized validates the fields in the ClientStockRealizedRequest object before reporting realized profit and loss.
+// It checks if the required fields (AccountId, UserId) are valid (non-zero).
+func (v validation) StockRealized(request domain.ClientStockRealizedRequest) error {
+	if request.AccountId == 0 {
+		return errors.New("invalid account id") // AccountId must be non-zero
+	}
+	if request.UserId == 0 {
+		return errors.New("invalid user id") // UserId must be non-zero
+	}
+
+	return nil // Return nil if all validations pass
+}
+
+// StockCapitalGains validates the fields in the ClientStockCapitalGainsRequest object before reporting capital gains.
+// It checks if the required fields (AccountId, UserId) are valid (non-zero), the financial year is not negative and
+// every fair market value names a stock and has a positive price.
+func (v validation) StockCapitalGains(request domain.ClientStockCapitalGainsRequest) error {
+	if request.AccountId == 0 {
+		return errors.New("invalid account id") // AccountId must be non-zero
+	}
+	if request.UserId == 0 {
+		return errors.New("invalid user id") // UserId must be non-zero
+	}
+	if request.FinancialYear < 0 {
+		return errors.New("invalid financial year") // FinancialYear, when given, must be a year
+	}
+	for _, fairMarketValue := range request.FairMarketValues {
+		if fairMarketValue.StockId == 0 {
+			return errors.New("invalid fair market value stock id") // Every fair market value must name a stock
+		}
+		if !fairMarketValue.Price.IsPositive() {
+			return errors.New("invalid fair market value price") // Every fair market value must be positive
+		}
+	}
+
+	return nil // Return nil if all validations pass
+}
+
+// StockReturns validates the fields in the ClientStockReturnsRequest object before reporting returns.
+// It checks if the required field (UserId) is valid (non-zero); the account and the stock are optional.
+func (v validation) StockReturns(request domain.ClientStockReturnsRequest) error {
+	if request.UserId == 0 {
+		return errors.New("invalid user id") // UserId must be non-zero
+	}
+
+	return nil // Return nil if all validations pass
+}
+
+// StockHoldings validates the fields in the ClientStockHoldingsRequest object before reconstructing past holdings.
+// It checks if the required fields (AccountId, UserId) are valid (non-zero) and the date is set.
+func (v validation) StockHoldings(request domain.ClientStockHoldingsRequest) error {
+	if request.AccountId == 0 {
+		return errors.New("invalid account id") // AccountId must be non-zero
+	}
+	if request.UserId == 0 {
+		return errors.New("invalid user id") // UserId must be non-zero
+	}
+	if request.Date == "" {
+		return errors.New("invalid date") // Date must be set
+	}
+
+	return nil // Return nil if all validations pass
+}
+
+// StockChargesCalculate validates the fields in the ClientStockChargesCalculateRequest object before calculating
+// charges. It checks if the required fields (UserId, StockId) are non-zero, Type is BUY or SELL, and Quantity and
+// Price are positive.
+func (v validation) StockChargesCalculate(request domain.ClientStockChargesCalculateRequest) error {
+	if request.UserId == 0 {
+		return errors.New("invalid user id") // UserId must be non-zero
+	}
+
+	if request.StockId == 0 {
+		return errors.New("invalid stock id") // StockId must be non-zero
+	}
+
+	side := domain.TransactionType(strings.ToUpper(request.Type))
+	if side != domain.BUY && side != domain.SELL {
+		return errors.New("invalid type") // Type must be BUY or SELL
+	}
+
+	if !request.Quantity.IsPositive() {
+		return errors.New("invalid quantity") // Quantity must be greater than 0
+	}
+
+	if !request.Price.IsPositive() {
+		return errors.New("invalid price") // Price must be greater than 0
+	}
+
+	return nil // Return nil if all validations pass
+}
+
+// StockCharges validates the fields in the ClientStockChargesRequest object before reporting charges.
+// It checks if the required fields (AccountId, UserId) are valid (non-zero); the financial year is optional.
+func (v validation) StockCharges(request domain.ClientStockChargesRequest) error {
+	if request.AccountId == 0 {
+		return errors.New("invalid account id") // AccountId must be non-zero
+	}
+	if request.UserId == 0 {
+		return errors.New("invalid user id") // UserId must be non-zero
+	}
+
+	if request.FinancialYear < 0 {
+		return errors.New("invalid financial year") // FinancialYear must not be negative
+	}
+
+	return nil // Return nil if all validations pass
+}
+
+// StockRightsEntitle validates the fields in the ClientStockRightsEntitleRequest object before recording rights
+// entitlements. It checks the account, user and stock, the ratio, and that the price payable on application is not
+// above the issue price.
+func (v validation) StockRightsEntitle(request domain.ClientStockRightsEntitleRequest) error {
+	if request.AccountId == 0 {
+		return errors.New("invalid account id") // AccountId must be non-zero
+	}
+	if request.UserId == 0 {
+		return errors.New("invalid user id") // UserId must be non-zero
+	}
+
+	if request.StockId == 0 {
+		return errors.New("invalid stock id") // StockId must be non-zero
+	}
+
+	if !request.RatioRights.IsPositive() || !request.RatioHeld.IsPositive() {
+		return errors.New("invalid rights ratio") // Both sides of the ratio must be greater than 0
+	}
+
+	if !request.IssuePrice.IsPositive() {
+		return errors.New("invalid issue price") // IssuePrice must be greater than 0
+	}
+
+	if request.ApplicationPrice.IsNegative() || request.ApplicationPrice.GreaterThan(request.IssuePrice) {
+		return errors.New("invalid application price") // ApplicationPrice must not exceed the issue price
+	}
+
+	return nil // Return nil if all validations pass
+}
+
+// StockRightsRenounce validates the fields in the ClientStockRightsRenounceRequest object before renouncing rights
+// entitlements. It checks the account, user and rights issue, and that the quantity is positive; the price is zero
+// when the entitlements are given up for nothing.
+func (v validation) StockRightsRenounce(request domain.ClientStockRightsRenounceRequest) error {
+	if request.AccountId == 0 {
+		return errors.New("invalid account id") // AccountId must be non-zero
+	}
+	if request.UserId == 0 {
+		return errors.New("invalid user id") // UserId must be non-zero
+	}
+
+	if request.RightsIssueId == 0 {
+		return errors.New("invalid rights issue id") // RightsIssueId must be non-zero
+	}
+
+	if !request.Quantity.IsPositive() {
 		return errors.New("invalid quantity") // Quantity must be greater than 0
 	}
 
+	if request.Price.IsNegative() {
+		return errors.New("invalid price") // Price must not be negative
+	}
+
+	if request.FeeAmount.IsNegative() {
+		return errors.New("invalid fee amount") // FeeAmount must not be negative
+	}
+
 	return nil // Return nil if all validations pass
 }
+
+// StockRightsSubscribe validates the fields in the ClientStockRightsSubscribeRequest object before subscribing rights
+// shares. It checks the account, user and rights issue, and that the quantity is positive.
+func (v validation) StockRightsSubscribe(request domain.ClientStockRightsSubscribeRequest) error {
+	if request.AccountId == 0 {
+		return errors.New("invalid account id") // AccountId must be non-zero
+	}
+	if request.UserId == 0 {
+		return errors.New("invalid user id") // UserId must be non-zero
+	}
+
+	if request.RightsIssueId == 0 {
+		return errors.New("invalid rights issue id") // RightsIssueId must be non-zero
+	}
+
+	if !request.Quantity.IsPositive() {
+		return errors.New("invalid quantity") // Quantity must be greater than 0
+	}
+
+	if request.FeeAmount.IsNegative() {
+		return errors.New("invalid fee amount") // FeeAmount must not be negative
+	}
+
+	return nil // Return nil if all validations pass
+}
+
+// StockRightsCall validates the fields in the ClientStockRightsCallRequest object before paying a call on partly
+// paid rights shares. It checks the account, user and rights issue, and that the call is positive.
+func (v validation) StockRightsCall(request domain.ClientStockRightsCallRequest) error {
+	if request.AccountId == 0 {
+		return errors.New("invalid account id") // AccountId must be non-zero
+	}
+	if request.UserId == 0 {
+		return errors.New("invalid user id") // UserId must be non-zero
+	}
+
+	if request.RightsIssueId == 0 {
+		return errors.New("invalid rights issue id") // RightsIssueId must be non-zero
+	}
+
+	if !request.CallPrice.IsPositive() {
+		return errors.New("invalid call price") // CallPrice must be greater than 0
+	}
+
+	if request.FeeAmount.IsNegative() {
+		return errors.New("invalid fee amount") // FeeAmount must not be negative
+	}
+
+	return nil // Return nil if all validations pass
+}
+
+// StockBuyback validates the fields in the ClientStockBuybackRequest object before recording a buyback or tender
+// offer. It checks the account, user and stock, that the offer price and the shares tendered are positive, that the
+// shares accepted are positive and no more than those tendered, and the lot method if given.
+func (v validation) StockBuyback(request domain.ClientStockBuybackRequest) error {
+	if request.AccountId == 0 {
+		return errors.New("invalid account id") // AccountId must be non-zero
+	}
+	if request.UserId == 0 {
+		return errors.New("invalid user id") // UserId must be non-zero
+	}
+	if request.StockId == 0 {
+		return errors.New("invalid stock id") // StockId must be non-zero
+	}
+
+	if !request.OfferPrice.IsPositive() {
+		return errors.New("invalid offer price") // OfferPrice must be greater than 0
+	}
+
+	if !request.Tendered.IsPositive() {
+		return errors.New("invalid tendered quantity") // Tendered must be greater than 0
+	}
+	if !request.Accepted.IsPositive() || request.Accepted.GreaterThan(request.Tendered) {
+		return errors.New("invalid accepted quantity") // Accepted must be greater than 0 and no more than Tendered
+	}
+
+	if request.LotMethod != "" && !request.LotMethod.IsValid() {
+		return errors.New("invalid lot method") // LotMethod, when given, must be a supported method
+	}
+
+	if request.FeeAmount.IsNegative() {
+		return errors.New("invalid fee amount") // FeeAmount must not be negative
+	}
+
+	return nil // Return nil if all validations pass
+}
+
+// validateCharges checks that no item of a trade's itemised charges is negative; the charges are optional.
+func validateCharges(charges *domain.ClientTradeCharges) error {
+	if charges == nil {
+		return nil
+	}
+	for _, item := range []decimal.Decimal{charges.Brokerage, charges.Stt, charges.ExchangeFee, charges.SebiFee, charges.StampDuty, charges.Gst} {
+		if item.IsNegative() {
+			return errors.New("invalid charges") // Charge items must not be negative
+		}
+	}
+	return nil
+}
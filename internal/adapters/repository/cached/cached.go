@@ -0,0 +1,143 @@
+// Package cached wraps a RepositoryStore so reads of the security master are answered from a port.Cache.
+package cached
+
+import (
+	"assetio/internal/domain"
+	"assetio/internal/port"
+	"context"
+	"strconv"
+	"sync"
+)
+
+// SECURITY_KEY_PREFIX starts the key of every cached security read, so one DeletePrefix drops them all.
+const SECURITY_KEY_PREFIX = "security:"
+
+type cached struct {
+	port.RepositoryStore
+	cache port.Cache
+
+	// generation counts the security writes. A read that loaded its row before a write finished is not cached,
+	// or it would keep the old row cached after the write dropped it.
+	mu         sync.Mutex
+	generation uint64
+}
+
+// New returns repo with its security lookups, listings and searches served from cache. Any security insert
+// or update drops every cached security read, since an update can move a security between listings.
+//
+// Only reads made through the returned store are cached. The store handed to a WithTx callback is the
+// wrapped store's own, so reads inside a transaction always see the database.
+func New(repo port.RepositoryStore, cache port.Cache) port.RepositoryStore {
+	return &cached{
+		RepositoryStore: repo,
+		cache:           cache,
+	}
+}
+
+// GetSecurityDataById returns the security with the given ID, from the cache when present.
+func (c *cached) GetSecurityDataById(ctx context.Context, securityId int) (domain.Securities, error) {
+	key := SECURITY_KEY_PREFIX + "id:" + strconv.Itoa(securityId)
+	if value, ok := c.cache.Get(key); ok {
+		return value.(domain.Securities), nil
+	}
+
+	generation := c.readGeneration()
+	securityData, err := c.RepositoryStore.GetSecurityDataById(ctx, securityId)
+	if err == nil {
+		c.fill(key, securityData, generation)
+	}
+	return securityData, err
+}
+
+// GetSecurityDataByTypeAndExchangeAndSymbol returns the security matching type, exchange and symbol, from the cache when present.
+func (c *cached) GetSecurityDataByTypeAndExchangeAndSymbol(ctx context.Context, types, exchange int, symbol string) (domain.Securities, error) {
+	key := SECURITY_KEY_PREFIX + "symbol:" + strconv.Itoa(types) + ":" + strconv.Itoa(exchange) + ":" + symbol
+	if value, ok := c.cache.Get(key); ok {
+		return value.(domain.Securities), nil
+	}
+
+	generation := c.readGeneration()
+	securityData, err := c.RepositoryStore.GetSecurityDataByTypeAndExchangeAndSymbol(ctx, types, exchange, symbol)
+	if err == nil {
+		c.fill(key, securityData, generation)
+	}
+	return securityData, err
+}
+
+// GetSecuritiesDataByType returns every security of a type, from the cache when present.
+func (c *cached) GetSecuritiesDataByType(ctx context.Context, types int) ([]domain.Securities, error) {
+	key := SECURITY_KEY_PREFIX + "type:" + strconv.Itoa(types)
+	if value, ok := c.cache.Get(key); ok {
+		return copySecurities(value.([]domain.Securities)), nil
+	}
+
+	generation := c.readGeneration()
+	securitiesData, err := c.RepositoryStore.GetSecuritiesDataByType(ctx, types)
+	if err == nil {
+		c.fill(key, copySecurities(securitiesData), generation)
+	}
+	return securitiesData, err
+}
+
+// SearchSecuritiesDataByTypeAndExchange returns the securities matching a search term, from the cache when present.
+func (c *cached) SearchSecuritiesDataByTypeAndExchange(ctx context.Context, types, exchange int, search string) ([]domain.Securities, error) {
+	key := SECURITY_KEY_PREFIX + "search:" + strconv.Itoa(types) + ":" + strconv.Itoa(exchange) + ":" + search
+	if value, ok := c.cache.Get(key); ok {
+		return copySecurities(value.([]domain.Securities)), nil
+	}
+
+	generation := c.readGeneration()
+	securitiesData, err := c.RepositoryStore.SearchSecuritiesDataByTypeAndExchange(ctx, types, exchange, search)
+	if err == nil {
+		c.fill(key, copySecurities(securitiesData), generation)
+	}
+	return securitiesData, err
+}
+
+// InsertSecurityData inserts a security and drops every cached security read, including cached misses.
+func (c *cached) InsertSecurityData(ctx context.Context, securityData domain.Securities) (domain.Securities, error) {
+	securityData, err := c.RepositoryStore.InsertSecurityData(ctx, securityData)
+	c.invalidate()
+	return securityData, err
+}
+
+// UpdateSecurityData updates a security and drops every cached security read.
+func (c *cached) UpdateSecurityData(ctx context.Context, securityId int, securityData domain.Securities) error {
+	err := c.RepositoryStore.UpdateSecurityData(ctx, securityId, securityData)
+	c.invalidate()
+	return err
+}
+
+// readGeneration returns the security write generation, read before loading a row to cache.
+func (c *cached) readGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// fill caches value under key unless a security write finished since generation was read, in which case value
+// may predate the write.
+func (c *cached) fill(key string, value any, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation == generation {
+		c.cache.Set(key, value)
+	}
+}
+
+// invalidate starts a new generation and drops every cached security read. It runs after the write, so a read
+// filling the cache either lands before and is dropped, or sees the new generation and is skipped.
+func (c *cached) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	c.cache.DeletePrefix(SECURITY_KEY_PREFIX)
+}
+
+// copySecurities keeps the cached slice private, so a caller changing its result cannot change the cache.
+func copySecurities(securitiesData []domain.Securities) []domain.Securities {
+	if securitiesData == nil {
+		return nil
+	}
+	return append([]domain.Securities(nil), securitiesData...)
+}
@@ -0,0 +1,107 @@
+package cached
+
+import (
+	"assetio/internal/adapters/cache/lru"
+	"assetio/internal/adapters/repository/contract"
+	"assetio/internal/adapters/repository/memory"
+	"assetio/internal/domain"
+	"assetio/internal/port"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestContract(t *testing.T) {
+	contract.Run(t, func(t *testing.T) port.RepositoryStore {
+		return New(memory.New(), lru.New(100, time.Hour))
+	})
+}
+
+// countingRepo counts the security lookups that reach the wrapped store and runs afterRead, when set, once a
+// lookup has loaded its row.
+type countingRepo struct {
+	port.RepositoryStore
+	lookups   int
+	afterRead func()
+}
+
+func (c *countingRepo) GetSecurityDataById(ctx context.Context, securityId int) (domain.Securities, error) {
+	c.lookups++
+	securityData, err := c.RepositoryStore.GetSecurityDataById(ctx, securityId)
+	if c.afterRead != nil {
+		c.afterRead()
+	}
+	return securityData, err
+}
+
+func TestSecurityReadsAreCachedUntilWritten(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingRepo{RepositoryStore: memory.New()}
+	cache := lru.New(100, time.Hour)
+	repo := New(inner, cache)
+
+	security, err := repo.InsertSecurityData(ctx, domain.Securities{Type: 1, Exchange: 1, Symbol: "ABC", Name: "Before"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if got, err := repo.GetSecurityDataById(ctx, security.Id); err != nil || got.Name != "Before" {
+			t.Fatalf("GetSecurityDataById = %+v, %v", got, err)
+		}
+	}
+	if inner.lookups != 1 {
+		t.Fatalf("store was queried %d times, want 1", inner.lookups)
+	}
+
+	if err := repo.UpdateSecurityData(ctx, security.Id, domain.Securities{Name: "After"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := repo.GetSecurityDataById(ctx, security.Id); got.Name != "After" {
+		t.Fatalf("read after update returned %q, want the updated name", got.Name)
+	}
+	if inner.lookups != 2 {
+		t.Fatalf("store was queried %d times, want 2", inner.lookups)
+	}
+
+	// A search cached before an insert must not hide the new security.
+	if found, _ := repo.SearchSecuritiesDataByTypeAndExchange(ctx, 1, 1, "XY"); len(found) != 0 {
+		t.Fatalf("search = %+v, want nothing", found)
+	}
+	if _, err := repo.InsertSecurityData(ctx, domain.Securities{Type: 1, Exchange: 1, Symbol: "XYZ", Name: "New"}); err != nil {
+		t.Fatal(err)
+	}
+	if found, _ := repo.SearchSecuritiesDataByTypeAndExchange(ctx, 1, 1, "XY"); len(found) != 1 {
+		t.Fatalf("search after insert = %+v, want the new security", found)
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 2 || stats.Misses != 4 {
+		t.Fatalf("stats = %+v, want 2 hits and 4 misses", stats)
+	}
+}
+
+func TestReadRacingWriteIsNotCached(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingRepo{RepositoryStore: memory.New()}
+	repo := New(inner, lru.New(100, time.Hour))
+
+	security, err := repo.InsertSecurityData(ctx, domain.Securities{Type: 1, Exchange: 1, Symbol: "ABC", Name: "Before"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The update lands after the read loaded the old row but before the read fills the cache.
+	inner.afterRead = func() {
+		inner.afterRead = nil
+		if err := repo.UpdateSecurityData(ctx, security.Id, domain.Securities{Name: "After"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, _ := repo.GetSecurityDataById(ctx, security.Id); got.Name != "Before" {
+		t.Fatalf("racing read returned %q, want the row it loaded", got.Name)
+	}
+	if got, _ := repo.GetSecurityDataById(ctx, security.Id); got.Name != "After" {
+		t.Fatalf("read after the racing update returned %q, want the updated name", got.Name)
+	}
+}
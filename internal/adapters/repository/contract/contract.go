@@ -0,0 +1,678 @@
+// Package contract holds the behaviour every port.RepositoryStore adapter must share.
+// Adapter packages call Run from their own tests so mysql, postgres, sqlite and the
+// in-memory store are held to the same expectations.
+package contract
+
+import (
+	"assetio/internal/domain"
+	"assetio/internal/port"
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Factory returns an empty, migrated repository. It is called once per sub test.
+type Factory func(t *testing.T) port.RepositoryStore
+
+// Run executes the repository contract against the adapter built by newRepo.
+func Run(t *testing.T, newRepo Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, repo port.RepositoryStore)
+	}{
+		{"Accounts", testAccounts},
+		{"Securities", testSecurities},
+		{"SecuritySearch", testSecuritySearch},
+		{"Inventories", testInventories},
+		{"InventoryVersion", testInventoryVersion},
+		{"DecimalPrecision", testDecimalPrecision},
+		{"InventorySummary", testInventorySummary},
+		{"InventoryLedgers", testInventoryLedgers},
+		{"AvailableQuantityByDate", testAvailableQuantityByDate},
+		{"DividendTransactions", testDividendTransactions},
+		{"ConsistencyQueries", testConsistencyQueries},
+		{"VoidedTransactions", testVoidedTransactions},
+		{"TransactionEdits", testTransactionEdits},
+		{"RealizedGains", testRealizedGains},
+		{"TransactionCharges", testTransactionCharges},
+		{"RightsIssues", testRightsIssues},
+		{"WithTxCommit", testWithTxCommit},
+		{"WithTxRollback", testWithTxRollback},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.fn(t, newRepo(t))
+		})
+	}
+}
+
+// day returns midnight UTC of the given date; whole days survive every driver's datetime precision.
+func day(year int, month time.Month, date int) time.Time {
+	return time.Date(year, month, date, 0, 0, 0, 0, time.UTC)
+}
+
+// must unwraps a (value, error) pair, failing the test on error: must(repo.Get(...))(t).
+func must[T any](value T, err error) func(t *testing.T) T {
+	return func(t *testing.T) T {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return value
+	}
+}
+
+func mustNil(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// dec parses a decimal literal: dec("12.5").
+func dec(value string) decimal.Decimal {
+	return decimal.RequireFromString(value)
+}
+
+// equalDecimal compares exactly; every adapter must return the digits it was given.
+func equalDecimal(a decimal.Decimal, b string) bool {
+	return a.Equal(dec(b))
+}
+
+func testAccounts(t *testing.T, repo port.RepositoryStore) {
+	ctx := context.Background()
+
+	account := must(repo.InsertAccountData(ctx, domain.Accounts{UserId: 7, Name: "primary", Status: 1, LotMethod: domain.LOT_METHOD_FIFO}))(t)
+	if account.Id == 0 {
+		t.Fatal("InsertAccountData did not assign an id")
+	}
+	must(repo.InsertAccountData(ctx, domain.Accounts{UserId: 7, Name: "secondary", Status: 1}))(t)
+	must(repo.InsertAccountData(ctx, domain.Accounts{UserId: 8, Name: "other user", Status: 1}))(t)
+
+	got := must(repo.GetAccountDataByIdAndUserId(ctx, account.Id, 7))(t)
+	if got.Id != account.Id || got.Name != "primary" || got.Status != 1 || got.LotMethod != domain.LOT_METHOD_FIFO {
+		t.Fatalf("GetAccountDataByIdAndUserId = %+v", got)
+	}
+
+	if got := must(repo.GetAccountDataByIdAndUserId(ctx, account.Id, 8))(t); got.Id != 0 {
+		t.Fatalf("account of another user returned: %+v", got)
+	}
+
+	if accounts := must(repo.GetAccountsData(ctx, 7))(t); len(accounts) != 2 {
+		t.Fatalf("GetAccountsData returned %d accounts, want 2", len(accounts))
+	}
+
+	mustNil(t, repo.UpdateAccountData(ctx, account.Id, 7, domain.Accounts{Name: "renamed"}))
+	got = must(repo.GetAccountDataByIdAndUserId(ctx, account.Id, 7))(t)
+	if got.Name != "renamed" || got.Status != 1 || got.LotMethod != domain.LOT_METHOD_FIFO {
+		t.Fatalf("UpdateAccountData changed more than the name: %+v", got)
+	}
+
+	mustNil(t, repo.UpdateAccountData(ctx, account.Id, 7, domain.Accounts{LotMethod: domain.LOT_METHOD_HIFO}))
+	if got := must(repo.GetAccountDataByIdAndUserId(ctx, account.Id, 7))(t); got.Name != "renamed" || got.LotMethod != domain.LOT_METHOD_HIFO {
+		t.Fatalf("UpdateAccountData did not change the lot method: %+v", got)
+	}
+}
+
+func testSecurities(t *testing.T, repo port.RepositoryStore) {
+	ctx := context.Background()
+
+	security := must(repo.InsertSecurityData(ctx, domain.Securities{Type: 1, Exchange: 1, Symbol: "TCS", Name: "Tata Consultancy Services"}))(t)
+	if security.Id == 0 {
+		t.Fatal("InsertSecurityData did not assign an id")
+	}
+	must(repo.InsertSecurityData(ctx, domain.Securities{Type: 1, Exchange: 2, Symbol: "TCS", Name: "Tata Consultancy Services"}))(t)
+	fund := must(repo.InsertSecurityData(ctx, domain.Securities{Type: 2, Exchange: 1, Symbol: "PPFAS", Name: "Parag Parikh Flexi Cap", AssetClass: 1}))(t)
+
+	if _, err := repo.InsertSecurityData(ctx, domain.Securities{Type: 1, Exchange: 1, Symbol: "TCS", Name: "duplicate"}); err == nil {
+		t.Fatal("duplicate type, exchange and symbol was accepted")
+	}
+
+	got := must(repo.GetSecurityDataById(ctx, security.Id))(t)
+	if got.Id != security.Id || got.Type != 1 || got.Exchange != 1 || got.Symbol != "TCS" || got.Name != "Tata Consultancy Services" {
+		t.Fatalf("GetSecurityDataById = %+v", got)
+	}
+	if got := must(repo.GetSecurityDataById(ctx, security.Id+100))(t); got.Id != 0 {
+		t.Fatalf("unknown security returned: %+v", got)
+	}
+
+	if got := must(repo.GetSecurityDataByTypeAndExchangeAndSymbol(ctx, 1, 1, "TCS"))(t); got.Id != security.Id {
+		t.Fatalf("GetSecurityDataByTypeAndExchangeAndSymbol = %+v", got)
+	}
+
+	mustNil(t, repo.UpdateSecurityData(ctx, security.Id, domain.Securities{Name: "TCS Ltd"}))
+	if got := must(repo.GetSecurityDataById(ctx, security.Id))(t); got.Name != "TCS Ltd" || got.Symbol != "TCS" {
+		t.Fatalf("UpdateSecurityData = %+v", got)
+	}
+
+	if securities := must(repo.GetSecuritiesDataByType(ctx, 1))(t); len(securities) != 2 {
+		t.Fatalf("GetSecuritiesDataByType returned %d securities, want 2", len(securities))
+	}
+
+	mustNil(t, repo.UpdateSecurityData(ctx, fund.Id, domain.Securities{AssetClass: 2}))
+	if got := must(repo.GetSecurityDataById(ctx, fund.Id))(t); got.AssetClass != 2 || got.Symbol != "PPFAS" {
+		t.Fatalf("UpdateSecurityData did not change the asset class: %+v", got)
+	}
+}
+
+func testSecuritySearch(t *testing.T, repo port.RepositoryStore) {
+	ctx := context.Background()
+
+	must(repo.InsertSecurityData(ctx, domain.Securities{Type: 1, Exchange: 1, Symbol: "TATASTEEL", Name: "Tata Steel"}))(t)
+	must(repo.InsertSecurityData(ctx, domain.Securities{Type: 1, Exchange: 1, Symbol: "TITAN", Name: "Titan Company"}))(t)
+	must(repo.InsertSecurityData(ctx, domain.Securities{Type: 1, Exchange: 2, Symbol: "TATAPOWER", Name: "Tata Power"}))(t)
+
+	securities := must(repo.SearchSecuritiesDataByTypeAndExchange(ctx, 1, 1, "tata"))(t)
+	if len(securities) != 1 || securities[0].Symbol != "TATASTEEL" {
+		t.Fatalf("SearchSecuritiesDataByTypeAndExchange = %+v", securities)
+	}
+
+	if securities := must(repo.SearchSecuritiesDataByTypeAndExchange(ctx, 1, 1, "company"))(t); len(securities) != 1 {
+		t.Fatalf("name search returned %+v", securities)
+	}
+
+	all := must(repo.GetSecuritiesData(ctx))(t)
+	if len(all) != 3 || all[0].Symbol != "TATASTEEL" || all[2].Symbol != "TATAPOWER" {
+		t.Fatalf("GetSecuritiesData = %+v", all)
+	}
+}
+
+func testInventories(t *testing.T, repo port.RepositoryStore) {
+	ctx := context.Background()
+
+	first := must(repo.InsertInventoryData(ctx, domain.Inventories{AccountId: 1, SecurityId: 5, Date: day(2024, 1, 10)}))(t)
+	second := must(repo.InsertInventoryData(ctx, domain.Inventories{AccountId: 1, SecurityId: 5, Date: day(2024, 3, 10)}))(t)
+	empty := must(repo.InsertInventoryData(ctx, domain.Inventories{AccountId: 1, SecurityId: 5, Date: day(2024, 2, 10)}))(t)
+	must(repo.InsertInventoryData(ctx, domain.Inventories{AccountId: 2, SecurityId: 5, Date: day(2024, 1, 10)}))(t)
+
+	mustNil(t, repo.UpdateInventoryDetailsById(ctx, first.Id, 0, dec("10"), dec("100"), dec("1000")))
+	mustNil(t, repo.UpdateInventoryDetailsById(ctx, second.Id, 0, dec("4"), dec("250"), dec("1000")))
+	mustNil(t, repo.UpdateInventoryDetailsById(ctx, empty.Id, 0, dec("3"), dec("10"), dec("30")))
+	mustNil(t, repo.UpdateAvailableQuanityToInventoryById(ctx, empty.Id, decimal.Zero))
+
+	got := must(repo.GetInventoryDataById(ctx, first.Id))(t)
+	if got.AccountId != 1 || got.SecurityId != 5 || !equalDecimal(got.AvailableQuantity, "10") || !equalDecimal(got.AveragePrice, "100") || !equalDecimal(got.TotalValue, "1000") || !got.Date.Equal(day(2024, 1, 10)) {
+		t.Fatalf("GetInventoryDataById = %+v", got)
+	}
+	if got := must(repo.GetInventoryDataById(ctx, first.Id+100))(t); got.Id != 0 {
+		t.Fatalf("unknown inventory returned: %+v", got)
+	}
+
+	active := must(repo.GetActiveInventoriesByAccountIdAndSecurityId(ctx, 1, 5))(t)
+	if len(active) != 2 || active[0].Id != first.Id || active[1].Id != second.Id {
+		t.Fatalf("GetActiveInventoriesByAccountIdAndSecurityId = %+v", active)
+	}
+	if !equalDecimal(active[1].AvailableQuantity, "4") || !equalDecimal(active[1].AveragePrice, "250") || !equalDecimal(active[1].TotalValue, "1000") {
+		t.Fatalf("active inventory columns = %+v", active[1])
+	}
+
+	details := must(repo.GetInvertriesByAccountIdAndSecurityId(ctx, 1, 5))(t)
+	if len(details) != 2 || details[0].Id != second.Id || details[1].Id != first.Id {
+		t.Fatalf("GetInvertriesByAccountIdAndSecurityId = %+v", details)
+	}
+
+	// Closed inventories are included; a zero id matches any account or security.
+	all := must(repo.GetInventoriesByAccountIdOrSecurityId(ctx, 1, 0))(t)
+	if len(all) != 3 || all[0].Id != first.Id || all[1].Id != second.Id || all[2].Id != empty.Id {
+		t.Fatalf("GetInventoriesByAccountIdOrSecurityId(account) = %+v", all)
+	}
+	if all[0].AccountId != 1 || all[0].SecurityId != 5 || all[0].Version != 1 || !equalDecimal(all[0].TotalValue, "1000") {
+		t.Fatalf("inventory columns = %+v", all[0])
+	}
+	if all := must(repo.GetInventoriesByAccountIdOrSecurityId(ctx, 0, 5))(t); len(all) != 4 {
+		t.Fatalf("GetInventoriesByAccountIdOrSecurityId(security) returned %d inventories, want 4", len(all))
+	}
+	if all := must(repo.GetInventoriesByAccountIdOrSecurityId(ctx, 2, 5))(t); len(all) != 1 {
+		t.Fatalf("GetInventoriesByAccountIdOrSecurityId(account, security) returned %d inventories, want 1", len(all))
+	}
+}
+
+func testInventoryVersion(t *testing.T, repo port.RepositoryStore) {
+	ctx := context.Background()
+
+	inventory := must(repo.InsertInventoryData(ctx, domain.Inventories{AccountId: 1, SecurityId: 5, Date: day(2024, 1, 10)}))(t)
+	if inventory.Version != 0 {
+		t.Fatalf("new inventory has version %d, want 0", inventory.Version)
+	}
+
+	mustNil(t, repo.UpdateInventoryDetailsById(ctx, inventory.Id, 0, dec("10"), dec("100"), dec("1000")))
+
+	active := must(repo.GetActiveInventoriesByAccountIdAndSecurityId(ctx, 1, 5))(t)
+	if len(active) != 1 || active[0].Version != 1 {
+		t.Fatalf("GetActiveInventoriesByAccountIdAndSecurityId = %+v, want version 1", active)
+	}
+
+	// Two requests read the same version; only the first update may apply.
+	mustNil(t, repo.UpdateInventoryDetailsById(ctx, inventory.Id, active[0].Version, dec("6"), dec("100"), dec("600")))
+	err := repo.UpdateInventoryDetailsById(ctx, inventory.Id, active[0].Version, dec("2"), dec("100"), dec("200"))
+	if !errors.Is(err, domain.ErrInventoryConflict) {
+		t.Fatalf("stale update returned %v, want ErrInventoryConflict", err)
+	}
+
+	got := must(repo.GetInventoryDataById(ctx, inventory.Id))(t)
+	if got.Version != 2 || !equalDecimal(got.AvailableQuantity, "6") {
+		t.Fatalf("stale update was applied: %+v", got)
+	}
+
+	if err := repo.UpdateInventoryDetailsById(ctx, inventory.Id+100, 0, dec("1"), dec("1"), dec("1")); !errors.Is(err, domain.ErrInventoryConflict) {
+		t.Fatalf("update of unknown inventory returned %v, want ErrInventoryConflict", err)
+	}
+}
+
+func testDecimalPrecision(t *testing.T, repo port.RepositoryStore) {
+	ctx := context.Background()
+
+	// Ten integer digits overflow the original decimal(12,4) columns. SQLite keeps decimals as REAL, so the
+	// values stay within the fifteen significant digits every adapter can return exactly.
+	inventory := must(repo.InsertInventoryData(ctx, domain.Inventories{AccountId: 1, SecurityId: 5, Date: day(2024, 1, 10)}))(t)
+	mustNil(t, repo.UpdateInventoryDetailsById(ctx, inventory.Id, 0, dec("1234567.8912"), dec("8100.0001"), dec("1234567890.1234")))
+
+	got := must(repo.GetInventoryDataById(ctx, inventory.Id))(t)
+	if !equalDecimal(got.AvailableQuantity, "1234567.8912") || !equalDecimal(got.AveragePrice, "8100.0001") || !equalDecimal(got.TotalValue, "1234567890.1234") {
+		t.Fatalf("decimal columns did not round trip: %+v", got)
+	}
+
+	ledger := must(repo.InsertInventoryLedger(ctx, domain.InventoryLedger{InventoryId: inventory.Id, Type: domain.BUY, Quantity: dec("0.0001"), AveragePrice: dec("0.1"), TotalValue: dec("0.3"), Date: day(2024, 1, 10)}))(t)
+	ledgers := must(repo.GetInventoryLedgersByInventoryId(ctx, inventory.Id))(t)
+	if len(ledgers) != 1 || ledgers[0].Id != ledger.Id || !equalDecimal(ledgers[0].Quantity, "0.0001") || !equalDecimal(ledgers[0].Price, "0.1") || !equalDecimal(ledgers[0].TotalValue, "0.3") {
+		t.Fatalf("GetInventoryLedgersByInventoryId = %+v", ledgers)
+	}
+}
+
+func testInventorySummary(t *testing.T, repo port.RepositoryStore) {
+	ctx := context.Background()
+
+	stock := must(repo.InsertSecurityData(ctx, domain.Securities{Type: 1, Exchange: 1, Symbol: "INFY", Name: "Infosys"}))(t)
+	fund := must(repo.InsertSecurityData(ctx, domain.Securities{Type: 2, Exchange: 1, Symbol: "PPFAS", Name: "Parag Parikh Flexi Cap"}))(t)
+
+	for _, inventory := range []struct {
+		securityId int
+		quantity   string
+		value      string
+	}{
+		{stock.Id, "10", "1500"},
+		{stock.Id, "5", "800"},
+		{stock.Id, "0", "0"},
+		{fund.Id, "20", "1000"},
+	} {
+		created := must(repo.InsertInventoryData(ctx, domain.Inventories{AccountId: 1, SecurityId: inventory.securityId, Date: day(2024, 1, 1)}))(t)
+		mustNil(t, repo.UpdateInventoryDetailsById(ctx, created.Id, 0, dec(inventory.quantity), decimal.Zero, dec(inventory.value)))
+	}
+
+	summary := must(repo.GetInvertriesSummaryByAccountIdAndSecurityType(ctx, 1, 1))(t)
+	if len(summary) != 1 {
+		t.Fatalf("GetInvertriesSummaryByAccountIdAndSecurityType returned %d rows, want 1", len(summary))
+	}
+	got := summary[0]
+	if got.SecurityId != stock.Id || got.SecuritySymbol != "INFY" || got.SecurityName != "Infosys" || got.SecurityExchange != "1" {
+		t.Fatalf("summary security columns = %+v", got)
+	}
+	if !equalDecimal(got.AvailableQuantity, "15") || !equalDecimal(got.TotalValue, "2300") {
+		t.Fatalf("summary totals = %+v", got)
+	}
+}
+
+func testInventoryLedgers(t *testing.T, repo port.RepositoryStore) {
+	ctx := context.Background()
+
+	inventory := must(repo.InsertInventoryData(ctx, domain.Inventories{AccountId: 1, SecurityId: 1, Date: day(2024, 1, 1)}))(t)
+	buy := must(repo.InsertInventoryLedger(ctx, domain.InventoryLedger{InventoryId: inventory.Id, Type: domain.BUY, Quantity: dec("10"), AveragePrice: dec("100"), TotalValue: dec("1000"), Date: day(2024, 1, 1)}))(t)
+	split := must(repo.InsertInventoryLedger(ctx, domain.InventoryLedger{InventoryId: inventory.Id, Type: domain.SPLIT, Quantity: dec("10"), Date: day(2024, 2, 1)}))(t)
+	sell := must(repo.InsertInventoryLedger(ctx, domain.InventoryLedger{InventoryId: inventory.Id, Type: domain.SELL, Quantity: dec("5"), AveragePrice: dec("60"), TotalValue: dec("300"), Date: day(2024, 2, 1)}))(t)
+	must(repo.InsertInventoryLedger(ctx, domain.InventoryLedger{InventoryId: inventory.Id + 1, Type: domain.BUY, Quantity: dec("1"), Date: day(2024, 1, 1)}))(t)
+
+	transaction := must(repo.InsertTransaction(ctx, domain.Transactions{AccountId: 1, SecurityId: 1, Type: domain.BUY, Quantity: dec("10"), Date: day(2024, 1, 1)}))(t)
+	if transaction.Id == 0 {
+		t.Fatal("InsertTransaction did not assign an id")
+	}
+	mustNil(t, repo.UpdateInventoryLedgerTransactionIdById(ctx, buy.Id, transaction.Id))
+	mustNil(t, repo.UpdateInventoryLedgerTransactionIdByIds(ctx, []int{split.Id, sell.Id}, transaction.Id))
+
+	ledgers := must(repo.GetInventoryLedgersByInventoryId(ctx, inventory.Id))(t)
+	if len(ledgers) != 3 {
+		t.Fatalf("GetInventoryLedgersByInventoryId returned %d ledgers, want 3", len(ledgers))
+	}
+
+	// Ordered by date, and within the same date the latest ledger first.
+	if ledgers[0].Id != buy.Id || ledgers[1].Id != sell.Id || ledgers[2].Id != split.Id {
+		t.Fatalf("ledger order = %d, %d, %d", ledgers[0].Id, ledgers[1].Id, ledgers[2].Id)
+	}
+	if ledgers[1].Type != domain.SELL || !equalDecimal(ledgers[1].Quantity, "5") || !equalDecimal(ledgers[1].Price, "60") || !equalDecimal(ledgers[1].TotalValue, "300") {
+		t.Fatalf("ledger columns = %+v", ledgers[1])
+	}
+}
+
+func testAvailableQuantityByDate(t *testing.T, repo port.RepositoryStore) {
+	ctx := context.Background()
+
+	for _, transaction := range []domain.Transactions{
+		{AccountId: 1, SecurityId: 1, Type: domain.BUY, Quantity: dec("10"), Date: day(2024, 1, 1)},
+		{AccountId: 1, SecurityId: 1, Type: domain.BUY, Quantity: dec("5"), Date: day(2024, 3, 1)},
+		{AccountId: 1, SecurityId: 1, Type: domain.SELL, Quantity: dec("4"), Date: day(2024, 3, 1)},
+		{AccountId: 1, SecurityId: 1, Type: domain.DIVIDEND, Quantity: dec("6"), Date: day(2024, 2, 1)},
+		{AccountId: 1, SecurityId: 1, Type: domain.BUYBACK, Quantity: dec("2"), Date: day(2024, 3, 5)},
+		{AccountId: 1, SecurityId: 1, Type: domain.RIGHTS_SUBSCRIBE, Quantity: dec("3"), Date: day(2024, 3, 10)},
+		{AccountId: 2, SecurityId: 1, Type: domain.BUY, Quantity: dec("100"), Date: day(2024, 1, 1)},
+	} {
+		must(repo.InsertTransaction(ctx, transaction))(t)
+	}
+
+	// Buys and rights subscriptions count strictly before the date, sells and buybacks up to and including it.
+	for _, test := range []struct {
+		date time.Time
+		want string
+	}{
+		{day(2024, 1, 1), "0"},
+		{day(2024, 2, 1), "10"},
+		{day(2024, 3, 1), "6"},
+		{day(2024, 3, 2), "11"},
+		{day(2024, 3, 5), "9"},
+		{day(2024, 3, 10), "9"},
+		{day(2024, 3, 11), "12"},
+	} {
+		got := must(repo.GetInventoryAvailableQuanitityBySecurityIdAndDate(ctx, 1, 1, test.date))(t)
+		if !equalDecimal(got, test.want) {
+			t.Errorf("available quantity on %s = %v, want %v", test.date.Format(time.DateOnly), got, test.want)
+		}
+	}
+
+	if got := must(repo.GetInventoryAvailableQuanitityBySecurityIdAndDate(ctx, 3, 1, day(2024, 3, 2)))(t); !got.IsZero() {
+		t.Fatalf("available quantity without transactions = %v, want 0", got)
+	}
+}
+
+func testDividendTransactions(t *testing.T, repo port.RepositoryStore) {
+	ctx := context.Background()
+
+	for _, transaction := range []domain.Transactions{
+		{AccountId: 1, SecurityId: 1, Type: domain.DIVIDEND, Quantity: dec("10"), AveragePrice: dec("2"), TotalValue: dec("20"), Date: day(2023, 6, 1)},
+		{AccountId: 1, SecurityId: 1, Type: domain.DIVIDEND, Quantity: dec("12"), AveragePrice: dec("3"), TotalValue: dec("36"), Date: day(2024, 6, 1)},
+		{AccountId: 1, SecurityId: 1, Type: domain.BUY, Quantity: dec("2"), Date: day(2024, 1, 1)},
+		{AccountId: 1, SecurityId: 2, Type: domain.DIVIDEND, Quantity: dec("1"), Date: day(2024, 6, 1)},
+	} {
+		must(repo.InsertTransaction(ctx, transaction))(t)
+	}
+
+	dividends := must(repo.GetDividendTransactionsByAccountIdAndSecurityId(ctx, 1, 1))(t)
+	if len(dividends) != 2 {
+		t.Fatalf("GetDividendTransactionsByAccountIdAndSecurityId returned %d rows, want 2", len(dividends))
+	}
+	if !dividends[0].Date.Equal(day(2024, 6, 1)) || !equalDecimal(dividends[0].Price, "3") || !equalDecimal(dividends[0].TotalValue, "36") {
+		t.Fatalf("latest dividend = %+v", dividends[0])
+	}
+}
+
+func testWithTxCommit(t *testing.T, repo port.RepositoryStore) {
+	ctx := context.Background()
+
+	var security domain.Securities
+	err := repo.WithTx(ctx, func(tx port.RepositoryStore) error {
+		var err error
+		security, err = tx.InsertSecurityData(ctx, domain.Securities{Type: 1, Exchange: 1, Symbol: "HDFCBANK", Name: "HDFC Bank"})
+		return err
+	})
+	mustNil(t, err)
+
+	if got := must(repo.GetSecurityDataById(ctx, security.Id))(t); got.Symbol != "HDFCBANK" {
+		t.Fatalf("committed security not visible: %+v", got)
+	}
+}
+
+func testWithTxRollback(t *testing.T, repo port.RepositoryStore) {
+	ctx := context.Background()
+	errAbort := errors.New("abort")
+
+	inventory := must(repo.InsertInventoryData(ctx, domain.Inventories{AccountId: 1, SecurityId: 1, Date: day(2024, 1, 1)}))(t)
+	mustNil(t, repo.UpdateInventoryDetailsById(ctx, inventory.Id, 0, dec("10"), dec("100"), dec("1000")))
+
+	var ledgerInventoryId int
+	err := repo.WithTx(ctx, func(tx port.RepositoryStore) error {
+		created, err := tx.InsertInventoryData(ctx, domain.Inventories{AccountId: 1, SecurityId: 1, Date: day(2024, 2, 1)})
+		if err != nil {
+			return err
+		}
+		ledgerInventoryId = created.Id
+		if _, err := tx.InsertInventoryLedger(ctx, domain.InventoryLedger{InventoryId: created.Id, Type: domain.BUY, Quantity: dec("1"), Date: day(2024, 2, 1)}); err != nil {
+			return err
+		}
+		if err := tx.UpdateInventoryDetailsById(ctx, inventory.Id, 1, dec("0"), dec("0"), dec("0")); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("WithTx returned %v, want the closure error", err)
+	}
+
+	active := must(repo.GetActiveInventoriesByAccountIdAndSecurityId(ctx, 1, 1))(t)
+	sort.Slice(active, func(i, j int) bool { return active[i].Id < active[j].Id })
+	if len(active) != 1 || active[0].Id != inventory.Id || !equalDecimal(active[0].AvailableQuantity, "10") {
+		t.Fatalf("rolled back changes are visible: %+v", active)
+	}
+	if ledgers := must(repo.GetInventoryLedgersByInventoryId(ctx, ledgerInventoryId))(t); len(ledgers) != 0 {
+		t.Fatalf("rolled back ledger is visible: %+v", ledgers)
+	}
+}
+
+func testConsistencyQueries(t *testing.T, repo port.RepositoryStore) {
+	ctx := context.Background()
+
+	inventory := must(repo.InsertInventoryData(ctx, domain.Inventories{AccountId: 1, SecurityId: 5, Date: day(2024, 1, 10)}))(t)
+	other := must(repo.InsertInventoryData(ctx, domain.Inventories{AccountId: 2, SecurityId: 5, Date: day(2024, 1, 10)}))(t)
+
+	buy := must(repo.InsertTransaction(ctx, domain.Transactions{AccountId: 1, SecurityId: 5, Type: domain.BUY, Quantity: dec("10"), Date: day(2024, 1, 10)}))(t)
+	sell := must(repo.InsertTransaction(ctx, domain.Transactions{AccountId: 1, SecurityId: 5, Type: domain.SELL, Quantity: dec("4"), Date: day(2024, 2, 10)}))(t)
+	must(repo.InsertTransaction(ctx, domain.Transactions{AccountId: 1, SecurityId: 5, Type: domain.DIVIDEND, Quantity: dec("10"), Date: day(2024, 3, 10)}))(t)
+
+	linked := must(repo.InsertInventoryLedger(ctx, domain.InventoryLedger{InventoryId: inventory.Id, Type: domain.BUY, Quantity: dec("6"), Date: day(2024, 1, 10)}))(t)
+	linkedToo := must(repo.InsertInventoryLedger(ctx, domain.InventoryLedger{InventoryId: inventory.Id, Type: domain.BUY, Quantity: dec("3.5"), Date: day(2024, 1, 10)}))(t)
+	mustNil(t, repo.UpdateInventoryLedgerTransactionIdByIds(ctx, []int{linked.Id, linkedToo.Id}, buy.Id))
+	unset := must(repo.InsertInventoryLedger(ctx, domain.InventoryLedger{InventoryId: inventory.Id, Type: domain.SELL, Quantity: dec("4"), Date: day(2024, 2, 10)}))(t)
+	dangling := must(repo.InsertInventoryLedger(ctx, domain.InventoryLedger{InventoryId: inventory.Id, TransactionId: sell.Id + 100, Type: domain.SELL, Quantity: dec("1"), Date: day(2024, 2, 10)}))(t)
+	otherUnset := must(repo.InsertInventoryLedger(ctx, domain.InventoryLedger{InventoryId: other.Id, Type: domain.BUY, Quantity: dec("1"), Date: day(2024, 1, 10)}))(t)
+
+	unlinked := must(repo.GetUnlinkedInventoryLedgers(ctx, 1))(t)
+	if len(unlinked) != 2 || unlinked[0].Id != unset.Id || unlinked[1].Id != dangling.Id || unlinked[1].InventoryId != inventory.Id || unlinked[1].TransactionId != sell.Id+100 {
+		t.Fatalf("GetUnlinkedInventoryLedgers(1) = %+v", unlinked)
+	}
+	if all := must(repo.GetUnlinkedInventoryLedgers(ctx, 0))(t); len(all) != 3 || all[2].Id != otherUnset.Id {
+		t.Fatalf("GetUnlinkedInventoryLedgers(0) = %+v", all)
+	}
+
+	totals := must(repo.GetTransactionLedgerTotals(ctx, 1))(t)
+	if len(totals) != 2 || totals[0].Id != buy.Id || totals[1].Id != sell.Id {
+		t.Fatalf("GetTransactionLedgerTotals = %+v", totals)
+	}
+	if totals[0].Type != domain.BUY || totals[0].AccountId != 1 || totals[0].SecurityId != 5 || !equalDecimal(totals[0].Quantity, "10") || !equalDecimal(totals[0].LedgerQuantity, "9.5") || totals[0].LedgerCount != 2 {
+		t.Fatalf("buy totals = %+v", totals[0])
+	}
+	if !equalDecimal(totals[1].LedgerQuantity, "0") || totals[1].LedgerCount != 0 {
+		t.Fatalf("sell totals = %+v", totals[1])
+	}
+	if totals := must(repo.GetTransactionLedgerTotals(ctx, 2))(t); len(totals) != 0 {
+		t.Fatalf("GetTransactionLedgerTotals(2) = %+v", totals)
+	}
+}
+
+func testVoidedTransactions(t *testing.T, repo port.RepositoryStore) {
+	ctx := context.Background()
+
+	inventory := must(repo.InsertInventoryData(ctx, domain.Inventories{AccountId: 1, SecurityId: 5, Date: day(2024, 1, 10)}))(t)
+	buy := must(repo.InsertTransaction(ctx, domain.Transactions{AccountId: 1, SecurityId: 5, Type: domain.BUY, Quantity: dec("10"), Date: day(2024, 1, 10)}))(t)
+	sell := must(repo.InsertTransaction(ctx, domain.Transactions{AccountId: 1, SecurityId: 5, Type: domain.SELL, Quantity: dec("4"), Date: day(2024, 2, 10)}))(t)
+	must(repo.InsertInventoryLedger(ctx, domain.InventoryLedger{InventoryId: inventory.Id, TransactionId: buy.Id, Type: domain.BUY, Quantity: dec("10"), Date: day(2024, 1, 10)}))(t)
+	sold := must(repo.InsertInventoryLedger(ctx, domain.InventoryLedger{InventoryId: inventory.Id, TransactionId: sell.Id, Type: domain.SELL, Quantity: dec("4"), Date: day(2024, 2, 10)}))(t)
+
+	if got := must(repo.GetTransactionDataById(ctx, sell.Id))(t); got.Id != sell.Id || got.Type != domain.SELL || got.State != domain.TRANSACTION_STATE_ACTIVE {
+		t.Fatalf("GetTransactionDataById = %+v", got)
+	}
+	if got := must(repo.GetTransactionDataById(ctx, sell.Id+100))(t); got.Id != 0 {
+		t.Fatalf("GetTransactionDataById for a missing id = %+v", got)
+	}
+
+	// Void the sale: reverse its ledger entry and mark it voided.
+	must(repo.InsertInventoryLedger(ctx, domain.InventoryLedger{InventoryId: inventory.Id, TransactionId: sell.Id, Type: domain.VOID, Quantity: dec("4"), Date: day(2024, 3, 10)}))(t)
+	mustNil(t, repo.UpdateTransactionStateById(ctx, sell.Id, domain.TRANSACTION_STATE_VOIDED))
+
+	if got := must(repo.GetTransactionDataById(ctx, sell.Id))(t); got.State != domain.TRANSACTION_STATE_VOIDED {
+		t.Fatalf("state after UpdateTransactionStateById = %d", got.State)
+	}
+
+	ledgers := must(repo.GetInventoryLedgersByTransactionId(ctx, sell.Id))(t)
+	if len(ledgers) != 2 || ledgers[0].Id != sold.Id || ledgers[1].Type != domain.VOID || ledgers[1].InventoryId != inventory.Id {
+		t.Fatalf("GetInventoryLedgersByTransactionId = %+v", ledgers)
+	}
+
+	for _, ledger := range must(repo.GetInventoryLedgersByInventoryId(ctx, inventory.Id))(t) {
+		if ledger.TransactionId == 0 {
+			t.Fatalf("GetInventoryLedgersByInventoryId left out the transaction id: %+v", ledger)
+		}
+	}
+
+	// VOID entries do not count towards their transaction's ledger quantity.
+	totals := must(repo.GetTransactionLedgerTotals(ctx, 1))(t)
+	if len(totals) != 2 || !equalDecimal(totals[1].LedgerQuantity, "4") || totals[1].LedgerCount != 1 {
+		t.Fatalf("GetTransactionLedgerTotals = %+v", totals)
+	}
+
+	// A voided sale no longer reduces the quantity held.
+	if got := must(repo.GetInventoryAvailableQuanitityBySecurityIdAndDate(ctx, 1, 5, day(2024, 4, 10)))(t); !equalDecimal(got, "10") {
+		t.Fatalf("available quantity after void = %s, want 10", got)
+	}
+}
+
+func testTransactionEdits(t *testing.T, repo port.RepositoryStore) {
+	ctx := context.Background()
+
+	later := must(repo.InsertTransaction(ctx, domain.Transactions{AccountId: 1, SecurityId: 6, Type: domain.SELL, Quantity: dec("2"), Date: day(2024, 3, 1)}))(t)
+	buy := must(repo.InsertTransaction(ctx, domain.Transactions{AccountId: 1, SecurityId: 6, Type: domain.BUY, Quantity: dec("5"), AveragePrice: dec("10"), Date: day(2024, 1, 1)}))(t)
+	must(repo.InsertTransaction(ctx, domain.Transactions{AccountId: 2, SecurityId: 6, Type: domain.BUY, Quantity: dec("1"), Date: day(2024, 1, 1)}))(t)
+
+	transactions := must(repo.GetTransactionsByAccountIdAndSecurityId(ctx, 1, 6))(t)
+	if len(transactions) != 2 || transactions[0].Id != buy.Id || transactions[1].Id != later.Id {
+		t.Fatalf("GetTransactionsByAccountIdAndSecurityId = %+v, want the buy then the sell", transactions)
+	}
+	other := must(repo.InsertTransaction(ctx, domain.Transactions{AccountId: 1, SecurityId: 9, Type: domain.DIVIDEND, TotalValue: dec("4"), Date: day(2024, 2, 1)}))(t)
+	transactions = must(repo.GetTransactionsByAccountId(ctx, 1))(t)
+	if len(transactions) != 3 || transactions[0].Id != buy.Id || transactions[1].Id != other.Id || transactions[2].Id != later.Id {
+		t.Fatalf("GetTransactionsByAccountId = %+v, want every security of the account by date", transactions)
+	}
+
+	mustNil(t, repo.UpdateTransactionById(ctx, buy.Id, domain.Transactions{Quantity: dec("6"), AveragePrice: dec("11"), TotalValue: dec("66"), Fee: dec("1.5"), Date: day(2024, 1, 2)}))
+	updated := must(repo.GetTransactionDataById(ctx, buy.Id))(t)
+	if !equalDecimal(updated.Quantity, "6") || !equalDecimal(updated.TotalValue, "66") || !equalDecimal(updated.Fee, "1.5") || !updated.Date.Equal(day(2024, 1, 2)) || updated.Type != domain.BUY {
+		t.Fatalf("UpdateTransactionById left %+v", updated)
+	}
+
+	// The terms of a split are kept through an edit of its values.
+	split := must(repo.InsertTransaction(ctx, domain.Transactions{AccountId: 1, SecurityId: 6, Type: domain.SPLIT, Quantity: dec("10"), RatioFrom: dec("1"), RatioTo: dec("3"), Date: day(2024, 2, 1)}))(t)
+	mustNil(t, repo.UpdateTransactionById(ctx, split.Id, domain.Transactions{Quantity: dec("12"), Date: day(2024, 2, 1)}))
+	if got := must(repo.GetTransactionDataById(ctx, split.Id))(t); !equalDecimal(got.Quantity, "12") || !equalDecimal(got.RatioFrom, "1") || !equalDecimal(got.RatioTo, "3") {
+		t.Fatalf("split after update = %+v, want its ratio kept", got)
+	}
+
+	inventory := must(repo.InsertInventoryData(ctx, domain.Inventories{AccountId: 1, SecurityId: 6, Date: day(2024, 1, 1)}))(t)
+	kept := must(repo.InsertInventoryLedger(ctx, domain.InventoryLedger{InventoryId: inventory.Id, TransactionId: buy.Id, Type: domain.BUY, Quantity: dec("5"), Date: day(2024, 1, 1)}))(t)
+	dropped := must(repo.InsertInventoryLedger(ctx, domain.InventoryLedger{InventoryId: inventory.Id, TransactionId: later.Id, Type: domain.SELL, Quantity: dec("2"), Date: day(2024, 3, 1)}))(t)
+
+	mustNil(t, repo.UpdateInventoryLedgerById(ctx, kept.Id, domain.InventoryLedger{Quantity: dec("6"), AveragePrice: dec("11"), TotalValue: dec("66"), Fee: dec("1.5"), Date: day(2024, 1, 2)}))
+	mustNil(t, repo.DeleteInventoryLedgerById(ctx, dropped.Id))
+	mustNil(t, repo.UpdateInventoryDateById(ctx, inventory.Id, day(2024, 1, 2)))
+
+	ledgers := must(repo.GetInventoryLedgersByInventoryId(ctx, inventory.Id))(t)
+	if len(ledgers) != 1 || ledgers[0].Id != kept.Id || !equalDecimal(ledgers[0].Quantity, "6") || !equalDecimal(ledgers[0].TotalValue, "66") || !ledgers[0].Date.Equal(day(2024, 1, 2)) {
+		t.Fatalf("ledgers after update and delete = %+v", ledgers)
+	}
+	if got := must(repo.GetInventoryDataById(ctx, inventory.Id))(t); !got.Date.Equal(day(2024, 1, 2)) {
+		t.Fatalf("inventory date = %v", got.Date)
+	}
+}
+
+func testRealizedGains(t *testing.T, repo port.RepositoryStore) {
+	ctx := context.Background()
+
+	later := must(repo.InsertRealizedGain(ctx, domain.RealizedGains{AccountId: 1, SecurityId: 6, InventoryId: 1, Quantity: dec("2"), CostValue: dec("20.5"), Proceeds: dec("30"), Fee: dec("0.25"), Gain: dec("9.25"), AcquisitionDate: day(2024, 1, 1), Date: day(2024, 3, 1)}))(t)
+	earlier := must(repo.InsertRealizedGain(ctx, domain.RealizedGains{AccountId: 1, SecurityId: 6, InventoryId: 2, Quantity: dec("1"), Date: day(2024, 2, 1)}))(t)
+	other := must(repo.InsertRealizedGain(ctx, domain.RealizedGains{AccountId: 1, SecurityId: 7, InventoryId: 3, Quantity: dec("4"), Date: day(2024, 2, 15)}))(t)
+	must(repo.InsertRealizedGain(ctx, domain.RealizedGains{AccountId: 2, SecurityId: 6, InventoryId: 4, Quantity: dec("1"), Date: day(2024, 2, 1)}))(t)
+	if later.Id == 0 {
+		t.Fatal("InsertRealizedGain did not assign an id")
+	}
+
+	all := must(repo.GetRealizedGains(ctx, 1, 0, time.Time{}, time.Time{}))(t)
+	if len(all) != 3 || all[0].Id != earlier.Id || all[1].Id != other.Id || all[2].Id != later.Id {
+		t.Fatalf("GetRealizedGains(any) = %+v, want the account's records by date", all)
+	}
+	if !equalDecimal(all[2].CostValue, "20.5") || !equalDecimal(all[2].Gain, "9.25") || !all[2].AcquisitionDate.Equal(day(2024, 1, 1)) {
+		t.Fatalf("GetRealizedGains returned %+v", all[2])
+	}
+
+	if got := must(repo.GetRealizedGains(ctx, 1, 6, time.Time{}, time.Time{}))(t); len(got) != 2 {
+		t.Fatalf("GetRealizedGains(security 6) returned %d records, want 2", len(got))
+	}
+	if got := must(repo.GetRealizedGains(ctx, 1, 0, day(2024, 2, 10), day(2024, 3, 1)))(t); len(got) != 2 || got[0].Id != other.Id {
+		t.Fatalf("GetRealizedGains(range) = %+v, want the two later records", got)
+	}
+
+	mustNil(t, repo.DeleteRealizedGainsByInventoryId(ctx, 1))
+	if got := must(repo.GetRealizedGains(ctx, 1, 6, time.Time{}, time.Time{}))(t); len(got) != 1 || got[0].Id != earlier.Id {
+		t.Fatalf("GetRealizedGains after delete = %+v", got)
+	}
+}
+
+func testTransactionCharges(t *testing.T, repo port.RepositoryStore) {
+	ctx := context.Background()
+
+	if got := must(repo.GetTransactionChargesByTransactionIds(ctx, nil))(t); len(got) != 0 {
+		t.Fatalf("GetTransactionChargesByTransactionIds(none) = %+v", got)
+	}
+
+	later := must(repo.InsertTransactionCharges(ctx, domain.TransactionCharges{TransactionId: 9, Brokerage: dec("20"), Stt: dec("12"), ExchangeFee: dec("0.36"), SebiFee: dec("0.01"), StampDuty: dec("1.8"), Gst: dec("3.67")}))(t)
+	earlier := must(repo.InsertTransactionCharges(ctx, domain.TransactionCharges{TransactionId: 4, Stt: dec("1")}))(t)
+	must(repo.InsertTransactionCharges(ctx, domain.TransactionCharges{TransactionId: 5, Stt: dec("2")}))(t)
+	if later.Id == 0 {
+		t.Fatal("InsertTransactionCharges did not assign an id")
+	}
+
+	got := must(repo.GetTransactionChargesByTransactionIds(ctx, []int{9, 4, 7}))(t)
+	if len(got) != 2 || got[0].Id != earlier.Id || got[1].Id != later.Id {
+		t.Fatalf("GetTransactionChargesByTransactionIds = %+v, want the two charges by transaction", got)
+	}
+	if !equalDecimal(got[1].ExchangeFee, "0.36") || !equalDecimal(got[1].Total(), "37.84") {
+		t.Fatalf("GetTransactionChargesByTransactionIds returned %+v", got[1])
+	}
+
+	mustNil(t, repo.DeleteTransactionChargesByTransactionId(ctx, 9))
+	if got := must(repo.GetTransactionChargesByTransactionIds(ctx, []int{9, 4}))(t); len(got) != 1 || got[0].Id != earlier.Id {
+		t.Fatalf("GetTransactionChargesByTransactionIds after delete = %+v", got)
+	}
+}
+
+func testRightsIssues(t *testing.T, repo port.RepositoryStore) {
+	ctx := context.Background()
+
+	rightsIssue := must(repo.InsertRightsIssue(ctx, domain.RightsIssues{AccountId: 1, SecurityId: 7, TransactionId: 3, RecordDate: day(2024, 5, 10), RatioRights: dec("1"), RatioHeld: dec("4"), IssuePrice: dec("120.5"), PaidPrice: dec("60.25"), Entitled: dec("25")}))(t)
+	if rightsIssue.Id == 0 {
+		t.Fatal("InsertRightsIssue did not assign an id")
+	}
+
+	if got := must(repo.GetRightsIssueByIdAndAccountId(ctx, rightsIssue.Id, 2))(t); got.Id != 0 {
+		t.Fatalf("GetRightsIssueByIdAndAccountId(other account) = %+v", got)
+	}
+	got := must(repo.GetRightsIssueByIdAndAccountId(ctx, rightsIssue.Id, 1))(t)
+	if got.SecurityId != 7 || !got.RecordDate.Equal(day(2024, 5, 10)) || !equalDecimal(got.IssuePrice, "120.5") || !equalDecimal(got.Entitled, "25") || !equalDecimal(got.Unused(), "25") {
+		t.Fatalf("GetRightsIssueByIdAndAccountId = %+v", got)
+	}
+
+	got.InventoryId, got.PaidPrice, got.Renounced, got.Subscribed = 4, dec("120.5"), dec("5"), dec("20")
+	mustNil(t, repo.UpdateRightsIssueById(ctx, rightsIssue.Id, got))
+	got = must(repo.GetRightsIssueByIdAndAccountId(ctx, rightsIssue.Id, 1))(t)
+	if got.InventoryId != 4 || !equalDecimal(got.PaidPrice, "120.5") || !equalDecimal(got.Renounced, "5") || !equalDecimal(got.Subscribed, "20") || !got.Unused().IsZero() {
+		t.Fatalf("GetRightsIssueByIdAndAccountId after update = %+v", got)
+	}
+}
@@ -0,0 +1,963 @@
+package memory
+
+import (
+	"assetio/internal/adapters/repository/migration"
+	"assetio/internal/domain"
+	"assetio/internal/port"
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrDuplicateSecurity mirrors the unique (type, exchange, symbol) index of the SQL adapters.
+var ErrDuplicateSecurity = errors.New("security already exists for type, exchange and symbol")
+
+// tables holds every row of the store. Rows are kept by value so a copy of the slices is a full snapshot.
+type tables struct {
+	accounts         []domain.Accounts
+	securities       []domain.Securities
+	inventories      []domain.Inventories
+	inventoryLedgers []domain.InventoryLedger
+	transactions     []domain.Transactions
+	realizedGains    []domain.RealizedGains
+	charges          []domain.TransactionCharges
+	rightsIssues     []domain.RightsIssues
+	lastId           map[string]int
+}
+
+type memory struct {
+	mu   *sync.Mutex
+	data *tables
+	inTx bool
+}
+
+// New creates an empty in-memory RepositoryStore. It is safe for concurrent use and is meant for
+// tests and for trying the API without a database; nothing is persisted across restarts.
+func New() port.RepositoryStore {
+	return &memory{
+		mu: &sync.Mutex{},
+		data: &tables{
+			lastId: map[string]int{},
+		},
+	}
+}
+
+// lock acquires the store mutex unless the call runs inside WithTx, which already holds it.
+func (m *memory) lock() {
+	if !m.inTx {
+		m.mu.Lock()
+	}
+}
+
+// unlock releases the store mutex acquired by lock.
+func (m *memory) unlock() {
+	if !m.inTx {
+		m.mu.Unlock()
+	}
+}
+
+// nextId returns the next auto increment id for the given table.
+func (m *memory) nextId(table string) int {
+	m.data.lastId[table]++
+	return m.data.lastId[table]
+}
+
+// snapshot copies every table so a failed transaction can be rolled back.
+func (t *tables) snapshot() tables {
+	lastId := make(map[string]int, len(t.lastId))
+	for table, id := range t.lastId {
+		lastId[table] = id
+	}
+
+	return tables{
+		accounts:         append([]domain.Accounts(nil), t.accounts...),
+		securities:       append([]domain.Securities(nil), t.securities...),
+		inventories:      append([]domain.Inventories(nil), t.inventories...),
+		inventoryLedgers: append([]domain.InventoryLedger(nil), t.inventoryLedgers...),
+		transactions:     append([]domain.Transactions(nil), t.transactions...),
+		realizedGains:    append([]domain.RealizedGains(nil), t.realizedGains...),
+		charges:          append([]domain.TransactionCharges(nil), t.charges...),
+		rightsIssues:     append([]domain.RightsIssues(nil), t.rightsIssues...),
+		lastId:           lastId,
+	}
+}
+
+// WithTx runs fn while holding the store lock. Every change made through the repository passed
+// to fn is discarded when fn returns an error or panics. Nested calls join the outer transaction.
+func (m *memory) WithTx(ctx context.Context, fn func(repo port.RepositoryStore) error) (err error) {
+	if m.inTx {
+		return fn(m)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot := m.data.snapshot()
+	committed := false
+	defer func() {
+		if !committed {
+			*m.data = snapshot
+		}
+	}()
+
+	if err = fn(&memory{mu: m.mu, data: m.data, inTx: true}); err != nil {
+		return err
+	}
+
+	committed = true
+	return nil
+}
+
+// MigrateUp is a no-op; the in-memory tables need no schema.
+func (m *memory) MigrateUp(ctx context.Context) ([]domain.SchemaMigration, error) {
+	return nil, nil
+}
+
+// MigrateDown always fails; there is no schema to revert.
+func (m *memory) MigrateDown(ctx context.Context) (domain.SchemaMigration, error) {
+	return domain.SchemaMigration{}, errors.New("the in-memory store has no schema to revert")
+}
+
+// MigrationStatus reports every known migration as applied.
+func (m *memory) MigrationStatus(ctx context.Context) ([]domain.SchemaMigration, error) {
+	return migration.Known(), nil
+}
+
+// SchemaVersion reports the in-memory store as always being on the latest schema.
+func (m *memory) SchemaVersion(ctx context.Context) (int, int, error) {
+	return migration.Latest(), migration.Latest(), nil
+}
+
+// InsertAccountData adds a new account and returns it with its generated id.
+func (m *memory) InsertAccountData(ctx context.Context, accountData domain.Accounts) (domain.Accounts, error) {
+	m.lock()
+	defer m.unlock()
+
+	now := time.Now()
+	accountData.Id = m.nextId("accounts")
+	accountData.CreatedAt, accountData.UpdatedAt = now, now
+	m.data.accounts = append(m.data.accounts, accountData)
+	return accountData, nil
+}
+
+// GetAccountDataByIdAndUserId returns the id, name, status and lot method of the matching account, or an empty account.
+func (m *memory) GetAccountDataByIdAndUserId(ctx context.Context, accountId, userId int) (domain.Accounts, error) {
+	m.lock()
+	defer m.unlock()
+
+	for _, account := range m.data.accounts {
+		if account.Id == accountId && account.UserId == userId {
+			return domain.Accounts{Id: account.Id, Name: account.Name, Status: account.Status, LotMethod: account.LotMethod}, nil
+		}
+	}
+	return domain.Accounts{}, nil
+}
+
+// GetAccountsData returns the id, name, status and lot method of every account owned by the user.
+func (m *memory) GetAccountsData(ctx context.Context, userId int) ([]domain.Accounts, error) {
+	m.lock()
+	defer m.unlock()
+
+	var accountsData []domain.Accounts
+	for _, account := range m.data.accounts {
+		if account.UserId == userId {
+			accountsData = append(accountsData, domain.Accounts{Id: account.Id, Name: account.Name, Status: account.Status, LotMethod: account.LotMethod})
+		}
+	}
+	return accountsData, nil
+}
+
+// UpdateAccountData updates the non-zero fields of the matching account, like gorm's Updates with a struct.
+func (m *memory) UpdateAccountData(ctx context.Context, accountId, userId int, accountData domain.Accounts) error {
+	m.lock()
+	defer m.unlock()
+
+	for i, account := range m.data.accounts {
+		if account.Id != accountId || account.UserId != userId {
+			continue
+		}
+		if accountData.Name != "" {
+			account.Name = accountData.Name
+		}
+		if accountData.Status != 0 {
+			account.Status = accountData.Status
+		}
+		if accountData.LotMethod != "" {
+			account.LotMethod = accountData.LotMethod
+		}
+		account.UpdatedAt = time.Now()
+		m.data.accounts[i] = account
+	}
+	return nil
+}
+
+// InsertSecurityData adds a new security, rejecting duplicates of the type, exchange and symbol.
+func (m *memory) InsertSecurityData(ctx context.Context, securityData domain.Securities) (domain.Securities, error) {
+	m.lock()
+	defer m.unlock()
+
+	for _, security := range m.data.securities {
+		if security.Type == securityData.Type && security.Exchange == securityData.Exchange && security.Symbol == securityData.Symbol {
+			return securityData, ErrDuplicateSecurity
+		}
+	}
+
+	now := time.Now()
+	securityData.Id = m.nextId("securities")
+	securityData.CreatedAt, securityData.UpdatedAt = now, now
+	m.data.securities = append(m.data.securities, securityData)
+	return securityData, nil
+}
+
+// securityColumns returns the columns the SQL adapters select for security lookups.
+func securityColumns(security domain.Securities) domain.Securities {
+	return domain.Securities{
+		Id:         security.Id,
+		Type:       security.Type,
+		Exchange:   security.Exchange,
+		Symbol:     security.Symbol,
+		Name:       security.Name,
+		AssetClass: security.AssetClass,
+	}
+}
+
+// GetSecurityDataById returns the security with the given id, or an empty security.
+func (m *memory) GetSecurityDataById(ctx context.Context, securityId int) (domain.Securities, error) {
+	m.lock()
+	defer m.unlock()
+
+	for _, security := range m.data.securities {
+		if security.Id == securityId {
+			return securityColumns(security), nil
+		}
+	}
+	return domain.Securities{}, nil
+}
+
+// GetSecurityDataByTypeAndExchangeAndSymbol returns only the id of the matching security, or an empty security.
+func (m *memory) GetSecurityDataByTypeAndExchangeAndSymbol(ctx context.Context, types, exchange int, symbol string) (domain.Securities, error) {
+	m.lock()
+	defer m.unlock()
+
+	for _, security := range m.data.securities {
+		if security.Type == types && security.Exchange == exchange && security.Symbol == symbol {
+			return domain.Securities{Id: security.Id}, nil
+		}
+	}
+	return domain.Securities{}, nil
+}
+
+// UpdateSecurityData updates the non-zero fields of the security with the given id.
+func (m *memory) UpdateSecurityData(ctx context.Context, securityId int, securityData domain.Securities) error {
+	m.lock()
+	defer m.unlock()
+
+	for i, security := range m.data.securities {
+		if security.Id != securityId {
+			continue
+		}
+		if securityData.Type != 0 {
+			security.Type = securityData.Type
+		}
+		if securityData.Exchange != 0 {
+			security.Exchange = securityData.Exchange
+		}
+		if securityData.Symbol != "" {
+			security.Symbol = securityData.Symbol
+		}
+		if securityData.Name != "" {
+			security.Name = securityData.Name
+		}
+		if securityData.AssetClass != 0 {
+			security.AssetClass = securityData.AssetClass
+		}
+		security.UpdatedAt = time.Now()
+		m.data.securities[i] = security
+	}
+	return nil
+}
+
+// GetSecuritiesDataByType returns every security of the given type.
+func (m *memory) GetSecuritiesDataByType(ctx context.Context, types int) ([]domain.Securities, error) {
+	m.lock()
+	defer m.unlock()
+
+	var securitiesData []domain.Securities
+	for _, security := range m.data.securities {
+		if security.Type == types {
+			securitiesData = append(securitiesData, securityColumns(security))
+		}
+	}
+	return securitiesData, nil
+}
+
+// GetSecuritiesData returns every security, ordered by id.
+func (m *memory) GetSecuritiesData(ctx context.Context) ([]domain.Securities, error) {
+	m.lock()
+	defer m.unlock()
+
+	var securitiesData []domain.Securities
+	for _, security := range m.data.securities {
+		securitiesData = append(securitiesData, securityColumns(security))
+	}
+	return securitiesData, nil
+}
+
+// SearchSecuritiesDataByTypeAndExchange returns securities of the type and exchange whose name or symbol
+// contains the search term, ignoring case like the SQL adapters' LIKE match.
+func (m *memory) SearchSecuritiesDataByTypeAndExchange(ctx context.Context, types, exchange int, search string) ([]domain.Securities, error) {
+	m.lock()
+	defer m.unlock()
+
+	search = strings.ToLower(search)
+	var securitiesData []domain.Securities
+	for _, security := range m.data.securities {
+		if security.Type != types || security.Exchange != exchange {
+			continue
+		}
+		if strings.Contains(strings.ToLower(security.Name), search) || strings.Contains(strings.ToLower(security.Symbol), search) {
+			securitiesData = append(securitiesData, securityColumns(security))
+		}
+	}
+	return securitiesData, nil
+}
+
+// InsertInventoryLedger adds a new inventory ledger entry and returns it with its generated id.
+func (m *memory) InsertInventoryLedger(ctx context.Context, inventoryLedgerData domain.InventoryLedger) (domain.InventoryLedger, error) {
+	m.lock()
+	defer m.unlock()
+
+	now := time.Now()
+	inventoryLedgerData.Id = m.nextId("inventory_ledgers")
+	inventoryLedgerData.CreatedAt, inventoryLedgerData.UpdatedAt = now, now
+	m.data.inventoryLedgers = append(m.data.inventoryLedgers, inventoryLedgerData)
+	return inventoryLedgerData, nil
+}
+
+// UpdateInventoryDetailsById updates the quantity, average price and total value of an inventory that is still
+// at the given version and bumps its version. It returns domain.ErrInventoryConflict when the version moved on.
+func (m *memory) UpdateInventoryDetailsById(ctx context.Context, inventoryId, version int, availableQuantity, averagePrice, totalValue decimal.Decimal) error {
+	m.lock()
+	defer m.unlock()
+
+	for i := range m.data.inventories {
+		if m.data.inventories[i].Id == inventoryId && m.data.inventories[i].Version == version {
+			m.data.inventories[i].AvailableQuantity = availableQuantity
+			m.data.inventories[i].AveragePrice = averagePrice
+			m.data.inventories[i].TotalValue = totalValue
+			m.data.inventories[i].Version++
+			m.data.inventories[i].UpdatedAt = time.Now()
+			return nil
+		}
+	}
+	return domain.ErrInventoryConflict
+}
+
+// InsertTransaction adds a new transaction and returns it with its generated id.
+func (m *memory) InsertTransaction(ctx context.Context, transactionData domain.Transactions) (domain.Transactions, error) {
+	m.lock()
+	defer m.unlock()
+
+	now := time.Now()
+	transactionData.Id = m.nextId("transactions")
+	transactionData.CreatedAt, transactionData.UpdatedAt = now, now
+	m.data.transactions = append(m.data.transactions, transactionData)
+	return transactionData, nil
+}
+
+// UpdateInventoryLedgerTransactionIdById links a ledger entry to a transaction.
+func (m *memory) UpdateInventoryLedgerTransactionIdById(ctx context.Context, ledgerId, transactionId int) error {
+	return m.UpdateInventoryLedgerTransactionIdByIds(ctx, []int{ledgerId}, transactionId)
+}
+
+// UpdateInventoryLedgerTransactionIdByIds links several ledger entries to a transaction.
+func (m *memory) UpdateInventoryLedgerTransactionIdByIds(ctx context.Context, ledgerIds []int, transactionId int) error {
+	m.lock()
+	defer m.unlock()
+
+	for _, ledgerId := range ledgerIds {
+		for i := range m.data.inventoryLedgers {
+			if m.data.inventoryLedgers[i].Id == ledgerId {
+				m.data.inventoryLedgers[i].TransactionId = transactionId
+				m.data.inventoryLedgers[i].UpdatedAt = time.Now()
+			}
+		}
+	}
+	return nil
+}
+
+// GetInvertriesSummaryByAccountIdAndSecurityType sums the open inventories of an account per security
+// of the given type, ordered by security id.
+func (m *memory) GetInvertriesSummaryByAccountIdAndSecurityType(ctx context.Context, accountId, securityType int) ([]domain.InventorySummary, error) {
+	m.lock()
+	defer m.unlock()
+
+	securities := map[int]domain.Securities{}
+	for _, security := range m.data.securities {
+		if security.Type == securityType {
+			securities[security.Id] = security
+		}
+	}
+
+	summaries := map[int]*domain.InventorySummary{}
+	for _, inventory := range m.data.inventories {
+		security, ok := securities[inventory.SecurityId]
+		if !ok || inventory.AccountId != accountId || !inventory.AvailableQuantity.IsPositive() {
+			continue
+		}
+
+		summary, ok := summaries[inventory.SecurityId]
+		if !ok {
+			summary = &domain.InventorySummary{
+				Id:               inventory.Id,
+				AccountId:        inventory.AccountId,
+				SecurityId:       inventory.SecurityId,
+				SecurityExchange: strconv.Itoa(security.Exchange),
+				SecuritySymbol:   security.Symbol,
+				SecurityName:     security.Name,
+			}
+			summaries[inventory.SecurityId] = summary
+		}
+		summary.AvailableQuantity = summary.AvailableQuantity.Add(inventory.AvailableQuantity)
+		summary.TotalValue = summary.TotalValue.Add(inventory.TotalValue)
+	}
+
+	var inventoryData []domain.InventorySummary
+	for _, summary := range summaries {
+		inventoryData = append(inventoryData, *summary)
+	}
+	sort.Slice(inventoryData, func(i, j int) bool {
+		return inventoryData[i].SecurityId < inventoryData[j].SecurityId
+	})
+	return inventoryData, nil
+}
+
+// GetInvertriesByAccountIdAndSecurityId returns the open inventories of an account and security, latest first.
+func (m *memory) GetInvertriesByAccountIdAndSecurityId(ctx context.Context, accountId, securityId int) ([]domain.InventoryDetails, error) {
+	m.lock()
+	defer m.unlock()
+
+	var inventoryData []domain.InventoryDetails
+	for _, inventory := range m.data.inventories {
+		if inventory.AccountId == accountId && inventory.SecurityId == securityId && inventory.AvailableQuantity.IsPositive() {
+			inventoryData = append(inventoryData, domain.InventoryDetails{
+				Id:                inventory.Id,
+				AvailableQuantity: inventory.AvailableQuantity,
+				TotalValue:        inventory.TotalValue,
+				Date:              inventory.Date,
+			})
+		}
+	}
+	sort.SliceStable(inventoryData, func(i, j int) bool {
+		return inventoryData[i].Date.After(inventoryData[j].Date)
+	})
+	return inventoryData, nil
+}
+
+// InsertTransactionData adds a new transaction and returns it with its generated id.
+func (m *memory) InsertTransactionData(ctx context.Context, transactionData domain.Transactions) (domain.Transactions, error) {
+	return m.InsertTransaction(ctx, transactionData)
+}
+
+// InsertInventoryData adds a new inventory and returns it with its generated id.
+func (m *memory) InsertInventoryData(ctx context.Context, inventoryData domain.Inventories) (domain.Inventories, error) {
+	m.lock()
+	defer m.unlock()
+
+	now := time.Now()
+	inventoryData.Id = m.nextId("inventories")
+	inventoryData.CreatedAt, inventoryData.UpdatedAt = now, now
+	m.data.inventories = append(m.data.inventories, inventoryData)
+	return inventoryData, nil
+}
+
+// GetInventoryDataById returns the inventory with the given id, or an empty inventory.
+func (m *memory) GetInventoryDataById(ctx context.Context, inventoryId int) (domain.Inventories, error) {
+	m.lock()
+	defer m.unlock()
+
+	for _, inventory := range m.data.inventories {
+		if inventory.Id == inventoryId {
+			return inventory, nil
+		}
+	}
+	return domain.Inventories{}, nil
+}
+
+// UpdateAvailableQuanityToInventoryById sets the available quantity of an inventory.
+func (m *memory) UpdateAvailableQuanityToInventoryById(ctx context.Context, inventoryId int, quantity decimal.Decimal) error {
+	m.lock()
+	defer m.unlock()
+
+	for i := range m.data.inventories {
+		if m.data.inventories[i].Id == inventoryId {
+			m.data.inventories[i].AvailableQuantity = quantity
+			m.data.inventories[i].Version++
+			m.data.inventories[i].UpdatedAt = time.Now()
+		}
+	}
+	return nil
+}
+
+// GetActiveInventoriesByAccountIdAndSecurityId returns the open inventories of an account and security, oldest id first.
+// Only the columns selected by the SQL adapters are filled in.
+func (m *memory) GetActiveInventoriesByAccountIdAndSecurityId(ctx context.Context, accountId, securityId int) ([]domain.Inventories, error) {
+	m.lock()
+	defer m.unlock()
+
+	var inventoriesData []domain.Inventories
+	for _, inventory := range m.data.inventories {
+		if inventory.AccountId == accountId && inventory.SecurityId == securityId && inventory.AvailableQuantity.IsPositive() {
+			inventoriesData = append(inventoriesData, domain.Inventories{
+				Id:                inventory.Id,
+				AvailableQuantity: inventory.AvailableQuantity,
+				TotalValue:        inventory.TotalValue,
+				AveragePrice:      inventory.AveragePrice,
+				Date:              inventory.Date,
+				Version:           inventory.Version,
+			})
+		}
+	}
+	return inventoriesData, nil
+}
+
+// GetInventoriesByAccountIdOrSecurityId returns every inventory, including closed ones, of an account, a security,
+// or both; a zero id matches any value. Inventories are returned oldest id first.
+func (m *memory) GetInventoriesByAccountIdOrSecurityId(ctx context.Context, accountId, securityId int) ([]domain.Inventories, error) {
+	m.lock()
+	defer m.unlock()
+
+	var inventoriesData []domain.Inventories
+	for _, inventory := range m.data.inventories {
+		if (accountId == 0 || inventory.AccountId == accountId) && (securityId == 0 || inventory.SecurityId == securityId) {
+			inventoriesData = append(inventoriesData, inventory)
+		}
+	}
+	return inventoriesData, nil
+}
+
+// GetInventoryLedgersByInventoryId returns the ledger entries of an inventory ordered by date, then latest id first.
+func (m *memory) GetInventoryLedgersByInventoryId(ctx context.Context, inventoryId int) ([]domain.InventoryLedgers, error) {
+	m.lock()
+	defer m.unlock()
+
+	var inventoryLedgerData []domain.InventoryLedgers
+	for _, ledger := range m.data.inventoryLedgers {
+		if ledger.InventoryId == inventoryId {
+			inventoryLedgerData = append(inventoryLedgerData, domain.InventoryLedgers{
+				Id:            ledger.Id,
+				TransactionId: ledger.TransactionId,
+				Type:          ledger.Type,
+				Quantity:      ledger.Quantity,
+				Price:         ledger.AveragePrice,
+				TotalValue:    ledger.TotalValue,
+				Fee:           ledger.Fee,
+				Date:          ledger.Date,
+			})
+		}
+	}
+	sort.Slice(inventoryLedgerData, func(i, j int) bool {
+		if !inventoryLedgerData[i].Date.Equal(inventoryLedgerData[j].Date) {
+			return inventoryLedgerData[i].Date.Before(inventoryLedgerData[j].Date)
+		}
+		return inventoryLedgerData[i].Id > inventoryLedgerData[j].Id
+	})
+	return inventoryLedgerData, nil
+}
+
+// GetInventoryAvailableQuanitityBySecurityIdAndDate returns the quantity bought or subscribed in a rights issue before
+// date minus the quantity sold or bought back up to date.
+func (m *memory) GetInventoryAvailableQuanitityBySecurityIdAndDate(ctx context.Context, accountId, securityId int, date time.Time) (decimal.Decimal, error) {
+	m.lock()
+	defer m.unlock()
+
+	var totalQuantity decimal.Decimal
+	for _, transaction := range m.data.transactions {
+		if transaction.AccountId != accountId || transaction.SecurityId != securityId || transaction.State == domain.TRANSACTION_STATE_VOIDED {
+			continue
+		}
+		switch {
+		case (transaction.Type == domain.BUY || transaction.Type == domain.RIGHTS_SUBSCRIBE) && transaction.Date.Before(date):
+			totalQuantity = totalQuantity.Add(transaction.Quantity)
+		case (transaction.Type == domain.SELL || transaction.Type == domain.BUYBACK) && !transaction.Date.After(date):
+			totalQuantity = totalQuantity.Sub(transaction.Quantity)
+		}
+	}
+	return totalQuantity, nil
+}
+
+// GetDividendTransactionsByAccountIdAndSecurityId returns the dividend transactions of an account and security, latest first.
+func (m *memory) GetDividendTransactionsByAccountIdAndSecurityId(ctx context.Context, accountId, securityId int) ([]domain.DividendTransaction, error) {
+	m.lock()
+	defer m.unlock()
+
+	var transactionsData []domain.DividendTransaction
+	for _, transaction := range m.data.transactions {
+		if transaction.AccountId == accountId && transaction.SecurityId == securityId && transaction.Type == domain.DIVIDEND {
+			transactionsData = append(transactionsData, domain.DividendTransaction{
+				Quantity:   transaction.Quantity,
+				Price:      transaction.AveragePrice,
+				TotalValue: transaction.TotalValue,
+				Date:       transaction.Date,
+			})
+		}
+	}
+	sort.SliceStable(transactionsData, func(i, j int) bool {
+		return transactionsData[i].Date.After(transactionsData[j].Date)
+	})
+	return transactionsData, nil
+}
+
+// GetUnlinkedInventoryLedgers returns the ledger entries of an account whose transaction id is unset or refers to no
+// transaction; an account id of zero scans every account. Entries are returned oldest id first.
+func (m *memory) GetUnlinkedInventoryLedgers(ctx context.Context, accountId int) ([]domain.InventoryLedger, error) {
+	m.lock()
+	defer m.unlock()
+
+	accounts := map[int]int{}
+	for _, inventory := range m.data.inventories {
+		accounts[inventory.Id] = inventory.AccountId
+	}
+	transactions := map[int]bool{}
+	for _, transaction := range m.data.transactions {
+		transactions[transaction.Id] = true
+	}
+
+	var inventoryLedgerData []domain.InventoryLedger
+	for _, ledger := range m.data.inventoryLedgers {
+		owner, ok := accounts[ledger.InventoryId]
+		if !ok || (accountId != 0 && owner != accountId) || transactions[ledger.TransactionId] {
+			continue
+		}
+		inventoryLedgerData = append(inventoryLedgerData, ledger)
+	}
+	return inventoryLedgerData, nil
+}
+
+// GetTransactionLedgerTotals returns every non dividend transaction of an account with the summed quantity and count
+// of its linked ledger entries; an account id of zero scans every account. Transactions are returned oldest id first.
+func (m *memory) GetTransactionLedgerTotals(ctx context.Context, accountId int) ([]domain.TransactionLedgerTotal, error) {
+	m.lock()
+	defer m.unlock()
+
+	var totalsData []domain.TransactionLedgerTotal
+	for _, transaction := range m.data.transactions {
+		if transaction.Type == domain.DIVIDEND || (accountId != 0 && transaction.AccountId != accountId) {
+			continue
+		}
+		total := domain.TransactionLedgerTotal{
+			Id:         transaction.Id,
+			AccountId:  transaction.AccountId,
+			SecurityId: transaction.SecurityId,
+			Type:       transaction.Type,
+			Quantity:   transaction.Quantity,
+		}
+		for _, ledger := range m.data.inventoryLedgers {
+			if ledger.TransactionId == transaction.Id && ledger.Type != domain.VOID {
+				total.LedgerQuantity = total.LedgerQuantity.Add(ledger.Quantity)
+				total.LedgerCount++
+			}
+		}
+		totalsData = append(totalsData, total)
+	}
+	return totalsData, nil
+}
+
+// GetTransactionDataById returns the transaction with the given id, or an empty transaction.
+func (m *memory) GetTransactionDataById(ctx context.Context, transactionId int) (domain.Transactions, error) {
+	m.lock()
+	defer m.unlock()
+
+	for _, transaction := range m.data.transactions {
+		if transaction.Id == transactionId {
+			return transaction, nil
+		}
+	}
+	return domain.Transactions{}, nil
+}
+
+// GetInventoryLedgersByTransactionId returns the ledger entries linked to a transaction, ordered by id.
+func (m *memory) GetInventoryLedgersByTransactionId(ctx context.Context, transactionId int) ([]domain.InventoryLedger, error) {
+	m.lock()
+	defer m.unlock()
+
+	var inventoryLedgerData []domain.InventoryLedger
+	for _, ledger := range m.data.inventoryLedgers {
+		if ledger.TransactionId == transactionId {
+			inventoryLedgerData = append(inventoryLedgerData, ledger)
+		}
+	}
+	return inventoryLedgerData, nil
+}
+
+// UpdateTransactionStateById sets the state of a transaction.
+func (m *memory) UpdateTransactionStateById(ctx context.Context, transactionId, state int) error {
+	m.lock()
+	defer m.unlock()
+
+	for i := range m.data.transactions {
+		if m.data.transactions[i].Id == transactionId {
+			m.data.transactions[i].State = state
+			m.data.transactions[i].UpdatedAt = time.Now()
+		}
+	}
+	return nil
+}
+
+// GetTransactionsByAccountIdAndSecurityId returns every transaction of an account and security, voided ones
+// included, ordered by date and then by id.
+func (m *memory) GetTransactionsByAccountIdAndSecurityId(ctx context.Context, accountId, securityId int) ([]domain.Transactions, error) {
+	m.lock()
+	defer m.unlock()
+
+	var transactionsData []domain.Transactions
+	for _, transaction := range m.data.transactions {
+		if transaction.AccountId == accountId && transaction.SecurityId == securityId {
+			transactionsData = append(transactionsData, transaction)
+		}
+	}
+	sort.SliceStable(transactionsData, func(i, j int) bool {
+		if !transactionsData[i].Date.Equal(transactionsData[j].Date) {
+			return transactionsData[i].Date.Before(transactionsData[j].Date)
+		}
+		return transactionsData[i].Id < transactionsData[j].Id
+	})
+	return transactionsData, nil
+}
+
+// GetTransactionsByAccountId returns every transaction of an account, voided ones included, ordered by date and then
+// by id.
+func (m *memory) GetTransactionsByAccountId(ctx context.Context, accountId int) ([]domain.Transactions, error) {
+	m.lock()
+	defer m.unlock()
+
+	var transactionsData []domain.Transactions
+	for _, transaction := range m.data.transactions {
+		if transaction.AccountId == accountId {
+			transactionsData = append(transactionsData, transaction)
+		}
+	}
+	sort.SliceStable(transactionsData, func(i, j int) bool {
+		if !transactionsData[i].Date.Equal(transactionsData[j].Date) {
+			return transactionsData[i].Date.Before(transactionsData[j].Date)
+		}
+		return transactionsData[i].Id < transactionsData[j].Id
+	})
+	return transactionsData, nil
+}
+
+// UpdateTransactionById sets the quantity, average price, total value, fee and date of a transaction.
+func (m *memory) UpdateTransactionById(ctx context.Context, transactionId int, transactionData domain.Transactions) error {
+	m.lock()
+	defer m.unlock()
+
+	for i := range m.data.transactions {
+		if m.data.transactions[i].Id == transactionId {
+			m.data.transactions[i].Quantity = transactionData.Quantity
+			m.data.transactions[i].AveragePrice = transactionData.AveragePrice
+			m.data.transactions[i].TotalValue = transactionData.TotalValue
+			m.data.transactions[i].Fee = transactionData.Fee
+			m.data.transactions[i].Date = transactionData.Date
+			m.data.transactions[i].UpdatedAt = time.Now()
+		}
+	}
+	return nil
+}
+
+// UpdateInventoryLedgerById sets the quantity, average price, total value, fee and date of a ledger entry.
+func (m *memory) UpdateInventoryLedgerById(ctx context.Context, ledgerId int, ledgerData domain.InventoryLedger) error {
+	m.lock()
+	defer m.unlock()
+
+	for i := range m.data.inventoryLedgers {
+		if m.data.inventoryLedgers[i].Id == ledgerId {
+			m.data.inventoryLedgers[i].Quantity = ledgerData.Quantity
+			m.data.inventoryLedgers[i].AveragePrice = ledgerData.AveragePrice
+			m.data.inventoryLedgers[i].TotalValue = ledgerData.TotalValue
+			m.data.inventoryLedgers[i].Fee = ledgerData.Fee
+			m.data.inventoryLedgers[i].Date = ledgerData.Date
+			m.data.inventoryLedgers[i].UpdatedAt = time.Now()
+		}
+	}
+	return nil
+}
+
+// DeleteInventoryLedgerById removes a ledger entry.
+func (m *memory) DeleteInventoryLedgerById(ctx context.Context, ledgerId int) error {
+	m.lock()
+	defer m.unlock()
+
+	for i := range m.data.inventoryLedgers {
+		if m.data.inventoryLedgers[i].Id == ledgerId {
+			m.data.inventoryLedgers = append(m.data.inventoryLedgers[:i], m.data.inventoryLedgers[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// UpdateInventoryDateById sets the date of an inventory.
+func (m *memory) UpdateInventoryDateById(ctx context.Context, inventoryId int, date time.Time) error {
+	m.lock()
+	defer m.unlock()
+
+	for i := range m.data.inventories {
+		if m.data.inventories[i].Id == inventoryId {
+			m.data.inventories[i].Date = date
+			m.data.inventories[i].UpdatedAt = time.Now()
+		}
+	}
+	return nil
+}
+
+// InsertRealizedGain adds a realized gain record and returns it with its generated id.
+func (m *memory) InsertRealizedGain(ctx context.Context, realizedGainData domain.RealizedGains) (domain.RealizedGains, error) {
+	m.lock()
+	defer m.unlock()
+
+	now := time.Now()
+	realizedGainData.Id = m.nextId("realized_gains")
+	realizedGainData.CreatedAt, realizedGainData.UpdatedAt = now, now
+	m.data.realizedGains = append(m.data.realizedGains, realizedGainData)
+	return realizedGainData, nil
+}
+
+// DeleteRealizedGainsByInventoryId removes every realized gain record of an inventory.
+func (m *memory) DeleteRealizedGainsByInventoryId(ctx context.Context, inventoryId int) error {
+	m.lock()
+	defer m.unlock()
+
+	kept := m.data.realizedGains[:0]
+	for _, realizedGain := range m.data.realizedGains {
+		if realizedGain.InventoryId != inventoryId {
+			kept = append(kept, realizedGain)
+		}
+	}
+	m.data.realizedGains = kept
+	return nil
+}
+
+// GetRealizedGains returns the realized gain records of an account sold between from and to, both inclusive.
+// A zero security id matches every security and a zero time leaves that end open. Records are ordered by date, then id.
+func (m *memory) GetRealizedGains(ctx context.Context, accountId, securityId int, from, to time.Time) ([]domain.RealizedGains, error) {
+	m.lock()
+	defer m.unlock()
+
+	var realizedGainsData []domain.RealizedGains
+	for _, realizedGain := range m.data.realizedGains {
+		if realizedGain.AccountId != accountId || (securityId != 0 && realizedGain.SecurityId != securityId) {
+			continue
+		}
+		if (!from.IsZero() && realizedGain.Date.Before(from)) || (!to.IsZero() && realizedGain.Date.After(to)) {
+			continue
+		}
+		realizedGainsData = append(realizedGainsData, realizedGain)
+	}
+	sort.SliceStable(realizedGainsData, func(i, j int) bool {
+		if !realizedGainsData[i].Date.Equal(realizedGainsData[j].Date) {
+			return realizedGainsData[i].Date.Before(realizedGainsData[j].Date)
+		}
+		return realizedGainsData[i].Id < realizedGainsData[j].Id
+	})
+	return realizedGainsData, nil
+}
+
+// InsertTransactionCharges adds the itemised charges of a transaction and returns them with their generated id.
+func (m *memory) InsertTransactionCharges(ctx context.Context, chargesData domain.TransactionCharges) (domain.TransactionCharges, error) {
+	m.lock()
+	defer m.unlock()
+
+	now := time.Now()
+	chargesData.Id = m.nextId("transaction_charges")
+	chargesData.CreatedAt, chargesData.UpdatedAt = now, now
+	m.data.charges = append(m.data.charges, chargesData)
+	return chargesData, nil
+}
+
+// GetTransactionChargesByTransactionIds returns the itemised charges of the given transactions, ordered by
+// transaction id.
+func (m *memory) GetTransactionChargesByTransactionIds(ctx context.Context, transactionIds []int) ([]domain.TransactionCharges, error) {
+	m.lock()
+	defer m.unlock()
+
+	wanted := map[int]bool{}
+	for _, transactionId := range transactionIds {
+		wanted[transactionId] = true
+	}
+
+	var chargesData []domain.TransactionCharges
+	for _, charges := range m.data.charges {
+		if wanted[charges.TransactionId] {
+			chargesData = append(chargesData, charges)
+		}
+	}
+	sort.SliceStable(chargesData, func(i, j int) bool {
+		return chargesData[i].TransactionId < chargesData[j].TransactionId
+	})
+	return chargesData, nil
+}
+
+// DeleteTransactionChargesByTransactionId removes the itemised charges of a transaction.
+func (m *memory) DeleteTransactionChargesByTransactionId(ctx context.Context, transactionId int) error {
+	m.lock()
+	defer m.unlock()
+
+	kept := m.data.charges[:0]
+	for _, charges := range m.data.charges {
+		if charges.TransactionId != transactionId {
+			kept = append(kept, charges)
+		}
+	}
+	m.data.charges = kept
+	return nil
+}
+
+// InsertRightsIssue adds the rights entitlements of an account from a rights issue and returns them with their
+// generated id.
+func (m *memory) InsertRightsIssue(ctx context.Context, rightsIssueData domain.RightsIssues) (domain.RightsIssues, error) {
+	m.lock()
+	defer m.unlock()
+
+	now := time.Now()
+	rightsIssueData.Id = m.nextId("rights_issues")
+	rightsIssueData.CreatedAt, rightsIssueData.UpdatedAt = now, now
+	m.data.rightsIssues = append(m.data.rightsIssues, rightsIssueData)
+	return rightsIssueData, nil
+}
+
+// GetRightsIssueByIdAndAccountId returns a rights issue of an account, or a zero value when there is none.
+func (m *memory) GetRightsIssueByIdAndAccountId(ctx context.Context, rightsIssueId, accountId int) (domain.RightsIssues, error) {
+	m.lock()
+	defer m.unlock()
+
+	for _, rightsIssue := range m.data.rightsIssues {
+		if rightsIssue.Id == rightsIssueId && rightsIssue.AccountId == accountId {
+			return rightsIssue, nil
+		}
+	}
+	return domain.RightsIssues{}, nil
+}
+
+// UpdateRightsIssueById sets the renounced and subscribed entitlements, the lot and the paid-up price of a rights
+// issue.
+func (m *memory) UpdateRightsIssueById(ctx context.Context, rightsIssueId int, rightsIssueData domain.RightsIssues) error {
+	m.lock()
+	defer m.unlock()
+
+	for i := range m.data.rightsIssues {
+		if m.data.rightsIssues[i].Id == rightsIssueId {
+			m.data.rightsIssues[i].InventoryId = rightsIssueData.InventoryId
+			m.data.rightsIssues[i].PaidPrice = rightsIssueData.PaidPrice
+			m.data.rightsIssues[i].Renounced = rightsIssueData.Renounced
+			m.data.rightsIssues[i].Subscribed = rightsIssueData.Subscribed
+			m.data.rightsIssues[i].UpdatedAt = time.Now()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"assetio/internal/adapters/repository/contract"
+	"assetio/internal/domain"
+	"assetio/internal/port"
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestContract(t *testing.T) {
+	contract.Run(t, func(t *testing.T) port.RepositoryStore {
+		return New()
+	})
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	repo := New()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = repo.WithTx(ctx, func(tx port.RepositoryStore) error {
+				_, err := tx.InsertAccountData(ctx, domain.Accounts{UserId: 1, Name: "account"})
+				return err
+			})
+			_, _ = repo.GetAccountsData(ctx, 1)
+		}()
+	}
+	wg.Wait()
+
+	accounts, err := repo.GetAccountsData(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 20 {
+		t.Fatalf("got %d accounts, want 20", len(accounts))
+	}
+}
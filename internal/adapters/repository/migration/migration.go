@@ -0,0 +1,179 @@
+// Package migration applies the numbered schema migrations shared by the gorm backed
+// repository adapters (mysql, postgres and sqlite). Each applied migration is recorded in
+// the schema_version table so the store can report how far its schema has been upgraded.
+package migration
+
+import (
+	"assetio/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrNothingToRevert is returned by Down when no migration has been applied.
+var ErrNothingToRevert = errors.New("no applied migration to revert")
+
+// Migration is one numbered schema change. Up applies it and Down reverts it; both run inside a
+// transaction on dialects that support transactional DDL.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// schemaVersion is one row of the schema_version table.
+type schemaVersion struct {
+	Version   int       `gorm:"primarykey;autoIncrement:false;column:version"`
+	Name      string    `gorm:"column:name;size:255"`
+	AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+type Runner struct {
+	db    *gorm.DB
+	table string
+}
+
+// New returns a Runner for db. The schema_version table is created on first use with the same
+// prefix as the other tables.
+func New(db *gorm.DB, prefix string) *Runner {
+	return &Runner{
+		db:    db,
+		table: prefix + "schema_version",
+	}
+}
+
+// Latest returns the schema version this binary expects.
+func Latest() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// Known lists every migration compiled into this binary, marked as applied. Stores without a schema use it to
+// report that they are always current.
+func Known() []domain.SchemaMigration {
+	known := make([]domain.SchemaMigration, len(migrations))
+	for i, migration := range migrations {
+		known[i] = domain.SchemaMigration{Version: migration.Version, Name: migration.Name, Applied: true}
+	}
+	return known
+}
+
+// Version returns the highest applied migration, or 0 when the schema_version table does not exist yet.
+func (r *Runner) Version(ctx context.Context) (int, error) {
+	db := r.db.WithContext(ctx)
+	if !db.Migrator().HasTable(r.table) {
+		return 0, nil
+	}
+
+	var version int
+	err := db.Table(r.table).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
+
+// Status lists every known migration together with the time it was applied, if it was.
+func (r *Runner) Status(ctx context.Context) ([]domain.SchemaMigration, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]domain.SchemaMigration, len(migrations))
+	for i, migration := range migrations {
+		status[i] = domain.SchemaMigration{Version: migration.Version, Name: migration.Name}
+		if row, ok := applied[migration.Version]; ok {
+			status[i].Applied = true
+			status[i].AppliedAt = row.AppliedAt
+		}
+	}
+	return status, nil
+}
+
+// Up applies every pending migration in version order. Each migration and its schema_version row are written
+// in one transaction; the first failure stops the run and is returned with the migrations applied so far.
+func (r *Runner) Up(ctx context.Context) ([]domain.SchemaMigration, error) {
+	applied, err := r.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []domain.SchemaMigration
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		row := schemaVersion{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}
+		err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			return tx.Table(r.table).Create(&row).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Name, err)
+		}
+
+		done = append(done, domain.SchemaMigration{Version: row.Version, Name: row.Name, Applied: true, AppliedAt: row.AppliedAt})
+	}
+	return done, nil
+}
+
+// Down reverts the most recently applied migration and removes its schema_version row.
+func (r *Runner) Down(ctx context.Context) (domain.SchemaMigration, error) {
+	version, err := r.Version(ctx)
+	if err != nil {
+		return domain.SchemaMigration{}, err
+	}
+	if version == 0 {
+		return domain.SchemaMigration{}, ErrNothingToRevert
+	}
+
+	migration, ok := find(version)
+	if !ok {
+		return domain.SchemaMigration{}, fmt.Errorf("schema version %d is newer than this binary (%d)", version, Latest())
+	}
+
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := migration.Down(tx); err != nil {
+			return err
+		}
+		return tx.Table(r.table).Where("version = ?", migration.Version).Delete(&schemaVersion{}).Error
+	})
+	if err != nil {
+		return domain.SchemaMigration{}, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Name, err)
+	}
+
+	return domain.SchemaMigration{Version: migration.Version, Name: migration.Name}, nil
+}
+
+// applied creates the schema_version table when missing and returns its rows keyed by version.
+func (r *Runner) applied(ctx context.Context) (map[int]schemaVersion, error) {
+	db := r.db.WithContext(ctx)
+	if err := db.Table(r.table).AutoMigrate(&schemaVersion{}); err != nil {
+		return nil, err
+	}
+
+	var rows []schemaVersion
+	if err := db.Table(r.table).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]schemaVersion, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// find returns the migration with the given version.
+func find(version int) (Migration, bool) {
+	for _, migration := range migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
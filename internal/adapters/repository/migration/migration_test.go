@@ -0,0 +1,153 @@
+package migration
+
+import (
+	"assetio/internal/domain"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	gormSqlite "github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+const prefix = "pm_"
+
+func open(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(gormSqlite.Open(filepath.Join(t.TempDir(), "assetio.db")), &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Silent),
+		NamingStrategy: schema.NamingStrategy{TablePrefix: prefix},
+	})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	return db
+}
+
+func TestVersionsAreConsecutive(t *testing.T) {
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			t.Fatalf("migration %q has version %d, want %d", migration.Name, migration.Version, i+1)
+		}
+		if migration.Up == nil || migration.Down == nil {
+			t.Fatalf("migration %d must define both Up and Down", migration.Version)
+		}
+	}
+}
+
+// mysqlDialect reports itself as MySQL so column types can be checked without a server.
+type mysqlDialect struct {
+	gorm.Dialector
+}
+
+func (mysqlDialect) Name() string { return "mysql" }
+
+func TestInitialTransactionTypeIsFrozen(t *testing.T) {
+	db := &gorm.DB{Config: &gorm.Config{Dialector: mysqlDialect{}}}
+	want := "enum('BUY', 'SELL', 'DIVIDEND', 'SPLIT', 'BONUS', 'MERGER', 'MERGER_TRANSFER', 'DEMERGER', 'DEMERGER_TRANSFER')"
+	if got := (initialTransactionType("")).GormDBDataType(db, nil); got != want {
+		t.Fatalf("migration 1 type column = %s, want %s", got, want)
+	}
+	if live := domain.TransactionType("").GormDBDataType(db, nil); live == want {
+		t.Fatalf("live type column = %s, want it widened past migration 1", live)
+	}
+}
+
+func TestUpDown(t *testing.T) {
+	ctx := context.Background()
+	db := open(t)
+	runner := New(db, prefix)
+
+	if version, err := runner.Version(ctx); err != nil || version != 0 {
+		t.Fatalf("Version on empty database = %d, %v; want 0", version, err)
+	}
+
+	applied, err := runner.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if len(applied) != len(migrations) {
+		t.Fatalf("Up applied %d migrations, want %d", len(applied), len(migrations))
+	}
+	if version, _ := runner.Version(ctx); version != Latest() {
+		t.Fatalf("Version after Up = %d, want %d", version, Latest())
+	}
+	if !db.Migrator().HasTable(prefix + "inventory_ledgers") {
+		t.Fatal("Up did not create the inventory_ledgers table")
+	}
+	if !db.Migrator().HasIndex(prefix+"inventories", prefix+"inventories_account_security_idx") {
+		t.Fatal("Up did not create the inventories lookup index")
+	}
+	for table, index := range map[string]string{
+		prefix + "realized_gains": prefix + "realized_gains_account_security_date_idx",
+		prefix + "rights_issues":  prefix + "rights_issues_account_security_idx",
+	} {
+		if !db.Migrator().HasIndex(table, index) {
+			t.Fatalf("Up did not create the %s lookup index %s", table, index)
+		}
+	}
+
+	applied, err = runner.Up(ctx)
+	if err != nil || len(applied) != 0 {
+		t.Fatalf("second Up = %v, %v; want nothing applied", applied, err)
+	}
+
+	status, err := runner.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	for _, migration := range status {
+		if !migration.Applied || migration.AppliedAt.IsZero() {
+			t.Fatalf("migration %d not reported as applied: %+v", migration.Version, migration)
+		}
+	}
+
+	for version := Latest(); version > 0; version-- {
+		reverted, err := runner.Down(ctx)
+		if err != nil {
+			t.Fatalf("Down from %d: %v", version, err)
+		}
+		if reverted.Version != version {
+			t.Fatalf("Down reverted %d, want %d", reverted.Version, version)
+		}
+	}
+	if db.Migrator().HasTable(prefix + "inventories") {
+		t.Fatal("reverting every migration left the inventories table behind")
+	}
+	if _, err := runner.Down(ctx); !errors.Is(err, ErrNothingToRevert) {
+		t.Fatalf("Down on empty schema = %v, want ErrNothingToRevert", err)
+	}
+
+	status, _ = runner.Status(ctx)
+	for _, migration := range status {
+		if migration.Applied {
+			t.Fatalf("migration %d still reported as applied", migration.Version)
+		}
+	}
+}
+
+// TestAdoptsAutoMigratedSchema covers databases created by releases that ran AutoMigrate on startup.
+func TestAdoptsAutoMigratedSchema(t *testing.T) {
+	ctx := context.Background()
+	db := open(t)
+	if err := db.AutoMigrate(&domain.Accounts{}, &domain.Securities{}, &domain.Inventories{}, &domain.InventoryLedger{}, &domain.Transactions{}); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	if err := db.Create(&domain.Accounts{UserId: 1, Name: "existing"}).Error; err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	runner := New(db, prefix)
+	if _, err := runner.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	var count int64
+	db.Model(&domain.Accounts{}).Count(&count)
+	if count != 1 {
+		t.Fatalf("existing rows = %d after Up, want 1", count)
+	}
+}
@@ -0,0 +1,483 @@
+package migration
+
+import (
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// migrations lists every schema change in the order it must be applied. Versions are consecutive and
+// a released migration is never edited; change the schema by appending a new one.
+//
+// Each migration declares its own copy of the models it touches so that later changes to the domain
+// structs cannot alter what an old migration creates. The local types keep the domain names so the
+// gorm naming strategy resolves them to the same prefixed tables.
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_initial_tables",
+		Up: func(tx *gorm.DB) error {
+			// AutoMigrate rather than CreateTable so databases created by earlier releases, which ran
+			// AutoMigrate on startup, are adopted as version 1 without error.
+			return tx.AutoMigrate(initialTables()...)
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(initialTables()...)
+		},
+	},
+	{
+		Version: 2,
+		Name:    "add_lookup_indexes",
+		Up: func(tx *gorm.DB) error {
+			return createIndexes(tx, lookupIndexes(tx))
+		},
+		Down: func(tx *gorm.DB) error {
+			return dropIndexes(tx, lookupIndexes(tx))
+		},
+	},
+	{
+		Version: 3,
+		Name:    "add_inventory_version",
+		Up: func(tx *gorm.DB) error {
+			type Inventories struct {
+				Version int `gorm:"column:version;not null;default:0"`
+			}
+			if tx.Migrator().HasColumn(&Inventories{}, "version") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&Inventories{}, "Version")
+		},
+		Down: func(tx *gorm.DB) error {
+			type Inventories struct {
+				Version int `gorm:"column:version;not null;default:0"`
+			}
+			return tx.Migrator().DropColumn(&Inventories{}, "version")
+		},
+	},
+	{
+		Version: 4,
+		Name:    "widen_decimal_columns",
+		Up: func(tx *gorm.DB) error {
+			return alterDecimalColumns(tx, "decimal(20,4)")
+		},
+		Down: func(tx *gorm.DB) error {
+			return alterDecimalColumns(tx, "decimal(12,4)")
+		},
+	},
+	{
+		Version: 5,
+		Name:    "add_void_transaction_type",
+		Up: func(tx *gorm.DB) error {
+			return alterTransactionTypeColumns(tx, []string{
+				"BUY", "SELL", "DIVIDEND", "SPLIT", "BONUS", "MERGER", "MERGER_TRANSFER", "DEMERGER", "DEMERGER_TRANSFER", "VOID",
+			})
+		},
+		Down: func(tx *gorm.DB) error {
+			return alterTransactionTypeColumns(tx, []string{
+				"BUY", "SELL", "DIVIDEND", "SPLIT", "BONUS", "MERGER", "MERGER_TRANSFER", "DEMERGER", "DEMERGER_TRANSFER",
+			})
+		},
+	},
+	{
+		Version: 6,
+		Name:    "create_realized_gains",
+		Up: func(tx *gorm.DB) error {
+			if err := tx.Migrator().CreateTable(realizedGainsTable()); err != nil {
+				return err
+			}
+			realizedGains := tx.NamingStrategy.TableName("RealizedGains")
+			return createIndexes(tx, []index{
+				{realizedGains, realizedGains + "_account_security_date_idx", "account_id, security_id, date"},
+			})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(realizedGainsTable())
+		},
+	},
+	{
+		Version: 7,
+		Name:    "add_account_lot_method",
+		Up: func(tx *gorm.DB) error {
+			type Accounts struct {
+				LotMethod string `gorm:"column:lot_method;size:16;not null;default:'FIFO'"`
+			}
+			if tx.Migrator().HasColumn(&Accounts{}, "lot_method") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&Accounts{}, "LotMethod")
+		},
+		Down: func(tx *gorm.DB) error {
+			type Accounts struct {
+				LotMethod string `gorm:"column:lot_method;size:16;not null;default:'FIFO'"`
+			}
+			return tx.Migrator().DropColumn(&Accounts{}, "lot_method")
+		},
+	},
+	{
+		Version: 8,
+		Name:    "add_security_asset_class",
+		Up: func(tx *gorm.DB) error {
+			type Securities struct {
+				AssetClass int `gorm:"column:asset_class;size:11;not null;default:1"`
+			}
+			if tx.Migrator().HasColumn(&Securities{}, "asset_class") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&Securities{}, "AssetClass")
+		},
+		Down: func(tx *gorm.DB) error {
+			type Securities struct {
+				AssetClass int `gorm:"column:asset_class;size:11;not null;default:1"`
+			}
+			return tx.Migrator().DropColumn(&Securities{}, "asset_class")
+		},
+	},
+	{
+		Version: 9,
+		Name:    "create_transaction_charges",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(transactionChargesTable())
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(transactionChargesTable())
+		},
+	},
+	{
+		Version: 10,
+		Name:    "add_rights_issues",
+		Up: func(tx *gorm.DB) error {
+			if err := alterTransactionTypeColumns(tx, []string{
+				"BUY", "SELL", "DIVIDEND", "SPLIT", "BONUS", "MERGER", "MERGER_TRANSFER", "DEMERGER", "DEMERGER_TRANSFER", "VOID",
+				"RIGHTS_ENTITLEMENT", "RIGHTS_RENOUNCE", "RIGHTS_SUBSCRIBE", "RIGHTS_CALL",
+			}); err != nil {
+				return err
+			}
+			if err := tx.Migrator().CreateTable(rightsIssuesTable()); err != nil {
+				return err
+			}
+			rightsIssues := tx.NamingStrategy.TableName("RightsIssues")
+			return createIndexes(tx, []index{
+				{rightsIssues, rightsIssues + "_account_security_idx", "account_id, security_id"},
+			})
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := tx.Migrator().DropTable(rightsIssuesTable()); err != nil {
+				return err
+			}
+			return alterTransactionTypeColumns(tx, []string{
+				"BUY", "SELL", "DIVIDEND", "SPLIT", "BONUS", "MERGER", "MERGER_TRANSFER", "DEMERGER", "DEMERGER_TRANSFER", "VOID",
+			})
+		},
+	},
+	{
+		Version: 11,
+		Name:    "add_buyback_transaction_type",
+		Up: func(tx *gorm.DB) error {
+			return alterTransactionTypeColumns(tx, []string{
+				"BUY", "SELL", "DIVIDEND", "SPLIT", "BONUS", "MERGER", "MERGER_TRANSFER", "DEMERGER", "DEMERGER_TRANSFER", "VOID",
+				"RIGHTS_ENTITLEMENT", "RIGHTS_RENOUNCE", "RIGHTS_SUBSCRIBE", "RIGHTS_CALL", "BUYBACK",
+			})
+		},
+		Down: func(tx *gorm.DB) error {
+			return alterTransactionTypeColumns(tx, []string{
+				"BUY", "SELL", "DIVIDEND", "SPLIT", "BONUS", "MERGER", "MERGER_TRANSFER", "DEMERGER", "DEMERGER_TRANSFER", "VOID",
+				"RIGHTS_ENTITLEMENT", "RIGHTS_RENOUNCE", "RIGHTS_SUBSCRIBE", "RIGHTS_CALL",
+			})
+		},
+	},
+	{
+		Version: 12,
+		Name:    "add_transaction_ratio",
+		Up: func(tx *gorm.DB) error {
+			type Transactions struct {
+				RatioFrom decimal.Decimal `gorm:"type:decimal(20,4);column:ratio_from;not null;default:0"`
+				RatioTo   decimal.Decimal `gorm:"type:decimal(20,4);column:ratio_to;not null;default:0"`
+			}
+			for _, column := range []string{"RatioFrom", "RatioTo"} {
+				if tx.Migrator().HasColumn(&Transactions{}, column) {
+					continue
+				}
+				if err := tx.Migrator().AddColumn(&Transactions{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			type Transactions struct {
+				RatioFrom decimal.Decimal `gorm:"type:decimal(20,4);column:ratio_from;not null;default:0"`
+				RatioTo   decimal.Decimal `gorm:"type:decimal(20,4);column:ratio_to;not null;default:0"`
+			}
+			for _, column := range []string{"ratio_from", "ratio_to"} {
+				if err := tx.Migrator().DropColumn(&Transactions{}, column); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 13,
+		Name:    "add_transaction_ex_date",
+		Up: func(tx *gorm.DB) error {
+			type Transactions struct {
+				ExDate *time.Time `gorm:"column:ex_date"`
+			}
+			if tx.Migrator().HasColumn(&Transactions{}, "ex_date") {
+				return nil
+			}
+			return tx.Migrator().AddColumn(&Transactions{}, "ExDate")
+		},
+		Down: func(tx *gorm.DB) error {
+			type Transactions struct {
+				ExDate *time.Time `gorm:"column:ex_date"`
+			}
+			return tx.Migrator().DropColumn(&Transactions{}, "ex_date")
+		},
+	},
+}
+
+// rightsIssuesTable returns the rights issues model as created by migration 10, which adds its lookup index.
+func rightsIssuesTable() interface{} {
+	type RightsIssues struct {
+		Id            int             `gorm:"primarykey;size:16"`
+		AccountId     int             `gorm:"column:account_id;size:16"`
+		SecurityId    int             `gorm:"column:security_id;size:16"`
+		TransactionId int             `gorm:"column:transaction_id;size:16"`
+		InventoryId   int             `gorm:"column:inventory_id;size:16"`
+		RecordDate    time.Time       `gorm:"column:record_date"`
+		RatioRights   decimal.Decimal `gorm:"type:decimal(20,4);column:ratio_rights"`
+		RatioHeld     decimal.Decimal `gorm:"type:decimal(20,4);column:ratio_held"`
+		IssuePrice    decimal.Decimal `gorm:"type:decimal(20,4);column:issue_price"`
+		PaidPrice     decimal.Decimal `gorm:"type:decimal(20,4);column:paid_price"`
+		Entitled      decimal.Decimal `gorm:"type:decimal(20,4);column:entitled"`
+		Renounced     decimal.Decimal `gorm:"type:decimal(20,4);column:renounced"`
+		Subscribed    decimal.Decimal `gorm:"type:decimal(20,4);column:subscribed"`
+		CreatedAt     time.Time       `gorm:"autoCreateTime,column:created_at"`
+		UpdatedAt     time.Time       `gorm:"autoUpdateTime,column:updated_at"`
+	}
+
+	return &RightsIssues{}
+}
+
+// transactionChargesTable returns the transaction charges model as created by migration 9.
+func transactionChargesTable() interface{} {
+	type TransactionCharges struct {
+		Id            int             `gorm:"primarykey;size:16"`
+		TransactionId int             `gorm:"uniqueIndex;column:transaction_id;size:16"`
+		Brokerage     decimal.Decimal `gorm:"type:decimal(20,4);column:brokerage"`
+		Stt           decimal.Decimal `gorm:"type:decimal(20,4);column:stt"`
+		ExchangeFee   decimal.Decimal `gorm:"type:decimal(20,4);column:exchange_fee"`
+		SebiFee       decimal.Decimal `gorm:"type:decimal(20,4);column:sebi_fee"`
+		StampDuty     decimal.Decimal `gorm:"type:decimal(20,4);column:stamp_duty"`
+		Gst           decimal.Decimal `gorm:"type:decimal(20,4);column:gst"`
+		CreatedAt     time.Time       `gorm:"autoCreateTime,column:created_at"`
+		UpdatedAt     time.Time       `gorm:"autoUpdateTime,column:updated_at"`
+	}
+
+	return &TransactionCharges{}
+}
+
+// realizedGainsTable returns the realized gains model as created by migration 6, which adds its lookup index.
+func realizedGainsTable() interface{} {
+	type RealizedGains struct {
+		Id              int             `gorm:"primarykey;size:16"`
+		AccountId       int             `gorm:"column:account_id;size:16"`
+		SecurityId      int             `gorm:"column:security_id;size:16"`
+		InventoryId     int             `gorm:"index;column:inventory_id;size:16"`
+		LedgerId        int             `gorm:"column:ledger_id;size:16"`
+		TransactionId   int             `gorm:"column:transaction_id;size:16"`
+		Quantity        decimal.Decimal `gorm:"type:decimal(20,4);column:quantity"`
+		AcquisitionDate time.Time       `gorm:"column:acquisition_date"`
+		CostPrice       decimal.Decimal `gorm:"type:decimal(20,4);column:cost_price"`
+		CostValue       decimal.Decimal `gorm:"type:decimal(20,4);column:cost_value"`
+		SalePrice       decimal.Decimal `gorm:"type:decimal(20,4);column:sale_price"`
+		Proceeds        decimal.Decimal `gorm:"type:decimal(20,4);column:proceeds"`
+		Fee             decimal.Decimal `gorm:"type:decimal(20,4);column:fee"`
+		Gain            decimal.Decimal `gorm:"type:decimal(20,4);column:gain"`
+		Date            time.Time       `gorm:"column:date"`
+		CreatedAt       time.Time       `gorm:"autoCreateTime,column:created_at"`
+		UpdatedAt       time.Time       `gorm:"autoUpdateTime,column:updated_at"`
+	}
+
+	return &RealizedGains{}
+}
+
+// initialTables returns the models as they were when the schema was first versioned.
+func initialTables() []interface{} {
+	type Accounts struct {
+		Id        int       `gorm:"primarykey;size:16"`
+		UserId    int       `gorm:"column:user_id;size:16"`
+		Status    int       `gorm:"column:status;size:11"`
+		Name      string    `gorm:"column:name;size:255"`
+		CreatedAt time.Time `gorm:"autoCreateTime,column:created_at"`
+		UpdatedAt time.Time `gorm:"autoUpdateTime,column:updated_at"`
+	}
+
+	type Securities struct {
+		Id        int       `gorm:"primarykey;size:16"`
+		Type      int       `gorm:"index:idx_type_exchange_symbol,unique;column:type;size:16"`
+		Exchange  int       `gorm:"index:idx_type_exchange_symbol,unique;column:exchange;size:16"`
+		Symbol    string    `gorm:"index:idx_type_exchange_symbol,unique;column:symbol;size:255"`
+		Name      string    `gorm:"column:name;size:255"`
+		CreatedAt time.Time `gorm:"autoCreateTime,column:created_at"`
+		UpdatedAt time.Time `gorm:"autoUpdateTime,column:updated_at"`
+	}
+
+	type Inventories struct {
+		Id                int       `gorm:"primarykey;size:16"`
+		AccountId         int       `gorm:"column:account_id;size:16"`
+		SecurityId        int       `gorm:"column:security_id;size:16"`
+		AvailableQuantity float64   `gorm:"type:decimal(12,4);column:available_quantity"`
+		AveragePrice      float64   `gorm:"type:decimal(12,4);column:average_price"`
+		TotalValue        float64   `gorm:"type:decimal(12,4);column:total_value"`
+		Date              time.Time `gorm:"column:date"`
+		State             int       `gorm:"column:state;size:11;"`
+		CreatedAt         time.Time `gorm:"autoCreateTime,column:created_at"`
+		UpdatedAt         time.Time `gorm:"autoUpdateTime,column:updated_at"`
+	}
+
+	type InventoryLedger struct {
+		Id            int                    `gorm:"primarykey;size:16"`
+		InventoryId   int                    `gorm:"column:inventory_id;size:16"`
+		TransactionId int                    `gorm:"column:transaction_id;size:16"`
+		Type          initialTransactionType `gorm:"column:type;size:16"`
+		Quantity      float64                `gorm:"type:decimal(12,4);column:quantity"`
+		AveragePrice  float64                `gorm:"type:decimal(12,4);column:average_price"`
+		TotalValue    float64                `gorm:"type:decimal(12,4);column:total_value"`
+		Fee           float64                `gorm:"type:decimal(12,4);column:fee"`
+		Date          time.Time              `gorm:"column:date"`
+		CreatedAt     time.Time              `gorm:"autoCreateTime,column:created_at"`
+		UpdatedAt     time.Time              `gorm:"autoUpdateTime,column:updated_at"`
+	}
+
+	type Transactions struct {
+		Id           int                    `gorm:"primarykey;size:16"`
+		AccountId    int                    `gorm:"column:account_id;size:16"`
+		SecurityId   int                    `gorm:"column:security_id;size:16"`
+		Type         initialTransactionType `gorm:"column:type;size:16"`
+		Quantity     float64                `gorm:"type:decimal(12,4);column:quantity"`
+		AveragePrice float64                `gorm:"type:decimal(12,4);column:average_price"`
+		TotalValue   float64                `gorm:"type:decimal(12,4);column:total_value"`
+		Fee          float64                `gorm:"type:decimal(12,4);column:fee"`
+		State        int                    `gorm:"column:state;size:11;"`
+		Date         time.Time              `gorm:"column:date"`
+		CreatedAt    time.Time              `gorm:"autoCreateTime,column:created_at"`
+		UpdatedAt    time.Time              `gorm:"autoUpdateTime,column:updated_at"`
+	}
+
+	return []interface{}{&Accounts{}, &Securities{}, &Inventories{}, &InventoryLedger{}, &Transactions{}}
+}
+
+// initialTransactionType is the type column as migration 1 creates it. Later migrations widen the MySQL enum as
+// they add types, so it must not follow domain.TransactionTypes.
+type initialTransactionType string
+
+// GormDBDataType returns the column type migration 1 creates: on MySQL an enum of the types known then, elsewhere a
+// plain string.
+func (initialTransactionType) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if db.Dialector.Name() == "mysql" {
+		return "enum('BUY', 'SELL', 'DIVIDEND', 'SPLIT', 'BONUS', 'MERGER', 'MERGER_TRANSFER', 'DEMERGER', 'DEMERGER_TRANSFER')"
+	}
+	return "varchar(32)"
+}
+
+// alterDecimalColumns changes every quantity, price and value column to the given decimal type.
+func alterDecimalColumns(tx *gorm.DB, dataType string) error {
+	for _, table := range []struct {
+		model   string
+		columns []string
+	}{
+		{"Inventories", []string{"available_quantity", "average_price", "total_value"}},
+		{"InventoryLedger", []string{"quantity", "average_price", "total_value", "fee"}},
+		{"Transactions", []string{"quantity", "average_price", "total_value", "fee"}},
+	} {
+		name := tx.NamingStrategy.TableName(table.model)
+		for _, column := range table.columns {
+			if err := alterColumnType(tx, name, column, dataType); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// alterColumnType changes the type of one column, keeping its values. SQLite stores decimals with numeric
+// affinity whatever their declared precision, so there is nothing to change there.
+func alterColumnType(tx *gorm.DB, table, column, dataType string) error {
+	switch tx.Dialector.Name() {
+	case "mysql":
+		return tx.Exec("ALTER TABLE " + table + " MODIFY COLUMN " + column + " " + dataType).Error
+	case "postgres":
+		return tx.Exec("ALTER TABLE " + table + " ALTER COLUMN " + column + " TYPE " + dataType).Error
+	}
+	return nil
+}
+
+// alterTransactionTypeColumns sets the values MySQL accepts in the ledger and transaction type columns. Other
+// dialects store the type as a plain string, so there is nothing to change there.
+func alterTransactionTypeColumns(tx *gorm.DB, values []string) error {
+	if tx.Dialector.Name() != "mysql" {
+		return nil
+	}
+
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = "'" + value + "'"
+	}
+	for _, model := range []string{"InventoryLedger", "Transactions"} {
+		if err := alterColumnType(tx, tx.NamingStrategy.TableName(model), "type", "enum("+strings.Join(quoted, ", ")+")"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type index struct {
+	table   string
+	name    string
+	columns string
+}
+
+// lookupIndexes covers the columns every inventory, ledger and transaction query filters on. Index names
+// carry the table prefix because Postgres requires them to be unique per schema, not per table.
+func lookupIndexes(tx *gorm.DB) []index {
+	inventories := tx.NamingStrategy.TableName("Inventories")
+	inventoryLedgers := tx.NamingStrategy.TableName("InventoryLedger")
+	transactions := tx.NamingStrategy.TableName("Transactions")
+
+	return []index{
+		{inventories, inventories + "_account_security_idx", "account_id, security_id"},
+		{inventoryLedgers, inventoryLedgers + "_inventory_idx", "inventory_id"},
+		{transactions, transactions + "_account_security_idx", "account_id, security_id"},
+	}
+}
+
+// createIndexes creates the indexes not already there.
+func createIndexes(tx *gorm.DB, indexes []index) error {
+	for _, index := range indexes {
+		if tx.Migrator().HasIndex(index.table, index.name) {
+			continue
+		}
+		if err := tx.Exec("CREATE INDEX " + index.name + " ON " + index.table + " (" + index.columns + ")").Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropIndexes drops the indexes that are there.
+func dropIndexes(tx *gorm.DB, indexes []index) error {
+	for _, index := range indexes {
+		if !tx.Migrator().HasIndex(index.table, index.name) {
+			continue
+		}
+		if err := tx.Migrator().DropIndex(index.table, index.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,11 +1,16 @@
 package mysql
 
 import (
+	"assetio/internal/adapters/repository/migration"
 	"assetio/internal/domain"
 	"assetio/internal/port"
 	"context"
+	"net"
+	"sync/atomic"
 	"time"
 
+	mysqlDriver "github.com/go-sql-driver/mysql"
+	"github.com/shopspring/decimal"
 	gormMysql "gorm.io/driver/mysql"
 
 	"gorm.io/gorm"
@@ -13,34 +18,179 @@ import (
 	"gorm.io/gorm/schema"
 )
 
+// Config holds the connection settings of the primary and of the optional read replicas. Zero values keep the
+// driver defaults, except Timezone which falls back to the local zone the adapter has always used.
+type Config struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	Name     string
+	Prefix   string
+
+	// DSN options, shared by the primary and the replicas.
+	TLS          string        // "true", "false", "skip-verify", "preferred" or a registered TLS config name
+	Timezone     string        // IANA zone DATETIME values are read and written in, e.g. "UTC"
+	Timeout      time.Duration // Dial timeout
+	ReadTimeout  time.Duration // I/O read timeout
+	WriteTimeout time.Duration // I/O write timeout
+
+	// Pool limits, applied to every connection pool.
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// Replicas receive the read-only queries made outside a transaction. They share the primary's
+	// credentials and database name.
+	Replicas []Replica
+}
+
+// Replica is the address of one read replica.
+type Replica struct {
+	Host string
+	Port string
+}
+
 type mysql struct {
-	dialer *gorm.DB
-	prefix string
+	dialer   *gorm.DB
+	replicas []*gorm.DB
+	next     *uint32 // Round robin position over replicas
+	prefix   string
 }
 
 // New creates a new MySQL database connection using GORM with custom configurations.
-// It takes database credentials and a prefix for table naming conventions, returning a
-// RepositoryStore interface for accessing database methods or an error if connection fails.
-func New(hostname, port, username, password, name string, prefix string) (port.RepositoryStore, error) {
-	dsn := username + ":" + password + "@tcp(" + hostname + ":" + port + ")/" + name + "?charset=utf8mb4&parseTime=True&loc=Local"
+// It connects to the primary and to every configured replica, returning a RepositoryStore
+// interface for accessing database methods or an error if a connection fails.
+//
+// Mutations, transactions and migrations always use the primary. Read-only methods called outside a
+// transaction are spread over the replicas, so they may briefly lag behind a write made just before.
+func New(config Config) (port.RepositoryStore, error) {
+	dialer, err := open(config, config.Host, config.Port)
+	if err != nil {
+		return nil, err
+	}
+
+	replicas := make([]*gorm.DB, 0, len(config.Replicas))
+	for _, replica := range config.Replicas {
+		replicaDialer, err := open(config, replica.Host, replica.Port)
+		if err != nil {
+			return nil, err
+		}
+		replicas = append(replicas, replicaDialer)
+	}
+
+	return &mysql{
+		dialer:   dialer,
+		replicas: replicas,
+		next:     new(uint32),
+		prefix:   config.Prefix,
+	}, nil
+}
+
+// open connects to one server with the DSN options and pool limits of config.
+func open(config Config, host, port string) (*gorm.DB, error) {
+	dsn, err := dataSourceName(config, host, port)
+	if err != nil {
+		return nil, err
+	}
+
 	dialer, err := gorm.Open(gormMysql.Open(dsn), &gorm.Config{
 		Logger: logger.Default.LogMode(logger.Error),
 		NamingStrategy: schema.NamingStrategy{
-			TablePrefix: prefix,
+			TablePrefix: config.Prefix,
 		},
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	return &mysql{
-		dialer: dialer,
-		prefix: prefix,
-	}, err
+	sqlDB, err := dialer.DB()
+	if err != nil {
+		return nil, err
+	}
+	if config.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(config.MaxOpenConns)
+	}
+	if config.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(config.MaxIdleConns)
+	}
+	if config.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
+	}
+	if config.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+	}
+	return dialer, nil
+}
+
+// dataSourceName builds the go-sql-driver DSN for one server.
+func dataSourceName(config Config, host, port string) (string, error) {
+	location := time.Local
+	if config.Timezone != "" {
+		var err error
+		if location, err = time.LoadLocation(config.Timezone); err != nil {
+			return "", err
+		}
+	}
+
+	dsnConfig := mysqlDriver.NewConfig()
+	dsnConfig.User = config.Username
+	dsnConfig.Passwd = config.Password
+	dsnConfig.Net = "tcp"
+	dsnConfig.Addr = net.JoinHostPort(host, port)
+	dsnConfig.DBName = config.Name
+	dsnConfig.Params = map[string]string{"charset": "utf8mb4"}
+	dsnConfig.ParseTime = true
+	dsnConfig.Loc = location
+	dsnConfig.TLSConfig = config.TLS
+	dsnConfig.Timeout = config.Timeout
+	dsnConfig.ReadTimeout = config.ReadTimeout
+	dsnConfig.WriteTimeout = config.WriteTimeout
+	return dsnConfig.FormatDSN(), nil
+}
+
+// reader returns the connection for a read-only query: the next replica in turn, or the primary when
+// there are none. Inside WithTx there are no replicas, so reads see the transaction's own writes.
+func (m *mysql) reader() *gorm.DB {
+	if len(m.replicas) == 0 {
+		return m.dialer
+	}
+	return m.replicas[atomic.AddUint32(m.next, 1)%uint32(len(m.replicas))]
+}
+
+// MigrateUp applies every pending schema migration and returns the migrations it applied.
+func (m *mysql) MigrateUp(ctx context.Context) ([]domain.SchemaMigration, error) {
+	return migration.New(m.dialer, m.prefix).Up(ctx)
+}
 
+// MigrateDown reverts the most recently applied schema migration.
+func (m *mysql) MigrateDown(ctx context.Context) (domain.SchemaMigration, error) {
+	return migration.New(m.dialer, m.prefix).Down(ctx)
 }
 
-// AutoMigrate automatically migrates all defined models, creating or updating tables
-// to match the structs in the domain package. Used for schema versioning.
-func (m *mysql) AutoMigrate() {
-	m.dialer.AutoMigrate(&domain.Accounts{}, &domain.Securities{}, &domain.Inventories{}, &domain.InventoryLedger{}, &domain.Transactions{})
+// MigrationStatus lists every known schema migration and whether it has been applied.
+func (m *mysql) MigrationStatus(ctx context.Context) ([]domain.SchemaMigration, error) {
+	return migration.New(m.dialer, m.prefix).Status(ctx)
+}
+
+// SchemaVersion returns the applied schema version along with the version this binary expects.
+func (m *mysql) SchemaVersion(ctx context.Context) (int, int, error) {
+	current, err := migration.New(m.dialer, m.prefix).Version(ctx)
+	return current, migration.Latest(), err
+}
+
+// WithTx runs fn inside a database transaction. The repository passed to fn shares the
+// transaction, so every write it performs is committed or rolled back together.
+// Returning an error from fn (or panicking) rolls the transaction back.
+func (m *mysql) WithTx(ctx context.Context, fn func(repo port.RepositoryStore) error) error {
+	return m.dialer.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&mysql{
+			dialer: tx,
+			next:   m.next,
+			prefix: m.prefix,
+		})
+	})
 }
 
 // InsertAccountData adds a new account entry to the Accounts table.
@@ -52,13 +202,13 @@ func (m *mysql) InsertAccountData(ctx context.Context, accountData domain.Accoun
 }
 
 // GetAccountDataByIdAndUserId fetches account details based on account ID and user ID.
-// Only selects specific fields: id, name, and status. Returns the account data if found, or nil if no matching record exists.
+// Only selects specific fields: id, name, status, and lot method. Returns the account data if found, or nil if no matching record exists.
 func (m *mysql) GetAccountDataByIdAndUserId(ctx context.Context, accountId, userId int) (domain.Accounts, error) {
 	var accountData domain.Accounts
 
 	// Query the Accounts table for a record that matches the specified account ID and user ID
-	result := m.dialer.WithContext(ctx).Model(&domain.Accounts{}).
-		Select("id", "name", "status").
+	result := m.reader().WithContext(ctx).Model(&domain.Accounts{}).
+		Select("id", "name", "status", "lot_method").
 		Where("id = ? and user_id = ?", accountId, userId).
 		First(&accountData)
 
@@ -75,8 +225,8 @@ func (m *mysql) GetAccountsData(ctx context.Context, userId int) ([]domain.Accou
 	var accountsData []domain.Accounts
 
 	// Query the Accounts table for records that match the specified user ID
-	result := m.dialer.WithContext(ctx).Model(&domain.Accounts{}).
-		Select("id", "name", "status").
+	result := m.reader().WithContext(ctx).Model(&domain.Accounts{}).
+		Select("id", "name", "status", "lot_method").
 		Where("user_id = ?", userId).
 		Find(&accountsData)
 
@@ -112,9 +262,9 @@ func (m *mysql) GetSecurityDataById(ctx context.Context, securityId int) (domain
 	var securityData domain.Securities
 
 	// Query the Securities table for a record matching the specified security ID
-	result := m.dialer.WithContext(ctx).
+	result := m.reader().WithContext(ctx).
 		Model(&domain.Securities{}).
-		Select("id", "type", "exchange", "symbol", "name").
+		Select("id", "type", "exchange", "symbol", "name", "asset_class").
 		Where("id = ?", securityId).
 		First(&securityData)
 
@@ -131,7 +281,7 @@ func (m *mysql) GetSecurityDataByTypeAndExchangeAndSymbol(ctx context.Context, t
 	var securityData domain.Securities
 
 	// Query the Securities table to find the security that matches the provided type, exchange, and symbol
-	result := m.dialer.WithContext(ctx).Model(&domain.Securities{}).
+	result := m.reader().WithContext(ctx).Model(&domain.Securities{}).
 		Select("id").
 		Where("type = ? and exchange = ? and symbol = ?", types, exchange, symbol).
 		First(&securityData)
@@ -159,8 +309,8 @@ func (m *mysql) GetSecuritiesDataByType(ctx context.Context, types int) ([]domai
 	var securitiesData []domain.Securities
 
 	// Query the Securities table for records that match the given type and exchange
-	result := m.dialer.WithContext(ctx).Model(&domain.Securities{}).
-		Select("id", "type", "exchange", "symbol", "name").
+	result := m.reader().WithContext(ctx).Model(&domain.Securities{}).
+		Select("id", "type", "exchange", "symbol", "name", "asset_class").
 		Where("type = ? ", types).
 		Find(&securitiesData)
 
@@ -171,6 +321,23 @@ func (m *mysql) GetSecuritiesDataByType(ctx context.Context, types int) ([]domai
 	return securitiesData, result.Error
 }
 
+// GetSecuritiesData retrieves every security of every type and exchange, ordered by id.
+func (m *mysql) GetSecuritiesData(ctx context.Context) ([]domain.Securities, error) {
+	var securitiesData []domain.Securities
+
+	// Query the whole Securities table
+	result := m.reader().WithContext(ctx).Model(&domain.Securities{}).
+		Select("id", "type", "exchange", "symbol", "name", "asset_class").
+		Order("id").
+		Find(&securitiesData)
+
+	// Set result.Error to nil if no record is found, preventing "record not found" error
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+	return securitiesData, result.Error
+}
+
 // SearchSecuritiesDataByTypeAndExchange performs a search for securities by type, exchange, name, or symbol.
 // The search term is matched partially with both the name and symbol fields using SQL LIKE.
 // Returns a slice of matching Securities records or an empty slice if none found.
@@ -178,8 +345,8 @@ func (m *mysql) SearchSecuritiesDataByTypeAndExchange(ctx context.Context, types
 	var securitiesData []domain.Securities
 
 	// Query the Securities table to find records that match the type, exchange, and partially match the search term in name or symbol
-	result := m.dialer.WithContext(ctx).Model(&domain.Securities{}).
-		Select("id", "type", "exchange", "symbol", "name").
+	result := m.reader().WithContext(ctx).Model(&domain.Securities{}).
+		Select("id", "type", "exchange", "symbol", "name", "asset_class").
 		Where("type = ? and exchange = ? and (name LIKE ? or symbol LIKE ?)", types, exchange, "%"+search+"%", "%"+search+"%").
 		Find(&securitiesData)
 
@@ -199,15 +366,24 @@ func (m *mysql) InsertInventoryLedger(ctx context.Context, inventoryLedgerData d
 }
 
 // UpdateInventoryDetailsById updates an inventory record by its ID with the provided inventory data.
-// Returns any error encountered during the update.
-func (m *mysql) UpdateInventoryDetailsById(ctx context.Context, inventoryId int, availableQuantity, averagePrice, totalValue float64) error {
-	// Update the inventory record where the ID matches the given inventoryId
-	result := m.dialer.WithContext(ctx).Model(&domain.Inventories{}).Where("id = ?", inventoryId).Updates(map[string]interface{}{
+// The update only applies while the row is still at the given version and bumps the version, so a row
+// changed by a concurrent request since it was read is never overwritten; domain.ErrInventoryConflict is
+// returned instead.
+func (m *mysql) UpdateInventoryDetailsById(ctx context.Context, inventoryId, version int, availableQuantity, averagePrice, totalValue decimal.Decimal) error {
+	// Update the inventory record where the ID and version match
+	result := m.dialer.WithContext(ctx).Model(&domain.Inventories{}).Where("id = ? AND version = ?", inventoryId, version).Updates(map[string]interface{}{
 		"available_quantity": availableQuantity,
 		"average_price":      averagePrice,
 		"total_value":        totalValue,
+		"version":            gorm.Expr("version + 1"),
 	})
-	return result.Error
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrInventoryConflict
+	}
+	return nil
 }
 
 // InsertTransaction adds a new transaction entry to the Transactions table using the provided transaction data.
@@ -242,7 +418,7 @@ func (m *mysql) UpdateInventoryLedgerTransactionIdByIds(ctx context.Context, led
 
 // UpdateAvailableQuantityToInventoryById sets the available quantity in an inventory record by its inventory ID.
 // Returns any error encountered during the update.
-func (m *mysql) UpdateAvailableQuantityToInventoryById(ctx context.Context, inventoryId int, quantity float64) error {
+func (m *mysql) UpdateAvailableQuantityToInventoryById(ctx context.Context, inventoryId int, quantity decimal.Decimal) error {
 	// Update the available_quantity field in the Inventories record with the specified inventoryId
 	result := m.dialer.WithContext(ctx).Model(&domain.Inventories{}).Where("id = ?", inventoryId).Updates(map[string]interface{}{
 		"available_quantity": quantity,
@@ -256,7 +432,7 @@ func (m *mysql) GetInvertriesSummaryByAccountIdAndSecurityType(ctx context.Conte
 	var inventoryData []domain.InventorySummary
 
 	// Query to join inventories with securities to get a summary, filtered by account ID and security type
-	result := m.dialer.WithContext(ctx).
+	result := m.reader().WithContext(ctx).
 		Model(&domain.Inventories{}).
 		Select(m.prefix+"inventories.id", m.prefix+"inventories.account_id", m.prefix+"inventories.security_id", m.prefix+"securities.name as security_name", m.prefix+"securities.exchange as security_exchange", m.prefix+"securities.symbol as security_symbol", "SUM("+m.prefix+"inventories.available_quantity) as available_quantity", "SUM("+m.prefix+"inventories.total_value) as total_value").
 		Joins("JOIN "+m.prefix+"securities ON (security_id = "+m.prefix+"securities.id and type = ? )", securityType).
@@ -278,7 +454,7 @@ func (m *mysql) GetInvertriesByAccountIdAndSecurityId(ctx context.Context, accou
 	var inventoryData []domain.InventoryDetails
 
 	// Query to get inventory details based on account and security IDs, ordered by the latest creation date
-	result := m.dialer.WithContext(ctx).
+	result := m.reader().WithContext(ctx).
 		Model(&domain.Inventories{}).
 		Select("id", "available_quantity", "total_value", "date").
 		Where("account_id = ? and security_id = ? and available_quantity > 0 ", accountId, securityId).
@@ -316,7 +492,7 @@ func (m *mysql) GetInventoryDataById(ctx context.Context, inventoryId int) (doma
 	var inventoryData domain.Inventories
 
 	// Query the Inventories table for a record matching the specified inventory ID
-	result := m.dialer.WithContext(ctx).Model(&domain.Inventories{}).
+	result := m.reader().WithContext(ctx).Model(&domain.Inventories{}).
 		Select("*").
 		Where("id = ?", inventoryId).
 		Find(&inventoryData)
@@ -331,13 +507,14 @@ func (m *mysql) GetInventoryDataById(ctx context.Context, inventoryId int) (doma
 // UpdateAvailableQuantityToInventoryById updates the available quantity for a specific inventory record by its ID.
 // Takes the inventory ID and the new quantity as parameters.
 // Returns an error if the update operation fails.
-func (m *mysql) UpdateAvailableQuanityToInventoryById(ctx context.Context, inventoryId int, quantity float64) error {
+func (m *mysql) UpdateAvailableQuanityToInventoryById(ctx context.Context, inventoryId int, quantity decimal.Decimal) error {
 	// Update the available_quantity field for the record with the specified inventory ID
 	result := m.dialer.WithContext(ctx).
 		Model(&domain.Inventories{}).
 		Where("id = ?", inventoryId).
 		Updates(map[string]interface{}{
 			"available_quantity": quantity,
+			"version":            gorm.Expr("version + 1"),
 		})
 
 	return result.Error
@@ -349,7 +526,7 @@ func (m *mysql) GetActiveInventoriesByAccountIdAndSecurityId(ctx context.Context
 	var InventoriesData []domain.Inventories
 
 	// Query to find active inventories based on account and security IDs with positive available quantity
-	result := m.dialer.WithContext(ctx).Model(&domain.Inventories{}).Select("id", "available_quantity", "total_value", "average_price", "date").
+	result := m.reader().WithContext(ctx).Model(&domain.Inventories{}).Select("id", "available_quantity", "total_value", "average_price", "date", "version").
 		Where("account_id = ? and security_id = ? and available_quantity > 0", accountId, securityId).
 		Order("id"). // Fetch old data first by ordering by ID
 		Find(&InventoriesData)
@@ -362,15 +539,37 @@ func (m *mysql) GetActiveInventoriesByAccountIdAndSecurityId(ctx context.Context
 	return InventoriesData, result.Error
 }
 
+// GetInventoriesByAccountIdOrSecurityId retrieves every inventory, including closed ones, of an account, a security,
+// or both; a zero id matches any value. Records are ordered by ID.
+func (m *mysql) GetInventoriesByAccountIdOrSecurityId(ctx context.Context, accountId, securityId int) ([]domain.Inventories, error) {
+	var inventoriesData []domain.Inventories
+
+	query := m.reader().WithContext(ctx).Model(&domain.Inventories{})
+	if accountId != 0 {
+		query = query.Where("account_id = ?", accountId)
+	}
+	if securityId != 0 {
+		query = query.Where("security_id = ?", securityId)
+	}
+	result := query.Order("id").Find(&inventoriesData)
+
+	// If no record found, set error to nil to avoid returning an error for empty results
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+
+	return inventoriesData, result.Error
+}
+
 // GetInventoryLedgersByInventoryIdAndAccountId retrieves ledger entries associated with a specific inventory ID, ordered by date.
 // Each ledger entry includes ID, type, quantity, average price, total value, and date fields.
 func (m *mysql) GetInventoryLedgersByInventoryId(ctx context.Context, inventoryId int) ([]domain.InventoryLedgers, error) {
 	var inventoryLedgerData []domain.InventoryLedgers
 
 	// Query to find inventory ledger data based on inventory ID, ordered by date in descending order
-	result := m.dialer.WithContext(ctx).
+	result := m.reader().WithContext(ctx).
 		Model(&domain.InventoryLedger{}).
-		Select("id", "type", "quantity", "average_price", "total_value", "date").
+		Select("id", "transaction_id", "type", "quantity", "average_price", "total_value", "fee", "date").
 		Where("inventory_id = ?", inventoryId).
 		Order("date asc, id desc"). // Fetch the latest data first
 		Debug().                    // Logs the SQL query
@@ -388,7 +587,7 @@ func (m *mysql) GetDividendTransactionsByAccountIdAndSecurityId(ctx context.Cont
 	var transactionsData []domain.DividendTransaction
 
 	// Query to find inventory ledger data based on inventory ID, ordered by date in descending order
-	result := m.dialer.WithContext(ctx).
+	result := m.reader().WithContext(ctx).
 		Model(&domain.Transactions{}).
 		Select("quantity", "average_price", "total_value", "date").
 		Where("account_id =? and security_id = ? and type =?", accountId, securityId, domain.DIVIDEND).
@@ -403,21 +602,21 @@ func (m *mysql) GetDividendTransactionsByAccountIdAndSecurityId(ctx context.Cont
 
 }
 
-func (m *mysql) GetInventoryAvailableQuanitityBySecurityIdAndDate(ctx context.Context, accountId, securityId int, date time.Time) (float64, error) {
-	var totalQuantity float64
+func (m *mysql) GetInventoryAvailableQuanitityBySecurityIdAndDate(ctx context.Context, accountId, securityId int, date time.Time) (decimal.Decimal, error) {
+	var totalQuantity decimal.Decimal
 
 	// Query to calculate the total quantity
 
-	result := m.dialer.WithContext(ctx).
+	result := m.reader().WithContext(ctx).
 		Model(&domain.Transactions{}).
-		Select(`SUM(
+		Select(`COALESCE(SUM(
         CASE 
-            WHEN type = ? AND date < ? THEN quantity   
-            WHEN type = ?  AND date <= ? THEN -quantity  
+            WHEN type IN ? AND date < ? THEN quantity   
+            WHEN type IN ? AND date <= ? THEN -quantity  
             ELSE 0                            
         END
-    ) as total_quantity`, domain.BUY, date, domain.SELL, date).
-		Where("account_id = ? AND security_id = ?", accountId, securityId).
+    ), 0) as total_quantity`, []domain.TransactionType{domain.BUY, domain.RIGHTS_SUBSCRIBE}, date, []domain.TransactionType{domain.SELL, domain.BUYBACK}, date).
+		Where("account_id = ? AND security_id = ? AND state <> ?", accountId, securityId, domain.TRANSACTION_STATE_VOIDED).
 		Scan(&totalQuantity)
 
 		// If no record found, set error to nil for empty results
@@ -428,3 +627,279 @@ func (m *mysql) GetInventoryAvailableQuanitityBySecurityIdAndDate(ctx context.Co
 	return totalQuantity, result.Error
 
 }
+
+// GetUnlinkedInventoryLedgers retrieves the ledger entries of an account whose transaction ID is unset or refers to
+// no transaction; an account ID of zero scans every account. Records are ordered by ID.
+func (m *mysql) GetUnlinkedInventoryLedgers(ctx context.Context, accountId int) ([]domain.InventoryLedger, error) {
+	var inventoryLedgerData []domain.InventoryLedger
+
+	ledgers := m.prefix + "inventory_ledgers"
+	inventories := m.prefix + "inventories"
+	transactions := m.prefix + "transactions"
+
+	// Left join the transactions so a missing one shows up as a NULL id
+	query := m.reader().WithContext(ctx).
+		Model(&domain.InventoryLedger{}).
+		Select(ledgers + ".*").
+		Joins("JOIN " + inventories + " ON " + inventories + ".id = " + ledgers + ".inventory_id").
+		Joins("LEFT JOIN " + transactions + " ON " + transactions + ".id = " + ledgers + ".transaction_id").
+		Where(transactions + ".id IS NULL")
+	if accountId != 0 {
+		query = query.Where(inventories+".account_id = ?", accountId)
+	}
+	result := query.Order(ledgers + ".id").Find(&inventoryLedgerData)
+
+	// If no record found, set error to nil for empty results
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+
+	return inventoryLedgerData, result.Error
+}
+
+// GetTransactionLedgerTotals retrieves every transaction of an account that moves inventory, together with the
+// summed quantity and count of the ledger entries linked to it; an account ID of zero scans every account.
+// Dividends never touch inventory and are left out. Records are ordered by ID.
+func (m *mysql) GetTransactionLedgerTotals(ctx context.Context, accountId int) ([]domain.TransactionLedgerTotal, error) {
+	var totalsData []domain.TransactionLedgerTotal
+
+	ledgers := m.prefix + "inventory_ledgers"
+	transactions := m.prefix + "transactions"
+
+	query := m.reader().WithContext(ctx).
+		Model(&domain.Transactions{}).
+		Select(transactions+".id", transactions+".account_id", transactions+".security_id", transactions+".type", transactions+".quantity",
+			"COALESCE(SUM("+ledgers+".quantity), 0) as ledger_quantity", "COUNT("+ledgers+".id) as ledger_count").
+		Joins("LEFT JOIN "+ledgers+" ON "+ledgers+".transaction_id = "+transactions+".id AND "+ledgers+".type <> ?", domain.VOID).
+		Where(transactions+".type <> ?", domain.DIVIDEND)
+	if accountId != 0 {
+		query = query.Where(transactions+".account_id = ?", accountId)
+	}
+	result := query.
+		Group(transactions + ".id, " + transactions + ".account_id, " + transactions + ".security_id, " + transactions + ".type, " + transactions + ".quantity").
+		Order(transactions + ".id").
+		Find(&totalsData)
+
+	// If no record found, set error to nil for empty results
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+
+	return totalsData, result.Error
+}
+
+// GetTransactionDataById retrieves a transaction record by its ID.
+// Returns the transaction data if found, or an empty transaction if no matching record exists.
+func (m *mysql) GetTransactionDataById(ctx context.Context, transactionId int) (domain.Transactions, error) {
+	var transactionData domain.Transactions
+
+	// Query the Transactions table for a record matching the specified transaction ID
+	result := m.reader().WithContext(ctx).Model(&domain.Transactions{}).
+		Select("*").
+		Where("id = ?", transactionId).
+		Find(&transactionData)
+
+	// Set result.Error to nil if no record is found to avoid a "record not found" error
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+	return transactionData, result.Error
+}
+
+// GetInventoryLedgersByTransactionId retrieves every ledger entry linked to a transaction, ordered by ID.
+func (m *mysql) GetInventoryLedgersByTransactionId(ctx context.Context, transactionId int) ([]domain.InventoryLedger, error) {
+	var inventoryLedgerData []domain.InventoryLedger
+
+	result := m.reader().WithContext(ctx).Model(&domain.InventoryLedger{}).
+		Select("*").
+		Where("transaction_id = ?", transactionId).
+		Order("id").
+		Find(&inventoryLedgerData)
+
+	// If no record found, set error to nil for empty results
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+	return inventoryLedgerData, result.Error
+}
+
+// UpdateTransactionStateById sets the state of a transaction record by its ID.
+func (m *mysql) UpdateTransactionStateById(ctx context.Context, transactionId, state int) error {
+	result := m.dialer.WithContext(ctx).Model(&domain.Transactions{}).Where("id = ?", transactionId).Updates(map[string]interface{}{
+		"state": state,
+	})
+	return result.Error
+}
+
+// GetTransactionsByAccountIdAndSecurityId retrieves every transaction of an account and security, voided ones included,
+// ordered by date and then by ID.
+func (m *mysql) GetTransactionsByAccountIdAndSecurityId(ctx context.Context, accountId, securityId int) ([]domain.Transactions, error) {
+	var transactionsData []domain.Transactions
+
+	result := m.reader().WithContext(ctx).Model(&domain.Transactions{}).
+		Select("*").
+		Where("account_id = ? AND security_id = ?", accountId, securityId).
+		Order("date, id").
+		Find(&transactionsData)
+
+	// If no record found, set error to nil for empty results
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+	return transactionsData, result.Error
+}
+
+// GetTransactionsByAccountId retrieves every transaction of an account, voided ones included, ordered by date and then
+// by ID.
+func (m *mysql) GetTransactionsByAccountId(ctx context.Context, accountId int) ([]domain.Transactions, error) {
+	var transactionsData []domain.Transactions
+
+	result := m.reader().WithContext(ctx).Model(&domain.Transactions{}).
+		Select("*").
+		Where("account_id = ?", accountId).
+		Order("date, id").
+		Find(&transactionsData)
+
+	// If no record found, set error to nil for empty results
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+	return transactionsData, result.Error
+}
+
+// UpdateTransactionById sets the quantity, average price, total value, fee and date of a transaction record by its ID.
+func (m *mysql) UpdateTransactionById(ctx context.Context, transactionId int, transactionData domain.Transactions) error {
+	result := m.dialer.WithContext(ctx).Model(&domain.Transactions{}).Where("id = ?", transactionId).Updates(map[string]interface{}{
+		"quantity":      transactionData.Quantity,
+		"average_price": transactionData.AveragePrice,
+		"total_value":   transactionData.TotalValue,
+		"fee":           transactionData.Fee,
+		"date":          transactionData.Date,
+	})
+	return result.Error
+}
+
+// UpdateInventoryLedgerById sets the quantity, average price, total value, fee and date of a ledger entry by its ID.
+func (m *mysql) UpdateInventoryLedgerById(ctx context.Context, ledgerId int, ledgerData domain.InventoryLedger) error {
+	result := m.dialer.WithContext(ctx).Model(&domain.InventoryLedger{}).Where("id = ?", ledgerId).Updates(map[string]interface{}{
+		"quantity":      ledgerData.Quantity,
+		"average_price": ledgerData.AveragePrice,
+		"total_value":   ledgerData.TotalValue,
+		"fee":           ledgerData.Fee,
+		"date":          ledgerData.Date,
+	})
+	return result.Error
+}
+
+// DeleteInventoryLedgerById removes a ledger entry by its ID.
+func (m *mysql) DeleteInventoryLedgerById(ctx context.Context, ledgerId int) error {
+	result := m.dialer.WithContext(ctx).Where("id = ?", ledgerId).Delete(&domain.InventoryLedger{})
+	return result.Error
+}
+
+// UpdateInventoryDateById sets the date of an inventory record by its ID.
+func (m *mysql) UpdateInventoryDateById(ctx context.Context, inventoryId int, date time.Time) error {
+	result := m.dialer.WithContext(ctx).Model(&domain.Inventories{}).Where("id = ?", inventoryId).Updates(map[string]interface{}{
+		"date": date,
+	})
+	return result.Error
+}
+
+// InsertRealizedGain adds a realized gain record and returns it with its generated ID.
+func (m *mysql) InsertRealizedGain(ctx context.Context, realizedGainData domain.RealizedGains) (domain.RealizedGains, error) {
+	result := m.dialer.WithContext(ctx).Model(&domain.RealizedGains{}).Create(&realizedGainData)
+	return realizedGainData, result.Error
+}
+
+// DeleteRealizedGainsByInventoryId removes every realized gain record of an inventory.
+func (m *mysql) DeleteRealizedGainsByInventoryId(ctx context.Context, inventoryId int) error {
+	result := m.dialer.WithContext(ctx).Where("inventory_id = ?", inventoryId).Delete(&domain.RealizedGains{})
+	return result.Error
+}
+
+// GetRealizedGains retrieves the realized gain records of an account sold between from and to, both inclusive.
+// A zero security ID matches every security and a zero time leaves that end of the range open. Records are
+// ordered by date and then by ID.
+func (m *mysql) GetRealizedGains(ctx context.Context, accountId, securityId int, from, to time.Time) ([]domain.RealizedGains, error) {
+	var realizedGainsData []domain.RealizedGains
+
+	query := m.reader().WithContext(ctx).Model(&domain.RealizedGains{}).Where("account_id = ?", accountId)
+	if securityId != 0 {
+		query = query.Where("security_id = ?", securityId)
+	}
+	if !from.IsZero() {
+		query = query.Where("date >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("date <= ?", to)
+	}
+	result := query.Order("date, id").Find(&realizedGainsData)
+
+	// If no record found, set error to nil for empty results
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+	return realizedGainsData, result.Error
+}
+
+// InsertTransactionCharges adds the itemised charges of a transaction and returns them with their generated ID.
+func (m *mysql) InsertTransactionCharges(ctx context.Context, chargesData domain.TransactionCharges) (domain.TransactionCharges, error) {
+	result := m.dialer.WithContext(ctx).Model(&domain.TransactionCharges{}).Create(&chargesData)
+	return chargesData, result.Error
+}
+
+// GetTransactionChargesByTransactionIds retrieves the itemised charges of the given transactions, ordered by
+// transaction ID. Transactions recorded with only a total fee have none.
+func (m *mysql) GetTransactionChargesByTransactionIds(ctx context.Context, transactionIds []int) ([]domain.TransactionCharges, error) {
+	var chargesData []domain.TransactionCharges
+	if len(transactionIds) == 0 {
+		return chargesData, nil
+	}
+
+	result := m.reader().WithContext(ctx).Model(&domain.TransactionCharges{}).Where("transaction_id IN ?", transactionIds).Order("transaction_id").Find(&chargesData)
+
+	// If no record found, set error to nil for empty results
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+	return chargesData, result.Error
+}
+
+// DeleteTransactionChargesByTransactionId removes the itemised charges of a transaction.
+func (m *mysql) DeleteTransactionChargesByTransactionId(ctx context.Context, transactionId int) error {
+	result := m.dialer.WithContext(ctx).Where("transaction_id = ?", transactionId).Delete(&domain.TransactionCharges{})
+	return result.Error
+}
+
+// InsertRightsIssue adds the rights entitlements of an account from a rights issue and returns them with their
+// generated ID.
+func (m *mysql) InsertRightsIssue(ctx context.Context, rightsIssueData domain.RightsIssues) (domain.RightsIssues, error) {
+	result := m.dialer.WithContext(ctx).Model(&domain.RightsIssues{}).Create(&rightsIssueData)
+	return rightsIssueData, result.Error
+}
+
+// GetRightsIssueByIdAndAccountId retrieves a rights issue of an account by its ID.
+func (m *mysql) GetRightsIssueByIdAndAccountId(ctx context.Context, rightsIssueId, accountId int) (domain.RightsIssues, error) {
+	var rightsIssueData domain.RightsIssues
+	result := m.reader().WithContext(ctx).Model(&domain.RightsIssues{}).
+		Where("id = ? AND account_id = ?", rightsIssueId, accountId).
+		Find(&rightsIssueData)
+
+	// Set result.Error to nil if no record is found to avoid a "record not found" error
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+	return rightsIssueData, result.Error
+}
+
+// UpdateRightsIssueById sets the renounced and subscribed entitlements, the lot and the paid-up price of a rights
+// issue by its ID.
+func (m *mysql) UpdateRightsIssueById(ctx context.Context, rightsIssueId int, rightsIssueData domain.RightsIssues) error {
+	result := m.dialer.WithContext(ctx).Model(&domain.RightsIssues{}).Where("id = ?", rightsIssueId).Updates(map[string]interface{}{
+		"inventory_id": rightsIssueData.InventoryId,
+		"paid_price":   rightsIssueData.PaidPrice,
+		"renounced":    rightsIssueData.Renounced,
+		"subscribed":   rightsIssueData.Subscribed,
+	})
+	return result.Error
+}
@@ -0,0 +1,88 @@
+package mysql
+
+import (
+	"assetio/internal/adapters/repository/contract"
+	"assetio/internal/port"
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TestContract runs the repository contract against a real MySQL server. It is skipped unless
+// ASSETIO_TEST_MYSQL_HOST is set; every sub test migrates its own set of prefixed tables.
+func TestContract(t *testing.T) {
+	host := os.Getenv("ASSETIO_TEST_MYSQL_HOST")
+	if host == "" {
+		t.Skip("ASSETIO_TEST_MYSQL_HOST not set")
+	}
+
+	run := time.Now().UnixNano()
+	count := 0
+	contract.Run(t, func(t *testing.T) port.RepositoryStore {
+		count++
+		prefix := fmt.Sprintf("ct%d_%d_", run, count)
+		repo, err := New(Config{
+			Host:     host,
+			Port:     os.Getenv("ASSETIO_TEST_MYSQL_PORT"),
+			Username: os.Getenv("ASSETIO_TEST_MYSQL_USERNAME"),
+			Password: os.Getenv("ASSETIO_TEST_MYSQL_PASSWORD"),
+			Name:     os.Getenv("ASSETIO_TEST_MYSQL_NAME"),
+			Prefix:   prefix,
+		})
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		if _, err := repo.MigrateUp(context.Background()); err != nil {
+			t.Fatalf("MigrateUp: %v", err)
+		}
+		return repo
+	})
+}
+
+func TestDataSourceName(t *testing.T) {
+	config := Config{
+		Username:     "user",
+		Password:     "secret",
+		Name:         "assetio",
+		TLS:          "skip-verify",
+		Timezone:     "UTC",
+		Timeout:      5 * time.Second,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+	dsn, err := dataSourceName(config, "db.internal", "3306")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "user:secret@tcp(db.internal:3306)/assetio?parseTime=true&readTimeout=30s&timeout=5s&tls=skip-verify&writeTimeout=30s&charset=utf8mb4"
+	if dsn != want {
+		t.Fatalf("dsn = %s\nwant  %s", dsn, want)
+	}
+
+	config.Timezone = "Not/AZone"
+	if _, err := dataSourceName(config, "db.internal", "3306"); err == nil {
+		t.Fatal("unknown timezone was accepted")
+	}
+}
+
+func TestReaderRoundRobin(t *testing.T) {
+	primary, first, second := &gorm.DB{}, &gorm.DB{}, &gorm.DB{}
+
+	repo := &mysql{dialer: primary, next: new(uint32)}
+	if repo.reader() != primary {
+		t.Fatal("reads without replicas did not use the primary")
+	}
+
+	repo.replicas = []*gorm.DB{first, second}
+	seen := map[*gorm.DB]int{}
+	for i := 0; i < 4; i++ {
+		seen[repo.reader()]++
+	}
+	if seen[first] != 2 || seen[second] != 2 || seen[primary] != 0 {
+		t.Fatalf("reads were spread as %v, want two per replica", seen)
+	}
+}
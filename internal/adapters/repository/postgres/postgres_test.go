@@ -0,0 +1,35 @@
+package postgres
+
+import (
+	"assetio/internal/adapters/repository/contract"
+	"assetio/internal/port"
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestContract runs the repository contract against a real PostgreSQL server. It is skipped unless
+// ASSETIO_TEST_POSTGRES_HOST is set; every sub test migrates its own set of prefixed tables.
+func TestContract(t *testing.T) {
+	host := os.Getenv("ASSETIO_TEST_POSTGRES_HOST")
+	if host == "" {
+		t.Skip("ASSETIO_TEST_POSTGRES_HOST not set")
+	}
+
+	run := time.Now().UnixNano()
+	count := 0
+	contract.Run(t, func(t *testing.T) port.RepositoryStore {
+		count++
+		prefix := fmt.Sprintf("ct%d_%d_", run, count)
+		repo, err := New(host, os.Getenv("ASSETIO_TEST_POSTGRES_PORT"), os.Getenv("ASSETIO_TEST_POSTGRES_USERNAME"), os.Getenv("ASSETIO_TEST_POSTGRES_PASSWORD"), os.Getenv("ASSETIO_TEST_POSTGRES_NAME"), os.Getenv("ASSETIO_TEST_POSTGRES_SSLMODE"), prefix)
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		if _, err := repo.MigrateUp(context.Background()); err != nil {
+			t.Fatalf("MigrateUp: %v", err)
+		}
+		return repo
+	})
+}
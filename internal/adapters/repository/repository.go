@@ -0,0 +1,124 @@
+// Package repository builds the RepositoryStore adapter selected in the application configuration.
+package repository
+
+import (
+	"assetio/config"
+	"assetio/internal/constant"
+	"assetio/internal/port"
+	"fmt"
+	"time"
+
+	cipherAes "assetio/internal/adapters/cipher/aes"
+	repositoryMemory "assetio/internal/adapters/repository/memory"
+	repositoryMysql "assetio/internal/adapters/repository/mysql"
+	repositoryPostgres "assetio/internal/adapters/repository/postgres"
+	repositorySqlite "assetio/internal/adapters/repository/sqlite"
+)
+
+// New connects to the database selected by store.database.driver and returns its RepositoryStore.
+// The schema is not touched; apply migrations with MigrateUp.
+func New(appConfigIns config.App) (port.RepositoryStore, error) {
+	switch driver := appConfigIns.GetStoreDatabaseDriver(); driver {
+	case constant.DATABASE_DRIVER_MYSQL:
+		host, port, username, password, dbName, prefix, err := getCredentials(appConfigIns)
+		if err != nil {
+			return nil, err
+		}
+		replicas, err := getReplicas(appConfigIns)
+		if err != nil {
+			return nil, err
+		}
+		tls, timezone, timeout, readTimeout, writeTimeout := appConfigIns.GetStoreDatabaseMysqlOptions()
+		maxOpenConns, maxIdleConns, connMaxLifetime, connMaxIdleTime := appConfigIns.GetStoreDatabasePoolProperties()
+		return repositoryMysql.New(repositoryMysql.Config{
+			Host:            host,
+			Port:            port,
+			Username:        username,
+			Password:        password,
+			Name:            dbName,
+			Prefix:          prefix,
+			TLS:             tls,
+			Timezone:        timezone,
+			Timeout:         time.Duration(timeout) * time.Second,
+			ReadTimeout:     time.Duration(readTimeout) * time.Second,
+			WriteTimeout:    time.Duration(writeTimeout) * time.Second,
+			MaxOpenConns:    maxOpenConns,
+			MaxIdleConns:    maxIdleConns,
+			ConnMaxLifetime: time.Duration(connMaxLifetime) * time.Second,
+			ConnMaxIdleTime: time.Duration(connMaxIdleTime) * time.Second,
+			Replicas:        replicas,
+		})
+	case constant.DATABASE_DRIVER_POSTGRES:
+		host, port, username, password, dbName, prefix, err := getCredentials(appConfigIns)
+		if err != nil {
+			return nil, err
+		}
+		return repositoryPostgres.New(host, port, username, password, dbName, appConfigIns.GetStoreDatabaseSslMode(), prefix)
+	case constant.DATABASE_DRIVER_MEMORY:
+		// Nothing is persisted; useful for trying the API without a database.
+		return repositoryMemory.New(), nil
+	case constant.DATABASE_DRIVER_SQLITE:
+		// The sqlite file lives on the local disk, so its path is not encrypted.
+		path, prefix := appConfigIns.GetStoreDatabaseSqliteProperties()
+		return repositorySqlite.New(path, prefix)
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", driver)
+	}
+}
+
+// getCredentials decrypts the database connection properties shared by the mysql and postgres drivers.
+// It returns the host, port, username, password, database name and table prefix.
+func getCredentials(appConfigIns config.App) (string, string, string, string, string, string, error) {
+	// Retrieve the crypto key used for decryption from the configuration.
+	cipherCryptoKey := appConfigIns.GetCipherCryptoKey()
+	// Initialize the AES cipher instance for decryption.
+	cipherIns := cipherAes.New(cipherCryptoKey)
+
+	// Retrieve the encrypted database connection properties.
+	encryptDbHost, encryptDbPort, encryptDbUsename, encryptDbPasword, dbName, prefix := appConfigIns.GetStoreDatabaseProperties()
+
+	// Decrypt each property using the cipher instance.
+	decryptDbHost, decryptErr := cipherIns.Decrypt(encryptDbHost)
+	if decryptErr != nil {
+		return "", "", "", "", "", "", decryptErr
+	}
+
+	decryptdbPort, decryptErr := cipherIns.Decrypt(encryptDbPort)
+	if decryptErr != nil {
+		return "", "", "", "", "", "", decryptErr
+	}
+
+	decryptDbUsename, decryptErr := cipherIns.Decrypt(encryptDbUsename)
+	if decryptErr != nil {
+		return "", "", "", "", "", "", decryptErr
+	}
+
+	decryptDbPasword, decryptErr := cipherIns.Decrypt(encryptDbPasword)
+	if decryptErr != nil {
+		return "", "", "", "", "", "", decryptErr
+	}
+
+	// Return the decrypted connection properties.
+	return decryptDbHost, decryptdbPort, decryptDbUsename, decryptDbPasword, dbName, prefix, nil
+}
+
+// getReplicas decrypts the host and port of every configured read replica.
+func getReplicas(appConfigIns config.App) ([]repositoryMysql.Replica, error) {
+	cipherIns := cipherAes.New(appConfigIns.GetCipherCryptoKey())
+
+	var replicas []repositoryMysql.Replica
+	for _, replica := range appConfigIns.GetStoreDatabaseReplicas() {
+		decryptHost, decryptErr := cipherIns.Decrypt(replica.Host)
+		if decryptErr != nil {
+			return nil, decryptErr
+		}
+
+		decryptPort, decryptErr := cipherIns.Decrypt(replica.Port)
+		if decryptErr != nil {
+			return nil, decryptErr
+		}
+
+		replicas = append(replicas, repositoryMysql.Replica{Host: decryptHost, Port: decryptPort})
+	}
+	return replicas, nil
+}
@@ -0,0 +1,796 @@
+package sqlite
+
+import (
+	"assetio/internal/adapters/repository/migration"
+	"assetio/internal/domain"
+	"assetio/internal/port"
+	"context"
+	"time"
+
+	gormSqlite "github.com/glebarez/sqlite"
+	"github.com/shopspring/decimal"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+type sqlite struct {
+	dialer *gorm.DB
+	prefix string
+}
+
+// New opens (or creates) the SQLite database file at path using GORM with custom configurations.
+// It takes the file path and a prefix for table naming conventions, returning a RepositoryStore
+// interface for accessing database methods or an error if the file cannot be opened.
+// SQLite allows a single writer, so the pool is limited to one connection; this also keeps
+// ":memory:" databases shared across every query.
+// SQLite has no exact decimal storage: decimal columns hold REAL values, which keep fifteen significant
+// digits. That covers a single user's portfolio; use mysql or postgres when more digits are needed.
+func New(path string, prefix string) (port.RepositoryStore, error) {
+	dsn := path + "?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)"
+	dialer, err := gorm.Open(gormSqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Error),
+		NamingStrategy: schema.NamingStrategy{
+			TablePrefix: prefix,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := dialer.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	return &sqlite{
+		dialer: dialer,
+		prefix: prefix,
+	}, nil
+
+}
+
+// MigrateUp applies every pending schema migration and returns the migrations it applied.
+func (m *sqlite) MigrateUp(ctx context.Context) ([]domain.SchemaMigration, error) {
+	return migration.New(m.dialer, m.prefix).Up(ctx)
+}
+
+// MigrateDown reverts the most recently applied schema migration.
+func (m *sqlite) MigrateDown(ctx context.Context) (domain.SchemaMigration, error) {
+	return migration.New(m.dialer, m.prefix).Down(ctx)
+}
+
+// MigrationStatus lists every known schema migration and whether it has been applied.
+func (m *sqlite) MigrationStatus(ctx context.Context) ([]domain.SchemaMigration, error) {
+	return migration.New(m.dialer, m.prefix).Status(ctx)
+}
+
+// SchemaVersion returns the applied schema version along with the version this binary expects.
+func (m *sqlite) SchemaVersion(ctx context.Context) (int, int, error) {
+	current, err := migration.New(m.dialer, m.prefix).Version(ctx)
+	return current, migration.Latest(), err
+}
+
+// WithTx runs fn inside a database transaction. The repository passed to fn shares the
+// transaction, so every write it performs is committed or rolled back together.
+// Returning an error from fn (or panicking) rolls the transaction back.
+func (m *sqlite) WithTx(ctx context.Context, fn func(repo port.RepositoryStore) error) error {
+	return m.dialer.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&sqlite{
+			dialer: tx,
+			prefix: m.prefix,
+		})
+	})
+}
+
+// InsertAccountData adds a new account entry to the Accounts table.
+// Returns the created account data along with any error encountered during insertion.
+func (m *sqlite) InsertAccountData(ctx context.Context, accountData domain.Accounts) (domain.Accounts, error) {
+	// Create a new record in the Accounts table with the provided account data
+	result := m.dialer.WithContext(ctx).Model(&domain.Accounts{}).Create(&accountData)
+	return accountData, result.Error
+}
+
+// GetAccountDataByIdAndUserId fetches account details based on account ID and user ID.
+// Only selects specific fields: id, name, status, and lot method. Returns the account data if found, or nil if no matching record exists.
+func (m *sqlite) GetAccountDataByIdAndUserId(ctx context.Context, accountId, userId int) (domain.Accounts, error) {
+	var accountData domain.Accounts
+
+	// Query the Accounts table for a record that matches the specified account ID and user ID
+	result := m.dialer.WithContext(ctx).Model(&domain.Accounts{}).
+		Select("id", "name", "status", "lot_method").
+		Where("id = ? and user_id = ?", accountId, userId).
+		First(&accountData)
+
+	// Set result.Error to nil if no record is found to avoid a "record not found" error
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+	return accountData, result.Error
+}
+
+// GetAccountsData retrieves all accounts associated with the specified user ID.
+// Returns a slice of account records or an empty slice if none are found.
+func (m *sqlite) GetAccountsData(ctx context.Context, userId int) ([]domain.Accounts, error) {
+	var accountsData []domain.Accounts
+
+	// Query the Accounts table for records that match the specified user ID
+	result := m.dialer.WithContext(ctx).Model(&domain.Accounts{}).
+		Select("id", "name", "status", "lot_method").
+		Where("user_id = ?", userId).
+		Find(&accountsData)
+
+	// Set result.Error to nil if no records are found, avoiding a "record not found" error
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+	return accountsData, result.Error
+}
+
+// UpdateAccountData updates account information for a specific account and user ID.
+// Only updates the account record if both account ID and user ID match.
+func (m *sqlite) UpdateAccountData(ctx context.Context, accountId, userId int, accountData domain.Accounts) error {
+	// Update the account record where the ID and user ID match the specified values
+	result := m.dialer.WithContext(ctx).Model(&domain.Accounts{}).
+		Where("id = ? and user_id = ?", accountId, userId).
+		Updates(&accountData)
+	return result.Error
+}
+
+// InsertSecurityData adds a new security entry to the Securities table.
+// Returns the created security data along with any error encountered during insertion.
+func (m *sqlite) InsertSecurityData(ctx context.Context, securityData domain.Securities) (domain.Securities, error) {
+	// Create a new record in the Securities table with the provided security data
+	result := m.dialer.WithContext(ctx).Model(&domain.Securities{}).Create(&securityData)
+	return securityData, result.Error
+}
+
+// GetSecurityDataById retrieves security information based on the security ID.
+// It selects specific fields: id, type, exchange, symbol, and name.
+// Returns the security data if found, or nil if no matching record exists.
+func (m *sqlite) GetSecurityDataById(ctx context.Context, securityId int) (domain.Securities, error) {
+	var securityData domain.Securities
+
+	// Query the Securities table for a record matching the specified security ID
+	result := m.dialer.WithContext(ctx).
+		Model(&domain.Securities{}).
+		Select("id", "type", "exchange", "symbol", "name", "asset_class").
+		Where("id = ?", securityId).
+		First(&securityData)
+
+	// Set result.Error to nil if no record is found to avoid a "record not found" error
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+	return securityData, result.Error
+}
+
+// GetSecurityDataByTypeAndExchangeAndSymbol fetches security information based on the given type, exchange, and symbol.
+// Returns the security data if found, or nil if no matching record exists.
+func (m *sqlite) GetSecurityDataByTypeAndExchangeAndSymbol(ctx context.Context, types, exchange int, symbol string) (domain.Securities, error) {
+	var securityData domain.Securities
+
+	// Query the Securities table to find the security that matches the provided type, exchange, and symbol
+	result := m.dialer.WithContext(ctx).Model(&domain.Securities{}).
+		Select("id").
+		Where("type = ? and exchange = ? and symbol = ?", types, exchange, symbol).
+		First(&securityData)
+
+	// If no record is found, set result.Error to nil to avoid returning a "record not found" error
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+	return securityData, result.Error
+}
+
+// UpdateSecurityData modifies security information for a specified security ID with the provided security data.
+// Returns any error encountered during the update.
+func (m *sqlite) UpdateSecurityData(ctx context.Context, securityId int, securityData domain.Securities) error {
+	// Update the security record where the ID matches the specified securityId
+	result := m.dialer.WithContext(ctx).Model(&domain.Securities{}).
+		Where("id = ?", securityId).
+		Updates(&securityData)
+	return result.Error
+}
+
+// GetSecuritiesDataByType retrieves all securities data that match the given type and exchange.
+// Returns a slice of Securities if records are found, or an empty slice if no records match.
+func (m *sqlite) GetSecuritiesDataByType(ctx context.Context, types int) ([]domain.Securities, error) {
+	var securitiesData []domain.Securities
+
+	// Query the Securities table for records that match the given type and exchange
+	result := m.dialer.WithContext(ctx).Model(&domain.Securities{}).
+		Select("id", "type", "exchange", "symbol", "name", "asset_class").
+		Where("type = ? ", types).
+		Find(&securitiesData)
+
+	// Set result.Error to nil if no record is found, preventing "record not found" error
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+	return securitiesData, result.Error
+}
+
+// GetSecuritiesData retrieves every security of every type and exchange, ordered by id.
+func (m *sqlite) GetSecuritiesData(ctx context.Context) ([]domain.Securities, error) {
+	var securitiesData []domain.Securities
+
+	// Query the whole Securities table
+	result := m.dialer.WithContext(ctx).Model(&domain.Securities{}).
+		Select("id", "type", "exchange", "symbol", "name", "asset_class").
+		Order("id").
+		Find(&securitiesData)
+
+	// Set result.Error to nil if no record is found, preventing "record not found" error
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+	return securitiesData, result.Error
+}
+
+// SearchSecuritiesDataByTypeAndExchange performs a search for securities by type, exchange, name, or symbol.
+// The search term is matched partially with both the name and symbol fields using SQL LIKE.
+// Returns a slice of matching Securities records or an empty slice if none found.
+func (m *sqlite) SearchSecuritiesDataByTypeAndExchange(ctx context.Context, types, exchange int, search string) ([]domain.Securities, error) {
+	var securitiesData []domain.Securities
+
+	// Query the Securities table to find records that match the type, exchange, and partially match the search term in name or symbol
+	result := m.dialer.WithContext(ctx).Model(&domain.Securities{}).
+		Select("id", "type", "exchange", "symbol", "name", "asset_class").
+		Where("type = ? and exchange = ? and (name LIKE ? or symbol LIKE ?)", types, exchange, "%"+search+"%", "%"+search+"%").
+		Find(&securitiesData)
+
+	// Set result.Error to nil if no record is found
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+	return securitiesData, result.Error
+}
+
+// InsertInventoryLedger adds a new entry to the InventoryLedger table with the provided inventory ledger data.
+// Returns the inserted inventory ledger data along with any error encountered.
+func (m *sqlite) InsertInventoryLedger(ctx context.Context, inventoryLedgerData domain.InventoryLedger) (domain.InventoryLedger, error) {
+	// Insert the new ledger entry into the InventoryLedger table
+	result := m.dialer.WithContext(ctx).Model(&domain.InventoryLedger{}).Create(&inventoryLedgerData)
+	return inventoryLedgerData, result.Error
+}
+
+// UpdateInventoryDetailsById updates an inventory record by its ID with the provided inventory data.
+// The update only applies while the row is still at the given version and bumps the version, so a row
+// changed by a concurrent request since it was read is never overwritten; domain.ErrInventoryConflict is
+// returned instead.
+func (m *sqlite) UpdateInventoryDetailsById(ctx context.Context, inventoryId, version int, availableQuantity, averagePrice, totalValue decimal.Decimal) error {
+	// Update the inventory record where the ID and version match
+	result := m.dialer.WithContext(ctx).Model(&domain.Inventories{}).Where("id = ? AND version = ?", inventoryId, version).Updates(map[string]interface{}{
+		"available_quantity": availableQuantity,
+		"average_price":      averagePrice,
+		"total_value":        totalValue,
+		"version":            gorm.Expr("version + 1"),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrInventoryConflict
+	}
+	return nil
+}
+
+// InsertTransaction adds a new transaction entry to the Transactions table using the provided transaction data.
+// Returns the inserted transaction data along with any error encountered.
+func (m *sqlite) InsertTransaction(ctx context.Context, transactionData domain.Transactions) (domain.Transactions, error) {
+	// Insert the new transaction entry into the Transactions table
+	result := m.dialer.WithContext(ctx).Model(&domain.Transactions{}).Create(&transactionData)
+	return transactionData, result.Error
+}
+
+// UpdateInventoryLedgerTransactionIdById updates the transaction ID in an inventory ledger record by its ledger ID.
+// Returns any error encountered during the update.
+func (m *sqlite) UpdateInventoryLedgerTransactionIdById(ctx context.Context, ledgerId, transactionId int) error {
+	// Update the transaction_id field in the InventoryLedger record with the specified ledgerId
+	result := m.dialer.WithContext(ctx).Model(&domain.InventoryLedger{}).Where("id = ? ", ledgerId).Updates(map[string]interface{}{
+		"transaction_id": transactionId,
+	})
+	return result.Error
+}
+
+// UpdateInventoryLedgerTransactionIdByIds updates the transaction ID for multiple inventory ledger records based on a list of ledger IDs.
+// It sets the transaction_id field for all records where the id is in the provided ledgerIds slice.
+func (m *sqlite) UpdateInventoryLedgerTransactionIdByIds(ctx context.Context, ledgerIds []int, transactionId int) error {
+	// Update the transaction_id field for records with IDs in the ledgerIds slice
+	result := m.dialer.WithContext(ctx).Model(&domain.InventoryLedger{}).
+		Where("id IN ?", ledgerIds).
+		Updates(map[string]interface{}{
+			"transaction_id": transactionId,
+		})
+	return result.Error
+}
+
+// UpdateAvailableQuantityToInventoryById sets the available quantity in an inventory record by its inventory ID.
+// Returns any error encountered during the update.
+func (m *sqlite) UpdateAvailableQuantityToInventoryById(ctx context.Context, inventoryId int, quantity decimal.Decimal) error {
+	// Update the available_quantity field in the Inventories record with the specified inventoryId
+	result := m.dialer.WithContext(ctx).Model(&domain.Inventories{}).Where("id = ?", inventoryId).Updates(map[string]interface{}{
+		"available_quantity": quantity,
+	})
+	return result.Error
+}
+
+// GetInvertriesSummaryByAccountIdAndSecurityType retrieves a summary of inventories for a specific account ID and security type.
+// The summary includes security details (name, exchange, symbol) and aggregate values for available quantity and total value.
+func (m *sqlite) GetInvertriesSummaryByAccountIdAndSecurityType(ctx context.Context, accountId, securityType int) ([]domain.InventorySummary, error) {
+	var inventoryData []domain.InventorySummary
+
+	// Query to join inventories with securities to get a summary, filtered by account ID and security type
+	result := m.dialer.WithContext(ctx).
+		Model(&domain.Inventories{}).
+		Select(m.prefix+"inventories.id", m.prefix+"inventories.account_id", m.prefix+"inventories.security_id", m.prefix+"securities.name as security_name", m.prefix+"securities.exchange as security_exchange", m.prefix+"securities.symbol as security_symbol", "SUM("+m.prefix+"inventories.available_quantity) as available_quantity", "SUM("+m.prefix+"inventories.total_value) as total_value").
+		Joins("JOIN "+m.prefix+"securities ON (security_id = "+m.prefix+"securities.id and type = ? )", securityType).
+		Where("account_id = ? and available_quantity > 0 ", accountId).
+		Group("security_id"). // Group by security_id to get summary data per security
+		Find(&inventoryData)
+
+	// If no record found, set error to nil for empty results
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+
+	return inventoryData, result.Error
+}
+
+// GetInvertriesByAccountIdAndSecurityId retrieves detailed inventory records for a specific account and security.
+// The details include ID, available quantity, and total value, ordered by the creation date in descending order.
+func (m *sqlite) GetInvertriesByAccountIdAndSecurityId(ctx context.Context, accountId, securityId int) ([]domain.InventoryDetails, error) {
+	var inventoryData []domain.InventoryDetails
+
+	// Query to get inventory details based on account and security IDs, ordered by the latest creation date
+	result := m.dialer.WithContext(ctx).
+		Model(&domain.Inventories{}).
+		Select("id", "available_quantity", "total_value", "date").
+		Where("account_id = ? and security_id = ? and available_quantity > 0 ", accountId, securityId).
+		Order("date desc"). // Retrieve the latest data first
+		Find(&inventoryData)
+
+	// If no record found, set error to nil for empty results
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+
+	return inventoryData, result.Error
+}
+
+// InsertTransactionData adds a new transaction entry to the Transactions table.
+// Returns the created transaction data along with any error encountered during insertion.
+func (m *sqlite) InsertTransactionData(ctx context.Context, transactionData domain.Transactions) (domain.Transactions, error) {
+	// Create a new record in the Transactions table with the provided transaction data
+	result := m.dialer.WithContext(ctx).Model(&domain.Transactions{}).Create(&transactionData)
+	return transactionData, result.Error
+}
+
+// InsertInventoryData adds a new inventory entry to the Inventories table.
+// Returns the created inventory data along with any error encountered during insertion.
+func (m *sqlite) InsertInventoryData(ctx context.Context, inventoryData domain.Inventories) (domain.Inventories, error) {
+	// Create a new record in the Inventories table with the provided inventory data
+	result := m.dialer.WithContext(ctx).Model(&domain.Inventories{}).Create(&inventoryData)
+	return inventoryData, result.Error
+}
+
+// GetInventoryDataById retrieves an inventory record by its ID.
+// It fetches specific fields: id, account_id, security_id, available_quantity, and total_value.
+// Returns the inventory data if found, or nil if no matching record exists.
+func (m *sqlite) GetInventoryDataById(ctx context.Context, inventoryId int) (domain.Inventories, error) {
+	var inventoryData domain.Inventories
+
+	// Query the Inventories table for a record matching the specified inventory ID
+	result := m.dialer.WithContext(ctx).Model(&domain.Inventories{}).
+		Select("*").
+		Where("id = ?", inventoryId).
+		Find(&inventoryData)
+
+	// Set result.Error to nil if no record is found to avoid a "record not found" error
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+	return inventoryData, result.Error
+}
+
+// UpdateAvailableQuantityToInventoryById updates the available quantity for a specific inventory record by its ID.
+// Takes the inventory ID and the new quantity as parameters.
+// Returns an error if the update operation fails.
+func (m *sqlite) UpdateAvailableQuanityToInventoryById(ctx context.Context, inventoryId int, quantity decimal.Decimal) error {
+	// Update the available_quantity field for the record with the specified inventory ID
+	result := m.dialer.WithContext(ctx).
+		Model(&domain.Inventories{}).
+		Where("id = ?", inventoryId).
+		Updates(map[string]interface{}{
+			"available_quantity": quantity,
+			"version":            gorm.Expr("version + 1"),
+		})
+
+	return result.Error
+}
+
+// GetActiveInventoriesByAccountIdAndSecurityId retrieves active inventory records with available quantities greater than zero
+// for a specific account and security. Returns a list of inventory records with ID and available quantity fields.
+func (m *sqlite) GetActiveInventoriesByAccountIdAndSecurityId(ctx context.Context, accountId, securityId int) ([]domain.Inventories, error) {
+	var InventoriesData []domain.Inventories
+
+	// Query to find active inventories based on account and security IDs with positive available quantity
+	result := m.dialer.WithContext(ctx).Model(&domain.Inventories{}).Select("id", "available_quantity", "total_value", "average_price", "date", "version").
+		Where("account_id = ? and security_id = ? and available_quantity > 0", accountId, securityId).
+		Order("id"). // Fetch old data first by ordering by ID
+		Find(&InventoriesData)
+
+	// If no record found, set error to nil to avoid returning an error for empty results
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+
+	return InventoriesData, result.Error
+}
+
+// GetInventoriesByAccountIdOrSecurityId retrieves every inventory, including closed ones, of an account, a security,
+// or both; a zero id matches any value. Records are ordered by ID.
+func (m *sqlite) GetInventoriesByAccountIdOrSecurityId(ctx context.Context, accountId, securityId int) ([]domain.Inventories, error) {
+	var inventoriesData []domain.Inventories
+
+	query := m.dialer.WithContext(ctx).Model(&domain.Inventories{})
+	if accountId != 0 {
+		query = query.Where("account_id = ?", accountId)
+	}
+	if securityId != 0 {
+		query = query.Where("security_id = ?", securityId)
+	}
+	result := query.Order("id").Find(&inventoriesData)
+
+	// If no record found, set error to nil to avoid returning an error for empty results
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+
+	return inventoriesData, result.Error
+}
+
+// GetInventoryLedgersByInventoryIdAndAccountId retrieves ledger entries associated with a specific inventory ID, ordered by date.
+// Each ledger entry includes ID, type, quantity, average price, total value, and date fields.
+func (m *sqlite) GetInventoryLedgersByInventoryId(ctx context.Context, inventoryId int) ([]domain.InventoryLedgers, error) {
+	var inventoryLedgerData []domain.InventoryLedgers
+
+	// Query to find inventory ledger data based on inventory ID, ordered by date in descending order
+	result := m.dialer.WithContext(ctx).
+		Model(&domain.InventoryLedger{}).
+		Select("id", "transaction_id", "type", "quantity", "average_price", "total_value", "fee", "date").
+		Where("inventory_id = ?", inventoryId).
+		Order("date asc, id desc"). // Fetch the latest data first
+		Find(&inventoryLedgerData)
+
+	// If no record found, set error to nil for empty results
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+
+	return inventoryLedgerData, result.Error
+}
+
+func (m *sqlite) GetDividendTransactionsByAccountIdAndSecurityId(ctx context.Context, accountId, securityId int) ([]domain.DividendTransaction, error) {
+	var transactionsData []domain.DividendTransaction
+
+	// Query to find inventory ledger data based on inventory ID, ordered by date in descending order
+	result := m.dialer.WithContext(ctx).
+		Model(&domain.Transactions{}).
+		Select("quantity", "average_price", "total_value", "date").
+		Where("account_id =? and security_id = ? and type =?", accountId, securityId, domain.DIVIDEND).
+		Order("date desc"). // Fetch the latest data first
+		Find(&transactionsData)
+
+		// If no record found, set error to nil for empty results
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+	return transactionsData, result.Error
+
+}
+
+func (m *sqlite) GetInventoryAvailableQuanitityBySecurityIdAndDate(ctx context.Context, accountId, securityId int, date time.Time) (decimal.Decimal, error) {
+	var totalQuantity decimal.Decimal
+
+	// Query to calculate the total quantity
+
+	result := m.dialer.WithContext(ctx).
+		Model(&domain.Transactions{}).
+		Select(`COALESCE(SUM(
+        CASE 
+            WHEN type IN ? AND date < ? THEN quantity   
+            WHEN type IN ? AND date <= ? THEN -quantity  
+            ELSE 0                            
+        END
+    ), 0) as total_quantity`, []domain.TransactionType{domain.BUY, domain.RIGHTS_SUBSCRIBE}, date, []domain.TransactionType{domain.SELL, domain.BUYBACK}, date).
+		Where("account_id = ? AND security_id = ? AND state <> ?", accountId, securityId, domain.TRANSACTION_STATE_VOIDED).
+		Scan(&totalQuantity)
+
+		// If no record found, set error to nil for empty results
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+
+	return totalQuantity, result.Error
+
+}
+
+// GetUnlinkedInventoryLedgers retrieves the ledger entries of an account whose transaction ID is unset or refers to
+// no transaction; an account ID of zero scans every account. Records are ordered by ID.
+func (m *sqlite) GetUnlinkedInventoryLedgers(ctx context.Context, accountId int) ([]domain.InventoryLedger, error) {
+	var inventoryLedgerData []domain.InventoryLedger
+
+	ledgers := m.prefix + "inventory_ledgers"
+	inventories := m.prefix + "inventories"
+	transactions := m.prefix + "transactions"
+
+	// Left join the transactions so a missing one shows up as a NULL id
+	query := m.dialer.WithContext(ctx).
+		Model(&domain.InventoryLedger{}).
+		Select(ledgers + ".*").
+		Joins("JOIN " + inventories + " ON " + inventories + ".id = " + ledgers + ".inventory_id").
+		Joins("LEFT JOIN " + transactions + " ON " + transactions + ".id = " + ledgers + ".transaction_id").
+		Where(transactions + ".id IS NULL")
+	if accountId != 0 {
+		query = query.Where(inventories+".account_id = ?", accountId)
+	}
+	result := query.Order(ledgers + ".id").Find(&inventoryLedgerData)
+
+	// If no record found, set error to nil for empty results
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+
+	return inventoryLedgerData, result.Error
+}
+
+// GetTransactionLedgerTotals retrieves every transaction of an account that moves inventory, together with the
+// summed quantity and count of the ledger entries linked to it; an account ID of zero scans every account.
+// Dividends never touch inventory and are left out. Records are ordered by ID.
+func (m *sqlite) GetTransactionLedgerTotals(ctx context.Context, accountId int) ([]domain.TransactionLedgerTotal, error) {
+	var totalsData []domain.TransactionLedgerTotal
+
+	ledgers := m.prefix + "inventory_ledgers"
+	transactions := m.prefix + "transactions"
+
+	query := m.dialer.WithContext(ctx).
+		Model(&domain.Transactions{}).
+		Select(transactions+".id", transactions+".account_id", transactions+".security_id", transactions+".type", transactions+".quantity",
+			"COALESCE(SUM("+ledgers+".quantity), 0) as ledger_quantity", "COUNT("+ledgers+".id) as ledger_count").
+		Joins("LEFT JOIN "+ledgers+" ON "+ledgers+".transaction_id = "+transactions+".id AND "+ledgers+".type <> ?", domain.VOID).
+		Where(transactions+".type <> ?", domain.DIVIDEND)
+	if accountId != 0 {
+		query = query.Where(transactions+".account_id = ?", accountId)
+	}
+	result := query.
+		Group(transactions + ".id, " + transactions + ".account_id, " + transactions + ".security_id, " + transactions + ".type, " + transactions + ".quantity").
+		Order(transactions + ".id").
+		Find(&totalsData)
+
+	// If no record found, set error to nil for empty results
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+
+	return totalsData, result.Error
+}
+
+// GetTransactionDataById retrieves a transaction record by its ID.
+// Returns the transaction data if found, or an empty transaction if no matching record exists.
+func (m *sqlite) GetTransactionDataById(ctx context.Context, transactionId int) (domain.Transactions, error) {
+	var transactionData domain.Transactions
+
+	// Query the Transactions table for a record matching the specified transaction ID
+	result := m.dialer.WithContext(ctx).Model(&domain.Transactions{}).
+		Select("*").
+		Where("id = ?", transactionId).
+		Find(&transactionData)
+
+	// Set result.Error to nil if no record is found to avoid a "record not found" error
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+	return transactionData, result.Error
+}
+
+// GetInventoryLedgersByTransactionId retrieves every ledger entry linked to a transaction, ordered by ID.
+func (m *sqlite) GetInventoryLedgersByTransactionId(ctx context.Context, transactionId int) ([]domain.InventoryLedger, error) {
+	var inventoryLedgerData []domain.InventoryLedger
+
+	result := m.dialer.WithContext(ctx).Model(&domain.InventoryLedger{}).
+		Select("*").
+		Where("transaction_id = ?", transactionId).
+		Order("id").
+		Find(&inventoryLedgerData)
+
+	// If no record found, set error to nil for empty results
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+	return inventoryLedgerData, result.Error
+}
+
+// UpdateTransactionStateById sets the state of a transaction record by its ID.
+func (m *sqlite) UpdateTransactionStateById(ctx context.Context, transactionId, state int) error {
+	result := m.dialer.WithContext(ctx).Model(&domain.Transactions{}).Where("id = ?", transactionId).Updates(map[string]interface{}{
+		"state": state,
+	})
+	return result.Error
+}
+
+// GetTransactionsByAccountIdAndSecurityId retrieves every transaction of an account and security, voided ones included,
+// ordered by date and then by ID.
+func (m *sqlite) GetTransactionsByAccountIdAndSecurityId(ctx context.Context, accountId, securityId int) ([]domain.Transactions, error) {
+	var transactionsData []domain.Transactions
+
+	result := m.dialer.WithContext(ctx).Model(&domain.Transactions{}).
+		Select("*").
+		Where("account_id = ? AND security_id = ?", accountId, securityId).
+		Order("date, id").
+		Find(&transactionsData)
+
+	// If no record found, set error to nil for empty results
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+	return transactionsData, result.Error
+}
+
+// GetTransactionsByAccountId retrieves every transaction of an account, voided ones included, ordered by date and then
+// by ID.
+func (m *sqlite) GetTransactionsByAccountId(ctx context.Context, accountId int) ([]domain.Transactions, error) {
+	var transactionsData []domain.Transactions
+
+	result := m.dialer.WithContext(ctx).Model(&domain.Transactions{}).
+		Select("*").
+		Where("account_id = ?", accountId).
+		Order("date, id").
+		Find(&transactionsData)
+
+	// If no record found, set error to nil for empty results
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+	return transactionsData, result.Error
+}
+
+// UpdateTransactionById sets the quantity, average price, total value, fee and date of a transaction record by its ID.
+func (m *sqlite) UpdateTransactionById(ctx context.Context, transactionId int, transactionData domain.Transactions) error {
+	result := m.dialer.WithContext(ctx).Model(&domain.Transactions{}).Where("id = ?", transactionId).Updates(map[string]interface{}{
+		"quantity":      transactionData.Quantity,
+		"average_price": transactionData.AveragePrice,
+		"total_value":   transactionData.TotalValue,
+		"fee":           transactionData.Fee,
+		"date":          transactionData.Date,
+	})
+	return result.Error
+}
+
+// UpdateInventoryLedgerById sets the quantity, average price, total value, fee and date of a ledger entry by its ID.
+func (m *sqlite) UpdateInventoryLedgerById(ctx context.Context, ledgerId int, ledgerData domain.InventoryLedger) error {
+	result := m.dialer.WithContext(ctx).Model(&domain.InventoryLedger{}).Where("id = ?", ledgerId).Updates(map[string]interface{}{
+		"quantity":      ledgerData.Quantity,
+		"average_price": ledgerData.AveragePrice,
+		"total_value":   ledgerData.TotalValue,
+		"fee":           ledgerData.Fee,
+		"date":          ledgerData.Date,
+	})
+	return result.Error
+}
+
+// DeleteInventoryLedgerById removes a ledger entry by its ID.
+func (m *sqlite) DeleteInventoryLedgerById(ctx context.Context, ledgerId int) error {
+	result := m.dialer.WithContext(ctx).Where("id = ?", ledgerId).Delete(&domain.InventoryLedger{})
+	return result.Error
+}
+
+// UpdateInventoryDateById sets the date of an inventory record by its ID.
+func (m *sqlite) UpdateInventoryDateById(ctx context.Context, inventoryId int, date time.Time) error {
+	result := m.dialer.WithContext(ctx).Model(&domain.Inventories{}).Where("id = ?", inventoryId).Updates(map[string]interface{}{
+		"date": date,
+	})
+	return result.Error
+}
+
+// InsertRealizedGain adds a realized gain record and returns it with its generated ID.
+func (m *sqlite) InsertRealizedGain(ctx context.Context, realizedGainData domain.RealizedGains) (domain.RealizedGains, error) {
+	result := m.dialer.WithContext(ctx).Model(&domain.RealizedGains{}).Create(&realizedGainData)
+	return realizedGainData, result.Error
+}
+
+// DeleteRealizedGainsByInventoryId removes every realized gain record of an inventory.
+func (m *sqlite) DeleteRealizedGainsByInventoryId(ctx context.Context, inventoryId int) error {
+	result := m.dialer.WithContext(ctx).Where("inventory_id = ?", inventoryId).Delete(&domain.RealizedGains{})
+	return result.Error
+}
+
+// GetRealizedGains retrieves the realized gain records of an account sold between from and to, both inclusive.
+// A zero security ID matches every security and a zero time leaves that end of the range open. Records are
+// ordered by date and then by ID.
+func (m *sqlite) GetRealizedGains(ctx context.Context, accountId, securityId int, from, to time.Time) ([]domain.RealizedGains, error) {
+	var realizedGainsData []domain.RealizedGains
+
+	query := m.dialer.WithContext(ctx).Model(&domain.RealizedGains{}).Where("account_id = ?", accountId)
+	if securityId != 0 {
+		query = query.Where("security_id = ?", securityId)
+	}
+	if !from.IsZero() {
+		query = query.Where("date >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("date <= ?", to)
+	}
+	result := query.Order("date, id").Find(&realizedGainsData)
+
+	// If no record found, set error to nil for empty results
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+	return realizedGainsData, result.Error
+}
+
+// InsertTransactionCharges adds the itemised charges of a transaction and returns them with their generated ID.
+func (m *sqlite) InsertTransactionCharges(ctx context.Context, chargesData domain.TransactionCharges) (domain.TransactionCharges, error) {
+	result := m.dialer.WithContext(ctx).Model(&domain.TransactionCharges{}).Create(&chargesData)
+	return chargesData, result.Error
+}
+
+// GetTransactionChargesByTransactionIds retrieves the itemised charges of the given transactions, ordered by
+// transaction ID. Transactions recorded with only a total fee have none.
+func (m *sqlite) GetTransactionChargesByTransactionIds(ctx context.Context, transactionIds []int) ([]domain.TransactionCharges, error) {
+	var chargesData []domain.TransactionCharges
+	if len(transactionIds) == 0 {
+		return chargesData, nil
+	}
+
+	result := m.dialer.WithContext(ctx).Model(&domain.TransactionCharges{}).Where("transaction_id IN ?", transactionIds).Order("transaction_id").Find(&chargesData)
+
+	// If no record found, set error to nil for empty results
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+	return chargesData, result.Error
+}
+
+// DeleteTransactionChargesByTransactionId removes the itemised charges of a transaction.
+func (m *sqlite) DeleteTransactionChargesByTransactionId(ctx context.Context, transactionId int) error {
+	result := m.dialer.WithContext(ctx).Where("transaction_id = ?", transactionId).Delete(&domain.TransactionCharges{})
+	return result.Error
+}
+
+// InsertRightsIssue adds the rights entitlements of an account from a rights issue and returns them with their
+// generated ID.
+func (m *sqlite) InsertRightsIssue(ctx context.Context, rightsIssueData domain.RightsIssues) (domain.RightsIssues, error) {
+	result := m.dialer.WithContext(ctx).Model(&domain.RightsIssues{}).Create(&rightsIssueData)
+	return rightsIssueData, result.Error
+}
+
+// GetRightsIssueByIdAndAccountId retrieves a rights issue of an account by its ID.
+func (m *sqlite) GetRightsIssueByIdAndAccountId(ctx context.Context, rightsIssueId, accountId int) (domain.RightsIssues, error) {
+	var rightsIssueData domain.RightsIssues
+	result := m.dialer.WithContext(ctx).Model(&domain.RightsIssues{}).
+		Where("id = ? AND account_id = ?", rightsIssueId, accountId).
+		Find(&rightsIssueData)
+
+	// Set result.Error to nil if no record is found to avoid a "record not found" error
+	if result.Error == gorm.ErrRecordNotFound {
+		result.Error = nil
+	}
+	return rightsIssueData, result.Error
+}
+
+// UpdateRightsIssueById sets the renounced and subscribed entitlements, the lot and the paid-up price of a rights
+// issue by its ID.
+func (m *sqlite) UpdateRightsIssueById(ctx context.Context, rightsIssueId int, rightsIssueData domain.RightsIssues) error {
+	result := m.dialer.WithContext(ctx).Model(&domain.RightsIssues{}).Where("id = ?", rightsIssueId).Updates(map[string]interface{}{
+		"inventory_id": rightsIssueData.InventoryId,
+		"paid_price":   rightsIssueData.PaidPrice,
+		"renounced":    rightsIssueData.Renounced,
+		"subscribed":   rightsIssueData.Subscribed,
+	})
+	return result.Error
+}
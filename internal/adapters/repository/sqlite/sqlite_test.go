@@ -0,0 +1,22 @@
+package sqlite
+
+import (
+	"assetio/internal/adapters/repository/contract"
+	"assetio/internal/port"
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestContract(t *testing.T) {
+	contract.Run(t, func(t *testing.T) port.RepositoryStore {
+		repo, err := New(filepath.Join(t.TempDir(), "assetio.db"), "pm_")
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		if _, err := repo.MigrateUp(context.Background()); err != nil {
+			t.Fatalf("MigrateUp: %v", err)
+		}
+		return repo
+	})
+}
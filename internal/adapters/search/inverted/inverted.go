@@ -0,0 +1,318 @@
+// Package inverted ranks security searches from an in-process inverted index over symbols and names.
+// The index is loaded from the repository, so it works the same over every database driver.
+package inverted
+
+import (
+	"assetio/internal/domain"
+	"assetio/internal/port"
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// DEFAULT_LIMIT is the page size used when a query does not set one.
+const DEFAULT_LIMIT = 20
+
+// Rank of each match kind; lower ranks sort first.
+var matchRank = map[domain.SecuritySearchMatch]int{
+	domain.SEARCH_MATCH_EXACT_SYMBOL:  0,
+	domain.SEARCH_MATCH_SYMBOL_PREFIX: 1,
+	domain.SEARCH_MATCH_NAME_PREFIX:   2,
+	domain.SEARCH_MATCH_TOKEN:         3,
+	domain.SEARCH_MATCH_FUZZY:         4,
+}
+
+type entry struct {
+	security domain.Securities
+	symbol   string // Lower cased symbol
+	name     string // Lower cased name
+}
+
+type inverted struct {
+	repo    port.RepositoryStore
+	refresh time.Duration
+	now     func() time.Time
+
+	mu       sync.RWMutex
+	loaded   bool
+	loadedAt time.Time
+	entries  []entry
+	terms    []string         // Distinct words of every symbol and name, sorted for prefix lookups
+	postings map[string][]int // Entries containing each term
+}
+
+// New creates a searcher that indexes every security in repo on first use. The index is reloaded once it
+// is older than refresh, so securities written by other instances show up; a refresh of zero or less keeps
+// it until Invalidate is called. The searcher is safe for concurrent use.
+func New(repo port.RepositoryStore, refresh time.Duration) port.SecuritySearcher {
+	return &inverted{
+		repo:    repo,
+		refresh: refresh,
+		now:     time.Now,
+	}
+}
+
+// Invalidate drops the index so the next search reloads it.
+func (i *inverted) Invalidate() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.loaded = false
+}
+
+// Search returns the page of securities matching query, best match first. Ties are broken by the shorter
+// symbol, then symbol, exchange and id, so pages are stable.
+func (i *inverted) Search(ctx context.Context, query domain.SecuritySearchQuery) ([]domain.SecuritySearchHit, error) {
+	if err := i.load(ctx); err != nil {
+		return nil, err
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	search := strings.ToLower(strings.TrimSpace(query.Search))
+	words := tokenize(search)
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	// Every search word has to match a word of the security; keep the edit distance each needed.
+	var distances map[int]int
+	for _, word := range words {
+		matched := i.lookup(word)
+		if distances == nil {
+			distances = matched
+			continue
+		}
+		for position, distance := range distances {
+			if wordDistance, ok := matched[position]; ok {
+				distances[position] = distance + wordDistance
+			} else {
+				delete(distances, position)
+			}
+		}
+	}
+
+	type ranked struct {
+		hit      domain.SecuritySearchHit
+		distance int
+	}
+	var results []ranked
+	for position, distance := range distances {
+		item := i.entries[position]
+		if query.Type != 0 && item.security.Type != query.Type {
+			continue
+		}
+		if query.Exchange != 0 && item.security.Exchange != query.Exchange {
+			continue
+		}
+		results = append(results, ranked{
+			hit:      domain.SecuritySearchHit{Security: item.security, Match: classify(item, search, distance)},
+			distance: distance,
+		})
+	}
+
+	sort.Slice(results, func(a, b int) bool {
+		x, y := results[a], results[b]
+		if matchRank[x.hit.Match] != matchRank[y.hit.Match] {
+			return matchRank[x.hit.Match] < matchRank[y.hit.Match]
+		}
+		if x.distance != y.distance {
+			return x.distance < y.distance
+		}
+		if len(x.hit.Security.Symbol) != len(y.hit.Security.Symbol) {
+			return len(x.hit.Security.Symbol) < len(y.hit.Security.Symbol)
+		}
+		if x.hit.Security.Symbol != y.hit.Security.Symbol {
+			return x.hit.Security.Symbol < y.hit.Security.Symbol
+		}
+		if x.hit.Security.Exchange != y.hit.Security.Exchange {
+			return x.hit.Security.Exchange < y.hit.Security.Exchange
+		}
+		return x.hit.Security.Id < y.hit.Security.Id
+	})
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DEFAULT_LIMIT
+	}
+	if query.Offset >= len(results) {
+		return nil, nil
+	}
+	results = results[query.Offset:]
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	hits := make([]domain.SecuritySearchHit, 0, len(results))
+	for _, result := range results {
+		hits = append(hits, result.hit)
+	}
+	return hits, nil
+}
+
+// load builds the index when it is missing or older than the refresh interval.
+func (i *inverted) load(ctx context.Context) error {
+	i.mu.RLock()
+	fresh := i.loaded && (i.refresh <= 0 || i.now().Sub(i.loadedAt) < i.refresh)
+	i.mu.RUnlock()
+	if fresh {
+		return nil
+	}
+
+	securitiesData, err := i.repo.GetSecuritiesData(ctx)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]entry, 0, len(securitiesData))
+	postings := map[string][]int{}
+	for position, security := range securitiesData {
+		item := entry{
+			security: security,
+			symbol:   strings.ToLower(security.Symbol),
+			name:     strings.ToLower(security.Name),
+		}
+		entries = append(entries, item)
+
+		terms := append(tokenize(item.symbol), tokenize(item.name)...)
+		seen := map[string]bool{}
+		for _, term := range terms {
+			if seen[term] {
+				continue
+			}
+			seen[term] = true
+			postings[term] = append(postings[term], position)
+		}
+	}
+
+	terms := make([]string, 0, len(postings))
+	for term := range postings {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.entries = entries
+	i.terms = terms
+	i.postings = postings
+	i.loaded = true
+	i.loadedAt = i.now()
+	return nil
+}
+
+// lookup returns the entries with a term starting with word, mapped to the fewest typos needed.
+// The caller holds the read lock.
+func (i *inverted) lookup(word string) map[int]int {
+	matched := map[int]int{}
+	add := func(term string, distance int) {
+		for _, position := range i.postings[term] {
+			if current, ok := matched[position]; !ok || distance < current {
+				matched[position] = distance
+			}
+		}
+	}
+
+	// Exact prefixes sit in one run of the sorted terms.
+	for index := sort.SearchStrings(i.terms, word); index < len(i.terms) && strings.HasPrefix(i.terms[index], word); index++ {
+		add(i.terms[index], 0)
+	}
+
+	maxEdits := allowedEdits(word)
+	if maxEdits == 0 {
+		return matched
+	}
+	wordRunes := []rune(word)
+	for _, term := range i.terms {
+		if strings.HasPrefix(term, word) {
+			continue
+		}
+		if distance := prefixDistance(wordRunes, []rune(term), maxEdits); distance <= maxEdits {
+			add(term, distance)
+		}
+	}
+	return matched
+}
+
+// classify names the best kind of match of item for search, given the typos its words needed.
+func classify(item entry, search string, distance int) domain.SecuritySearchMatch {
+	switch {
+	case item.symbol == search:
+		return domain.SEARCH_MATCH_EXACT_SYMBOL
+	case strings.HasPrefix(item.symbol, search):
+		return domain.SEARCH_MATCH_SYMBOL_PREFIX
+	case strings.HasPrefix(item.name, search):
+		return domain.SEARCH_MATCH_NAME_PREFIX
+	case distance == 0:
+		return domain.SEARCH_MATCH_TOKEN
+	default:
+		return domain.SEARCH_MATCH_FUZZY
+	}
+}
+
+// allowedEdits is the number of typos tolerated in a search word: none for short words, where a single
+// edit matches almost anything, one up to seven letters and two beyond.
+func allowedEdits(word string) int {
+	switch length := len([]rune(word)); {
+	case length < 4:
+		return 0
+	case length < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// prefixDistance returns the Levenshtein distance between word and the closest prefix of term, or
+// maxEdits+1 once every prefix is known to be further away.
+func prefixDistance(word, term []rune, maxEdits int) int {
+	previous := make([]int, len(term)+1)
+	current := make([]int, len(term)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for x := 1; x <= len(word); x++ {
+		current[0] = x
+		rowMin := current[0]
+		for y := 1; y <= len(term); y++ {
+			cost := 1
+			if word[x-1] == term[y-1] {
+				cost = 0
+			}
+			current[y] = previous[y-1] + cost
+			if previous[y]+1 < current[y] {
+				current[y] = previous[y] + 1
+			}
+			if current[y-1]+1 < current[y] {
+				current[y] = current[y-1] + 1
+			}
+			if current[y] < rowMin {
+				rowMin = current[y]
+			}
+		}
+		if rowMin > maxEdits {
+			return maxEdits + 1
+		}
+		previous, current = current, previous
+	}
+
+	best := previous[0]
+	for _, distance := range previous {
+		if distance < best {
+			best = distance
+		}
+	}
+	return best
+}
+
+// tokenize splits text into its runs of letters and digits.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
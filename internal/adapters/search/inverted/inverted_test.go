@@ -0,0 +1,144 @@
+package inverted
+
+import (
+	"assetio/internal/adapters/repository/memory"
+	"assetio/internal/domain"
+	"assetio/internal/port"
+	"context"
+	"testing"
+	"time"
+)
+
+func newSearcher(t *testing.T, securities ...domain.Securities) (port.RepositoryStore, *inverted) {
+	t.Helper()
+	repo := memory.New()
+	for _, security := range securities {
+		if _, err := repo.InsertSecurityData(context.Background(), security); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repo, New(repo, 0).(*inverted)
+}
+
+func symbols(t *testing.T, hits []domain.SecuritySearchHit) []string {
+	t.Helper()
+	var got []string
+	for _, hit := range hits {
+		got = append(got, hit.Security.Symbol+"/"+string(hit.Match))
+	}
+	return got
+}
+
+func assertHits(t *testing.T, hits []domain.SecuritySearchHit, want ...string) {
+	t.Helper()
+	got := symbols(t, hits)
+	if len(got) != len(want) {
+		t.Fatalf("hits = %v, want %v", got, want)
+	}
+	for index := range want {
+		if got[index] != want[index] {
+			t.Fatalf("hits = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSearchRanksMatchKinds(t *testing.T) {
+	_, searcher := newSearcher(t,
+		domain.Securities{Type: 1, Exchange: 1, Symbol: "TATACONSUM", Name: "Tata Consumer Products"},
+		domain.Securities{Type: 1, Exchange: 1, Symbol: "TCS", Name: "Tata Consultancy Services"},
+		domain.Securities{Type: 1, Exchange: 1, Symbol: "TITAN", Name: "Titan Company"},
+		domain.Securities{Type: 1, Exchange: 1, Symbol: "VOLTAS", Name: "Voltas, a Tata Enterprise"},
+		domain.Securities{Type: 1, Exchange: 1, Symbol: "TATA", Name: "Tata Investment Corporation"},
+	)
+
+	hits, err := searcher.Search(context.Background(), domain.SecuritySearchQuery{Search: "Tata"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertHits(t, hits,
+		"TATA/EXACT_SYMBOL",
+		"TATACONSUM/SYMBOL_PREFIX",
+		"TCS/NAME_PREFIX",
+		"VOLTAS/TOKEN",
+		"TITAN/FUZZY",
+	)
+}
+
+func TestSearchToleratesTypos(t *testing.T) {
+	_, searcher := newSearcher(t,
+		domain.Securities{Type: 1, Exchange: 1, Symbol: "RELIANCE", Name: "Reliance Industries"},
+		domain.Securities{Type: 1, Exchange: 1, Symbol: "INFY", Name: "Infosys"},
+	)
+
+	hits, err := searcher.Search(context.Background(), domain.SecuritySearchQuery{Search: "relaince indstries"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertHits(t, hits, "RELIANCE/FUZZY")
+
+	// Short words must match exactly, or every three letter search would match most of the index.
+	if hits, _ := searcher.Search(context.Background(), domain.SecuritySearchQuery{Search: "inf"}); len(hits) != 1 {
+		t.Fatalf("inf = %v", symbols(t, hits))
+	}
+	if hits, _ := searcher.Search(context.Background(), domain.SecuritySearchQuery{Search: "ibf"}); len(hits) != 0 {
+		t.Fatalf("ibf = %v, want nothing", symbols(t, hits))
+	}
+}
+
+func TestSearchFiltersAndPages(t *testing.T) {
+	_, searcher := newSearcher(t,
+		domain.Securities{Type: 1, Exchange: 1, Symbol: "TATASTEEL", Name: "Tata Steel"},
+		domain.Securities{Type: 1, Exchange: 2, Symbol: "TATASTEEL", Name: "Tata Steel"},
+		domain.Securities{Type: 1, Exchange: 2, Symbol: "TATAPOWER", Name: "Tata Power"},
+		domain.Securities{Type: 2, Exchange: 1, Symbol: "TATADIGITAL", Name: "Tata Digital India Fund"},
+	)
+	ctx := context.Background()
+
+	all, _ := searcher.Search(ctx, domain.SecuritySearchQuery{Search: "tata"})
+	if len(all) != 4 {
+		t.Fatalf("cross exchange search = %v", symbols(t, all))
+	}
+
+	bse, _ := searcher.Search(ctx, domain.SecuritySearchQuery{Search: "tata", Type: 1, Exchange: 2})
+	assertHits(t, bse, "TATAPOWER/SYMBOL_PREFIX", "TATASTEEL/SYMBOL_PREFIX")
+
+	page, _ := searcher.Search(ctx, domain.SecuritySearchQuery{Search: "tata", Limit: 2, Offset: 1})
+	if len(page) != 2 || page[0].Security.Id != all[1].Security.Id || page[1].Security.Id != all[2].Security.Id {
+		t.Fatalf("page = %v, want the 2nd and 3rd of %v", symbols(t, page), symbols(t, all))
+	}
+
+	if past, _ := searcher.Search(ctx, domain.SecuritySearchQuery{Search: "tata", Offset: 10}); len(past) != 0 {
+		t.Fatalf("offset past the end = %v", symbols(t, past))
+	}
+}
+
+func TestSearchReloads(t *testing.T) {
+	repo, searcher := newSearcher(t, domain.Securities{Type: 1, Exchange: 1, Symbol: "INFY", Name: "Infosys"})
+	ctx := context.Background()
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	searcher.now = func() time.Time { return now }
+	searcher.refresh = time.Minute
+
+	if hits, _ := searcher.Search(ctx, domain.SecuritySearchQuery{Search: "wipro"}); len(hits) != 0 {
+		t.Fatalf("wipro = %v before it exists", symbols(t, hits))
+	}
+	if _, err := repo.InsertSecurityData(ctx, domain.Securities{Type: 1, Exchange: 1, Symbol: "WIPRO", Name: "Wipro"}); err != nil {
+		t.Fatal(err)
+	}
+	if hits, _ := searcher.Search(ctx, domain.SecuritySearchQuery{Search: "wipro"}); len(hits) != 0 {
+		t.Fatal("index reloaded before its refresh interval")
+	}
+
+	now = now.Add(time.Minute)
+	if hits, _ := searcher.Search(ctx, domain.SecuritySearchQuery{Search: "wipro"}); len(hits) != 1 {
+		t.Fatal("index was not reloaded after its refresh interval")
+	}
+
+	if _, err := repo.InsertSecurityData(ctx, domain.Securities{Type: 1, Exchange: 1, Symbol: "WIPROLTD", Name: "Wipro"}); err != nil {
+		t.Fatal(err)
+	}
+	searcher.Invalidate()
+	if hits, _ := searcher.Search(ctx, domain.SecuritySearchQuery{Search: "wipro"}); len(hits) != 2 {
+		t.Fatalf("wipro after Invalidate = %v", symbols(t, hits))
+	}
+}
@@ -9,6 +9,11 @@ const (
 	SECURITY_TYPE_STOCK_STRING       = "stock"
 	SECURITY_TYPE_MUTUAL_FUND_STRING = "mutualFund"
 
+	ASSET_CLASS_EQUITY        = 1
+	ASSET_CLASS_DEBT          = 2
+	ASSET_CLASS_EQUITY_STRING = "equity"
+	ASSET_CLASS_DEBT_STRING   = "debt"
+
 	EXCHANGE_TYPE_NSE = 1
 	EXCHANGE_TYPE_BSE = 2
 
@@ -21,6 +26,11 @@ const (
 
 	DATE_LAYOUT = "01/02/2006"
 
+	DATABASE_DRIVER_MEMORY   = "memory"
+	DATABASE_DRIVER_MYSQL    = "mysql"
+	DATABASE_DRIVER_POSTGRES = "postgres"
+	DATABASE_DRIVER_SQLITE   = "sqlite"
+
 	ERROR_TYPE_DBEXECUTION = "DbExecution"
 )
 
@@ -30,6 +40,8 @@ const (
 	ERROR_CODE_REQUEST_INVALID        = "RE01"
 	ERROR_CODE_REQUEST_PARAMS_INVALID = "RE02"
 
-	ERROR_CODE_NO_DATA     = "DA01"
-	ERROR_CODE_DATA_EXISTS = "DA02"
+	ERROR_CODE_NO_DATA      = "DA01"
+	ERROR_CODE_DATA_EXISTS  = "DA02"
+	ERROR_CODE_CONFLICT     = "DA03"
+	ERROR_CODE_DATA_INVALID = "DA04"
 )
@@ -1,8 +1,9 @@
 package domain
 
 type ClientAccountCreateRequest struct {
-	Name   string `json:"name" schema:"name"`
-	UserId int    `json:"uid" schema:"uid"`
+	Name      string    `json:"name" schema:"name"`
+	UserId    int       `json:"uid" schema:"uid"`
+	LotMethod LotMethod `json:"lot_method" schema:"lot_method"`
 }
 type ClientAccountCreateResponse struct {
 	Message string `json:"message" schema:"message"`
@@ -13,9 +14,10 @@ type ClientAccountAllRequest struct {
 }
 
 type ClientAccountAllResponse struct {
-	Id     int    `json:"id" schema:"id"`
-	Name   string `json:"name" schema:"name"`
-	Status string `json:"status" schema:"status"`
+	Id        int       `json:"id" schema:"id"`
+	Name      string    `json:"name" schema:"name"`
+	Status    string    `json:"status" schema:"status"`
+	LotMethod LotMethod `json:"lot_method" schema:"lot_method"`
 }
 
 type ClientAccountGetRequest struct {
@@ -23,15 +25,17 @@ type ClientAccountGetRequest struct {
 	AccountId int `json:"account_id" schema:"account_id"`
 }
 type ClientAccountGetResponse struct {
-	Id     int    `json:"id" schema:"id"`
-	Name   string `json:"name" schema:"name"`
-	Status string `json:"status" schema:"status"`
+	Id        int       `json:"id" schema:"id"`
+	Name      string    `json:"name" schema:"name"`
+	Status    string    `json:"status" schema:"status"`
+	LotMethod LotMethod `json:"lot_method" schema:"lot_method"`
 }
 
 type ClientAccountUpdateRequest struct {
-	Name      string `json:"name" schema:"name"`
-	UserId    int    `json:"uid" schema:"uid"`
-	AccountId int    `json:"account_id" schema:"account_id"`
+	Name      string    `json:"name" schema:"name"`
+	UserId    int       `json:"uid" schema:"uid"`
+	AccountId int       `json:"account_id" schema:"account_id"`
+	LotMethod LotMethod `json:"lot_method" schema:"lot_method"`
 }
 type ClientAccountUpdateResponse struct {
 	Message string `json:"message" schema:"uid"`
@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"github.com/shopspring/decimal"
+)
+
+// Quantities, prices and money values are carried as decimal.Decimal from the request, through the use case
+// arithmetic, into the database and back out as JSON. Every computed value is rounded once, with the rule
+// for its kind, before it is stored or returned; intermediate results keep full precision.
+//
+// Rounding is half away from zero:
+//   - quantities (shares, units) keep QUANTITY_SCALE decimal places,
+//   - per unit prices keep PRICE_SCALE decimal places,
+//   - money values (totals, fees, dividends) keep VALUE_SCALE decimal places,
+//   - percentages (gains, weights) keep PERCENT_SCALE decimal places.
+const (
+	QUANTITY_SCALE int32 = 4
+	PRICE_SCALE    int32 = 4
+	VALUE_SCALE    int32 = 2
+	PERCENT_SCALE  int32 = 2
+)
+
+func init() {
+	// Decimals are written as JSON numbers, so clients see the same shape as before; the digits are the
+	// stored ones rather than a float64 approximation.
+	decimal.MarshalJSONWithoutQuotes = true
+}
+
+// RoundQuantity rounds a share or unit count to QUANTITY_SCALE places.
+func RoundQuantity(quantity decimal.Decimal) decimal.Decimal {
+	return quantity.Round(QUANTITY_SCALE)
+}
+
+// RoundPrice rounds a per unit price to PRICE_SCALE places.
+func RoundPrice(price decimal.Decimal) decimal.Decimal {
+	return price.Round(PRICE_SCALE)
+}
+
+// RoundValue rounds a money value to VALUE_SCALE places.
+func RoundValue(value decimal.Decimal) decimal.Decimal {
+	return value.Round(VALUE_SCALE)
+}
+
+// AveragePrice returns value / quantity rounded as a price, or zero when the quantity is zero.
+func AveragePrice(value, quantity decimal.Decimal) decimal.Decimal {
+	if quantity.IsZero() {
+		return decimal.Zero
+	}
+	return RoundPrice(value.Div(quantity))
+}
+
+// Percent returns part as a percentage of whole rounded to PERCENT_SCALE places, or zero when whole is zero.
+func Percent(part, whole decimal.Decimal) decimal.Decimal {
+	if whole.IsZero() {
+		return decimal.Zero
+	}
+	return part.Mul(decimal.NewFromInt(100)).Div(whole).Round(PERCENT_SCALE)
+}
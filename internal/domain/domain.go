@@ -80,6 +80,9 @@ type StockSvr interface {
 	// StockSummary retrieves a summary of stock-related information.
 	StockSummary(request ClientStockSummaryRequest) Response
 
+	// StockSummaryTotals values the whole stock portfolio of an account at the market price.
+	StockSummaryTotals(request ClientStockSummaryRequest) Response
+
 	// StockInventories retrieves the inventory of stocks held.
 	StockInventories(request ClientStockInventoriesRequest) Response
 
@@ -87,6 +90,51 @@ type StockSvr interface {
 	StockInventoryLedgers(request ClientStockInventoryLedgersRequest) Response
 
 	StockDividends(request ClientStockDividendsRequest) Response
+
+	// StockRebuild recomputes inventories from their ledgers, reporting the differences and optionally applying them.
+	StockRebuild(request ClientStockRebuildRequest) Response
+
+	// StockConsistency checks inventories, ledgers and transactions against each other and reports every mismatch.
+	StockConsistency(request ClientStockConsistencyRequest) Response
+
+	// TransactionVoid voids a recorded buy, sell or buyback and restores the inventories it changed.
+	TransactionVoid(request ClientTransactionVoidRequest) Response
+
+	// TransactionUpdate edits a recorded buy, sell or dividend and recomputes everything recorded after it.
+	TransactionUpdate(request ClientTransactionUpdateRequest) Response
+
+	// StockRealized reports the profit and loss realized by the sells of an account.
+	StockRealized(request ClientStockRealizedRequest) Response
+
+	// StockCapitalGains classifies the gains realized in a financial year under Indian tax rules.
+	StockCapitalGains(request ClientStockCapitalGainsRequest) Response
+
+	// StockReturns computes the XIRR and CAGR of a user's holdings per stock, per account and overall.
+	StockReturns(request ClientStockReturnsRequest) Response
+
+	// StockHoldings reconstructs what an account held at the end of a past day.
+	StockHoldings(request ClientStockHoldingsRequest) Response
+
+	// StockChargesCalculate works out the itemised charges of a prospective trade from the exchange's rate table.
+	StockChargesCalculate(request ClientStockChargesCalculateRequest) Response
+
+	// StockCharges reports the charges an account paid per financial year.
+	StockCharges(request ClientStockChargesRequest) Response
+
+	// StockRightsEntitle records the rights entitlements a holding on the record date of a rights issue brings.
+	StockRightsEntitle(request ClientStockRightsEntitleRequest) Response
+
+	// StockRightsRenounce records the sale or renunciation of rights entitlements.
+	StockRightsRenounce(request ClientStockRightsRenounceRequest) Response
+
+	// StockRightsSubscribe takes up rights entitlements, opening a lot of fully or partly paid rights shares.
+	StockRightsSubscribe(request ClientStockRightsSubscribeRequest) Response
+
+	// StockRightsCall records a call-money payment on partly paid rights shares.
+	StockRightsCall(request ClientStockRightsCallRequest) Response
+
+	// StockBuyback records the shares accepted in a buyback or tender offer and the tax treatment of the proceeds.
+	StockBuyback(request ClientStockBuybackRequest) Response
 }
 
 // Response defines the interface for a service response.
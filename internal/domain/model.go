@@ -1,6 +1,15 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
 
 type TransactionType string
 
@@ -14,39 +23,118 @@ const (
 	MERGER_TRANSFER   TransactionType = "MERGER_TRANSFER"
 	DEMERGER          TransactionType = "DEMERGER"
 	DEMERGER_TRANSFER TransactionType = "DEMERGER_TRANSFER"
+
+	// Rights issue steps. An entitlement and its renunciation move no shares and have no ledger entries; a
+	// subscription opens a lot of the rights shares, and a call adds the money paid on it to the lot's cost.
+	RIGHTS_ENTITLEMENT TransactionType = "RIGHTS_ENTITLEMENT"
+	RIGHTS_RENOUNCE    TransactionType = "RIGHTS_RENOUNCE"
+	RIGHTS_SUBSCRIBE   TransactionType = "RIGHTS_SUBSCRIBE"
+	RIGHTS_CALL        TransactionType = "RIGHTS_CALL"
+
+	// BUYBACK is the sale of shares accepted in a buyback or tender offer. The shares leave their lots as a sale
+	// takes them, so its ledger entries are SELL entries; the transaction type tells the tax treatment apart.
+	BUYBACK TransactionType = "BUYBACK"
+
+	// VOID marks a ledger entry that reverses an entry of a voided transaction. It carries the transaction
+	// id, quantity and value of the entry it reverses.
+	VOID TransactionType = "VOID"
+)
+
+// LotMethod selects which lots a sell consumes when it does not name one.
+type LotMethod string
+
+const (
+	LOT_METHOD_FIFO    LotMethod = "FIFO"    // Oldest purchase date first
+	LOT_METHOD_LIFO    LotMethod = "LIFO"    // Newest purchase date first
+	LOT_METHOD_HIFO    LotMethod = "HIFO"    // Highest cost first
+	LOT_METHOD_LOFO    LotMethod = "LOFO"    // Lowest cost first
+	LOT_METHOD_AVERAGE LotMethod = "AVERAGE" // Every lot in proportion to its quantity, at the pooled average cost
 )
 
+// IsValid reports whether the method is one of the supported lot methods.
+func (l LotMethod) IsValid() bool {
+	switch l {
+	case LOT_METHOD_FIFO, LOT_METHOD_LIFO, LOT_METHOD_HIFO, LOT_METHOD_LOFO, LOT_METHOD_AVERAGE:
+		return true
+	}
+	return false
+}
+
+// Transactions.State values. Transactions recorded before voiding existed have state 0 and are active.
+const (
+	TRANSACTION_STATE_ACTIVE = 0
+	TRANSACTION_STATE_VOIDED = 1
+)
+
+// TransactionTypes lists every TransactionType the store accepts. It builds the column definition of the live
+// model; a type added here also needs a migration that widens the MySQL enum.
+var TransactionTypes = []TransactionType{
+	BUY, SELL, DIVIDEND, SPLIT, BONUS, MERGER, MERGER_TRANSFER, DEMERGER, DEMERGER_TRANSFER, VOID,
+	RIGHTS_ENTITLEMENT, RIGHTS_RENOUNCE, RIGHTS_SUBSCRIBE, RIGHTS_CALL, BUYBACK,
+}
+
+// IsValid reports whether t is one of the known transaction types.
+func (t TransactionType) IsValid() bool {
+	for _, transactionType := range TransactionTypes {
+		if t == transactionType {
+			return true
+		}
+	}
+	return false
+}
+
+// GormDBDataType returns the column type used for TransactionType on the connected dialect.
+// MySQL keeps its native enum built from TransactionTypes; Postgres, SQLite and any other
+// database store the value as a plain string, with IsValid guarding the allowed values.
+func (TransactionType) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	switch db.Dialector.Name() {
+	case "mysql":
+		values := make([]string, len(TransactionTypes))
+		for i, transactionType := range TransactionTypes {
+			values[i] = "'" + string(transactionType) + "'"
+		}
+		return "enum(" + strings.Join(values, ", ") + ")"
+	}
+	return "varchar(32)"
+}
+
 type Accounts struct {
 	Id        int       `gorm:"primarykey;size:16"`
 	UserId    int       `gorm:"column:user_id;size:16"`
 	Status    int       `gorm:"column:status;size:11"`
 	Name      string    `gorm:"column:name;size:255"`
+	LotMethod LotMethod `gorm:"column:lot_method;size:16"`
 	CreatedAt time.Time `gorm:"autoCreateTime,column:created_at"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime,column:updated_at"`
 }
 
 type Inventories struct {
-	Id                int       `gorm:"primarykey;size:16"`
-	AccountId         int       `gorm:"column:account_id;size:16"`
-	SecurityId        int       `gorm:"column:security_id;size:16"`
-	AvailableQuantity float64   `gorm:"type:decimal(12,4);column:available_quantity"`
-	AveragePrice      float64   `gorm:"type:decimal(12,4);column:average_price"`
-	TotalValue        float64   `gorm:"type:decimal(12,4);column:total_value"`
-	Date              time.Time `gorm:"column:date"`
-	State             int       `gorm:"column:state;size:11;"`
-	CreatedAt         time.Time `gorm:"autoCreateTime,column:created_at"`
-	UpdatedAt         time.Time `gorm:"autoUpdateTime,column:updated_at"`
+	Id                int             `gorm:"primarykey;size:16"`
+	AccountId         int             `gorm:"column:account_id;size:16"`
+	SecurityId        int             `gorm:"column:security_id;size:16"`
+	AvailableQuantity decimal.Decimal `gorm:"type:decimal(20,4);column:available_quantity"`
+	AveragePrice      decimal.Decimal `gorm:"type:decimal(20,4);column:average_price"`
+	TotalValue        decimal.Decimal `gorm:"type:decimal(20,4);column:total_value"`
+	Date              time.Time       `gorm:"column:date"`
+	State             int             `gorm:"column:state;size:11;"`
+	Version           int             `gorm:"column:version;not null;default:0"`
+	CreatedAt         time.Time       `gorm:"autoCreateTime,column:created_at"`
+	UpdatedAt         time.Time       `gorm:"autoUpdateTime,column:updated_at"`
 }
 
+// ErrInventoryConflict is returned when an inventory row changed between being read and being updated.
+// The surrounding transaction must be rolled back; running it again re-reads the current row.
+var ErrInventoryConflict = errors.New("inventory was modified by another request")
+
 type InventoryLedger struct {
 	Id            int             `gorm:"primarykey;size:16"`
 	InventoryId   int             `gorm:"column:inventory_id;size:16"`
 	TransactionId int             `gorm:"column:transaction_id;size:16"`
-	Type          TransactionType `gorm:"type:enum('BUY', 'SELL', 'DIVIDEND', 'SPLIT', 'BONUS' , 'MERGER', 'MERGER_TRANSFER', 'DEMERGER', 'DEMERGER_TRANSFER');column:type;size:16"`
-	Quantity      float64         `gorm:"type:decimal(12,4);column:quantity"`
-	AveragePrice  float64         `gorm:"type:decimal(12,4);column:average_price"`
-	TotalValue    float64         `gorm:"type:decimal(12,4);column:total_value"`
-	Fee           float64         `gorm:"type:decimal(12,4);column:fee"`
+	Type          TransactionType `gorm:"column:type;size:16"`
+	Quantity      decimal.Decimal `gorm:"type:decimal(20,4);column:quantity"`
+	AveragePrice  decimal.Decimal `gorm:"type:decimal(20,4);column:average_price"`
+	TotalValue    decimal.Decimal `gorm:"type:decimal(20,4);column:total_value"`
+	Fee           decimal.Decimal `gorm:"type:decimal(20,4);column:fee"`
 	Date          time.Time       `gorm:"column:date"`
 	CreatedAt     time.Time       `gorm:"autoCreateTime,column:created_at"`
 	UpdatedAt     time.Time       `gorm:"autoUpdateTime,column:updated_at"`
@@ -56,57 +144,167 @@ type Transactions struct {
 	Id           int             `gorm:"primarykey;size:16"`
 	AccountId    int             `gorm:"column:account_id;size:16"`
 	SecurityId   int             `gorm:"column:security_id;size:16"`
-	Type         TransactionType `gorm:"type:enum('BUY', 'SELL', 'DIVIDEND', 'SPLIT', 'BONUS' , 'MERGER', 'MERGER_TRANSFER', 'DEMERGER', 'DEMERGER_TRANSFER');column:type;size:16"`
-	Quantity     float64         `gorm:"type:decimal(12,4);column:quantity"`
-	AveragePrice float64         `gorm:"type:decimal(12,4);column:average_price"`
-	TotalValue   float64         `gorm:"type:decimal(12,4);column:total_value"`
-	Fee          float64         `gorm:"type:decimal(12,4);column:fee"`
-	State        int             `gorm:"column:state;size:11;"`
-	Date         time.Time       `gorm:"column:date"`
-	CreatedAt    time.Time       `gorm:"autoCreateTime,column:created_at"`
-	UpdatedAt    time.Time       `gorm:"autoUpdateTime,column:updated_at"`
+	Type         TransactionType `gorm:"column:type;size:16"`
+	Quantity     decimal.Decimal `gorm:"type:decimal(20,4);column:quantity"`
+	AveragePrice decimal.Decimal `gorm:"type:decimal(20,4);column:average_price"`
+	TotalValue   decimal.Decimal `gorm:"type:decimal(20,4);column:total_value"`
+	Fee          decimal.Decimal `gorm:"type:decimal(20,4);column:fee"`
+	// RatioFrom and RatioTo keep the terms of a ratio split or bonus, so an edit of an earlier transaction can work
+	// it out again: a split turns every RatioFrom shares held into RatioTo, a bonus issues RatioTo shares for every
+	// RatioFrom held at the start of ExDate. They are zero for every other transaction, and ExDate is only set on
+	// bonuses.
+	RatioFrom decimal.Decimal `gorm:"type:decimal(20,4);column:ratio_from"`
+	RatioTo   decimal.Decimal `gorm:"type:decimal(20,4);column:ratio_to"`
+	ExDate    *time.Time      `gorm:"column:ex_date"`
+	State     int             `gorm:"column:state;size:11;"`
+	Date      time.Time       `gorm:"column:date"`
+	CreatedAt time.Time       `gorm:"autoCreateTime,column:created_at"`
+	UpdatedAt time.Time       `gorm:"autoUpdateTime,column:updated_at"`
+}
+
+// RealizedGains records the part of one lot consumed by a sell, linked to the SELL ledger entry, with the
+// cost it was bought at and the gain realized on it. Fee holds the sell fee apportioned to the lot by
+// quantity plus the share of the lot's purchase fee carried by the shares sold. A renunciation of rights
+// entitlements is recorded without an inventory or ledger entry, at no cost, acquired on the record date.
+type RealizedGains struct {
+	Id              int             `gorm:"primarykey;size:16"`
+	AccountId       int             `gorm:"column:account_id;size:16"`
+	SecurityId      int             `gorm:"column:security_id;size:16"`
+	InventoryId     int             `gorm:"index;column:inventory_id;size:16"`
+	LedgerId        int             `gorm:"column:ledger_id;size:16"`
+	TransactionId   int             `gorm:"column:transaction_id;size:16"`
+	Quantity        decimal.Decimal `gorm:"type:decimal(20,4);column:quantity"`
+	AcquisitionDate time.Time       `gorm:"column:acquisition_date"`
+	CostPrice       decimal.Decimal `gorm:"type:decimal(20,4);column:cost_price"`
+	CostValue       decimal.Decimal `gorm:"type:decimal(20,4);column:cost_value"`
+	SalePrice       decimal.Decimal `gorm:"type:decimal(20,4);column:sale_price"`
+	Proceeds        decimal.Decimal `gorm:"type:decimal(20,4);column:proceeds"`
+	Fee             decimal.Decimal `gorm:"type:decimal(20,4);column:fee"`
+	Gain            decimal.Decimal `gorm:"type:decimal(20,4);column:gain"`
+	Date            time.Time       `gorm:"column:date"`
+	CreatedAt       time.Time       `gorm:"autoCreateTime,column:created_at"`
+	UpdatedAt       time.Time       `gorm:"autoUpdateTime,column:updated_at"`
+}
+
+// TransactionCharges itemises the fee of a transaction. The items add up to the fee recorded on the transaction; a
+// transaction recorded with only a total fee has no charges row.
+type TransactionCharges struct {
+	Id            int             `gorm:"primarykey;size:16"`
+	TransactionId int             `gorm:"uniqueIndex;column:transaction_id;size:16"`
+	Brokerage     decimal.Decimal `gorm:"type:decimal(20,4);column:brokerage"`
+	Stt           decimal.Decimal `gorm:"type:decimal(20,4);column:stt"`
+	ExchangeFee   decimal.Decimal `gorm:"type:decimal(20,4);column:exchange_fee"`
+	SebiFee       decimal.Decimal `gorm:"type:decimal(20,4);column:sebi_fee"`
+	StampDuty     decimal.Decimal `gorm:"type:decimal(20,4);column:stamp_duty"`
+	Gst           decimal.Decimal `gorm:"type:decimal(20,4);column:gst"`
+	CreatedAt     time.Time       `gorm:"autoCreateTime,column:created_at"`
+	UpdatedAt     time.Time       `gorm:"autoUpdateTime,column:updated_at"`
+}
+
+// Total returns the sum of the itemised charges.
+func (c TransactionCharges) Total() decimal.Decimal {
+	return c.Brokerage.Add(c.Stt).Add(c.ExchangeFee).Add(c.SebiFee).Add(c.StampDuty).Add(c.Gst)
+}
+
+// RightsIssues tracks the rights entitlements of an account from one rights issue: RatioRights rights shares offered
+// at IssuePrice for every RatioHeld shares held on RecordDate. Renounced and Subscribed count the entitlements given
+// up and taken up; the subscribed shares form the lot InventoryId, paid up to PaidPrice per share so far.
+type RightsIssues struct {
+	Id            int             `gorm:"primarykey;size:16"`
+	AccountId     int             `gorm:"column:account_id;size:16"`
+	SecurityId    int             `gorm:"column:security_id;size:16"`
+	TransactionId int             `gorm:"column:transaction_id;size:16"`
+	InventoryId   int             `gorm:"column:inventory_id;size:16"`
+	RecordDate    time.Time       `gorm:"column:record_date"`
+	RatioRights   decimal.Decimal `gorm:"type:decimal(20,4);column:ratio_rights"`
+	RatioHeld     decimal.Decimal `gorm:"type:decimal(20,4);column:ratio_held"`
+	IssuePrice    decimal.Decimal `gorm:"type:decimal(20,4);column:issue_price"`
+	PaidPrice     decimal.Decimal `gorm:"type:decimal(20,4);column:paid_price"`
+	Entitled      decimal.Decimal `gorm:"type:decimal(20,4);column:entitled"`
+	Renounced     decimal.Decimal `gorm:"type:decimal(20,4);column:renounced"`
+	Subscribed    decimal.Decimal `gorm:"type:decimal(20,4);column:subscribed"`
+	CreatedAt     time.Time       `gorm:"autoCreateTime,column:created_at"`
+	UpdatedAt     time.Time       `gorm:"autoUpdateTime,column:updated_at"`
+}
+
+// Unused returns the entitlements neither renounced nor subscribed.
+func (r RightsIssues) Unused() decimal.Decimal {
+	return r.Entitled.Sub(r.Renounced).Sub(r.Subscribed)
 }
 
 type Securities struct {
-	Id        int       `gorm:"primarykey;size:16"`
-	Type      int       `gorm:"index:idx_type_exchange_symbol,unique;column:type;size:16"`
-	Exchange  int       `gorm:"index:idx_type_exchange_symbol,unique;column:exchange;size:16"`
-	Symbol    string    `gorm:"index:idx_type_exchange_symbol,unique;column:symbol;size:255"`
-	Name      string    `gorm:"column:name;size:255"`
-	CreatedAt time.Time `gorm:"autoCreateTime,column:created_at"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime,column:updated_at"`
+	Id       int    `gorm:"primarykey;size:16"`
+	Type     int    `gorm:"index:idx_type_exchange_symbol,unique;column:type;size:16"`
+	Exchange int    `gorm:"index:idx_type_exchange_symbol,unique;column:exchange;size:16"`
+	Symbol   string `gorm:"index:idx_type_exchange_symbol,unique;column:symbol;size:255"`
+	Name     string `gorm:"column:name;size:255"`
+	// AssetClass decides how gains on the security are taxed; securities created before it was recorded are equity.
+	AssetClass int       `gorm:"column:asset_class;size:11"`
+	CreatedAt  time.Time `gorm:"autoCreateTime,column:created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime,column:updated_at"`
 }
 
 type InventorySummary struct {
-	Id                int     `gorm:"column:id"`
-	AccountId         int     `gorm:"column:account_id"`
-	SecurityId        int     `gorm:"column:security_id"`
-	SecurityExchange  string  `gorm:"column:security_exchange"`
-	SecuritySymbol    string  `gorm:"column:security_symbol"`
-	SecurityName      string  `gorm:"column:security_name"`
-	AvailableQuantity float64 `gorm:"column:available_quantity"`
-	TotalValue        float64 `gorm:"column:total_value"`
+	Id                int             `gorm:"column:id"`
+	AccountId         int             `gorm:"column:account_id"`
+	SecurityId        int             `gorm:"column:security_id"`
+	SecurityExchange  string          `gorm:"column:security_exchange"`
+	SecuritySymbol    string          `gorm:"column:security_symbol"`
+	SecurityName      string          `gorm:"column:security_name"`
+	AvailableQuantity decimal.Decimal `gorm:"column:available_quantity"`
+	TotalValue        decimal.Decimal `gorm:"column:total_value"`
 }
 type InventoryDetails struct {
-	Id                int       `gorm:"column:id"`
-	AvailableQuantity float64   `gorm:"column:available_quantity"`
-	Price             float64   `gorm:"column:price"`
-	TotalValue        float64   `gorm:"column:total_value"`
-	Date              time.Time `gorm:"column:date"`
+	Id                int             `gorm:"column:id"`
+	AvailableQuantity decimal.Decimal `gorm:"column:available_quantity"`
+	Price             decimal.Decimal `gorm:"column:price"`
+	TotalValue        decimal.Decimal `gorm:"column:total_value"`
+	Date              time.Time       `gorm:"column:date"`
 }
 
 type InventoryLedgers struct {
-	Id         int             `gorm:"column:id"`
-	Type       TransactionType `gorm:"column:type"`
-	Quantity   float64         `gorm:"column:quantity"`
-	Price      float64         `gorm:"column:average_price"`
-	TotalValue float64         `gorm:"column:total_value"`
-	Date       time.Time       `gorm:"column:date"`
+	Id            int             `gorm:"column:id"`
+	TransactionId int             `gorm:"column:transaction_id"`
+	Type          TransactionType `gorm:"column:type"`
+	Quantity      decimal.Decimal `gorm:"column:quantity"`
+	Price         decimal.Decimal `gorm:"column:average_price"`
+	TotalValue    decimal.Decimal `gorm:"column:total_value"`
+	Fee           decimal.Decimal `gorm:"column:fee"`
+	Date          time.Time       `gorm:"column:date"`
 }
 
 type DividendTransaction struct {
-	Quantity   float64   `gorm:"column:quantity"`
-	Price      float64   `gorm:"column:average_price"`
-	TotalValue float64   `gorm:"column:total_value"`
-	Date       time.Time `gorm:"column:date"`
+	Quantity   decimal.Decimal `gorm:"column:quantity"`
+	Price      decimal.Decimal `gorm:"column:average_price"`
+	TotalValue decimal.Decimal `gorm:"column:total_value"`
+	Date       time.Time       `gorm:"column:date"`
+}
+
+// TransactionLedgerTotal is a transaction together with the summed quantity and number of the ledger entries
+// linked to it through their transaction id.
+type TransactionLedgerTotal struct {
+	Id             int             `gorm:"column:id"`
+	AccountId      int             `gorm:"column:account_id"`
+	SecurityId     int             `gorm:"column:security_id"`
+	Type           TransactionType `gorm:"column:type"`
+	Quantity       decimal.Decimal `gorm:"column:quantity"`
+	LedgerQuantity decimal.Decimal `gorm:"column:ledger_quantity"`
+	LedgerCount    int             `gorm:"column:ledger_count"`
+}
+
+// CacheStats counts how a cache has been used since it was created.
+type CacheStats struct {
+	Hits        uint64 // Lookups answered from the cache
+	Misses      uint64 // Lookups for absent or expired keys
+	Evictions   uint64 // Entries dropped to stay within capacity
+	Expirations uint64 // Entries dropped because they outlived the expiry
+	Entries     int    // Entries currently held
+}
+
+// SchemaMigration describes one numbered schema migration and whether the connected store has applied it.
+type SchemaMigration struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
 }
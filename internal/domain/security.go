@@ -1,10 +1,11 @@
 package domain
 
 type ClientSecurityCreateRequest struct {
-	Type     string `json:"type" schema:"type"`
-	Exchange string `json:"exchange" schema:"exchange"`
-	Symbol   string `json:"symbol" schema:"symbol"`
-	Name     string `json:"name" schema:"name"`
+	Type       string `json:"type" schema:"type"`
+	Exchange   string `json:"exchange" schema:"exchange"`
+	Symbol     string `json:"symbol" schema:"symbol"`
+	Name       string `json:"name" schema:"name"`
+	AssetClass string `json:"asset_class" schema:"asset_class"` // equity or debt; defaults to equity
 }
 
 type ClientSecurityCreateResponse struct {
@@ -18,6 +19,7 @@ type ClientSecurityUpdateRequest struct {
 	Exchange   string `json:"exchange" schema:"exchange"`
 	Symbol     string `json:"symbol" schema:"symbol"`
 	Name       string `json:"name" schema:"name"`
+	AssetClass string `json:"asset_class" schema:"asset_class"` // equity or debt; left unchanged when empty
 }
 
 type ClientSecurityUpdateResponse struct {
@@ -29,11 +31,12 @@ type ClientSecurityAllRequest struct {
 }
 
 type ClientSecurityAllResponse struct {
-	Id       int    `json:"id" schema:"id"`
-	Type     string `json:"type" schema:"type"`
-	Exchange string `json:"exchange" schema:"exchange"`
-	Symbol   string `json:"symbol" schema:"symbol"`
-	Name     string `json:"name" schema:"name"`
+	Id         int    `json:"id" schema:"id"`
+	Type       string `json:"type" schema:"type"`
+	Exchange   string `json:"exchange" schema:"exchange"`
+	Symbol     string `json:"symbol" schema:"symbol"`
+	Name       string `json:"name" schema:"name"`
+	AssetClass string `json:"asset_class" schema:"asset_class"`
 }
 
 type ClientSecurityGetRequest struct {
@@ -41,23 +44,55 @@ type ClientSecurityGetRequest struct {
 }
 
 type ClientSecurityGetResponse struct {
-	Id       int    `json:"id" schema:"id"`
-	Type     string `json:"type" schema:"type"`
-	Exchange string `json:"exchange" schema:"exchange"`
-	Symbol   string `json:"symbol" schema:"symbol"`
-	Name     string `json:"name" schema:"name"`
+	Id         int    `json:"id" schema:"id"`
+	Type       string `json:"type" schema:"type"`
+	Exchange   string `json:"exchange" schema:"exchange"`
+	Symbol     string `json:"symbol" schema:"symbol"`
+	Name       string `json:"name" schema:"name"`
+	AssetClass string `json:"asset_class" schema:"asset_class"`
 }
 
+// ClientSecuritySearchRequest searches the security master. Type and Exchange are optional filters; leaving
+// Exchange empty returns matches from every exchange.
 type ClientSecuritySearchRequest struct {
 	Type     string `json:"type" schema:"type"`
 	Exchange string `json:"exchange" schema:"exchange"`
 	Search   string `json:"search" schema:"search"`
+	Limit    int    `json:"limit" schema:"limit"`   // Page size; defaults to 20, at most 100
+	Offset   int    `json:"offset" schema:"offset"` // Number of ranked results to skip
 }
 
 type ClientSecuritySearchResponse struct {
-	Id       int    `json:"id" schema:"id"`
-	Type     string `json:"type" schema:"type"`
-	Exchange string `json:"exchange" schema:"exchange"`
-	Symbol   string `json:"symbol" schema:"symbol"`
-	Name     string `json:"name" schema:"name"`
+	Id       int                 `json:"id" schema:"id"`
+	Type     string              `json:"type" schema:"type"`
+	Exchange string              `json:"exchange" schema:"exchange"`
+	Symbol   string              `json:"symbol" schema:"symbol"`
+	Name     string              `json:"name" schema:"name"`
+	Match    SecuritySearchMatch `json:"match" schema:"match"`
+}
+
+// SecuritySearchMatch names how a security matched a search. Results are ranked in the order below.
+type SecuritySearchMatch string
+
+const (
+	SEARCH_MATCH_EXACT_SYMBOL  SecuritySearchMatch = "EXACT_SYMBOL"  // The symbol equals the search
+	SEARCH_MATCH_SYMBOL_PREFIX SecuritySearchMatch = "SYMBOL_PREFIX" // The symbol starts with the search
+	SEARCH_MATCH_NAME_PREFIX   SecuritySearchMatch = "NAME_PREFIX"   // The name starts with the search
+	SEARCH_MATCH_TOKEN         SecuritySearchMatch = "TOKEN"         // Every search word starts a word of the name or symbol
+	SEARCH_MATCH_FUZZY         SecuritySearchMatch = "FUZZY"         // Every search word starts a word of the name or symbol, allowing typos
+)
+
+// SecuritySearchQuery is a search against the security index. A zero Type or Exchange matches any.
+type SecuritySearchQuery struct {
+	Search   string
+	Type     int
+	Exchange int
+	Limit    int
+	Offset   int
+}
+
+// SecuritySearchHit is one ranked search result.
+type SecuritySearchHit struct {
+	Security Securities
+	Match    SecuritySearchMatch
 }
@@ -1,64 +1,129 @@
 package domain
 
+import (
+	"github.com/shopspring/decimal"
+)
+
+// ClientStockBuyRequest records a purchase. The fee is either FeeAmount, the itemised Charges, or, when neither is
+// given, the charges worked out from the rate table of the stock's exchange.
 type ClientStockBuyRequest struct {
-	UserId       int     `json:"uid" schema:"uid"`
-	AccountId    int     `json:"account_id" schema:"account_id"`
-	StockId      int     `json:"stock_id" schema:"stock_id"`
-	InventoryId  int     `json:"inventory_id" schema:"inventory_id"`
-	Date         string  `json:"date" schema:"date"`
-	Quantity     float64 `json:"quantity" schema:"quantity"`
-	AveragePrice float64 `json:"average_price" schema:"average_price"`
-	FeeAmount    float64 `json:"fee_amount" schema:"fee_amount"`
+	UserId       int                 `json:"uid" schema:"uid"`
+	AccountId    int                 `json:"account_id" schema:"account_id"`
+	StockId      int                 `json:"stock_id" schema:"stock_id"`
+	InventoryId  int                 `json:"inventory_id" schema:"inventory_id"`
+	Date         string              `json:"date" schema:"date"`
+	Quantity     decimal.Decimal     `json:"quantity" schema:"quantity"`
+	AveragePrice decimal.Decimal     `json:"average_price" schema:"average_price"`
+	FeeAmount    decimal.Decimal     `json:"fee_amount" schema:"fee_amount"`
+	Charges      *ClientTradeCharges `json:"charges" schema:"charges"`
 }
 
 type ClientStockBuyResponse struct {
-	Message string `json:"message" schema:"message"`
+	Message string              `json:"message" schema:"message"`
+	Charges *ClientTradeCharges `json:"charges,omitempty" schema:"charges"`
 }
 
+// ClientStockSellRequest records a sale; the fee is given or worked out as for ClientStockBuyRequest.
 type ClientStockSellRequest struct {
-	UserId       int     `json:"uid" schema:"uid"`
-	AccountId    int     `json:"account_id" schema:"account_id"`
-	StockId      int     `json:"stock_id" schema:"stock_id"`
-	InventoryId  int     `json:"inventory_id" schema:"inventory_id"`
-	Date         string  `json:"date" schema:"date"`
-	Quantity     float64 `json:"quantity" schema:"quantity"`
-	AveragePrice float64 `json:"average_price" schema:"average_price"`
-	FeeAmount    float64 `json:"fee_amount" schema:"fee_amount"`
+	UserId       int                 `json:"uid" schema:"uid"`
+	AccountId    int                 `json:"account_id" schema:"account_id"`
+	StockId      int                 `json:"stock_id" schema:"stock_id"`
+	InventoryId  int                 `json:"inventory_id" schema:"inventory_id"`
+	Date         string              `json:"date" schema:"date"`
+	Quantity     decimal.Decimal     `json:"quantity" schema:"quantity"`
+	AveragePrice decimal.Decimal     `json:"average_price" schema:"average_price"`
+	FeeAmount    decimal.Decimal     `json:"fee_amount" schema:"fee_amount"`
+	Charges      *ClientTradeCharges `json:"charges" schema:"charges"`
 }
 type ClientStockSellResponse struct {
-	Message string `json:"message" schema:"message"`
+	Message   string               `json:"message" schema:"message"`
+	Charges   *ClientTradeCharges  `json:"charges,omitempty" schema:"charges"`
+	LotMethod LotMethod            `json:"lot_method,omitempty" schema:"lot_method"`
+	Lots      []ClientStockSellLot `json:"lots" schema:"lots"`
+}
+
+// ClientStockSellLot is the part of a sell taken from one lot, at the lot's cost.
+type ClientStockSellLot struct {
+	InventoryId  int             `json:"inventory_id" schema:"inventory_id"`
+	Date         string          `json:"date" schema:"date"`
+	Quantity     decimal.Decimal `json:"quantity" schema:"quantity"`
+	AveragePrice decimal.Decimal `json:"average_price" schema:"average_price"`
+	CostValue    decimal.Decimal `json:"cost_value" schema:"cost_value"`
 }
 
+// ClientStockSplitRequest records a split. The ratio is RatioFrom:RatioTo, the shares held to the shares they become
+// (1:5), or the face value before and after (10 to 2). Without either, Quantity is the number of new shares to add.
+// Date is the record date: the lots held at its end are eligible, and the split is recorded on it; it defaults to
+// today.
 type ClientStockSplitRequest struct {
-	UserId    int     `json:"uid" schema:"uid"`
-	AccountId int     `json:"account_id" schema:"account_id"`
-	StockId   int     `json:"stock_id" schema:"stock_id"`
-	Quantity  float64 `json:"quantity" schema:"quantity"`
-	FeeAmount float64 `json:"fee_amount" schema:"fee_amount"`
+	UserId        int             `json:"uid" schema:"uid"`
+	AccountId     int             `json:"account_id" schema:"account_id"`
+	StockId       int             `json:"stock_id" schema:"stock_id"`
+	Date          string          `json:"date" schema:"date"`
+	RatioFrom     decimal.Decimal `json:"ratio_from" schema:"ratio_from"`
+	RatioTo       decimal.Decimal `json:"ratio_to" schema:"ratio_to"`
+	FaceValueFrom decimal.Decimal `json:"face_value_from" schema:"face_value_from"`
+	FaceValueTo   decimal.Decimal `json:"face_value_to" schema:"face_value_to"`
+	Quantity      decimal.Decimal `json:"quantity" schema:"quantity"`
+	FeeAmount     decimal.Decimal `json:"fee_amount" schema:"fee_amount"`
 }
 
+// ClientStockSplitResponse reports the holding on the record date and the new shares it brought. A ratio split
+// entitles the account to whole shares only; Fractional is the part of a share it was entitled to beyond them, which
+// the company settles in cash.
 type ClientStockSplitResponse struct {
-	Message string `json:"message" schema:"message"`
+	Message    string                `json:"message" schema:"message"`
+	Date       string                `json:"date" schema:"date"`
+	Held       decimal.Decimal       `json:"held" schema:"held"`
+	Quantity   decimal.Decimal       `json:"quantity" schema:"quantity"`
+	Fractional decimal.Decimal       `json:"fractional" schema:"fractional"`
+	Lots       []ClientStockSplitLot `json:"lots" schema:"lots"`
+}
+
+// ClientStockSplitLot is a lot held on the record date, the new shares it received and its average price after the
+// split; its cost does not change.
+type ClientStockSplitLot struct {
+	InventoryId  int             `json:"inventory_id" schema:"inventory_id"`
+	Date         string          `json:"date" schema:"date"`
+	Held         decimal.Decimal `json:"held" schema:"held"`
+	Quantity     decimal.Decimal `json:"quantity" schema:"quantity"`
+	AveragePrice decimal.Decimal `json:"average_price" schema:"average_price"`
 }
 
+// ClientStockBonusRequest records a bonus issue of RatioBonus new shares for every RatioHeld shares held or, without
+// a ratio, of Quantity new shares. The shares held before ExDate are eligible. The bonus shares form a lot of their
+// own at no cost, acquired on AllotmentDate, which defaults to the ex-date; the ex-date defaults to today.
 type ClientStockBonusRequest struct {
-	UserId    int     `json:"uid" schema:"uid"`
-	AccountId int     `json:"account_id" schema:"account_id"`
-	StockId   int     `json:"stock_id" schema:"stock_id"`
-	Quantity  float64 `json:"quantity" schema:"quantity"`
-	FeeAmount float64 `json:"fee_amount" schema:"fee_amount"`
+	UserId        int             `json:"uid" schema:"uid"`
+	AccountId     int             `json:"account_id" schema:"account_id"`
+	StockId       int             `json:"stock_id" schema:"stock_id"`
+	ExDate        string          `json:"ex_date" schema:"ex_date"`
+	AllotmentDate string          `json:"allotment_date" schema:"allotment_date"`
+	RatioBonus    decimal.Decimal `json:"ratio_bonus" schema:"ratio_bonus"`
+	RatioHeld     decimal.Decimal `json:"ratio_held" schema:"ratio_held"`
+	Quantity      decimal.Decimal `json:"quantity" schema:"quantity"`
+	FeeAmount     decimal.Decimal `json:"fee_amount" schema:"fee_amount"`
 }
 
+// ClientStockBonusResponse reports the holding the bonus was issued on and the lot of bonus shares. A ratio bonus
+// entitles the account to whole shares only; Fractional is the part of a share beyond them, which the company settles
+// in cash.
 type ClientStockBonusResponse struct {
-	Message string `json:"message" schema:"message"`
+	Message       string          `json:"message" schema:"message"`
+	InventoryId   int             `json:"inventory_id" schema:"inventory_id"`
+	ExDate        string          `json:"ex_date" schema:"ex_date"`
+	AllotmentDate string          `json:"allotment_date" schema:"allotment_date"`
+	Held          decimal.Decimal `json:"held" schema:"held"`
+	Quantity      decimal.Decimal `json:"quantity" schema:"quantity"`
+	Fractional    decimal.Decimal `json:"fractional" schema:"fractional"`
 }
 
 type ClientStockDividendAddRequest struct {
-	UserId            int     `json:"uid" schema:"uid"`
-	AccountId         int     `json:"account_id" schema:"account_id"`
-	StockId           int     `json:"stock_id" schema:"stock_id"`
-	Date              string  `json:"date" schema:"date"`
-	AmountPerQuantity float64 `json:"amount_per_quantity" schema:"amount_per_quantity"`
+	UserId            int             `json:"uid" schema:"uid"`
+	AccountId         int             `json:"account_id" schema:"account_id"`
+	StockId           int             `json:"stock_id" schema:"stock_id"`
+	Date              string          `json:"date" schema:"date"`
+	AmountPerQuantity decimal.Decimal `json:"amount_per_quantity" schema:"amount_per_quantity"`
 }
 
 type ClientStockDividendResponse struct {
@@ -66,14 +131,14 @@ type ClientStockDividendResponse struct {
 }
 
 type ClientStockDemergeRequest struct {
-	UserId           int     `json:"uid" schema:"uid"`
-	AccountId        int     `json:"account_id" schema:"account_id"`
-	ParentStockId    int     `json:"parent_stock_id" schema:"parent_stock_id"`
-	NewStockId       int     `json:"new_stock_id" schema:"new_stock_id"`
-	Quantity         float64 `json:"quantity" schema:"quantity"`
-	Date             string  `json:"date" schema:"date"`
-	ListingPrice     float64 `json:"listing_price" schema:"listing_price"`
-	ParentStockPrice float64 `json:"parent_stock_price" schema:"parent_stock_price"`
+	UserId           int             `json:"uid" schema:"uid"`
+	AccountId        int             `json:"account_id" schema:"account_id"`
+	ParentStockId    int             `json:"parent_stock_id" schema:"parent_stock_id"`
+	NewStockId       int             `json:"new_stock_id" schema:"new_stock_id"`
+	Quantity         decimal.Decimal `json:"quantity" schema:"quantity"`
+	Date             string          `json:"date" schema:"date"`
+	ListingPrice     decimal.Decimal `json:"listing_price" schema:"listing_price"`
+	ParentStockPrice decimal.Decimal `json:"parent_stock_price" schema:"parent_stock_price"`
 }
 
 type ClientStockDemergeResponse struct {
@@ -81,12 +146,12 @@ type ClientStockDemergeResponse struct {
 }
 
 type ClientStockMergeRequest struct {
-	UserId        int     `json:"uid" schema:"uid"`
-	AccountId     int     `json:"account_id" schema:"account_id"`
-	ParentStockId int     `json:"parent_stock_id" schema:"parent_stock_id"`
-	NewStockId    int     `json:"new_stock_id" schema:"new_stock_id"`
-	Quantity      float64 `json:"quantity" schema:"quantity"`
-	Date          string  `json:"date" schema:"date"`
+	UserId        int             `json:"uid" schema:"uid"`
+	AccountId     int             `json:"account_id" schema:"account_id"`
+	ParentStockId int             `json:"parent_stock_id" schema:"parent_stock_id"`
+	NewStockId    int             `json:"new_stock_id" schema:"new_stock_id"`
+	Quantity      decimal.Decimal `json:"quantity" schema:"quantity"`
+	Date          string          `json:"date" schema:"date"`
 }
 
 type ClientStockMergeResponse struct {
@@ -98,16 +163,37 @@ type ClientStockSummaryRequest struct {
 	AccountId int `json:"account_id" schema:"account_id"`
 }
 
+// ClientStockSummaryResponse is a stock held by an account, valued at the market price. The totals of the portfolio
+// are served on their own by StockSummaryTotals.
 type ClientStockSummaryResponse struct {
-	StockId             int     `json:"stock_id" schema:"stock_id"`
-	StockSymbol         string  `json:"stock_symbol" schema:"stock_symbol"`
-	StockExchange       string  `json:"stock_exchange" schema:"stock_exchange"`
-	StockName           string  `json:"stock_name" schema:"stock_name"`
-	Quantity            int     `json:"quantity" schema:"quantity"`
-	Amount              float64 `json:"amount" schema:"amount"`
-	MarketPrice         float64 `json:"market_price" schema:"market_price"`
-	MarketChange        float64 `json:"market_change" schema:"market_change"`
-	MarketChangePercent float64 `json:"market_change_percent" schema:"market_change_percent"`
+	StockId             int             `json:"stock_id" schema:"stock_id"`
+	StockSymbol         string          `json:"stock_symbol" schema:"stock_symbol"`
+	StockExchange       string          `json:"stock_exchange" schema:"stock_exchange"`
+	StockName           string          `json:"stock_name" schema:"stock_name"`
+	Quantity            int             `json:"quantity" schema:"quantity"`
+	Amount              decimal.Decimal `json:"amount" schema:"amount"`
+	MarketPrice         decimal.Decimal `json:"market_price" schema:"market_price"`
+	MarketChange        decimal.Decimal `json:"market_change" schema:"market_change"`
+	MarketChangePercent decimal.Decimal `json:"market_change_percent" schema:"market_change_percent"`
+	Weight              decimal.Decimal `json:"weight" schema:"weight"` // Percentage of the portfolio's current value
+	ClientStockValuation
+}
+
+// ClientStockValuation values a holding at the market price, with the day's change.
+type ClientStockValuation struct {
+	ClientStockValue
+	DayChange        decimal.Decimal `json:"day_change" schema:"day_change"`
+	DayChangePercent decimal.Decimal `json:"day_change_percent" schema:"day_change_percent"`
+}
+
+// ClientStockValue is what a holding cost and what it is worth. A holding without a price is carried at what it
+// cost, with Priced unset; totals are priced only when every holding is.
+type ClientStockValue struct {
+	Priced                bool            `json:"priced" schema:"priced"`
+	InvestedValue         decimal.Decimal `json:"invested_value" schema:"invested_value"`
+	CurrentValue          decimal.Decimal `json:"current_value" schema:"current_value"`
+	UnrealizedGain        decimal.Decimal `json:"unrealized_gain" schema:"unrealized_gain"`
+	UnrealizedGainPercent decimal.Decimal `json:"unrealized_gain_percent" schema:"unrealized_gain_percent"`
 }
 
 type ClientStockInventoriesRequest struct {
@@ -117,13 +203,14 @@ type ClientStockInventoriesRequest struct {
 }
 
 type ClientStockInventoriesResponse struct {
-	InventoryId         int     `json:"inventory_id" schema:"inventory_id"`
-	Quantity            float64 `json:"quantity" schema:"quantity"`
-	Amount              float64 `json:"amount" schema:"amount"`
-	Date                string  `json:"date" schema:"date"`
-	MarketPrice         float64 `json:"market_price" schema:"market_price"`
-	MarketChange        float64 `json:"market_change" schema:"market_change"`
-	MarketChangePercent float64 `json:"market_change_percent" schema:"market_change_percent"`
+	InventoryId         int             `json:"inventory_id" schema:"inventory_id"`
+	Quantity            decimal.Decimal `json:"quantity" schema:"quantity"`
+	Amount              decimal.Decimal `json:"amount" schema:"amount"`
+	Date                string          `json:"date" schema:"date"`
+	MarketPrice         decimal.Decimal `json:"market_price" schema:"market_price"`
+	MarketChange        decimal.Decimal `json:"market_change" schema:"market_change"`
+	MarketChangePercent decimal.Decimal `json:"market_change_percent" schema:"market_change_percent"`
+	ClientStockValuation
 }
 
 type ClientStockInventoryLedgersRequest struct {
@@ -133,12 +220,12 @@ type ClientStockInventoryLedgersRequest struct {
 }
 
 type ClientStockInventoryLedgersResponse struct {
-	LedgerId    int     `json:"ledger_id" schema:"ledger_id"`
-	Type        string  `json:"type" schema:"type"`
-	TotalAmount float64 `json:"total_amount,omitempty" schema:"total_amount"`
-	Quantity    float64 `json:"quantity,omitempty" schema:"quantity"`
-	Amount      float64 `json:"amount,omitempty" schema:"amount"`
-	Date        string  `json:"date" schema:"date"`
+	LedgerId    int             `json:"ledger_id" schema:"ledger_id"`
+	Type        string          `json:"type" schema:"type"`
+	TotalAmount decimal.Decimal `json:"total_amount,omitempty" schema:"total_amount"`
+	Quantity    decimal.Decimal `json:"quantity,omitempty" schema:"quantity"`
+	Amount      decimal.Decimal `json:"amount,omitempty" schema:"amount"`
+	Date        string          `json:"date" schema:"date"`
 }
 type ClientStockDividendsRequest struct {
 	UserId    int `json:"uid" schema:"uid"`
@@ -147,7 +234,532 @@ type ClientStockDividendsRequest struct {
 }
 
 type ClientStockDividendsResponse struct {
-	Quantity int     `json:"quantity" schema:"quantity"`
-	Amount   float64 `json:"amount" schema:"amount"`
-	Date     string  `json:"date" schema:"date"`
+	Quantity int             `json:"quantity" schema:"quantity"`
+	Amount   decimal.Decimal `json:"amount" schema:"amount"`
+	Date     string          `json:"date" schema:"date"`
+}
+
+// ClientStockRebuildRequest selects the inventories to rebuild from their ledgers: those of an account, of a
+// stock, or of both. Without Apply the rebuild is a dry run that only reports the differences.
+type ClientStockRebuildRequest struct {
+	AccountId int  `json:"account_id" schema:"account_id"`
+	StockId   int  `json:"stock_id" schema:"stock_id"`
+	Apply     bool `json:"apply" schema:"apply"`
+}
+
+type ClientStockRebuildResponse struct {
+	Applied     bool                          `json:"applied" schema:"applied"`
+	Checked     int                           `json:"checked" schema:"checked"`
+	Inventories []ClientStockRebuildInventory `json:"inventories" schema:"inventories"`
+}
+
+// ClientStockRebuildInventory is one inventory whose stored running totals differ from its replayed ledger.
+type ClientStockRebuildInventory struct {
+	InventoryId int                      `json:"inventory_id" schema:"inventory_id"`
+	AccountId   int                      `json:"account_id" schema:"account_id"`
+	StockId     int                      `json:"stock_id" schema:"stock_id"`
+	Stored      ClientStockRebuildTotals `json:"stored" schema:"stored"`
+	Rebuilt     ClientStockRebuildTotals `json:"rebuilt" schema:"rebuilt"`
+}
+
+type ClientStockRebuildTotals struct {
+	Quantity     decimal.Decimal `json:"quantity" schema:"quantity"`
+	AveragePrice decimal.Decimal `json:"average_price" schema:"average_price"`
+	TotalValue   decimal.Decimal `json:"total_value" schema:"total_value"`
+}
+
+// ConsistencyIssueType names an invariant broken between inventories, their ledgers and transactions.
+type ConsistencyIssueType string
+
+const (
+	CONSISTENCY_INVENTORY_MISMATCH   ConsistencyIssueType = "INVENTORY_MISMATCH"   // Stored totals differ from the replayed ledger
+	CONSISTENCY_LEDGER_UNREPLAYABLE  ConsistencyIssueType = "LEDGER_UNREPLAYABLE"  // The ledger cannot be replayed at all
+	CONSISTENCY_LEDGER_UNLINKED      ConsistencyIssueType = "LEDGER_UNLINKED"      // Ledger transaction id is unset or refers to no transaction
+	CONSISTENCY_TRANSACTION_MISMATCH ConsistencyIssueType = "TRANSACTION_MISMATCH" // Linked ledger quantities do not add up to the transaction
+	CONSISTENCY_NEGATIVE_QUANTITY    ConsistencyIssueType = "NEGATIVE_QUANTITY"    // An inventory, ledger or transaction quantity is below zero
+	CONSISTENCY_ZERO_QUANTITY_VALUE  ConsistencyIssueType = "ZERO_QUANTITY_VALUE"  // A value is carried on zero quantity, so its average divides by zero
+)
+
+// ClientStockConsistencyRequest selects the account to check; an account id of zero checks every account.
+type ClientStockConsistencyRequest struct {
+	AccountId int `json:"account_id" schema:"account_id"`
+}
+
+type ClientStockConsistencyResponse struct {
+	AccountId           int                           `json:"account_id" schema:"account_id"`
+	Consistent          bool                          `json:"consistent" schema:"consistent"`
+	InventoriesChecked  int                           `json:"inventories_checked" schema:"inventories_checked"`
+	LedgersChecked      int                           `json:"ledgers_checked" schema:"ledgers_checked"`
+	TransactionsChecked int                           `json:"transactions_checked" schema:"transactions_checked"`
+	Issues              []ClientStockConsistencyIssue `json:"issues" schema:"issues"`
+}
+
+// ClientStockConsistencyIssue is one broken invariant, identified by the rows it concerns.
+type ClientStockConsistencyIssue struct {
+	Type          ConsistencyIssueType `json:"type" schema:"type"`
+	AccountId     int                  `json:"account_id,omitempty" schema:"account_id"`
+	StockId       int                  `json:"stock_id,omitempty" schema:"stock_id"`
+	InventoryId   int                  `json:"inventory_id,omitempty" schema:"inventory_id"`
+	LedgerId      int                  `json:"ledger_id,omitempty" schema:"ledger_id"`
+	TransactionId int                  `json:"transaction_id,omitempty" schema:"transaction_id"`
+	Message       string               `json:"message" schema:"message"`
+}
+
+// ClientTransactionVoidRequest voids a buy or sell of an account. Later sells of the same lots, splits and bonuses
+// depend on it; they are voided with it when Cascade is set and make the request fail otherwise.
+type ClientTransactionVoidRequest struct {
+	UserId        int  `json:"uid" schema:"uid"`
+	AccountId     int  `json:"account_id" schema:"account_id"`
+	TransactionId int  `json:"transaction_id" schema:"transaction_id"`
+	Cascade       bool `json:"cascade" schema:"cascade"`
+}
+
+type ClientTransactionVoidResponse struct {
+	Message      string `json:"message" schema:"message"`
+	Transactions []int  `json:"transactions" schema:"transactions"` // Voided transaction IDs, the requested one first
+}
+
+// ClientTransactionUpdateRequest edits a buy, sell or dividend of an account. Fields left out keep their recorded
+// value. A dividend's quantity always follows the holding on its date, so only its price and date can be edited.
+type ClientTransactionUpdateRequest struct {
+	UserId        int              `json:"uid" schema:"uid"`
+	AccountId     int              `json:"account_id" schema:"account_id"`
+	TransactionId int              `json:"transaction_id" schema:"transaction_id"`
+	Date          string           `json:"date" schema:"date"`
+	Quantity      *decimal.Decimal `json:"quantity" schema:"quantity"`
+	AveragePrice  *decimal.Decimal `json:"average_price" schema:"average_price"`
+	FeeAmount     *decimal.Decimal `json:"fee_amount" schema:"fee_amount"`
+}
+
+// ClientTransactionUpdateResponse lists every transaction, ledger entry and inventory the edit changed.
+type ClientTransactionUpdateResponse struct {
+	Message      string                               `json:"message" schema:"message"`
+	Transactions []ClientTransactionUpdateTransaction `json:"transactions" schema:"transactions"`
+	Ledgers      []ClientTransactionUpdateLedger      `json:"ledgers" schema:"ledgers"`
+	Inventories  []ClientTransactionUpdateInventory   `json:"inventories" schema:"inventories"`
+}
+
+type ClientTransactionUpdateTransaction struct {
+	TransactionId int                           `json:"transaction_id" schema:"transaction_id"`
+	Type          TransactionType               `json:"type" schema:"type"`
+	Before        ClientTransactionUpdateValues `json:"before" schema:"before"`
+	After         ClientTransactionUpdateValues `json:"after" schema:"after"`
+}
+
+type ClientTransactionUpdateValues struct {
+	Quantity     decimal.Decimal `json:"quantity" schema:"quantity"`
+	AveragePrice decimal.Decimal `json:"average_price" schema:"average_price"`
+	TotalValue   decimal.Decimal `json:"total_value" schema:"total_value"`
+	Fee          decimal.Decimal `json:"fee" schema:"fee"`
+	Date         string          `json:"date" schema:"date"`
+}
+
+// LedgerChange names what an edit did to a ledger entry.
+type LedgerChange string
+
+const (
+	LEDGER_INSERTED LedgerChange = "INSERTED"
+	LEDGER_UPDATED  LedgerChange = "UPDATED"
+	LEDGER_DELETED  LedgerChange = "DELETED"
+)
+
+// ClientTransactionUpdateLedger is one ledger entry written, rewritten or removed by an edit. The before values
+// are zero for inserted entries and the after values are zero for deleted ones.
+type ClientTransactionUpdateLedger struct {
+	LedgerId       int             `json:"ledger_id" schema:"ledger_id"`
+	InventoryId    int             `json:"inventory_id" schema:"inventory_id"`
+	TransactionId  int             `json:"transaction_id" schema:"transaction_id"`
+	Type           TransactionType `json:"type" schema:"type"`
+	Change         LedgerChange    `json:"change" schema:"change"`
+	QuantityBefore decimal.Decimal `json:"quantity_before" schema:"quantity_before"`
+	QuantityAfter  decimal.Decimal `json:"quantity_after" schema:"quantity_after"`
+}
+
+type ClientTransactionUpdateInventory struct {
+	InventoryId int                      `json:"inventory_id" schema:"inventory_id"`
+	Before      ClientStockRebuildTotals `json:"before" schema:"before"`
+	After       ClientStockRebuildTotals `json:"after" schema:"after"`
+}
+
+// ClientStockRealizedRequest selects the sells of an account to report; StockId, From and To are optional.
+type ClientStockRealizedRequest struct {
+	UserId    int    `json:"uid" schema:"uid"`
+	AccountId int    `json:"account_id" schema:"account_id"`
+	StockId   int    `json:"stock_id" schema:"stock_id"`
+	From      string `json:"from" schema:"from"`
+	To        string `json:"to" schema:"to"`
+}
+
+// ClientStockRealizedResponse is the realized profit and loss of an account, in total and per stock.
+type ClientStockRealizedResponse struct {
+	Totals ClientStockRealizedTotals  `json:"totals" schema:"totals"`
+	Stocks []ClientStockRealizedStock `json:"stocks" schema:"stocks"`
+}
+
+type ClientStockRealizedTotals struct {
+	Quantity  decimal.Decimal `json:"quantity" schema:"quantity"`
+	CostValue decimal.Decimal `json:"cost_value" schema:"cost_value"`
+	Proceeds  decimal.Decimal `json:"proceeds" schema:"proceeds"`
+	Fee       decimal.Decimal `json:"fee" schema:"fee"`
+	Gain      decimal.Decimal `json:"gain" schema:"gain"`
+}
+
+type ClientStockRealizedStock struct {
+	StockId     int                       `json:"stock_id" schema:"stock_id"`
+	StockSymbol string                    `json:"stock_symbol" schema:"stock_symbol"`
+	StockName   string                    `json:"stock_name" schema:"stock_name"`
+	Totals      ClientStockRealizedTotals `json:"totals" schema:"totals"`
+	Lots        []ClientStockRealizedLot  `json:"lots" schema:"lots"`
+}
+
+// ClientStockRealizedLot is the part of one lot consumed by one sell.
+type ClientStockRealizedLot struct {
+	TransactionId   int             `json:"transaction_id" schema:"transaction_id"`
+	InventoryId     int             `json:"inventory_id" schema:"inventory_id"`
+	Date            string          `json:"date" schema:"date"`
+	AcquisitionDate string          `json:"acquisition_date" schema:"acquisition_date"`
+	Quantity        decimal.Decimal `json:"quantity" schema:"quantity"`
+	CostPrice       decimal.Decimal `json:"cost_price" schema:"cost_price"`
+	CostValue       decimal.Decimal `json:"cost_value" schema:"cost_value"`
+	SalePrice       decimal.Decimal `json:"sale_price" schema:"sale_price"`
+	Proceeds        decimal.Decimal `json:"proceeds" schema:"proceeds"`
+	Fee             decimal.Decimal `json:"fee" schema:"fee"`
+	Gain            decimal.Decimal `json:"gain" schema:"gain"`
+}
+
+// CapitalGainTerm classifies a realized gain by how long the shares were held.
+type CapitalGainTerm string
+
+const (
+	CAPITAL_GAIN_SHORT_TERM CapitalGainTerm = "SHORT_TERM"
+	CAPITAL_GAIN_LONG_TERM  CapitalGainTerm = "LONG_TERM"
+)
+
+// BuybackTaxTreatment is how the proceeds of a buyback are taxed in the shareholder's hands.
+type BuybackTaxTreatment string
+
+const (
+	// BUYBACK_TAX_EXEMPT applies to buybacks before 1 October 2024, taxed in the company's hands (section 10(34A)).
+	BUYBACK_TAX_EXEMPT BuybackTaxTreatment = "EXEMPT"
+
+	// BUYBACK_TAX_DIVIDEND applies from 1 October 2024: the proceeds are a deemed dividend and the cost of the shares
+	// bought back is a capital loss.
+	BUYBACK_TAX_DIVIDEND BuybackTaxTreatment = "DIVIDEND"
+)
+
+// ClientStockCapitalGainsRequest selects the Indian financial year to report. FinancialYear is the calendar year it
+// starts in, 2024 for April 2024 to March 2025, and defaults to the current one. FairMarketValues carries the price of
+// each stock on 31 January 2018, used to grandfather the cost of older equity lots.
+type ClientStockCapitalGainsRequest struct {
+	UserId           int                          `json:"uid" schema:"uid"`
+	AccountId        int                          `json:"account_id" schema:"account_id"`
+	FinancialYear    int                          `json:"financial_year" schema:"financial_year"`
+	FairMarketValues []ClientStockFairMarketValue `json:"fair_market_values" schema:"fair_market_values"`
+}
+
+type ClientStockFairMarketValue struct {
+	StockId int             `json:"stock_id" schema:"stock_id"`
+	Price   decimal.Decimal `json:"price" schema:"price"`
+}
+
+// ClientStockCapitalGainsResponse is the capital gains of an account for one financial year, summarised by asset
+// class and holding period, with every realized gain behind the summary.
+type ClientStockCapitalGainsResponse struct {
+	FinancialYear string                         `json:"financial_year" schema:"financial_year"`
+	From          string                         `json:"from" schema:"from"`
+	To            string                         `json:"to" schema:"to"`
+	Summary       ClientStockCapitalGainsSummary `json:"summary" schema:"summary"`
+	Gains         []ClientStockCapitalGain       `json:"gains" schema:"gains"`
+}
+
+// ClientStockCapitalGainsSummary nets the gains of each bucket. The exemption applies to net long-term gains on
+// equity; set-off of losses across buckets is left to the return. BuybackDividend is the proceeds of buybacks taxed
+// as dividend, whose cost is counted as a loss in the buckets; ExemptBuyback is the gain on exempt buybacks, which is
+// left out of them.
+type ClientStockCapitalGainsSummary struct {
+	EquityShortTerm   ClientStockRealizedTotals `json:"equity_short_term" schema:"equity_short_term"`
+	EquityLongTerm    ClientStockRealizedTotals `json:"equity_long_term" schema:"equity_long_term"`
+	DebtShortTerm     ClientStockRealizedTotals `json:"debt_short_term" schema:"debt_short_term"`
+	DebtLongTerm      ClientStockRealizedTotals `json:"debt_long_term" schema:"debt_long_term"`
+	LongTermExemption decimal.Decimal           `json:"long_term_exemption" schema:"long_term_exemption"`
+	ExemptLongTerm    decimal.Decimal           `json:"exempt_long_term" schema:"exempt_long_term"`
+	TaxableLongTerm   decimal.Decimal           `json:"taxable_long_term" schema:"taxable_long_term"`
+	BuybackDividend   decimal.Decimal           `json:"buyback_dividend" schema:"buyback_dividend"`
+	ExemptBuyback     decimal.Decimal           `json:"exempt_buyback" schema:"exempt_buyback"`
+}
+
+// ClientStockCapitalGain is the gain realized on one lot by one sell. CostValue is the cost of acquisition used for
+// the gain; when Grandfathered is set it is the higher of the actual cost and the cost from FairMarketValue. Buyback
+// is set when the shares were bought back by the company; a buyback taxed as dividend has no proceeds here.
+type ClientStockCapitalGain struct {
+	TransactionId   int                 `json:"transaction_id" schema:"transaction_id"`
+	InventoryId     int                 `json:"inventory_id" schema:"inventory_id"`
+	StockId         int                 `json:"stock_id" schema:"stock_id"`
+	StockSymbol     string              `json:"stock_symbol" schema:"stock_symbol"`
+	StockName       string              `json:"stock_name" schema:"stock_name"`
+	AssetClass      string              `json:"asset_class" schema:"asset_class"`
+	Term            CapitalGainTerm     `json:"term" schema:"term"`
+	HoldingDays     int                 `json:"holding_days" schema:"holding_days"`
+	AcquisitionDate string              `json:"acquisition_date" schema:"acquisition_date"`
+	Date            string              `json:"date" schema:"date"`
+	Quantity        decimal.Decimal     `json:"quantity" schema:"quantity"`
+	Proceeds        decimal.Decimal     `json:"proceeds" schema:"proceeds"`
+	ActualCost      decimal.Decimal     `json:"actual_cost" schema:"actual_cost"`
+	FairMarketValue decimal.Decimal     `json:"fair_market_value,omitempty" schema:"fair_market_value"`
+	Grandfathered   bool                `json:"grandfathered" schema:"grandfathered"`
+	CostValue       decimal.Decimal     `json:"cost_value" schema:"cost_value"`
+	Fee             decimal.Decimal     `json:"fee" schema:"fee"`
+	Gain            decimal.Decimal     `json:"gain" schema:"gain"`
+	Buyback         BuybackTaxTreatment `json:"buyback,omitempty" schema:"buyback"`
+}
+
+// ClientStockReturnsRequest selects whose returns to report: one account when AccountId is set and every account of
+// the user otherwise. StockId, when set, limits the report to one stock.
+type ClientStockReturnsRequest struct {
+	UserId    int `json:"uid" schema:"uid"`
+	AccountId int `json:"account_id" schema:"account_id"`
+	StockId   int `json:"stock_id" schema:"stock_id"`
+}
+
+// ClientStockReturnsResponse is the return on the reported accounts together, per account and per stock, valued on
+// Date at the market price.
+type ClientStockReturnsResponse struct {
+	Date     string                      `json:"date" schema:"date"`
+	Totals   ClientStockReturns          `json:"totals" schema:"totals"`
+	Accounts []ClientStockReturnsAccount `json:"accounts" schema:"accounts"`
+}
+
+type ClientStockReturnsAccount struct {
+	AccountId   int                       `json:"account_id" schema:"account_id"`
+	AccountName string                    `json:"account_name" schema:"account_name"`
+	Stocks      []ClientStockReturnsStock `json:"stocks" schema:"stocks"`
+	ClientStockReturns
+}
+
+type ClientStockReturnsStock struct {
+	StockId     int    `json:"stock_id" schema:"stock_id"`
+	StockSymbol string `json:"stock_symbol" schema:"stock_symbol"`
+	StockName   string `json:"stock_name" schema:"stock_name"`
+	ClientStockReturns
+}
+
+// ClientStockReturns is the money-weighted and the compound annual return of a set of cash flows. Invested is the cash
+// paid for buys and Received the cash from sells and dividends, both net of fees; for a single stock they also carry
+// the cost moved in or out by a merger or demerger, which is not cash for the account. XIRR and CAGR are annual
+// percentages and null when they cannot be computed. Holdings without a quote are valued at cost and leave Priced
+// unset.
+type ClientStockReturns struct {
+	Priced       bool             `json:"priced" schema:"priced"`
+	StartDate    string           `json:"start_date" schema:"start_date"`
+	Invested     decimal.Decimal  `json:"invested" schema:"invested"`
+	Received     decimal.Decimal  `json:"received" schema:"received"`
+	CurrentValue decimal.Decimal  `json:"current_value" schema:"current_value"`
+	Gain         decimal.Decimal  `json:"gain" schema:"gain"`
+	XIRR         *decimal.Decimal `json:"xirr" schema:"xirr"`
+	CAGR         *decimal.Decimal `json:"cagr" schema:"cagr"`
+}
+
+// ClientStockHoldingsRequest selects the account and the day whose holdings to reconstruct; StockId is optional.
+type ClientStockHoldingsRequest struct {
+	UserId    int    `json:"uid" schema:"uid"`
+	AccountId int    `json:"account_id" schema:"account_id"`
+	StockId   int    `json:"stock_id" schema:"stock_id"`
+	Date      string `json:"date" schema:"date"`
+}
+
+// ClientStockHoldingsResponse is what an account held at the end of Date, replayed from the inventory ledgers and
+// valued at that day's close where one is known; a holding without a close is carried at cost.
+type ClientStockHoldingsResponse struct {
+	Date   string                     `json:"date" schema:"date"`
+	Totals ClientStockValue           `json:"totals" schema:"totals"`
+	Stocks []ClientStockHoldingsStock `json:"stocks" schema:"stocks"`
+}
+
+type ClientStockHoldingsStock struct {
+	StockId      int                      `json:"stock_id" schema:"stock_id"`
+	StockSymbol  string                   `json:"stock_symbol" schema:"stock_symbol"`
+	StockName    string                   `json:"stock_name" schema:"stock_name"`
+	Quantity     decimal.Decimal          `json:"quantity" schema:"quantity"`
+	AveragePrice decimal.Decimal          `json:"average_price" schema:"average_price"`
+	ClosePrice   decimal.Decimal          `json:"close_price" schema:"close_price"`
+	Lots         []ClientStockHoldingsLot `json:"lots" schema:"lots"`
+	ClientStockValue
+}
+
+// ClientStockHoldingsLot is one inventory as it stood on the day.
+type ClientStockHoldingsLot struct {
+	InventoryId  int             `json:"inventory_id" schema:"inventory_id"`
+	Date         string          `json:"date" schema:"date"`
+	Quantity     decimal.Decimal `json:"quantity" schema:"quantity"`
+	AveragePrice decimal.Decimal `json:"average_price" schema:"average_price"`
+	TotalValue   decimal.Decimal `json:"total_value" schema:"total_value"`
+}
+
+// ChargeRates is the rate table of one exchange. Rates are percentages of the trade value; BrokerageMax caps the
+// brokerage of a trade when it is positive. GST is charged on the brokerage, the exchange fee and the SEBI fee.
+type ChargeRates struct {
+	BrokeragePercent decimal.Decimal
+	BrokerageMax     decimal.Decimal
+	SttBuyPercent    decimal.Decimal
+	SttSellPercent   decimal.Decimal
+	ExchangePercent  decimal.Decimal
+	SebiPercent      decimal.Decimal
+	StampBuyPercent  decimal.Decimal
+	GstPercent       decimal.Decimal
+}
+
+// ClientTradeCharges itemises the charges of a trade. Total is the sum of the items; it is worked out, never read.
+type ClientTradeCharges struct {
+	Brokerage   decimal.Decimal `json:"brokerage" schema:"brokerage"`
+	Stt         decimal.Decimal `json:"stt" schema:"stt"`
+	ExchangeFee decimal.Decimal `json:"exchange_fee" schema:"exchange_fee"`
+	SebiFee     decimal.Decimal `json:"sebi_fee" schema:"sebi_fee"`
+	StampDuty   decimal.Decimal `json:"stamp_duty" schema:"stamp_duty"`
+	Gst         decimal.Decimal `json:"gst" schema:"gst"`
+	Total       decimal.Decimal `json:"total" schema:"-"`
+}
+
+// ClientStockChargesCalculateRequest prices a prospective buy or sell of a stock; Type is BUY or SELL.
+type ClientStockChargesCalculateRequest struct {
+	UserId   int             `json:"uid" schema:"uid"`
+	StockId  int             `json:"stock_id" schema:"stock_id"`
+	Type     string          `json:"type" schema:"type"`
+	Quantity decimal.Decimal `json:"quantity" schema:"quantity"`
+	Price    decimal.Decimal `json:"price" schema:"price"`
+}
+
+// ClientStockChargesCalculateResponse is the trade value of a prospective trade and the charges on it.
+type ClientStockChargesCalculateResponse struct {
+	Exchange   string             `json:"exchange" schema:"exchange"`
+	Type       string             `json:"type" schema:"type"`
+	TradeValue decimal.Decimal    `json:"trade_value" schema:"trade_value"`
+	Charges    ClientTradeCharges `json:"charges" schema:"charges"`
+}
+
+// ClientStockChargesRequest selects the account whose charges to report; FinancialYear is the calendar year the
+// Indian financial year starts in and zero reports every year.
+type ClientStockChargesRequest struct {
+	UserId        int `json:"uid" schema:"uid"`
+	AccountId     int `json:"account_id" schema:"account_id"`
+	FinancialYear int `json:"financial_year" schema:"financial_year"`
+}
+
+// ClientStockChargesResponse is the charges an account paid, in total and per financial year.
+type ClientStockChargesResponse struct {
+	Totals ClientStockChargesYear   `json:"totals" schema:"totals"`
+	Years  []ClientStockChargesYear `json:"years" schema:"years"`
+}
+
+// ClientStockChargesYear sums the itemised charges of the transactions recorded in a financial year. Fees recorded
+// only as a total are counted as Unitemised, and Total includes them.
+type ClientStockChargesYear struct {
+	FinancialYear string `json:"financial_year,omitempty" schema:"financial_year"`
+	Transactions  int    `json:"transactions" schema:"transactions"`
+	ClientTradeCharges
+	Unitemised decimal.Decimal `json:"unitemised" schema:"unitemised"`
+}
+
+// ClientStockRightsEntitleRequest records a rights issue of RatioRights shares at IssuePrice for every RatioHeld shares
+// held at the end of RecordDate, which defaults to today. ApplicationPrice is the part of the issue price payable on
+// subscription; it defaults to the issue price, leaving the shares fully paid.
+type ClientStockRightsEntitleRequest struct {
+	UserId           int             `json:"uid" schema:"uid"`
+	AccountId        int             `json:"account_id" schema:"account_id"`
+	StockId          int             `json:"stock_id" schema:"stock_id"`
+	RecordDate       string          `json:"record_date" schema:"record_date"`
+	RatioRights      decimal.Decimal `json:"ratio_rights" schema:"ratio_rights"`
+	RatioHeld        decimal.Decimal `json:"ratio_held" schema:"ratio_held"`
+	IssuePrice       decimal.Decimal `json:"issue_price" schema:"issue_price"`
+	ApplicationPrice decimal.Decimal `json:"application_price" schema:"application_price"`
+}
+
+// ClientStockRightsEntitleResponse reports the holding on the record date and the whole entitlements it brought;
+// Fractional is the part of an entitlement beyond them, which is not offered.
+type ClientStockRightsEntitleResponse struct {
+	Message       string          `json:"message" schema:"message"`
+	RightsIssueId int             `json:"rights_issue_id" schema:"rights_issue_id"`
+	RecordDate    string          `json:"record_date" schema:"record_date"`
+	Held          decimal.Decimal `json:"held" schema:"held"`
+	Entitled      decimal.Decimal `json:"entitled" schema:"entitled"`
+	Fractional    decimal.Decimal `json:"fractional" schema:"fractional"`
+}
+
+// ClientStockRightsRenounceRequest records the sale of Quantity rights entitlements at Price each, or their
+// renunciation for nothing when Price is zero. Date defaults to today.
+type ClientStockRightsRenounceRequest struct {
+	UserId        int             `json:"uid" schema:"uid"`
+	AccountId     int             `json:"account_id" schema:"account_id"`
+	RightsIssueId int             `json:"rights_issue_id" schema:"rights_issue_id"`
+	Date          string          `json:"date" schema:"date"`
+	Quantity      decimal.Decimal `json:"quantity" schema:"quantity"`
+	Price         decimal.Decimal `json:"price" schema:"price"`
+	FeeAmount     decimal.Decimal `json:"fee_amount" schema:"fee_amount"`
+}
+
+// ClientStockRightsSubscribeRequest takes up Quantity rights entitlements; the rights shares are allotted on Date,
+// which defaults to today.
+type ClientStockRightsSubscribeRequest struct {
+	UserId        int             `json:"uid" schema:"uid"`
+	AccountId     int             `json:"account_id" schema:"account_id"`
+	RightsIssueId int             `json:"rights_issue_id" schema:"rights_issue_id"`
+	Date          string          `json:"date" schema:"date"`
+	Quantity      decimal.Decimal `json:"quantity" schema:"quantity"`
+	FeeAmount     decimal.Decimal `json:"fee_amount" schema:"fee_amount"`
+}
+
+// ClientStockRightsCallRequest records a call of CallPrice per share on the partly paid shares of a rights issue,
+// paid on Date, which defaults to today.
+type ClientStockRightsCallRequest struct {
+	UserId        int             `json:"uid" schema:"uid"`
+	AccountId     int             `json:"account_id" schema:"account_id"`
+	RightsIssueId int             `json:"rights_issue_id" schema:"rights_issue_id"`
+	Date          string          `json:"date" schema:"date"`
+	CallPrice     decimal.Decimal `json:"call_price" schema:"call_price"`
+	FeeAmount     decimal.Decimal `json:"fee_amount" schema:"fee_amount"`
+}
+
+// ClientStockRightsResponse reports a rights issue after a renunciation, subscription or call. PaidPrice is what has
+// been paid per rights share so far; the shares are partly paid while it is below IssuePrice.
+type ClientStockRightsResponse struct {
+	Message       string          `json:"message" schema:"message"`
+	RightsIssueId int             `json:"rights_issue_id" schema:"rights_issue_id"`
+	InventoryId   int             `json:"inventory_id" schema:"inventory_id"`
+	Entitled      decimal.Decimal `json:"entitled" schema:"entitled"`
+	Renounced     decimal.Decimal `json:"renounced" schema:"renounced"`
+	Subscribed    decimal.Decimal `json:"subscribed" schema:"subscribed"`
+	Unused        decimal.Decimal `json:"unused" schema:"unused"`
+	IssuePrice    decimal.Decimal `json:"issue_price" schema:"issue_price"`
+	PaidPrice     decimal.Decimal `json:"paid_price" schema:"paid_price"`
+	PartlyPaid    bool            `json:"partly_paid" schema:"partly_paid"`
+}
+
+// ClientStockBuybackRequest records a buyback or tender offer at OfferPrice per share: Tendered shares were offered
+// and Accepted of them bought back on Date, which defaults to today. The accepted shares are taken from the lots by
+// LotMethod, or by the account's lot method when it is not set; the rest are returned and stay in their lots.
+type ClientStockBuybackRequest struct {
+	UserId     int             `json:"uid" schema:"uid"`
+	AccountId  int             `json:"account_id" schema:"account_id"`
+	StockId    int             `json:"stock_id" schema:"stock_id"`
+	Date       string          `json:"date" schema:"date"`
+	OfferPrice decimal.Decimal `json:"offer_price" schema:"offer_price"`
+	Tendered   decimal.Decimal `json:"tendered" schema:"tendered"`
+	Accepted   decimal.Decimal `json:"accepted" schema:"accepted"`
+	LotMethod  LotMethod       `json:"lot_method" schema:"lot_method"`
+	FeeAmount  decimal.Decimal `json:"fee_amount" schema:"fee_amount"`
+}
+
+// ClientStockBuybackResponse reports a buyback: the shares accepted and returned, the acceptance ratio as a percent
+// of the shares tendered, the proceeds, how they are taxed and the lots the accepted shares were taken from.
+type ClientStockBuybackResponse struct {
+	Message         string               `json:"message" schema:"message"`
+	TransactionId   int                  `json:"transaction_id" schema:"transaction_id"`
+	Date            string               `json:"date" schema:"date"`
+	Tendered        decimal.Decimal      `json:"tendered" schema:"tendered"`
+	Accepted        decimal.Decimal      `json:"accepted" schema:"accepted"`
+	Returned        decimal.Decimal      `json:"returned" schema:"returned"`
+	AcceptanceRatio decimal.Decimal      `json:"acceptance_ratio" schema:"acceptance_ratio"`
+	Proceeds        decimal.Decimal      `json:"proceeds" schema:"proceeds"`
+	TaxTreatment    BuybackTaxTreatment  `json:"tax_treatment" schema:"tax_treatment"`
+	LotMethod       LotMethod            `json:"lot_method" schema:"lot_method"`
+	Lots            []ClientStockSellLot `json:"lots" schema:"lots"`
 }
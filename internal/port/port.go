@@ -5,6 +5,8 @@ import (
 	"context"
 	"net/http"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 // Handler defines the interface for the various API handler methods for account, security, and stock management
@@ -34,8 +36,24 @@ type Handler interface {
 	StockDividendAdd(w http.ResponseWriter, r *http.Request)      // Adds a dividend for a specific stock
 	StockDividends(w http.ResponseWriter, r *http.Request)        // list of dividend for a specific stock
 	StockSummary(w http.ResponseWriter, r *http.Request)          // Retrieves a summary of a user's stock holdings
+	StockSummaryTotals(w http.ResponseWriter, r *http.Request)    // Retrieves the totals of a user's stock portfolio
 	StockInventories(w http.ResponseWriter, r *http.Request)      // Retrieves the stock inventory (holdings) for a user
 	StockInventoryLedgers(w http.ResponseWriter, r *http.Request) // Retrieves the inventory ledger for stock transactions
+	TransactionVoid(w http.ResponseWriter, r *http.Request)       // Voids a recorded buy, sell or buyback
+	TransactionUpdate(w http.ResponseWriter, r *http.Request)     // Edits a recorded buy, sell or dividend
+	StockRealized(w http.ResponseWriter, r *http.Request)         // Retrieves the realized profit and loss of an account
+	StockCapitalGains(w http.ResponseWriter, r *http.Request)     // Retrieves the capital gains of an account for a financial year
+	StockReturns(w http.ResponseWriter, r *http.Request)          // Retrieves the XIRR and CAGR returns of a user's stocks
+	StockHoldings(w http.ResponseWriter, r *http.Request)         // Retrieves the holdings of an account at the end of a past day
+	StockChargesCalculate(w http.ResponseWriter, r *http.Request) // Calculates the itemised charges of a prospective buy or sell
+	StockCharges(w http.ResponseWriter, r *http.Request)          // Retrieves the charges an account paid per financial year
+	StockRightsEntitle(w http.ResponseWriter, r *http.Request)    // Records the rights entitlements of a holding on a record date
+	StockRightsRenounce(w http.ResponseWriter, r *http.Request)   // Records the sale or renunciation of rights entitlements
+	StockRightsSubscribe(w http.ResponseWriter, r *http.Request)  // Subscribes rights shares as a lot of their own
+	StockRightsCall(w http.ResponseWriter, r *http.Request)       // Records a call paid on partly paid rights shares
+	StockBuyback(w http.ResponseWriter, r *http.Request)          // Records a buyback or tender offer
+	StockRebuild(w http.ResponseWriter, r *http.Request)          // Rebuilds inventories from their ledgers (admin)
+	StockConsistency(w http.ResponseWriter, r *http.Request)      // Reports inconsistent inventories, ledgers and transactions (admin)
 }
 
 // Validator defines the interface for validating the different requests for account, security, stock
@@ -67,12 +85,36 @@ type Validator interface {
 	StockInventories(request domain.ClientStockInventoriesRequest) error           // Validates request for stock inventories
 	StockInventoryLedgers(request domain.ClientStockInventoryLedgersRequest) error // Validates request for stock inventory ledgers
 	StockDividends(request domain.ClientStockDividendsRequest) error
+	StockRebuild(request domain.ClientStockRebuildRequest) error                   // Validates inventory rebuild request
+	StockConsistency(request domain.ClientStockConsistencyRequest) error           // Validates consistency check request
+	TransactionVoid(request domain.ClientTransactionVoidRequest) error             // Validates transaction void request
+	TransactionUpdate(request domain.ClientTransactionUpdateRequest) error         // Validates transaction update request
+	StockRealized(request domain.ClientStockRealizedRequest) error                 // Validates realized profit and loss request
+	StockCapitalGains(request domain.ClientStockCapitalGainsRequest) error         // Validates capital gains request
+	StockReturns(request domain.ClientStockReturnsRequest) error                   // Validates returns request
+	StockHoldings(request domain.ClientStockHoldingsRequest) error                 // Validates holdings request
+	StockChargesCalculate(request domain.ClientStockChargesCalculateRequest) error // Validates charges calculator request
+	StockCharges(request domain.ClientStockChargesRequest) error                   // Validates charges report request
+	StockRightsEntitle(request domain.ClientStockRightsEntitleRequest) error       // Validates rights entitlement request
+	StockRightsRenounce(request domain.ClientStockRightsRenounceRequest) error     // Validates rights renunciation request
+	StockRightsSubscribe(request domain.ClientStockRightsSubscribeRequest) error   // Validates rights subscription request
+	StockRightsCall(request domain.ClientStockRightsCallRequest) error             // Validates rights call request
+	StockBuyback(request domain.ClientStockBuybackRequest) error                   // Validates buyback request
 }
 
 // RepositoryStore defines the interface for interacting with the database to store and retrieve various entities like accounts, securities, transactions, etc.
 type RepositoryStore interface {
+	// WithTx runs fn inside a single database transaction. The RepositoryStore handed to fn is bound to that
+	// transaction; the transaction is committed when fn returns nil and rolled back when it returns an error.
+	WithTx(ctx context.Context, fn func(repo RepositoryStore) error) error
+
+	// Schema migrations
+	MigrateUp(ctx context.Context) ([]domain.SchemaMigration, error)          // Applies every pending migration and returns the ones applied
+	MigrateDown(ctx context.Context) (domain.SchemaMigration, error)          // Reverts the most recently applied migration
+	MigrationStatus(ctx context.Context) ([]domain.SchemaMigration, error)    // Lists every known migration and whether it is applied
+	SchemaVersion(ctx context.Context) (current int, expected int, err error) // Returns the applied schema version and the one this binary requires
+
 	// Account-related database interactions
-	AutoMigrate()                                                                                        // Automatically migrate the database schema
 	InsertAccountData(ctx context.Context, accountData domain.Accounts) (domain.Accounts, error)         // Inserts new account data
 	GetAccountDataByIdAndUserId(ctx context.Context, accountId int, userId int) (domain.Accounts, error) // Retrieves account data by account ID and user ID
 	GetAccountsData(ctx context.Context, userId int) ([]domain.Accounts, error)                          // Retrieves all accounts for a user
@@ -85,13 +127,14 @@ type RepositoryStore interface {
 	UpdateSecurityData(ctx context.Context, securityId int, securityData domain.Securities) error                                 // Updates an existing security
 	GetSecuritiesDataByType(ctx context.Context, types int) ([]domain.Securities, error)                                          // Retrieves securities data by exchange
 	SearchSecuritiesDataByTypeAndExchange(ctx context.Context, types, exchange int, search string) ([]domain.Securities, error)   // Searches for securities by type, exchange, and search term
+	GetSecuritiesData(ctx context.Context) ([]domain.Securities, error)                                                           // Retrieves every security, ordered by id
 
 	// Inventory-related database interactions
-	InsertInventoryLedger(ctx context.Context, inventoryLedgerData domain.InventoryLedger) (domain.InventoryLedger, error)      // Inserts new inventory ledger data
-	UpdateInventoryDetailsById(ctx context.Context, inventoryId int, availableQuantity, averagePrice, totalValue float64) error // Updates an inventory by ID
-	InsertTransaction(ctx context.Context, transactionData domain.Transactions) (domain.Transactions, error)                    // Inserts a new transaction
-	UpdateInventoryLedgerTransactionIdById(ctx context.Context, ledgerId, transactionId int) error                              // Updates inventory ledger with a transaction ID
-	UpdateInventoryLedgerTransactionIdByIds(ctx context.Context, ledgerIds []int, transactionId int) error                      // Updates inventory ledgers with a transaction ID
+	InsertInventoryLedger(ctx context.Context, inventoryLedgerData domain.InventoryLedger) (domain.InventoryLedger, error)                       // Inserts new inventory ledger data
+	UpdateInventoryDetailsById(ctx context.Context, inventoryId, version int, availableQuantity, averagePrice, totalValue decimal.Decimal) error // Updates an inventory by ID if it is still at version; otherwise returns domain.ErrInventoryConflict
+	InsertTransaction(ctx context.Context, transactionData domain.Transactions) (domain.Transactions, error)                                     // Inserts a new transaction
+	UpdateInventoryLedgerTransactionIdById(ctx context.Context, ledgerId, transactionId int) error                                               // Updates inventory ledger with a transaction ID
+	UpdateInventoryLedgerTransactionIdByIds(ctx context.Context, ledgerIds []int, transactionId int) error                                       // Updates inventory ledgers with a transaction ID
 
 	// Additional inventory-related database interactions
 	GetInvertriesSummaryByAccountIdAndSecurityType(ctx context.Context, accountId, securityType int) ([]domain.InventorySummary, error) // Retrieves inventory summary by account ID and security type
@@ -101,12 +144,47 @@ type RepositoryStore interface {
 	InsertTransactionData(ctx context.Context, transactionData domain.Transactions) (domain.Transactions, error)               // Inserts new transaction data
 	InsertInventoryData(ctx context.Context, inventoryData domain.Inventories) (domain.Inventories, error)                     // Inserts new inventory data
 	GetInventoryDataById(ctx context.Context, inventoryId int) (domain.Inventories, error)                                     // Retrieves inventory data by ID
-	UpdateAvailableQuanityToInventoryById(ctx context.Context, inventoryId int, quantity float64) error                        // Updates the available quantity of inventory by ID
+	UpdateAvailableQuanityToInventoryById(ctx context.Context, inventoryId int, quantity decimal.Decimal) error                // Updates the available quantity of inventory by ID
 	GetActiveInventoriesByAccountIdAndSecurityId(ctx context.Context, accountId, securityId int) ([]domain.Inventories, error) // Retrieves active inventories for an account and security
+	GetInventoriesByAccountIdOrSecurityId(ctx context.Context, accountId, securityId int) ([]domain.Inventories, error)        // Retrieves every inventory, including closed ones, of an account and/or security; a zero id matches any
 	GetInventoryLedgersByInventoryId(ctx context.Context, inventoryId int) ([]domain.InventoryLedgers, error)                  // Retrieves inventory ledgers by inventory and account ID
-	GetInventoryAvailableQuanitityBySecurityIdAndDate(ctx context.Context, accountId, securityId int, date time.Time) (float64, error)
+	GetInventoryAvailableQuanitityBySecurityIdAndDate(ctx context.Context, accountId, securityId int, date time.Time) (decimal.Decimal, error)
 
 	GetDividendTransactionsByAccountIdAndSecurityId(ctx context.Context, accountId, securityId int) ([]domain.DividendTransaction, error)
+
+	// Voiding transactions
+	GetTransactionDataById(ctx context.Context, transactionId int) (domain.Transactions, error)                  // Retrieves a transaction by ID
+	GetInventoryLedgersByTransactionId(ctx context.Context, transactionId int) ([]domain.InventoryLedger, error) // Retrieves the ledger entries linked to a transaction, ordered by ID
+	UpdateTransactionStateById(ctx context.Context, transactionId, state int) error                              // Sets the state of a transaction, e.g. domain.TRANSACTION_STATE_VOIDED
+
+	// Editing transactions
+	GetTransactionsByAccountIdAndSecurityId(ctx context.Context, accountId, securityId int) ([]domain.Transactions, error) // Retrieves every transaction of an account and security, voided ones included, ordered by date then ID
+	UpdateTransactionById(ctx context.Context, transactionId int, transactionData domain.Transactions) error               // Updates the quantity, average price, total value, fee and date of a transaction
+	UpdateInventoryLedgerById(ctx context.Context, ledgerId int, ledgerData domain.InventoryLedger) error                  // Updates the quantity, average price, total value, fee and date of a ledger entry
+	DeleteInventoryLedgerById(ctx context.Context, ledgerId int) error                                                     // Removes a ledger entry
+	UpdateInventoryDateById(ctx context.Context, inventoryId int, date time.Time) error                                    // Updates the date of an inventory
+
+	// Realized gains
+	InsertRealizedGain(ctx context.Context, realizedGainData domain.RealizedGains) (domain.RealizedGains, error)         // Inserts a realized gain record
+	DeleteRealizedGainsByInventoryId(ctx context.Context, inventoryId int) error                                         // Removes every realized gain record of an inventory
+	GetRealizedGains(ctx context.Context, accountId, securityId int, from, to time.Time) ([]domain.RealizedGains, error) // Retrieves the realized gains of an account sold in a date range; a zero security ID or time matches any
+
+	// Returns
+	GetTransactionsByAccountId(ctx context.Context, accountId int) ([]domain.Transactions, error) // Retrieves every transaction of an account, voided ones included, ordered by date then ID
+
+	// Transaction charges
+	InsertTransactionCharges(ctx context.Context, chargesData domain.TransactionCharges) (domain.TransactionCharges, error) // Inserts the itemised charges of a transaction
+	GetTransactionChargesByTransactionIds(ctx context.Context, transactionIds []int) ([]domain.TransactionCharges, error)   // Retrieves the itemised charges of the given transactions, ordered by transaction ID
+	DeleteTransactionChargesByTransactionId(ctx context.Context, transactionId int) error                                   // Removes the itemised charges of a transaction
+
+	// Rights issues
+	InsertRightsIssue(ctx context.Context, rightsIssueData domain.RightsIssues) (domain.RightsIssues, error)       // Inserts the rights entitlements of an account from a rights issue
+	GetRightsIssueByIdAndAccountId(ctx context.Context, rightsIssueId, accountId int) (domain.RightsIssues, error) // Retrieves a rights issue of an account by its ID
+	UpdateRightsIssueById(ctx context.Context, rightsIssueId int, rightsIssueData domain.RightsIssues) error       // Updates the renounced and subscribed entitlements, the lot and the paid-up price of a rights issue
+
+	// Consistency checks
+	GetUnlinkedInventoryLedgers(ctx context.Context, accountId int) ([]domain.InventoryLedger, error)       // Retrieves ledger entries without an existing transaction; a zero account id scans every account
+	GetTransactionLedgerTotals(ctx context.Context, accountId int) ([]domain.TransactionLedgerTotal, error) // Retrieves inventory moving transactions with the totals of their linked ledger entries
 }
 
 // Router defines the interface for routing API requests and handling middleware
@@ -161,8 +239,23 @@ type Logger interface {
 	Sync(ctx context.Context) error                               // Ensures all logs are written to storage
 }
 
+// Cache defines an in-process key/value cache whose entries expire and are evicted least recently used first
+type Cache interface {
+	Get(key string) (any, bool) // Returns the live value stored under key
+	Set(key string, value any)  // Stores value under key, evicting the least recently used entry when full
+	DeletePrefix(prefix string) // Removes every entry whose key starts with prefix
+	Stats() domain.CacheStats   // Returns the hit, miss and eviction counters
+}
+
+// SecuritySearcher ranks securities against a search term from an index kept in process
+type SecuritySearcher interface {
+	Search(ctx context.Context, query domain.SecuritySearchQuery) ([]domain.SecuritySearchHit, error) // Returns the requested page of ranked matches
+	Invalidate()                                                                                      // Drops the index so the next search reloads it from the repository
+}
+
 type Marketer interface {
 	Query(symbol, exchange string) (MarketerData, error)
+	QueryClose(symbol, exchange string, date time.Time) (float64, error) // Returns the close of the last trading day on or before date
 }
 type MarketerData interface {
 	GetMarketPrice() float64
@@ -38,11 +38,18 @@ func (a *accountUsecase) AccountCreate(request domain.ClientAccountCreateRequest
 	// Initialize a new response object to hold the result of the request.
 	res := response.New()
 
+	// Sells are matched oldest lot first unless the account asks otherwise.
+	lotMethod := request.LotMethod
+	if lotMethod == "" {
+		lotMethod = domain.LOT_METHOD_FIFO
+	}
+
 	// Insert the new account data into the database.
 	_, err := a.mysql.InsertAccountData(ctx, domain.Accounts{
-		Name:   request.Name,                   // Assign the account name from the request.
-		UserId: request.UserId,                 // Assign the user ID from the request.
-		Status: constant.ACCOUNT_STATUS_ACTIVE, // Set the account status to active by default.
+		Name:      request.Name,                   // Assign the account name from the request.
+		UserId:    request.UserId,                 // Assign the user ID from the request.
+		Status:    constant.ACCOUNT_STATUS_ACTIVE, // Set the account status to active by default.
+		LotMethod: lotMethod,                      // Assign the lot matching method for sells.
 	})
 
 	// If there is an error during the database insert operation.
@@ -116,9 +123,10 @@ func (a *accountUsecase) AccountAll(request domain.ClientAccountAllRequest) doma
 	// Iterate through the accounts and format the data for the response.
 	for _, account := range accounts {
 		resData = append(resData, domain.ClientAccountAllResponse{
-			Id:     account.Id,                        // Include the account ID in the response.
-			Name:   account.Name,                      // Include the account name in the response.
-			Status: a.getStatusString(account.Status), // Get the status string and include it in the response.
+			Id:        account.Id,                        // Include the account ID in the response.
+			Name:      account.Name,                      // Include the account name in the response.
+			Status:    a.getStatusString(account.Status), // Get the status string and include it in the response.
+			LotMethod: a.getLotMethod(account.LotMethod), // Include the lot matching method in the response.
 		})
 	}
 
@@ -169,9 +177,10 @@ func (a *accountUsecase) AccountGet(request domain.ClientAccountGetRequest) doma
 
 	// Create the response data object with the account details.
 	resData := domain.ClientAccountGetResponse{
-		Id:     account.Id,                        // Include the account ID in the response.
-		Name:   account.Name,                      // Include the account name in the response.
-		Status: a.getStatusString(account.Status), // Convert the account status to a string and include it in the response.
+		Id:        account.Id,                        // Include the account ID in the response.
+		Name:      account.Name,                      // Include the account name in the response.
+		Status:    a.getStatusString(account.Status), // Convert the account status to a string and include it in the response.
+		LotMethod: a.getLotMethod(account.LotMethod), // Include the lot matching method in the response.
 	}
 
 	// Set the response data containing the account details.
@@ -356,9 +365,10 @@ func (a *accountUsecase) AccountUpdate(request domain.ClientAccountUpdateRequest
 	// Initialize a new response object to hold the result of the request.
 	res := response.New()
 
-	// Prepare the account data to update the account's name.
+	// Prepare the account data to update the account's name and lot matching method; empty fields are left unchanged.
 	accountData := domain.Accounts{
-		Name: request.Name, // Set the account's name from the request data.
+		Name:      request.Name,      // Set the account's name from the request data.
+		LotMethod: request.LotMethod, // Set the account's lot matching method from the request data.
 	}
 
 	// Update the account data in the database.
@@ -400,3 +410,12 @@ func (a *accountUsecase) getStatusString(status int) string {
 	// If the account status is neither active nor inactive, return "unknown".
 	return "unkown"
 }
+
+// getLotMethod returns the lot matching method of an account; accounts created before the method was configurable
+// match sells oldest lot first.
+func (a *accountUsecase) getLotMethod(lotMethod domain.LotMethod) domain.LotMethod {
+	if lotMethod == "" {
+		return domain.LOT_METHOD_FIFO
+	}
+	return lotMethod
+}
@@ -10,14 +10,16 @@ import (
 )
 
 type securityUsecase struct {
-	logger port.Logger
-	mysql  port.RepositoryStore
+	logger   port.Logger
+	mysql    port.RepositoryStore
+	searcher port.SecuritySearcher
 }
 
-func New(loggerIns port.Logger, mysqlIns port.RepositoryStore) domain.SecuritySvr {
+func New(loggerIns port.Logger, mysqlIns port.RepositoryStore, searcherIns port.SecuritySearcher) domain.SecuritySvr {
 	return &securityUsecase{
-		mysql:  mysqlIns,
-		logger: loggerIns,
+		mysql:    mysqlIns,
+		logger:   loggerIns,
+		searcher: searcherIns,
 	}
 }
 
@@ -56,6 +58,18 @@ func (s *securityUsecase) SecurityCreate(request domain.ClientSecurityCreateRequ
 		return res
 	}
 
+	// Get the asset class, treating the security as equity unless told otherwise.
+	assetClass := constant.ASSET_CLASS_EQUITY
+	if request.AssetClass != "" {
+		assetClass = s.getAssetClass(request.AssetClass)
+	}
+	// If the asset class is invalid, return a bad request error.
+	if assetClass == 0 {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "invalid asset class")
+		return res
+	}
+
 	// Check if the security already exists in the database by type, exchange, and symbol.
 	securityData, err := s.mysql.GetSecurityDataByTypeAndExchangeAndSymbol(ctx, securityType, securityExchange, request.Symbol)
 
@@ -81,10 +95,11 @@ func (s *securityUsecase) SecurityCreate(request domain.ClientSecurityCreateRequ
 
 	// Insert the new security data into the database.
 	securityData, err = s.mysql.InsertSecurityData(ctx, domain.Securities{
-		Type:     securityType,
-		Exchange: securityExchange,
-		Name:     request.Name,
-		Symbol:   request.Symbol,
+		Type:       securityType,
+		Exchange:   securityExchange,
+		Name:       request.Name,
+		Symbol:     request.Symbol,
+		AssetClass: assetClass,
 	})
 
 	if err != nil {
@@ -100,6 +115,9 @@ func (s *securityUsecase) SecurityCreate(request domain.ClientSecurityCreateRequ
 		return res
 	}
 
+	// Make the new security searchable right away.
+	s.searcher.Invalidate()
+
 	// Set success response message upon successful security creation.
 	resData := domain.ClientSecurityCreateResponse{
 		SecurityId: securityData.Id,
@@ -163,11 +181,12 @@ func (s *securityUsecase) SecurityAll(request domain.ClientSecurityAllRequest) d
 	var resData []domain.ClientSecurityAllResponse
 	for _, securityData := range securitiesData {
 		resData = append(resData, domain.ClientSecurityAllResponse{
-			Id:       securityData.Id,
-			Type:     s.getTypeString(securityData.Type),
-			Exchange: s.getExchangeString(securityData.Exchange),
-			Symbol:   securityData.Symbol,
-			Name:     securityData.Name,
+			Id:         securityData.Id,
+			Type:       s.getTypeString(securityData.Type),
+			Exchange:   s.getExchangeString(securityData.Exchange),
+			Symbol:     securityData.Symbol,
+			Name:       securityData.Name,
+			AssetClass: s.getAssetClassString(securityData.AssetClass),
 		})
 	}
 
@@ -215,11 +234,12 @@ func (s *securityUsecase) SecurityGet(request domain.ClientSecurityGetRequest) d
 
 	// Prepare the response data with the security details.
 	resData := domain.ClientSecurityGetResponse{
-		Id:       securityData.Id,
-		Type:     s.getTypeString(securityData.Type),
-		Exchange: s.getExchangeString(securityData.Exchange),
-		Symbol:   securityData.Symbol,
-		Name:     securityData.Name,
+		Id:         securityData.Id,
+		Type:       s.getTypeString(securityData.Type),
+		Exchange:   s.getExchangeString(securityData.Exchange),
+		Symbol:     securityData.Symbol,
+		Name:       securityData.Name,
+		AssetClass: s.getAssetClassString(securityData.AssetClass),
 	}
 
 	// Set the response data.
@@ -227,15 +247,15 @@ func (s *securityUsecase) SecurityGet(request domain.ClientSecurityGetRequest) d
 	return res
 }
 
-// SecuritySearch searches for securities based on the provided type, exchange, and search query.
-// It returns a list of matching securities or an error message if the search fails.
+// SecuritySearch ranks securities against the search query: exact symbol, symbol prefix, name prefix,
+// word match and typo tolerant match, in that order. Type and exchange are optional filters.
 //
 // Parameters:
-//   - request: domain.ClientSecuritySearchRequest - contains the details of the search criteria,
-//     including type, exchange, and search query.
+//   - request: domain.ClientSecuritySearchRequest - contains the search query, the optional type and
+//     exchange filters, and the page to return.
 //
 // Returns:
-//   - domain.Response - contains the list of matching securities or an error message if the search fails.
+//   - domain.Response - contains the requested page of ranked securities or an error message if the search fails.
 func (s *securityUsecase) SecuritySearch(request domain.ClientSecuritySearchRequest) domain.Response {
 	// Create a new background context to manage the request lifecycle.
 	ctx := context.Background()
@@ -243,29 +263,41 @@ func (s *securityUsecase) SecuritySearch(request domain.ClientSecuritySearchRequ
 	// Initialize a new response object to hold the result of the request.
 	res := response.New()
 
-	// Get the security type based on the provided request type.
-	securityType := s.getType(request.Type)
-	// If the security type is invalid, return a bad request error.
-	if securityType == 0 {
-		res.SetStatus(http.StatusBadRequest)
-		res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "invalid type")
-		return res
-	}
-
-	// Get the security exchange based on the provided request exchange.
-	securityExchange := s.getExchange(request.Exchange)
-	// If the security exchange is invalid, return a bad request error.
-	if securityExchange == 0 {
-		res.SetStatus(http.StatusBadRequest)
-		res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "invalid exchange")
-		return res
-	}
-
-	// Perform the search for securities matching the provided type, exchange, and search query.
-	securitiesData, err := s.mysql.SearchSecuritiesDataByTypeAndExchange(ctx, securityType, securityExchange, request.Search)
+	// Get the security type when one is requested; otherwise every type is searched.
+	var securityType int
+	if request.Type != "" {
+		securityType = s.getType(request.Type)
+		// If the security type is invalid, return a bad request error.
+		if securityType == 0 {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "invalid type")
+			return res
+		}
+	}
+
+	// Get the security exchange when one is requested; otherwise every exchange is searched.
+	var securityExchange int
+	if request.Exchange != "" {
+		securityExchange = s.getExchange(request.Exchange)
+		// If the security exchange is invalid, return a bad request error.
+		if securityExchange == 0 {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "invalid exchange")
+			return res
+		}
+	}
+
+	// Rank the securities matching the search query.
+	hits, err := s.searcher.Search(ctx, domain.SecuritySearchQuery{
+		Search:   request.Search,
+		Type:     securityType,
+		Exchange: securityExchange,
+		Limit:    request.Limit,
+		Offset:   request.Offset,
+	})
 	if err != nil {
-		// Log error and return internal server error response if the search query fails.
-		s.logger.Errorw(ctx, "SearchSecuritiesDataByTypeAndExchange failed",
+		// Log error and return internal server error response if the index could not be loaded.
+		s.logger.Errorw(ctx, "SecuritySearcher.Search failed",
 			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
 			constant.ERROR_MESSAGE, err.Error(),
 			constant.REQUEST, request,
@@ -277,20 +309,21 @@ func (s *securityUsecase) SecuritySearch(request domain.ClientSecuritySearchRequ
 	}
 
 	// If no matching securities were found, return an empty response.
-	if len(securitiesData) == 0 {
+	if len(hits) == 0 {
 		res.SetData(nil)
 		return res
 	}
 
-	// Prepare the response data by transforming the database results into a structured format.
+	// Prepare the response data in rank order.
 	var resData []domain.ClientSecuritySearchResponse
-	for _, securityData := range securitiesData {
+	for _, hit := range hits {
 		resData = append(resData, domain.ClientSecuritySearchResponse{
-			Id:       securityData.Id,
-			Type:     s.getTypeString(securityData.Type),
-			Exchange: s.getExchangeString(securityData.Exchange),
-			Symbol:   securityData.Symbol,
-			Name:     securityData.Name,
+			Id:       hit.Security.Id,
+			Type:     s.getTypeString(hit.Security.Type),
+			Exchange: s.getExchangeString(hit.Security.Exchange),
+			Symbol:   hit.Security.Symbol,
+			Name:     hit.Security.Name,
+			Match:    hit.Match,
 		})
 	}
 
@@ -330,6 +363,14 @@ func (s *securityUsecase) SecurityUpdate(request domain.ClientSecurityUpdateRequ
 		return res
 	}
 
+	// Validate the provided asset class; an empty one leaves the recorded class unchanged.
+	assetClass := s.getAssetClass(request.AssetClass)
+	if request.AssetClass != "" && assetClass == 0 {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "invalid asset class")
+		return res
+	}
+
 	// Retrieve the security data by its ID to ensure it exists in the system.
 	securityData, err := s.mysql.GetSecurityDataById(ctx, request.SecurityId)
 	if err != nil {
@@ -376,10 +417,11 @@ func (s *securityUsecase) SecurityUpdate(request domain.ClientSecurityUpdateRequ
 
 	// Update the security data in the database.
 	securityData = domain.Securities{
-		Type:     securityType,
-		Exchange: securityExchange,
-		Name:     request.Name,
-		Symbol:   request.Symbol,
+		Type:       securityType,
+		Exchange:   securityExchange,
+		Name:       request.Name,
+		Symbol:     request.Symbol,
+		AssetClass: assetClass,
 	}
 
 	err = s.mysql.UpdateSecurityData(ctx, request.SecurityId, securityData)
@@ -396,6 +438,9 @@ func (s *securityUsecase) SecurityUpdate(request domain.ClientSecurityUpdateRequ
 		return res
 	}
 
+	// Search the updated symbol and name from now on.
+	s.searcher.Invalidate()
+
 	// Return a success message indicating that the security has been updated successfully.
 	resData := domain.ClientSecurityUpdateResponse{
 		Message: "security updated successfully",
@@ -454,3 +499,24 @@ func (s *securityUsecase) getExchangeString(exchange int) string {
 	// Return an empty string if the exchange is invalid
 	return ""
 }
+
+// getAssetClass converts a string representation of an asset class to its corresponding integer constant.
+// Returns the corresponding constant for equity or debt or 0 if invalid.
+func (s *securityUsecase) getAssetClass(assetClass string) int {
+	if assetClass == constant.ASSET_CLASS_EQUITY_STRING {
+		return constant.ASSET_CLASS_EQUITY
+	} else if assetClass == constant.ASSET_CLASS_DEBT_STRING {
+		return constant.ASSET_CLASS_DEBT
+	}
+	// Return 0 if the asset class is invalid
+	return 0
+}
+
+// getAssetClassString converts an integer asset class constant to its corresponding string representation.
+// Securities recorded before the asset class existed are equity.
+func (s *securityUsecase) getAssetClassString(assetClass int) string {
+	if assetClass == constant.ASSET_CLASS_DEBT {
+		return constant.ASSET_CLASS_DEBT_STRING
+	}
+	return constant.ASSET_CLASS_EQUITY_STRING
+}
@@ -0,0 +1,115 @@
+package stock
+
+import (
+	"assetio/internal/domain"
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+// lotMatch is the quantity a sell takes from one lot.
+type lotMatch struct {
+	inventory domain.Inventories
+	quantity  decimal.Decimal
+}
+
+// lotOrders orders the open lots of a security for the lot methods that consume lots one after another. Ties are
+// broken oldest lot first so every method is deterministic.
+var lotOrders = map[domain.LotMethod]func(a, b domain.Inventories) bool{
+	domain.LOT_METHOD_FIFO: olderLot,
+	domain.LOT_METHOD_LIFO: func(a, b domain.Inventories) bool {
+		return olderLot(b, a)
+	},
+	domain.LOT_METHOD_HIFO: func(a, b domain.Inventories) bool {
+		if !a.AveragePrice.Equal(b.AveragePrice) {
+			return a.AveragePrice.GreaterThan(b.AveragePrice)
+		}
+		return olderLot(a, b)
+	},
+	domain.LOT_METHOD_LOFO: func(a, b domain.Inventories) bool {
+		if !a.AveragePrice.Equal(b.AveragePrice) {
+			return a.AveragePrice.LessThan(b.AveragePrice)
+		}
+		return olderLot(a, b)
+	},
+}
+
+// olderLot reports whether lot a was bought before lot b, by trade date and then by the order it was recorded in.
+func olderLot(a, b domain.Inventories) bool {
+	if !a.Date.Equal(b.Date) {
+		return a.Date.Before(b.Date)
+	}
+	return a.Id < b.Id
+}
+
+// matchLots picks the lots a sell of quantity consumes under a lot method. An account without a method matches
+// oldest lot first. The caller has already checked that the lots hold enough.
+func matchLots(method domain.LotMethod, inventories []domain.Inventories, quantity decimal.Decimal) []lotMatch {
+	lots := make([]domain.Inventories, 0, len(inventories))
+	for _, inventory := range inventories {
+		if inventory.AvailableQuantity.IsPositive() {
+			lots = append(lots, inventory)
+		}
+	}
+	sort.SliceStable(lots, func(i, j int) bool {
+		return olderLot(lots[i], lots[j])
+	})
+
+	if method == domain.LOT_METHOD_AVERAGE {
+		return matchLotsPooled(lots, quantity)
+	}
+
+	if less, ok := lotOrders[method]; ok {
+		sort.SliceStable(lots, func(i, j int) bool {
+			return less(lots[i], lots[j])
+		})
+	}
+
+	var matches []lotMatch
+	remaining := quantity
+	for _, lot := range lots {
+		if !remaining.IsPositive() {
+			break
+		}
+		matched := decimal.Min(lot.AvailableQuantity, remaining)
+		matches = append(matches, lotMatch{inventory: lot, quantity: matched})
+		remaining = remaining.Sub(matched)
+	}
+	return matches
+}
+
+// matchLotsPooled takes from every lot in proportion to what it holds, so the cost of the shares sold is the
+// pooled average cost of the holding and the average of every lot left behind is unchanged. The largest lot
+// takes the rounding remainder, so no lot is asked for more than it holds.
+func matchLotsPooled(lots []domain.Inventories, quantity decimal.Decimal) []lotMatch {
+	var held decimal.Decimal
+	largest := 0
+	for i, lot := range lots {
+		held = held.Add(lot.AvailableQuantity)
+		if lot.AvailableQuantity.GreaterThan(lots[largest].AvailableQuantity) {
+			largest = i
+		}
+	}
+	if !held.IsPositive() {
+		return nil
+	}
+
+	shares := make([]decimal.Decimal, len(lots))
+	remaining := quantity
+	for i, lot := range lots {
+		if i == largest {
+			continue
+		}
+		shares[i] = domain.RoundQuantity(quantity.Mul(lot.AvailableQuantity).Div(held))
+		remaining = remaining.Sub(shares[i])
+	}
+	shares[largest] = remaining
+
+	var matches []lotMatch
+	for i, lot := range lots {
+		if shares[i].IsPositive() {
+			matches = append(matches, lotMatch{inventory: lot, quantity: shares[i]})
+		}
+	}
+	return matches
+}
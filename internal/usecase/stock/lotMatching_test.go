@@ -0,0 +1,93 @@
+package stock
+
+import (
+	"assetio/internal/domain"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStockSellMatchesLotsByAccountMethod(t *testing.T) {
+	tests := []struct {
+		method domain.LotMethod
+		want   []string // quantity taken from the lots bought at 100, 300 and 200
+	}{
+		{domain.LOT_METHOD_FIFO, []string{"10", "2", ""}},
+		{domain.LOT_METHOD_LIFO, []string{"", "2", "10"}},
+		{domain.LOT_METHOD_HIFO, []string{"", "10", "2"}},
+		{domain.LOT_METHOD_LOFO, []string{"10", "", "2"}},
+		{domain.LOT_METHOD_AVERAGE, []string{"4", "4", "4"}},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.method), func(t *testing.T) {
+			f := newFixture(t)
+			if err := f.repo.UpdateAccountData(context.Background(), f.accountId, 1, domain.Accounts{LotMethod: test.method}); err != nil {
+				t.Fatal(err)
+			}
+
+			// The lot bought last in time is recorded first, so date order and insertion order differ.
+			f.buy(t, 10, 300, date(2024, time.February, 1))
+			f.buy(t, 10, 100, date(2024, time.January, 1))
+			f.buy(t, 10, 200, date(2024, time.February, 15))
+			byPrice := map[string]int{}
+			for _, inventory := range f.inventories(t, f.stockId) {
+				byPrice[inventory.AveragePrice.String()] = inventory.Id
+			}
+
+			var result domain.ClientStockSellResponse
+			if err := json.Unmarshal(expectSuccess(t, f.usecase.StockSell(f.sell(12))).Data, &result); err != nil {
+				t.Fatal(err)
+			}
+			if result.LotMethod != test.method {
+				t.Fatalf("lot method = %q, want %q", result.LotMethod, test.method)
+			}
+
+			taken := map[int]string{}
+			for _, lot := range result.Lots {
+				taken[lot.InventoryId] = lot.Quantity.String()
+			}
+			for i, price := range []string{"100", "300", "200"} {
+				if got := taken[byPrice[price]]; got != test.want[i] {
+					t.Errorf("lot at %s sold %q, want %q", price, got, test.want[i])
+				}
+			}
+			f.expectConsistent(t)
+		})
+	}
+}
+
+func TestStockSellAverageKeepsLotCosts(t *testing.T) {
+	f := newFixture(t)
+	if err := f.repo.UpdateAccountData(context.Background(), f.accountId, 1, domain.Accounts{LotMethod: domain.LOT_METHOD_AVERAGE}); err != nil {
+		t.Fatal(err)
+	}
+	f.buy(t, 2, 100, date(2024, time.January, 1))
+	f.buy(t, 4, 400, date(2024, time.February, 1))
+
+	// Half of each lot goes, at the pooled cost of 300 a share.
+	var result domain.ClientStockSellResponse
+	if err := json.Unmarshal(expectSuccess(t, f.usecase.StockSell(f.sell(3))).Data, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Lots) != 2 {
+		t.Fatalf("lots = %+v, want both lots", result.Lots)
+	}
+	assertDecimal(t, "cost sold", result.Lots[0].CostValue.Add(result.Lots[1].CostValue), "900")
+
+	inventories := f.inventories(t, f.stockId)
+	assertDecimal(t, "first lot average", inventories[0].AveragePrice, "100")
+	assertDecimal(t, "second lot average", inventories[1].AveragePrice, "400")
+	f.expectConsistent(t)
+}
+
+func TestStockSellRejectsUnknownAccount(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+
+	request := f.sell(1)
+	request.UserId = 2
+	expectStatus(t, f.usecase.StockSell(request), http.StatusBadRequest)
+}
@@ -4,21 +4,27 @@ import (
 	"assetio/internal/adapters/handler/response"
 	"assetio/internal/constant"
 	"assetio/internal/domain"
+	"assetio/internal/port"
 	"context"
+	"errors"
 	"net/http"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
-// StockBonus processes a stock bonus for a client by validating security information,
-// managing inventory records, recording ledger entries, and updating transaction details.
+// StockBonus processes a bonus issue for a client. The shares held at the end of the day before the ex-date are
+// eligible; a ratio bonus entitles the account to the whole shares it gives. Under Indian tax rules bonus shares cost
+// nothing and are acquired on allotment, so they form a lot of their own dated on the allotment date, and the lots
+// they were issued on keep their cost and holding period.
 //
 // Parameters:
-//   - request: domain.ClientStockBonusRequest - contains details of the stock purchase request,
-//     including stock ID, account ID, quantity, price, and fees.
+//   - request: domain.ClientStockBonusRequest - contains details of the stock bonus request,
+//     including stock ID, account ID, the ratio or quantity, the ex-date, the allotment date and the fee.
 //
 // Returns:
-//   - domain.Response - contains the outcome of the stock purchase request,
-//     either confirming success or detailing any encountered error.
+//   - domain.Response - contains the eligible holding and the lot of bonus shares,
+//     or an error if the request is invalid or nothing was held.
 func (s *stockUsecase) StockBonus(request domain.ClientStockBonusRequest) domain.Response {
 	// Create a new background context to manage the request lifecycle.
 	ctx := context.Background()
@@ -46,55 +52,146 @@ func (s *stockUsecase) StockBonus(request domain.ClientStockBonusRequest) domain
 		return res
 	}
 
-	inventories, err := s.mysql.GetActiveInventoriesByAccountIdAndSecurityId(ctx, request.AccountId, request.StockId)
-	if err != nil {
-		s.logger.Errorw(ctx, "GetActiveInventoriesByAccountIdAndSecurityId failed",
-			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
-			constant.ERROR_MESSAGE, err.Error(),
-			constant.REQUEST, request,
-		)
-		res.SetStatus(http.StatusInternalServerError)
-		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-		return res
+	// Apply the rounding rules to the request before any arithmetic.
+	request.Quantity = domain.RoundQuantity(request.Quantity)
+	request.FeeAmount = domain.RoundValue(request.FeeAmount)
+
+	// Shares bought on the ex-date carry no bonus, so the holding at its start is eligible. Without an ex-date the
+	// bonus is issued on today's holding.
+	exDate := time.Now()
+	end := exDate
+	if request.ExDate != "" {
+		exDate, err = time.Parse(constant.DATE_LAYOUT, request.ExDate)
+		if err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "invalid ex-date")
+			return res
+		}
+		end = exDate
+	}
+	allotmentDate := exDate
+	if request.AllotmentDate != "" {
+		allotmentDate, err = time.Parse(constant.DATE_LAYOUT, request.AllotmentDate)
+		if err != nil || allotmentDate.Before(exDate) {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "invalid allotment date")
+			return res
+		}
 	}
 
-	// Insert transaction record for buy operation.
-	transactionData, err := s.mysql.InsertTransaction(ctx, domain.Transactions{
-		AccountId:  request.AccountId,
-		SecurityId: secuirity.Id,
-		Type:       domain.BONUS,
-		Quantity:   request.Quantity,
-		Fee:        request.FeeAmount,
-		Date:       time.Now(),
-	})
+	var resData domain.ClientStockBonusResponse
 
-	if err != nil {
-		s.logger.Errorw(ctx, "InsertTransaction failed",
-			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
-			constant.ERROR_MESSAGE, err.Error(),
-			constant.REQUEST, request,
-		)
-		res.SetStatus(http.StatusInternalServerError)
-		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-		return res
-	}
+	// Run every write inside one unit of work so a partial failure leaves no orphan rows behind.
+	err = s.withTx(ctx, func(repo port.RepositoryStore) error {
+		account, err := repo.GetAccountDataByIdAndUserId(ctx, request.AccountId, request.UserId)
+		if err != nil {
+			s.logger.Errorw(ctx, "GetAccountDataByIdAndUserId failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
+		if account.Id == 0 {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "incorrect account id")
+			return errTxAborted
+		}
 
-	var availableQuantities float64
-	for _, inventory := range inventories {
-		availableQuantities += inventory.AvailableQuantity
-	}
+		_, held, err := lotsHeldOn(ctx, repo, request.AccountId, request.StockId, end)
+		if errors.Is(err, ErrLedgerReplay) {
+			res.SetStatus(http.StatusUnprocessableEntity)
+			res.SetError(constant.ERROR_CODE_DATA_INVALID, err.Error())
+			return errTxAborted
+		}
+		if err != nil {
+			s.logger.Errorw(ctx, "lotsHeldOn failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
+		if held.IsZero() {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "no stock available for bonus")
+			return errTxAborted
+		}
 
-	newlyAddedRatio := float64(request.Quantity) / availableQuantities
+		resData = domain.ClientStockBonusResponse{
+			ExDate:        exDate.Format("02-01-2006"),
+			AllotmentDate: allotmentDate.Format("02-01-2006"),
+			Held:          held,
+			Quantity:      request.Quantity,
+		}
+		if request.RatioHeld.IsPositive() {
+			entitled := held.Mul(request.RatioBonus).Div(request.RatioHeld)
+			resData.Quantity = entitled.Floor()
+			resData.Fractional = domain.RoundQuantity(entitled.Sub(resData.Quantity))
+		}
+		if !resData.Quantity.IsPositive() {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "the bonus gives no new shares")
+			return errTxAborted
+		}
 
-	for _, inventory := range inventories {
-		newStockForInv := newlyAddedRatio * inventory.AvailableQuantity
-		inventoryLedgerData, err := s.mysql.InsertInventoryLedger(ctx, domain.InventoryLedger{
+		// Insert transaction record for the bonus.
+		transactionData, err := repo.InsertTransaction(ctx, domain.Transactions{
+			AccountId:  request.AccountId,
+			SecurityId: secuirity.Id,
+			Type:       domain.BONUS,
+			Quantity:   resData.Quantity,
+			Fee:        request.FeeAmount,
+			RatioFrom:  request.RatioHeld,
+			RatioTo:    request.RatioBonus,
+			ExDate:     &exDate,
+			Date:       allotmentDate,
+		})
+
+		if err != nil {
+			s.logger.Errorw(ctx, "InsertTransaction failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
+
+		// Insert the lot of bonus shares, acquired on allotment.
+		inventory, err := repo.InsertInventoryData(ctx, domain.Inventories{
+			AccountId:  request.AccountId,
+			SecurityId: secuirity.Id,
+			Date:       allotmentDate,
+		})
+
+		if err != nil {
+			s.logger.Errorw(ctx, "InsertInventoryData failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
+		resData.InventoryId = inventory.Id
+
+		// Record the bonus shares at no cost.
+		_, err = repo.InsertInventoryLedger(ctx, domain.InventoryLedger{
 			InventoryId:   inventory.Id,
 			TransactionId: transactionData.Id,
 			Type:          domain.BONUS,
-			Quantity:      newStockForInv,
+			Quantity:      resData.Quantity,
+			AveragePrice:  decimal.Zero,
+			TotalValue:    decimal.Zero,
 			Fee:           request.FeeAmount,
-			Date:          time.Now(),
+			Date:          allotmentDate,
 		})
 
 		if err != nil {
@@ -105,15 +202,15 @@ func (s *stockUsecase) StockBonus(request domain.ClientStockBonusRequest) domain
 			)
 			res.SetStatus(http.StatusInternalServerError)
 			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-			return res
+			return errTxAborted
 		}
 
-		inventory.AvailableQuantity += inventoryLedgerData.Quantity
-		inventory.TotalValue += inventoryLedgerData.TotalValue
-		inventory.AveragePrice = inventory.TotalValue / inventory.AvailableQuantity
-
 		// Update inventory data in database.
-		err = s.mysql.UpdateInventoryDetailsById(ctx, inventory.Id, inventory.AvailableQuantity, inventory.AveragePrice, inventory.TotalValue)
+		err = repo.UpdateInventoryDetailsById(ctx, inventory.Id, inventory.Version, resData.Quantity, decimal.Zero, decimal.Zero)
+		if errors.Is(err, domain.ErrInventoryConflict) {
+			// Another request changed this lot since it was read; withTx rolls back and retries.
+			return err
+		}
 		if err != nil {
 			s.logger.Errorw(ctx, "UpdateInventoryDataById failed",
 				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
@@ -122,15 +219,70 @@ func (s *stockUsecase) StockBonus(request domain.ClientStockBonusRequest) domain
 			)
 			res.SetStatus(http.StatusInternalServerError)
 			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-			return res
+			return errTxAborted
 		}
+
+		return nil
+	})
+	if err != nil {
+		return s.txFailed(ctx, res, err, request)
 	}
 
 	// Set success response message.
-	resData := domain.ClientStockBonusResponse{
-		Message: "stock bonus added successfully",
-	}
+	resData.Message = "stock bonus added successfully"
 
 	res.SetData(resData)
 	return res
 }
+
+// firstTransactionId returns the transaction of the earliest entry of an inventory; a lot of bonus shares is opened by
+// its bonus.
+func firstTransactionId(entries []domain.InventoryLedgers) int {
+	var first *domain.InventoryLedgers
+	for i := range entries {
+		entry := &entries[i]
+		if first == nil || entry.Date.Before(first.Date) || (entry.Date.Equal(first.Date) && entry.Id < first.Id) {
+			first = entry
+		}
+	}
+	if first == nil {
+		return 0
+	}
+	return first.TransactionId
+}
+
+// bonusDependents returns the bonuses of the stock recorded as lots of their own after ledger added shares to it,
+// since they were issued on the holding it added to.
+func bonusDependents(ctx context.Context, repo port.RepositoryStore, ledger domain.InventoryLedger) ([]int, error) {
+	inventory, err := repo.GetInventoryDataById(ctx, ledger.InventoryId)
+	if err != nil {
+		return nil, err
+	}
+	transactions, err := repo.GetTransactionsByAccountIdAndSecurityId(ctx, inventory.AccountId, inventory.SecurityId)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []int
+	for _, transaction := range transactions {
+		if transaction.Type != domain.BONUS || transaction.State == domain.TRANSACTION_STATE_VOIDED ||
+			transaction.Id == ledger.TransactionId || !transaction.Date.After(ledger.Date) {
+			continue
+		}
+		bonusLedgers, err := repo.GetInventoryLedgersByTransactionId(ctx, transaction.Id)
+		if err != nil {
+			return nil, err
+		}
+		if len(bonusLedgers) != 1 {
+			continue
+		}
+		entries, err := repo.GetInventoryLedgersByInventoryId(ctx, bonusLedgers[0].InventoryId)
+		if err != nil {
+			return nil, err
+		}
+		if firstTransactionId(entries) == transaction.Id {
+			found = append(found, transaction.Id)
+		}
+	}
+	return found, nil
+}
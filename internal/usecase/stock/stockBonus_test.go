@@ -0,0 +1,109 @@
+package stock
+
+import (
+	"assetio/internal/domain"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// bonus records a bonus issue through the use case and decodes its report.
+func (f *fixture) bonus(t *testing.T, request domain.ClientStockBonusRequest) domain.ClientStockBonusResponse {
+	t.Helper()
+	request.UserId, request.AccountId, request.StockId = 1, f.accountId, f.stockId
+	var result domain.ClientStockBonusResponse
+	if err := json.Unmarshal(expectSuccess(t, f.usecase.StockBonus(request)).Data, &result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestStockBonusRatioOnExDate(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	f.buy(t, 5, 100, date(2024, time.March, 1))
+
+	// Shares bought on the ex-date carry no bonus. 1 for 3 on 10 shares is 3 and a third; the third is settled in cash.
+	result := f.bonus(t, domain.ClientStockBonusRequest{
+		ExDate:        date(2024, time.March, 1),
+		AllotmentDate: date(2024, time.March, 5),
+		RatioBonus:    decimal.NewFromInt(1),
+		RatioHeld:     decimal.NewFromInt(3),
+	})
+	assertDecimal(t, "held", result.Held, "10")
+	assertDecimal(t, "bonus shares", result.Quantity, "3")
+	assertDecimal(t, "fractional", result.Fractional, "0.3333")
+	if result.AllotmentDate != "05-03-2024" {
+		t.Errorf("allotment date = %s, want 05-03-2024", result.AllotmentDate)
+	}
+
+	inventories := f.inventories(t, f.stockId)
+	if len(inventories) != 3 {
+		t.Fatalf("inventories = %+v, want the two bought lots and the bonus lot", inventories)
+	}
+	assertDecimal(t, "first lot quantity", inventories[0].AvailableQuantity, "10")
+	assertDecimal(t, "first lot average price", inventories[0].AveragePrice, "100")
+	bonusLot := inventories[2]
+	if bonusLot.Id != result.InventoryId || !bonusLot.Date.Equal(time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("bonus lot = %+v, want lot %d dated on the allotment", bonusLot, result.InventoryId)
+	}
+	assertDecimal(t, "bonus lot quantity", bonusLot.AvailableQuantity, "3")
+	assertDecimal(t, "bonus lot total value", bonusLot.TotalValue, "0")
+
+	// Selling the bonus lot realizes its whole price, held from the allotment.
+	expectSuccess(t, f.usecase.StockSell(domain.ClientStockSellRequest{
+		UserId:       1,
+		AccountId:    f.accountId,
+		StockId:      f.stockId,
+		InventoryId:  bonusLot.Id,
+		Date:         date(2024, time.June, 1),
+		Quantity:     decimal.NewFromInt(3),
+		AveragePrice: decimal.NewFromInt(150),
+	}))
+	gains, err := f.repo.GetRealizedGains(context.Background(), f.accountId, f.stockId, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gains) != 1 || !gains[0].AcquisitionDate.Equal(bonusLot.Date) {
+		t.Fatalf("realized gains = %+v, want the bonus lot sold, acquired on the allotment", gains)
+	}
+	assertDecimal(t, "bonus cost", gains[0].CostValue, "0")
+}
+
+func TestStockBonusLotKeptThroughEditAndVoid(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	result := f.bonus(t, domain.ClientStockBonusRequest{
+		ExDate:     date(2024, time.February, 1),
+		RatioBonus: decimal.NewFromInt(1),
+		RatioHeld:  decimal.NewFromInt(1),
+	})
+
+	// Doubling the buy doubles the bonus, which stays in its own lot.
+	buy := f.accountTransactions(t)[0]
+	quantity := decimal.NewFromInt(20)
+	expectSuccess(t, f.usecase.TransactionUpdate(domain.ClientTransactionUpdateRequest{
+		UserId:        1,
+		AccountId:     f.accountId,
+		TransactionId: buy.Id,
+		Quantity:      &quantity,
+	}))
+	inventories := f.inventories(t, f.stockId)
+	if len(inventories) != 2 || inventories[1].Id != result.InventoryId {
+		t.Fatalf("inventories = %+v, want the bought lot and the bonus lot", inventories)
+	}
+	assertDecimal(t, "bought lot quantity", inventories[0].AvailableQuantity, "20")
+	assertDecimal(t, "bonus lot quantity", inventories[1].AvailableQuantity, "20")
+	assertDecimal(t, "bonus lot total value", inventories[1].TotalValue, "0")
+
+	// The bonus was issued on the buy, so voiding the buy voids the bonus too.
+	expectStatus(t, f.void(buy.Id, false), http.StatusUnprocessableEntity)
+	expectSuccess(t, f.void(buy.Id, true))
+	if inventories := f.inventories(t, f.stockId); len(inventories) != 0 {
+		t.Fatalf("inventories = %+v, want none after the void", inventories)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"assetio/internal/adapters/handler/response"
 	"assetio/internal/constant"
 	"assetio/internal/domain"
+	"assetio/internal/port"
 	"context"
+	"errors"
 	"net/http"
 	"time"
 )
@@ -46,6 +48,20 @@ func (s *stockUsecase) StockBuy(request domain.ClientStockBuyRequest) domain.Res
 		return res
 	}
 
+	// Apply the rounding rules to the request before any arithmetic.
+	request.Quantity = domain.RoundQuantity(request.Quantity)
+	request.AveragePrice = domain.RoundPrice(request.AveragePrice)
+	request.FeeAmount = domain.RoundValue(request.FeeAmount)
+
+	// Settle the fee from the itemised charges or the exchange's rate table.
+	fee, charges, err := s.tradeFee(secuirity, domain.BUY, request.Quantity, request.AveragePrice, request.FeeAmount, request.Charges)
+	if err != nil {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(constant.ERROR_CODE_REQUEST_INVALID, err.Error())
+		return res
+	}
+	request.FeeAmount = fee
+
 	var date = time.Now()
 
 	if request.Date != "" {
@@ -55,104 +71,130 @@ func (s *stockUsecase) StockBuy(request domain.ClientStockBuyRequest) domain.Res
 		}
 	}
 
-	// Insert new inventory .
-	inventory, err := s.mysql.InsertInventoryData(ctx, domain.Inventories{
-		AccountId:  request.AccountId,
-		SecurityId: secuirity.Id,
-		Date:       date,
-	})
-
-	if err != nil {
-		s.logger.Errorw(ctx, "InsertInventoryData failed",
-			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
-			constant.ERROR_MESSAGE, err.Error(),
-			constant.REQUEST, request,
-		)
-		// Set HTTP status to 500 and include an internal server error message in the response.
-		res.SetStatus(http.StatusInternalServerError)
-		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-		return res
-	}
+	// Run every write inside one unit of work so a partial failure leaves no orphan rows behind.
+	err = s.withTx(ctx, func(repo port.RepositoryStore) error {
+		// Insert new inventory .
+		inventory, err := repo.InsertInventoryData(ctx, domain.Inventories{
+			AccountId:  request.AccountId,
+			SecurityId: secuirity.Id,
+			Date:       date,
+		})
+
+		if err != nil {
+			s.logger.Errorw(ctx, "InsertInventoryData failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			// Set HTTP status to 500 and include an internal server error message in the response.
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
 
-	// Record ledger entry for buy transaction.
-	inventoryLedgerData, err := s.mysql.InsertInventoryLedger(ctx, domain.InventoryLedger{
-		InventoryId:  inventory.Id,
-		Type:         domain.BUY,
-		Quantity:     request.Quantity,
-		AveragePrice: request.AveragePrice,
-		Fee:          request.FeeAmount,
-		TotalValue:   request.Quantity * request.AveragePrice,
-		Date:         date,
-	})
+		// Record ledger entry for buy transaction.
+		inventoryLedgerData, err := repo.InsertInventoryLedger(ctx, domain.InventoryLedger{
+			InventoryId:  inventory.Id,
+			Type:         domain.BUY,
+			Quantity:     request.Quantity,
+			AveragePrice: request.AveragePrice,
+			Fee:          request.FeeAmount,
+			TotalValue:   domain.RoundValue(request.Quantity.Mul(request.AveragePrice)),
+			Date:         date,
+		})
+
+		if err != nil {
+			s.logger.Errorw(ctx, "InsertInventoryLedger failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
 
-	if err != nil {
-		s.logger.Errorw(ctx, "InsertInventoryLedger failed",
-			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
-			constant.ERROR_MESSAGE, err.Error(),
-			constant.REQUEST, request,
-		)
-		res.SetStatus(http.StatusInternalServerError)
-		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-		return res
-	}
+		// Update inventory with new quantity, value, and average price.
+		inventory.AvailableQuantity = inventory.AvailableQuantity.Add(inventoryLedgerData.Quantity)
+		inventory.TotalValue = inventory.TotalValue.Add(inventoryLedgerData.TotalValue)
+		inventory.AveragePrice = domain.AveragePrice(inventory.TotalValue, inventory.AvailableQuantity)
 
-	// Update inventory with new quantity, value, and average price.
-	inventory.AvailableQuantity += inventoryLedgerData.Quantity
-	inventory.TotalValue += inventoryLedgerData.TotalValue
-	inventory.AveragePrice = inventory.TotalValue / inventory.AvailableQuantity
+		// Update inventory data in database.
+		err = repo.UpdateInventoryDetailsById(ctx, inventory.Id, inventory.Version, inventory.AvailableQuantity, inventory.AveragePrice, inventory.TotalValue)
+		if errors.Is(err, domain.ErrInventoryConflict) {
+			// Another request changed this lot since it was read; withTx rolls back and retries.
+			return err
+		}
+		if err != nil {
+			s.logger.Errorw(ctx, "UpdateInventoryDataById failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
 
-	// Update inventory data in database.
-	err = s.mysql.UpdateInventoryDetailsById(ctx, inventory.Id, inventory.AvailableQuantity, inventory.AveragePrice, inventory.TotalValue)
-	if err != nil {
-		s.logger.Errorw(ctx, "UpdateInventoryDataById failed",
-			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
-			constant.ERROR_MESSAGE, err.Error(),
-			constant.REQUEST, request,
-		)
-		res.SetStatus(http.StatusInternalServerError)
-		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-		return res
-	}
+		// Insert transaction record for buy operation.
+		transactionData, err := repo.InsertTransaction(ctx, domain.Transactions{
+			AccountId:    request.AccountId,
+			SecurityId:   secuirity.Id,
+			Type:         domain.BUY,
+			Quantity:     request.Quantity,
+			AveragePrice: request.AveragePrice,
+			Fee:          request.FeeAmount,
+			TotalValue:   domain.RoundValue(request.Quantity.Mul(request.AveragePrice)),
+			Date:         date,
+		})
+
+		if err != nil {
+			s.logger.Errorw(ctx, "InsertTransaction failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
 
-	// Insert transaction record for buy operation.
-	transactionData, err := s.mysql.InsertTransaction(ctx, domain.Transactions{
-		AccountId:    request.AccountId,
-		SecurityId:   secuirity.Id,
-		Type:         domain.BUY,
-		Quantity:     request.Quantity,
-		AveragePrice: request.AveragePrice,
-		Fee:          request.FeeAmount,
-		TotalValue:   request.Quantity * request.AveragePrice,
-		Date:         date,
-	})
+		// Link ledger entry to transaction by updating ledger with transaction ID.
+		err = repo.UpdateInventoryLedgerTransactionIdById(ctx, inventoryLedgerData.Id, transactionData.Id)
+		if err != nil {
+			s.logger.Errorw(ctx, "UpdateInventoryLedgerTransactionIdById failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
 
-	if err != nil {
-		s.logger.Errorw(ctx, "InsertTransaction failed",
-			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
-			constant.ERROR_MESSAGE, err.Error(),
-			constant.REQUEST, request,
-		)
-		res.SetStatus(http.StatusInternalServerError)
-		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-		return res
-	}
+		// Record the itemised charges of the fee.
+		err = insertCharges(ctx, repo, transactionData.Id, charges)
+		if err != nil {
+			s.logger.Errorw(ctx, "InsertTransactionCharges failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
 
-	// Link ledger entry to transaction by updating ledger with transaction ID.
-	err = s.mysql.UpdateInventoryLedgerTransactionIdById(ctx, inventoryLedgerData.Id, transactionData.Id)
+		return nil
+	})
 	if err != nil {
-		s.logger.Errorw(ctx, "UpdateInventoryLedgerTransactionIdById failed",
-			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
-			constant.ERROR_MESSAGE, err.Error(),
-			constant.REQUEST, request,
-		)
-		res.SetStatus(http.StatusInternalServerError)
-		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-		return res
+		return s.txFailed(ctx, res, err, request)
 	}
 
 	// Set success response message.
 	resData := domain.ClientStockBuyResponse{
 		Message: "stock buy successfully",
+		Charges: charges,
 	}
 
 	res.SetData(resData)
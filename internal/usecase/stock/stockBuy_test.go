@@ -0,0 +1,95 @@
+package stock
+
+import (
+	"assetio/internal/domain"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestStockBuyCreatesInventory(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 125.5, date(2024, time.January, 15))
+
+	inventories := f.inventories(t, f.stockId)
+	if len(inventories) != 1 {
+		t.Fatalf("got %d inventories, want 1", len(inventories))
+	}
+	assertDecimal(t, "quantity", inventories[0].AvailableQuantity, "10")
+	assertDecimal(t, "average price", inventories[0].AveragePrice, "125.5")
+	assertDecimal(t, "total value", inventories[0].TotalValue, "1255")
+
+	ledgers, err := f.repo.GetInventoryLedgersByInventoryId(context.Background(), inventories[0].Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ledgers) != 1 || ledgers[0].Type != domain.BUY {
+		t.Fatalf("ledgers = %+v", ledgers)
+	}
+}
+
+func TestStockSellConsumesOldestInventoryFirst(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	f.buy(t, 10, 200, date(2024, time.February, 1))
+
+	expectSuccess(t, f.usecase.StockSell(domain.ClientStockSellRequest{
+		UserId:       1,
+		AccountId:    f.accountId,
+		StockId:      f.stockId,
+		Date:         date(2024, time.March, 1),
+		Quantity:     decimal.NewFromInt(15),
+		AveragePrice: decimal.NewFromInt(300),
+	}))
+
+	inventories := f.inventories(t, f.stockId)
+	if len(inventories) != 1 {
+		t.Fatalf("got %d open inventories, want 1", len(inventories))
+	}
+	assertDecimal(t, "quantity", inventories[0].AvailableQuantity, "5")
+	assertDecimal(t, "average price", inventories[0].AveragePrice, "200")
+	assertDecimal(t, "total value", inventories[0].TotalValue, "1000")
+}
+
+func TestStockSellRejectsOverselling(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+
+	expectStatus(t, f.usecase.StockSell(domain.ClientStockSellRequest{
+		UserId:       1,
+		AccountId:    f.accountId,
+		StockId:      f.stockId,
+		Quantity:     decimal.NewFromInt(11),
+		AveragePrice: decimal.NewFromInt(100),
+	}), http.StatusBadRequest)
+
+	assertDecimal(t, "quantity", f.inventories(t, f.stockId)[0].AvailableQuantity, "10")
+}
+
+func TestStockSellRollsBackOnFailure(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	inventoryId := f.inventories(t, f.stockId)[0].Id
+
+	usecase := New(nopLogger{}, failingRepo{RepositoryStore: f.repo, failOn: "UpdateInventoryLedgerTransactionIdByIds"}, stubMarketer{}, nil)
+	expectStatus(t, usecase.StockSell(domain.ClientStockSellRequest{
+		UserId:       1,
+		AccountId:    f.accountId,
+		StockId:      f.stockId,
+		Quantity:     decimal.NewFromInt(4),
+		AveragePrice: decimal.NewFromInt(150),
+	}), http.StatusInternalServerError)
+
+	assertDecimal(t, "quantity", f.inventories(t, f.stockId)[0].AvailableQuantity, "10")
+
+	ledgers, err := f.repo.GetInventoryLedgersByInventoryId(context.Background(), inventoryId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ledgers) != 1 {
+		t.Fatalf("sell ledger survived the rollback: %+v", ledgers)
+	}
+}
@@ -0,0 +1,246 @@
+package stock
+
+import (
+	"assetio/internal/adapters/handler/response"
+	"assetio/internal/constant"
+	"assetio/internal/domain"
+	"assetio/internal/port"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// StockBuyback records a buyback or tender offer. Of the shares tendered the company accepts some, which leave the
+// lots as a sale at the offer price takes them, chosen by the request's lot method or the account's; the rest are
+// returned and stay where they were. The transaction is recorded as a BUYBACK so the capital gains report taxes it
+// by the rules in force on its date.
+//
+// Parameters:
+//   - request: domain.ClientStockBuybackRequest - contains the stock, the offer price, the shares tendered and
+//     accepted, the date, the lot method and the fee.
+//
+// Returns:
+//   - domain.Response - contains the acceptance, the proceeds, their tax treatment and the lots consumed,
+//     or an error if more shares are tendered than are held.
+func (s *stockUsecase) StockBuyback(request domain.ClientStockBuybackRequest) domain.Response {
+	ctx := context.Background()
+	res := response.New()
+
+	security, err := s.mysql.GetSecurityDataById(ctx, request.StockId)
+	if err != nil {
+		s.logger.Errorw(ctx, "GetSecurityDataById failed",
+			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+			constant.ERROR_MESSAGE, err.Error(),
+			constant.REQUEST, request,
+		)
+		res.SetStatus(http.StatusInternalServerError)
+		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+		return res
+	}
+	if security.Type != constant.SECURITY_TYPE_STOCK {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "incorrect stock")
+		return res
+	}
+
+	// Apply the rounding rules to the request before any arithmetic.
+	request.OfferPrice = domain.RoundPrice(request.OfferPrice)
+	request.Tendered = domain.RoundQuantity(request.Tendered)
+	request.Accepted = domain.RoundQuantity(request.Accepted)
+	request.FeeAmount = domain.RoundValue(request.FeeAmount)
+
+	date := time.Now()
+	if request.Date != "" {
+		date, err = time.Parse(constant.DATE_LAYOUT, request.Date)
+		if err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "invalid date")
+			return res
+		}
+	}
+
+	resData := domain.ClientStockBuybackResponse{
+		Date:            date.Format("02-01-2006"),
+		Tendered:        request.Tendered,
+		Accepted:        request.Accepted,
+		Returned:        request.Tendered.Sub(request.Accepted),
+		AcceptanceRatio: domain.Percent(request.Accepted, request.Tendered),
+		Proceeds:        domain.RoundValue(request.Accepted.Mul(request.OfferPrice)),
+		TaxTreatment:    buybackTaxTreatment(date),
+	}
+
+	// Run every write inside one unit of work so a partial failure leaves no orphan rows behind.
+	err = s.withTx(ctx, func(repo port.RepositoryStore) error {
+		resData.Lots = nil
+
+		account, err := repo.GetAccountDataByIdAndUserId(ctx, request.AccountId, request.UserId)
+		if err != nil {
+			s.logger.Errorw(ctx, "GetAccountDataByIdAndUserId failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
+		if account.Id == 0 {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "incorrect account id")
+			return errTxAborted
+		}
+		resData.LotMethod = request.LotMethod
+		if resData.LotMethod == "" {
+			resData.LotMethod = account.LotMethod
+		}
+		if resData.LotMethod == "" {
+			resData.LotMethod = domain.LOT_METHOD_FIFO
+		}
+
+		inventories, err := repo.GetActiveInventoriesByAccountIdAndSecurityId(ctx, request.AccountId, request.StockId)
+		if err != nil {
+			s.logger.Errorw(ctx, "GetActiveInventoriesByAccountIdAndSecurityId failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
+
+		// Only shares held can be tendered.
+		var held decimal.Decimal
+		for _, inventory := range inventories {
+			held = held.Add(inventory.AvailableQuantity)
+		}
+		if held.LessThan(request.Tendered) {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "tendered stock not available")
+			return errTxAborted
+		}
+
+		transactionData, err := repo.InsertTransaction(ctx, domain.Transactions{
+			AccountId:    request.AccountId,
+			SecurityId:   security.Id,
+			Type:         domain.BUYBACK,
+			Quantity:     request.Accepted,
+			AveragePrice: request.OfferPrice,
+			TotalValue:   resData.Proceeds,
+			Fee:          request.FeeAmount,
+			Date:         date,
+		})
+		if err != nil {
+			s.logger.Errorw(ctx, "InsertTransaction failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
+		resData.TransactionId = transactionData.Id
+
+		// Take the accepted shares from the lots. Each lot bears its share of the fee and the last takes whatever
+		// rounding left over.
+		matches := matchLots(resData.LotMethod, inventories, request.Accepted)
+		remainingFee := request.FeeAmount
+		for i, match := range matches {
+			inventory := match.inventory
+			fee := remainingFee
+			if i < len(matches)-1 {
+				fee = shareFee(request.FeeAmount, match.quantity, request.Accepted)
+			}
+			remainingFee = remainingFee.Sub(fee)
+
+			_, err = repo.InsertInventoryLedger(ctx, domain.InventoryLedger{
+				InventoryId:   inventory.Id,
+				TransactionId: transactionData.Id,
+				Type:          domain.SELL,
+				Quantity:      match.quantity,
+				AveragePrice:  request.OfferPrice,
+				TotalValue:    domain.RoundValue(match.quantity.Mul(request.OfferPrice)),
+				Fee:           fee,
+				Date:          date,
+			})
+			if err != nil {
+				s.logger.Errorw(ctx, "InsertInventoryLedger failed",
+					constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+					constant.ERROR_MESSAGE, err.Error(),
+					constant.REQUEST, request,
+				)
+				res.SetStatus(http.StatusInternalServerError)
+				res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+				return errTxAborted
+			}
+
+			// The update is conditional on the version read above, so a lot sold by a concurrent request is never
+			// oversold.
+			quantity := inventory.AvailableQuantity.Sub(match.quantity)
+			totalValue := domain.RoundValue(quantity.Mul(inventory.AveragePrice))
+			err = repo.UpdateInventoryDetailsById(ctx, inventory.Id, inventory.Version, quantity, inventory.AveragePrice, totalValue)
+			if errors.Is(err, domain.ErrInventoryConflict) {
+				// Another request changed this lot since it was read; withTx rolls back and retries.
+				return err
+			}
+			if err != nil {
+				s.logger.Errorw(ctx, "UpdateInventoryDetailsById failed",
+					constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+					constant.ERROR_MESSAGE, err.Error(),
+					constant.REQUEST, request,
+				)
+				res.SetStatus(http.StatusInternalServerError)
+				res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+				return errTxAborted
+			}
+
+			// Record the cost basis and the gain realized on the lot.
+			err = realizeInventory(ctx, repo, inventory.Id)
+			if errors.Is(err, ErrLedgerReplay) {
+				res.SetStatus(http.StatusUnprocessableEntity)
+				res.SetError(constant.ERROR_CODE_DATA_INVALID, err.Error())
+				return errTxAborted
+			}
+			if err != nil {
+				s.logger.Errorw(ctx, "realizeInventory failed",
+					constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+					constant.ERROR_MESSAGE, err.Error(),
+					constant.REQUEST, request,
+				)
+				res.SetStatus(http.StatusInternalServerError)
+				res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+				return errTxAborted
+			}
+
+			resData.Lots = append(resData.Lots, domain.ClientStockSellLot{
+				InventoryId:  inventory.Id,
+				Date:         inventory.Date.Format("02-01-2006"),
+				Quantity:     match.quantity,
+				AveragePrice: inventory.AveragePrice,
+				CostValue:    domain.RoundValue(match.quantity.Mul(inventory.AveragePrice)),
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return s.txFailed(ctx, res, err, request)
+	}
+
+	resData.Message = "stock buyback added successfully"
+
+	res.SetData(resData)
+	return res
+}
+
+// buybackTaxTreatment returns how the proceeds of a buyback on date are taxed.
+func buybackTaxTreatment(date time.Time) domain.BuybackTaxTreatment {
+	if date.Before(buybackTaxedAsDividendFrom) {
+		return domain.BUYBACK_TAX_EXEMPT
+	}
+	return domain.BUYBACK_TAX_DIVIDEND
+}
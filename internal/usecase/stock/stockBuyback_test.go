@@ -0,0 +1,105 @@
+package stock
+
+import (
+	"assetio/internal/domain"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// buyback records a buyback through the use case and decodes its report.
+func (f *fixture) buyback(t *testing.T, request domain.ClientStockBuybackRequest) domain.ClientStockBuybackResponse {
+	t.Helper()
+	request.UserId, request.AccountId, request.StockId = 1, f.accountId, f.stockId
+	var result domain.ClientStockBuybackResponse
+	if err := json.Unmarshal(expectSuccess(t, f.usecase.StockBuyback(request)).Data, &result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestStockBuybackTaxedAsDividend(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	f.buy(t, 10, 120, date(2024, time.March, 1))
+
+	// More shares than are held cannot be tendered.
+	expectStatus(t, f.usecase.StockBuyback(domain.ClientStockBuybackRequest{
+		UserId:     1,
+		AccountId:  f.accountId,
+		StockId:    f.stockId,
+		Date:       date(2024, time.November, 1),
+		OfferPrice: decimal.NewFromInt(200),
+		Tendered:   decimal.NewFromInt(21),
+		Accepted:   decimal.NewFromInt(6),
+	}), http.StatusBadRequest)
+
+	// Of 15 shares tendered 6 are accepted, taken from the newest lot; the other 9 stay where they were.
+	result := f.buyback(t, domain.ClientStockBuybackRequest{
+		Date:       date(2024, time.November, 1),
+		OfferPrice: decimal.NewFromInt(200),
+		Tendered:   decimal.NewFromInt(15),
+		Accepted:   decimal.NewFromInt(6),
+		LotMethod:  domain.LOT_METHOD_LIFO,
+		FeeAmount:  decimal.NewFromInt(6),
+	})
+	assertDecimal(t, "returned", result.Returned, "9")
+	assertDecimal(t, "acceptance ratio", result.AcceptanceRatio, "40")
+	assertDecimal(t, "proceeds", result.Proceeds, "1200")
+	if result.TaxTreatment != domain.BUYBACK_TAX_DIVIDEND || len(result.Lots) != 1 {
+		t.Fatalf("buyback = %+v, want one lot taxed as dividend", result)
+	}
+
+	inventories := f.inventories(t, f.stockId)
+	assertDecimal(t, "oldest lot quantity", inventories[0].AvailableQuantity, "10")
+	assertDecimal(t, "newest lot quantity", inventories[1].AvailableQuantity, "4")
+	f.expectConsistent(t)
+
+	// The proceeds are a dividend and the cost of the shares, with the fee, a capital loss.
+	gains := capitalGainsResult(t, f.usecase.StockCapitalGains(domain.ClientStockCapitalGainsRequest{
+		UserId:        1,
+		AccountId:     f.accountId,
+		FinancialYear: 2024,
+	}))
+	if len(gains.Gains) != 1 || gains.Gains[0].Buyback != domain.BUYBACK_TAX_DIVIDEND {
+		t.Fatalf("capital gains = %+v, want the buyback taxed as dividend", gains.Gains)
+	}
+	assertDecimal(t, "buyback dividend", gains.Summary.BuybackDividend, "1200")
+	assertDecimal(t, "capital loss", gains.Summary.EquityShortTerm.Gain, "-726")
+
+	// Voiding the buyback returns the shares to their lot.
+	expectSuccess(t, f.void(result.TransactionId, false))
+	inventories = f.inventories(t, f.stockId)
+	assertDecimal(t, "newest lot quantity after the void", inventories[1].AvailableQuantity, "10")
+	f.expectConsistent(t)
+}
+
+func TestStockBuybackExemptBeforeOctober2024(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	result := f.buyback(t, domain.ClientStockBuybackRequest{
+		Date:       date(2024, time.July, 1),
+		OfferPrice: decimal.NewFromInt(150),
+		Tendered:   decimal.NewFromInt(10),
+		Accepted:   decimal.NewFromInt(10),
+	})
+	if result.TaxTreatment != domain.BUYBACK_TAX_EXEMPT || result.LotMethod != domain.LOT_METHOD_FIFO {
+		t.Fatalf("buyback = %+v, want an exempt buyback under the account's lot method", result)
+	}
+
+	// The gain is listed but kept out of the buckets.
+	gains := capitalGainsResult(t, f.usecase.StockCapitalGains(domain.ClientStockCapitalGainsRequest{
+		UserId:        1,
+		AccountId:     f.accountId,
+		FinancialYear: 2024,
+	}))
+	if len(gains.Gains) != 1 || gains.Gains[0].Buyback != domain.BUYBACK_TAX_EXEMPT {
+		t.Fatalf("capital gains = %+v, want the exempt buyback", gains.Gains)
+	}
+	assertDecimal(t, "exempt buyback", gains.Summary.ExemptBuyback, "500")
+	assertDecimal(t, "short-term equity", gains.Summary.EquityShortTerm.Gain, "0")
+	assertDecimal(t, "buyback dividend", gains.Summary.BuybackDividend, "0")
+}
@@ -0,0 +1,267 @@
+package stock
+
+import (
+	"assetio/internal/adapters/handler/response"
+	"assetio/internal/constant"
+	"assetio/internal/domain"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// grandfatheringDate is the last purchase date whose equity gains are computed from the fair market value on
+	// that date when it is higher than the cost (section 112A).
+	grandfatheringDate = time.Date(2018, time.January, 31, 0, 0, 0, 0, time.UTC)
+
+	// debtShortTermFrom is the first purchase date from which gains on debt funds are short-term however long the
+	// units are held (section 50AA).
+	debtShortTermFrom = time.Date(2023, time.April, 1, 0, 0, 0, 0, time.UTC)
+
+	// holdingPeriodRevisedFrom is the first sale date on which debt held over 24 rather than 36 months is long-term.
+	holdingPeriodRevisedFrom = time.Date(2024, time.July, 23, 0, 0, 0, 0, time.UTC)
+
+	// buybackTaxedAsDividendFrom is the first date on which the proceeds of a buyback are taxed as a dividend in the
+	// shareholder's hands and the cost of the shares bought back becomes a capital loss; before it they were exempt
+	// (section 10(34A)).
+	buybackTaxedAsDividendFrom = time.Date(2024, time.October, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// StockCapitalGains classifies the gains an account realized in an Indian financial year as short-term or long-term
+// by asset class and holding period, grandfathers the cost of equity bought on or before 31 January 2018 from the
+// fair market values in the request, and sums the gains per bucket together with the long-term exemption. Shares
+// bought back from October 2024 are a dividend of their proceeds and a capital loss of their cost; earlier buybacks
+// are listed but left out of the buckets as exempt.
+//
+// Parameters:
+//   - request: domain.ClientStockCapitalGainsRequest - contains the account, the financial year and the fair market
+//     values used for grandfathering.
+//
+// Returns:
+//   - domain.Response - contains the capital gains of the year, or an error if the request is invalid.
+func (s *stockUsecase) StockCapitalGains(request domain.ClientStockCapitalGainsRequest) domain.Response {
+	ctx := context.Background()
+	res := response.New()
+
+	startYear := request.FinancialYear
+	if startYear == 0 {
+		startYear = financialYear(time.Now())
+	}
+	from := time.Date(startYear, time.April, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(1, 0, 0).Add(-time.Nanosecond)
+
+	fairMarketValues := map[int]decimal.Decimal{}
+	for _, fairMarketValue := range request.FairMarketValues {
+		fairMarketValues[fairMarketValue.StockId] = domain.RoundPrice(fairMarketValue.Price)
+	}
+
+	account, err := s.mysql.GetAccountDataByIdAndUserId(ctx, request.AccountId, request.UserId)
+	if err != nil {
+		s.logger.Errorw(ctx, "GetAccountDataByIdAndUserId failed",
+			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+			constant.ERROR_MESSAGE, err.Error(),
+			constant.REQUEST, request,
+		)
+		res.SetStatus(http.StatusInternalServerError)
+		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+		return res
+	}
+	if account.Id == 0 {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "incorrect account id")
+		return res
+	}
+
+	realizedGains, err := s.mysql.GetRealizedGains(ctx, request.AccountId, 0, from, to)
+	if err != nil {
+		s.logger.Errorw(ctx, "GetRealizedGains failed",
+			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+			constant.ERROR_MESSAGE, err.Error(),
+			constant.REQUEST, request,
+		)
+		res.SetStatus(http.StatusInternalServerError)
+		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+		return res
+	}
+
+	// Buybacks realize their gains as sells do and are told apart by their transaction.
+	transactions, err := s.mysql.GetTransactionsByAccountId(ctx, request.AccountId)
+	if err != nil {
+		s.logger.Errorw(ctx, "GetTransactionsByAccountId failed",
+			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+			constant.ERROR_MESSAGE, err.Error(),
+			constant.REQUEST, request,
+		)
+		res.SetStatus(http.StatusInternalServerError)
+		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+		return res
+	}
+	buybacks := map[int]bool{}
+	for _, transaction := range transactions {
+		if transaction.Type == domain.BUYBACK {
+			buybacks[transaction.Id] = true
+		}
+	}
+
+	resData := domain.ClientStockCapitalGainsResponse{
+		FinancialYear: fmt.Sprintf("%d-%02d", startYear, (startYear+1)%100),
+		From:          from.Format("02-01-2006"),
+		To:            to.Format("02-01-2006"),
+		Gains:         []domain.ClientStockCapitalGain{},
+	}
+
+	securities := map[int]domain.Securities{}
+	for _, realizedGain := range realizedGains {
+		security, ok := securities[realizedGain.SecurityId]
+		if !ok {
+			security, err = s.mysql.GetSecurityDataById(ctx, realizedGain.SecurityId)
+			if err != nil {
+				s.logger.Errorw(ctx, "GetSecurityDataById failed",
+					constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+					constant.ERROR_MESSAGE, err.Error(),
+					constant.REQUEST, request,
+				)
+				res.SetStatus(http.StatusInternalServerError)
+				res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+				return res
+			}
+			securities[realizedGain.SecurityId] = security
+		}
+
+		var treatment domain.BuybackTaxTreatment
+		if buybacks[realizedGain.TransactionId] {
+			treatment = buybackTaxTreatment(realizedGain.Date)
+		}
+		if treatment == domain.BUYBACK_TAX_DIVIDEND {
+			// The proceeds are income from other sources; what the shares cost is lost.
+			resData.Summary.BuybackDividend = resData.Summary.BuybackDividend.Add(realizedGain.Proceeds)
+			realizedGain.Proceeds = decimal.Zero
+			realizedGain.Gain = realizedGain.CostValue.Add(realizedGain.Fee).Neg()
+		}
+
+		capitalGain := classifyGain(realizedGain, assetClass(security), fairMarketValues)
+		capitalGain.StockSymbol = security.Symbol
+		capitalGain.StockName = security.Name
+		capitalGain.Buyback = treatment
+		resData.Gains = append(resData.Gains, capitalGain)
+		if treatment == domain.BUYBACK_TAX_EXEMPT {
+			resData.Summary.ExemptBuyback = resData.Summary.ExemptBuyback.Add(capitalGain.Gain)
+			continue
+		}
+
+		bucket := &resData.Summary.EquityShortTerm
+		switch {
+		case capitalGain.AssetClass == constant.ASSET_CLASS_EQUITY_STRING && capitalGain.Term == domain.CAPITAL_GAIN_LONG_TERM:
+			bucket = &resData.Summary.EquityLongTerm
+		case capitalGain.AssetClass == constant.ASSET_CLASS_DEBT_STRING && capitalGain.Term == domain.CAPITAL_GAIN_SHORT_TERM:
+			bucket = &resData.Summary.DebtShortTerm
+		case capitalGain.AssetClass == constant.ASSET_CLASS_DEBT_STRING:
+			bucket = &resData.Summary.DebtLongTerm
+		}
+		bucket.Quantity = bucket.Quantity.Add(capitalGain.Quantity)
+		bucket.CostValue = bucket.CostValue.Add(capitalGain.CostValue)
+		bucket.Proceeds = bucket.Proceeds.Add(capitalGain.Proceeds)
+		bucket.Fee = bucket.Fee.Add(capitalGain.Fee)
+		bucket.Gain = bucket.Gain.Add(capitalGain.Gain)
+	}
+
+	// The exemption covers net long-term equity gains up to the limit of the year; the excess is taxable.
+	summary := &resData.Summary
+	summary.LongTermExemption = longTermExemption(startYear)
+	if summary.EquityLongTerm.Gain.IsPositive() {
+		summary.ExemptLongTerm = decimal.Min(summary.EquityLongTerm.Gain, summary.LongTermExemption)
+		summary.TaxableLongTerm = summary.EquityLongTerm.Gain.Sub(summary.ExemptLongTerm)
+	}
+
+	res.SetData(resData)
+	return res
+}
+
+// classifyGain works out the holding period of one realized gain and, for long-term equity bought on or before the
+// grandfathering date, its cost of acquisition: the actual cost, or the lower of the fair market value and the sale
+// value if that is higher. Lots without a fair market value keep their actual cost, and renounced rights
+// entitlements, which were never shares, are not grandfathered.
+func classifyGain(realizedGain domain.RealizedGains, assetClass int, fairMarketValues map[int]decimal.Decimal) domain.ClientStockCapitalGain {
+	capitalGain := domain.ClientStockCapitalGain{
+		TransactionId:   realizedGain.TransactionId,
+		InventoryId:     realizedGain.InventoryId,
+		StockId:         realizedGain.SecurityId,
+		AssetClass:      constant.ASSET_CLASS_EQUITY_STRING,
+		Term:            holdingTerm(assetClass, realizedGain.AcquisitionDate, realizedGain.Date),
+		HoldingDays:     int(realizedGain.Date.Sub(realizedGain.AcquisitionDate).Hours() / 24),
+		AcquisitionDate: realizedGain.AcquisitionDate.Format("02-01-2006"),
+		Date:            realizedGain.Date.Format("02-01-2006"),
+		Quantity:        realizedGain.Quantity,
+		Proceeds:        realizedGain.Proceeds,
+		ActualCost:      realizedGain.CostValue,
+		CostValue:       realizedGain.CostValue,
+		Fee:             realizedGain.Fee,
+		Gain:            realizedGain.Gain,
+	}
+	if assetClass == constant.ASSET_CLASS_DEBT {
+		capitalGain.AssetClass = constant.ASSET_CLASS_DEBT_STRING
+		return capitalGain
+	}
+
+	fairMarketValue, ok := fairMarketValues[realizedGain.SecurityId]
+	if !ok || realizedGain.InventoryId == 0 || capitalGain.Term != domain.CAPITAL_GAIN_LONG_TERM || realizedGain.AcquisitionDate.After(grandfatheringDate) {
+		return capitalGain
+	}
+
+	capitalGain.FairMarketValue = fairMarketValue
+	capitalGain.Grandfathered = true
+	grandfatheredCost := decimal.Min(domain.RoundValue(fairMarketValue.Mul(realizedGain.Quantity)), realizedGain.Proceeds)
+	capitalGain.CostValue = decimal.Max(realizedGain.CostValue, grandfatheredCost)
+	capitalGain.Gain = capitalGain.Proceeds.Sub(capitalGain.CostValue).Sub(capitalGain.Fee)
+	return capitalGain
+}
+
+// holdingTerm decides whether shares bought on acquired and sold on sold were held long-term. Equity is long-term
+// when held more than 12 months. Debt bought from April 2023 is always short-term; older debt is long-term when held
+// more than 36 months, or more than 24 months when sold from 23 July 2024.
+func holdingTerm(assetClass int, acquired, sold time.Time) domain.CapitalGainTerm {
+	months := 12
+	if assetClass == constant.ASSET_CLASS_DEBT {
+		if !acquired.Before(debtShortTermFrom) {
+			return domain.CAPITAL_GAIN_SHORT_TERM
+		}
+		months = 36
+		if !sold.Before(holdingPeriodRevisedFrom) {
+			months = 24
+		}
+	}
+
+	if sold.After(acquired.AddDate(0, months, 0)) {
+		return domain.CAPITAL_GAIN_LONG_TERM
+	}
+	return domain.CAPITAL_GAIN_SHORT_TERM
+}
+
+// assetClass returns how gains on a security are taxed. Listed shares are always equity; a mutual fund is taxed by
+// the asset class recorded for it.
+func assetClass(security domain.Securities) int {
+	if security.Type == constant.SECURITY_TYPE_MUTUAL_FUND && security.AssetClass == constant.ASSET_CLASS_DEBT {
+		return constant.ASSET_CLASS_DEBT
+	}
+	return constant.ASSET_CLASS_EQUITY
+}
+
+// longTermExemption returns the yearly exemption on long-term equity gains for the financial year starting in
+// startYear: 1,25,000 from 2024-25 and 1,00,000 before.
+func longTermExemption(startYear int) decimal.Decimal {
+	if startYear >= 2024 {
+		return decimal.NewFromInt(125000)
+	}
+	return decimal.NewFromInt(100000)
+}
+
+// financialYear returns the calendar year in which the financial year containing date starts.
+func financialYear(date time.Time) int {
+	if date.Month() < time.April {
+		return date.Year() - 1
+	}
+	return date.Year()
+}
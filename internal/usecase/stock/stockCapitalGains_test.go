@@ -0,0 +1,132 @@
+package stock
+
+import (
+	"assetio/internal/constant"
+	"assetio/internal/domain"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func capitalGainsResult(t *testing.T, res domain.Response) domain.ClientStockCapitalGainsResponse {
+	t.Helper()
+	var result domain.ClientStockCapitalGainsResponse
+	if err := json.Unmarshal(expectSuccess(t, res).Data, &result); err != nil {
+		t.Fatalf("decode capital gains response: %v", err)
+	}
+	return result
+}
+
+func TestStockCapitalGainsGrandfathersAndAppliesExemption(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 1000, 100, date(2017, time.January, 1))
+	f.buy(t, 10, 100, date(2023, time.June, 1))
+	expectSuccess(t, f.usecase.StockSell(f.sell(1010)))
+
+	request := domain.ClientStockCapitalGainsRequest{
+		UserId:           1,
+		AccountId:        f.accountId,
+		FinancialYear:    2023,
+		FairMarketValues: []domain.ClientStockFairMarketValue{{StockId: f.stockId, Price: decimal.NewFromInt(150)}},
+	}
+	result := capitalGainsResult(t, f.usecase.StockCapitalGains(request))
+	if result.FinancialYear != "2023-24" || result.From != "01-04-2023" || result.To != "31-03-2024" || len(result.Gains) != 2 {
+		t.Fatalf("capital gains = %+v", result)
+	}
+
+	// The old lot is costed at its value on 31 January 2018 rather than what was paid for it.
+	old := result.Gains[0]
+	if old.Term != domain.CAPITAL_GAIN_LONG_TERM || !old.Grandfathered || old.AssetClass != constant.ASSET_CLASS_EQUITY_STRING {
+		t.Fatalf("old lot = %+v, want grandfathered long-term equity", old)
+	}
+	assertDecimal(t, "actual cost", old.ActualCost, "100000")
+	assertDecimal(t, "grandfathered cost", old.CostValue, "150000")
+	assertDecimal(t, "long-term gain", old.Gain, "150000")
+	if result.Gains[1].Term != domain.CAPITAL_GAIN_SHORT_TERM || result.Gains[1].Grandfathered {
+		t.Fatalf("recent lot = %+v, want short-term", result.Gains[1])
+	}
+
+	summary := result.Summary
+	assertDecimal(t, "short-term equity", summary.EquityShortTerm.Gain, "2000")
+	assertDecimal(t, "long-term equity", summary.EquityLongTerm.Gain, "150000")
+	assertDecimal(t, "exemption", summary.LongTermExemption, "100000")
+	assertDecimal(t, "exempt", summary.ExemptLongTerm, "100000")
+	assertDecimal(t, "taxable", summary.TaxableLongTerm, "50000")
+
+	// Without a fair market value the actual cost stands.
+	request.FairMarketValues = nil
+	result = capitalGainsResult(t, f.usecase.StockCapitalGains(request))
+	assertDecimal(t, "long-term equity without fair market value", result.Summary.EquityLongTerm.Gain, "200000")
+
+	// Nothing was sold in the next year.
+	request.FinancialYear = 2024
+	result = capitalGainsResult(t, f.usecase.StockCapitalGains(request))
+	if len(result.Gains) != 0 {
+		t.Fatalf("gains in 2024-25 = %+v", result.Gains)
+	}
+	assertDecimal(t, "exemption in 2024-25", result.Summary.LongTermExemption, "125000")
+
+	// Another user's account is refused.
+	request.UserId = 2
+	expectStatus(t, f.usecase.StockCapitalGains(request), http.StatusBadRequest)
+}
+
+func TestStockCapitalGainsClassifiesDebtFunds(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	fund, err := f.repo.InsertSecurityData(ctx, domain.Securities{Type: constant.SECURITY_TYPE_MUTUAL_FUND, Exchange: constant.EXCHANGE_TYPE_NSE, Symbol: "LIQUID", Name: "Liquid Fund", AssetClass: constant.ASSET_CLASS_DEBT})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, acquired := range []time.Time{
+		time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2023, time.April, 1, 0, 0, 0, 0, time.UTC),
+	} {
+		if _, err := f.repo.InsertRealizedGain(ctx, domain.RealizedGains{
+			AccountId:       f.accountId,
+			SecurityId:      fund.Id,
+			Quantity:        decimal.NewFromInt(1),
+			AcquisitionDate: acquired,
+			Gain:            decimal.NewFromInt(10),
+			Date:            time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC),
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result := capitalGainsResult(t, f.usecase.StockCapitalGains(domain.ClientStockCapitalGainsRequest{UserId: 1, AccountId: f.accountId, FinancialYear: 2024}))
+	assertDecimal(t, "long-term debt", result.Summary.DebtLongTerm.Gain, "10")
+	assertDecimal(t, "short-term debt", result.Summary.DebtShortTerm.Gain, "10")
+	if !result.Summary.EquityLongTerm.Gain.IsZero() || !result.Summary.ExemptLongTerm.IsZero() {
+		t.Fatalf("debt gains counted as equity: %+v", result.Summary)
+	}
+}
+
+func TestHoldingTerm(t *testing.T) {
+	day := func(year int, month time.Month, date int) time.Time {
+		return time.Date(year, month, date, 0, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name       string
+		assetClass int
+		acquired   time.Time
+		sold       time.Time
+		want       domain.CapitalGainTerm
+	}{
+		{"equity held exactly a year", constant.ASSET_CLASS_EQUITY, day(2023, 1, 1), day(2024, 1, 1), domain.CAPITAL_GAIN_SHORT_TERM},
+		{"equity held over a year", constant.ASSET_CLASS_EQUITY, day(2023, 1, 1), day(2024, 1, 2), domain.CAPITAL_GAIN_LONG_TERM},
+		{"debt held 30 months before the revision", constant.ASSET_CLASS_DEBT, day(2021, 1, 1), day(2023, 7, 2), domain.CAPITAL_GAIN_SHORT_TERM},
+		{"debt held over 36 months", constant.ASSET_CLASS_DEBT, day(2020, 1, 1), day(2023, 1, 2), domain.CAPITAL_GAIN_LONG_TERM},
+		{"debt held 30 months after the revision", constant.ASSET_CLASS_DEBT, day(2022, 3, 1), day(2024, 9, 1), domain.CAPITAL_GAIN_LONG_TERM},
+		{"debt bought from April 2023", constant.ASSET_CLASS_DEBT, day(2023, 4, 1), day(2026, 6, 1), domain.CAPITAL_GAIN_SHORT_TERM},
+	}
+	for _, test := range tests {
+		if got := holdingTerm(test.assetClass, test.acquired, test.sold); got != test.want {
+			t.Errorf("%s: term = %s, want %s", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package stock
+
+import (
+	"assetio/internal/adapters/handler/response"
+	"assetio/internal/constant"
+	"assetio/internal/domain"
+	"assetio/internal/port"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// errChargesMismatch is returned when a trade gives both a fee and itemised charges that do not add up to it.
+var errChargesMismatch = errors.New("fee amount does not match the charges")
+
+// StockChargesCalculate works out the itemised charges of a prospective buy or sell of a stock from the rate table of
+// the exchange it is listed on.
+//
+// Parameters:
+//   - request: domain.ClientStockChargesCalculateRequest - contains the stock, the side, the quantity and the price.
+//
+// Returns:
+//   - domain.Response - contains the trade value and its charges, or an error if the exchange has no rate table.
+func (s *stockUsecase) StockChargesCalculate(request domain.ClientStockChargesCalculateRequest) domain.Response {
+	ctx := context.Background()
+	res := response.New()
+
+	security, err := s.mysql.GetSecurityDataById(ctx, request.StockId)
+	if err != nil {
+		s.logger.Errorw(ctx, "GetSecurityDataById failed",
+			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+			constant.ERROR_MESSAGE, err.Error(),
+			constant.REQUEST, request,
+		)
+		res.SetStatus(http.StatusInternalServerError)
+		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+		return res
+	}
+	if security.Type != constant.SECURITY_TYPE_STOCK {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "incorrect stock")
+		return res
+	}
+
+	exchange := exchangeString(security.Exchange)
+	rates, ok := s.chargeRates[strings.ToLower(exchange)]
+	if !ok {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "no charge rates for the exchange")
+		return res
+	}
+
+	side := domain.TransactionType(strings.ToUpper(request.Type))
+	tradeValue := domain.RoundValue(domain.RoundQuantity(request.Quantity).Mul(domain.RoundPrice(request.Price)))
+	res.SetData(domain.ClientStockChargesCalculateResponse{
+		Exchange:   exchange,
+		Type:       string(side),
+		TradeValue: tradeValue,
+		Charges:    calculateCharges(rates, side, tradeValue),
+	})
+	return res
+}
+
+// StockCharges sums the charges an account paid per Indian financial year. Itemised charges are summed item by item;
+// fees recorded only as a total are counted as unitemised. Voided transactions are left out.
+//
+// Parameters:
+//   - request: domain.ClientStockChargesRequest - contains the account and, optionally, one financial year.
+//
+// Returns:
+//   - domain.Response - contains the charges per year and in total.
+func (s *stockUsecase) StockCharges(request domain.ClientStockChargesRequest) domain.Response {
+	ctx := context.Background()
+	res := response.New()
+
+	account, err := s.mysql.GetAccountDataByIdAndUserId(ctx, request.AccountId, request.UserId)
+	if err != nil {
+		s.logger.Errorw(ctx, "GetAccountDataByIdAndUserId failed",
+			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+			constant.ERROR_MESSAGE, err.Error(),
+			constant.REQUEST, request,
+		)
+		res.SetStatus(http.StatusInternalServerError)
+		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+		return res
+	}
+	if account.Id == 0 {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "incorrect account id")
+		return res
+	}
+
+	transactions, err := s.mysql.GetTransactionsByAccountId(ctx, request.AccountId)
+	if err != nil {
+		s.logger.Errorw(ctx, "GetTransactionsByAccountId failed",
+			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+			constant.ERROR_MESSAGE, err.Error(),
+			constant.REQUEST, request,
+		)
+		res.SetStatus(http.StatusInternalServerError)
+		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+		return res
+	}
+
+	var charged []domain.Transactions
+	var transactionIds []int
+	for _, transaction := range transactions {
+		if transaction.State == domain.TRANSACTION_STATE_VOIDED || !transaction.Fee.IsPositive() {
+			continue
+		}
+		if request.FinancialYear != 0 && financialYear(transaction.Date) != request.FinancialYear {
+			continue
+		}
+		charged = append(charged, transaction)
+		transactionIds = append(transactionIds, transaction.Id)
+	}
+
+	chargesData, err := s.mysql.GetTransactionChargesByTransactionIds(ctx, transactionIds)
+	if err != nil {
+		s.logger.Errorw(ctx, "GetTransactionChargesByTransactionIds failed",
+			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+			constant.ERROR_MESSAGE, err.Error(),
+			constant.REQUEST, request,
+		)
+		res.SetStatus(http.StatusInternalServerError)
+		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+		return res
+	}
+	itemised := map[int]domain.TransactionCharges{}
+	for _, charges := range chargesData {
+		itemised[charges.TransactionId] = charges
+	}
+
+	years := map[int]*domain.ClientStockChargesYear{}
+	var order []int
+	resData := domain.ClientStockChargesResponse{Years: []domain.ClientStockChargesYear{}}
+	for _, transaction := range charged {
+		startYear := financialYear(transaction.Date)
+		year, ok := years[startYear]
+		if !ok {
+			year = &domain.ClientStockChargesYear{FinancialYear: fmt.Sprintf("%d-%02d", startYear, (startYear+1)%100)}
+			years[startYear] = year
+			order = append(order, startYear)
+		}
+
+		for _, sum := range []*domain.ClientStockChargesYear{year, &resData.Totals} {
+			sum.Transactions++
+			sum.Total = sum.Total.Add(transaction.Fee)
+			charges, ok := itemised[transaction.Id]
+			if !ok {
+				sum.Unitemised = sum.Unitemised.Add(transaction.Fee)
+				continue
+			}
+			sum.Brokerage = sum.Brokerage.Add(charges.Brokerage)
+			sum.Stt = sum.Stt.Add(charges.Stt)
+			sum.ExchangeFee = sum.ExchangeFee.Add(charges.ExchangeFee)
+			sum.SebiFee = sum.SebiFee.Add(charges.SebiFee)
+			sum.StampDuty = sum.StampDuty.Add(charges.StampDuty)
+			sum.Gst = sum.Gst.Add(charges.Gst)
+		}
+	}
+
+	sort.Ints(order)
+	for _, startYear := range order {
+		resData.Years = append(resData.Years, *years[startYear])
+	}
+
+	res.SetData(resData)
+	return res
+}
+
+// calculateCharges works out the charges of a trade of tradeValue from an exchange's rate table. STT is rounded to
+// the rupee; stamp duty is charged on buys only; GST is charged on the brokerage and the exchange and SEBI fees.
+func calculateCharges(rates domain.ChargeRates, side domain.TransactionType, tradeValue decimal.Decimal) domain.ClientTradeCharges {
+	percentOf := func(rate decimal.Decimal, value decimal.Decimal) decimal.Decimal {
+		return value.Mul(rate).Div(decimal.NewFromInt(100))
+	}
+
+	charges := domain.ClientTradeCharges{
+		Brokerage:   domain.RoundValue(percentOf(rates.BrokeragePercent, tradeValue)),
+		ExchangeFee: domain.RoundValue(percentOf(rates.ExchangePercent, tradeValue)),
+		SebiFee:     domain.RoundValue(percentOf(rates.SebiPercent, tradeValue)),
+	}
+	if rates.BrokerageMax.IsPositive() {
+		charges.Brokerage = decimal.Min(charges.Brokerage, rates.BrokerageMax)
+	}
+	if side == domain.SELL {
+		charges.Stt = percentOf(rates.SttSellPercent, tradeValue).Round(0)
+	} else {
+		charges.Stt = percentOf(rates.SttBuyPercent, tradeValue).Round(0)
+		charges.StampDuty = domain.RoundValue(percentOf(rates.StampBuyPercent, tradeValue))
+	}
+	charges.Gst = domain.RoundValue(percentOf(rates.GstPercent, charges.Brokerage.Add(charges.ExchangeFee).Add(charges.SebiFee)))
+	completeCharges(&charges)
+	return charges
+}
+
+// completeCharges rounds the items of charges and sums them into Total.
+func completeCharges(charges *domain.ClientTradeCharges) {
+	charges.Brokerage = domain.RoundValue(charges.Brokerage)
+	charges.Stt = domain.RoundValue(charges.Stt)
+	charges.ExchangeFee = domain.RoundValue(charges.ExchangeFee)
+	charges.SebiFee = domain.RoundValue(charges.SebiFee)
+	charges.StampDuty = domain.RoundValue(charges.StampDuty)
+	charges.Gst = domain.RoundValue(charges.Gst)
+	charges.Total = charges.Brokerage.Add(charges.Stt).Add(charges.ExchangeFee).Add(charges.SebiFee).Add(charges.StampDuty).Add(charges.Gst)
+}
+
+// tradeFee settles the fee of a buy or sell. Itemised charges are kept and must add up to the fee when one is given
+// too; a fee given alone is kept as it is; with neither, the charges are worked out from the rate table of the
+// security's exchange, if there is one. The charges are nil when the fee is not itemised.
+func (s *stockUsecase) tradeFee(security domain.Securities, side domain.TransactionType, quantity, price, fee decimal.Decimal, itemised *domain.ClientTradeCharges) (decimal.Decimal, *domain.ClientTradeCharges, error) {
+	if itemised != nil {
+		charges := *itemised
+		completeCharges(&charges)
+		if !fee.IsZero() && !fee.Equal(charges.Total) {
+			return fee, nil, errChargesMismatch
+		}
+		return charges.Total, &charges, nil
+	}
+	if !fee.IsZero() {
+		return fee, nil, nil
+	}
+
+	rates, ok := s.chargeRates[strings.ToLower(exchangeString(security.Exchange))]
+	if !ok {
+		return fee, nil, nil
+	}
+	charges := calculateCharges(rates, side, domain.RoundValue(quantity.Mul(price)))
+	return charges.Total, &charges, nil
+}
+
+// insertCharges records the itemised charges of a transaction; charges is nil when the fee is not itemised.
+func insertCharges(ctx context.Context, repo port.RepositoryStore, transactionId int, charges *domain.ClientTradeCharges) error {
+	if charges == nil {
+		return nil
+	}
+	_, err := repo.InsertTransactionCharges(ctx, domain.TransactionCharges{
+		TransactionId: transactionId,
+		Brokerage:     charges.Brokerage,
+		Stt:           charges.Stt,
+		ExchangeFee:   charges.ExchangeFee,
+		SebiFee:       charges.SebiFee,
+		StampDuty:     charges.StampDuty,
+		Gst:           charges.Gst,
+	})
+	return err
+}
+
+// shareFee returns the part of fee that falls to part of a whole quantity, rounded as a value. The caller gives the
+// last part whatever is left of the fee, so the parts add up to it exactly.
+func shareFee(fee, part, whole decimal.Decimal) decimal.Decimal {
+	if !whole.IsPositive() {
+		return decimal.Zero
+	}
+	return domain.RoundValue(fee.Mul(part).Div(whole))
+}
+
+// exchangeString returns the name of an exchange type, e.g. "NSE".
+func exchangeString(exchange int) string {
+	switch exchange {
+	case constant.EXCHANGE_TYPE_NSE:
+		return constant.EXCHANGE_TYPE_NSE_STRING
+	case constant.EXCHANGE_TYPE_BSE:
+		return constant.EXCHANGE_TYPE_BSE_STRING
+	}
+	return ""
+}
@@ -0,0 +1,321 @@
+package stock
+
+import (
+	"assetio/internal/domain"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// nseRates is an equity delivery rate table like the example config's, with a brokerage to cap.
+var nseRates = domain.ChargeRates{
+	BrokeragePercent: decimal.RequireFromString("0.03"),
+	BrokerageMax:     decimal.NewFromInt(20),
+	SttBuyPercent:    decimal.RequireFromString("0.1"),
+	SttSellPercent:   decimal.RequireFromString("0.1"),
+	ExchangePercent:  decimal.RequireFromString("0.00297"),
+	SebiPercent:      decimal.RequireFromString("0.0001"),
+	StampBuyPercent:  decimal.RequireFromString("0.015"),
+	GstPercent:       decimal.NewFromInt(18),
+}
+
+// newChargesFixture is a fixture whose use case knows the NSE rate table.
+func newChargesFixture(t *testing.T) *fixture {
+	t.Helper()
+	f := newFixture(t)
+	f.usecase = New(nopLogger{}, f.repo, stubMarketer{price: 100}, map[string]domain.ChargeRates{"nse": nseRates})
+	return f
+}
+
+// accountTransactions returns every transaction of the fixture account, oldest first.
+func (f *fixture) accountTransactions(t *testing.T) []domain.Transactions {
+	t.Helper()
+	transactions, err := f.repo.GetTransactionsByAccountId(context.Background(), f.accountId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return transactions
+}
+
+// charges reports the charges of the fixture account through the use case and decodes them.
+func (f *fixture) charges(t *testing.T, financialYear int) domain.ClientStockChargesResponse {
+	t.Helper()
+	var result domain.ClientStockChargesResponse
+	res := f.usecase.StockCharges(domain.ClientStockChargesRequest{UserId: 1, AccountId: f.accountId, FinancialYear: financialYear})
+	if err := json.Unmarshal(expectSuccess(t, res).Data, &result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestCalculateCharges(t *testing.T) {
+	tests := []struct {
+		name       string
+		side       domain.TransactionType
+		tradeValue string
+		want       domain.ClientTradeCharges
+	}{
+		{
+			name:       "buy",
+			side:       domain.BUY,
+			tradeValue: "50000",
+			want: domain.ClientTradeCharges{
+				Brokerage:   decimal.NewFromInt(15),
+				Stt:         decimal.NewFromInt(50),
+				ExchangeFee: decimal.RequireFromString("1.49"),
+				SebiFee:     decimal.RequireFromString("0.05"),
+				StampDuty:   decimal.RequireFromString("7.5"),
+				Gst:         decimal.RequireFromString("2.98"),
+				Total:       decimal.RequireFromString("77.02"),
+			},
+		},
+		{
+			name:       "sell pays no stamp duty",
+			side:       domain.SELL,
+			tradeValue: "50000",
+			want: domain.ClientTradeCharges{
+				Brokerage:   decimal.NewFromInt(15),
+				Stt:         decimal.NewFromInt(50),
+				ExchangeFee: decimal.RequireFromString("1.49"),
+				SebiFee:     decimal.RequireFromString("0.05"),
+				Gst:         decimal.RequireFromString("2.98"),
+				Total:       decimal.RequireFromString("69.52"),
+			},
+		},
+		{
+			name:       "brokerage capped",
+			side:       domain.SELL,
+			tradeValue: "500000",
+			want: domain.ClientTradeCharges{
+				Brokerage:   decimal.NewFromInt(20),
+				Stt:         decimal.NewFromInt(500),
+				ExchangeFee: decimal.RequireFromString("14.85"),
+				SebiFee:     decimal.RequireFromString("0.5"),
+				Gst:         decimal.RequireFromString("6.36"),
+				Total:       decimal.RequireFromString("541.71"),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := calculateCharges(nseRates, test.side, decimal.RequireFromString(test.tradeValue))
+			assertDecimal(t, "brokerage", got.Brokerage, test.want.Brokerage.String())
+			assertDecimal(t, "stt", got.Stt, test.want.Stt.String())
+			assertDecimal(t, "exchange fee", got.ExchangeFee, test.want.ExchangeFee.String())
+			assertDecimal(t, "sebi fee", got.SebiFee, test.want.SebiFee.String())
+			assertDecimal(t, "stamp duty", got.StampDuty, test.want.StampDuty.String())
+			assertDecimal(t, "gst", got.Gst, test.want.Gst.String())
+			assertDecimal(t, "total", got.Total, test.want.Total.String())
+		})
+	}
+}
+
+func TestStockChargesCalculate(t *testing.T) {
+	f := newChargesFixture(t)
+
+	var result domain.ClientStockChargesCalculateResponse
+	res := f.usecase.StockChargesCalculate(domain.ClientStockChargesCalculateRequest{
+		UserId:   1,
+		StockId:  f.stockId,
+		Type:     "sell",
+		Quantity: decimal.NewFromInt(100),
+		Price:    decimal.NewFromInt(500),
+	})
+	if err := json.Unmarshal(expectSuccess(t, res).Data, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Exchange != "NSE" || result.Type != string(domain.SELL) {
+		t.Fatalf("result = %+v, want an NSE sell", result)
+	}
+	assertDecimal(t, "trade value", result.TradeValue, "50000")
+	assertDecimal(t, "total", result.Charges.Total, "69.52")
+
+	// Without a rate table for the exchange there is nothing to calculate from.
+	f.usecase = New(nopLogger{}, f.repo, stubMarketer{price: 100}, nil)
+	expectStatus(t, f.usecase.StockChargesCalculate(domain.ClientStockChargesCalculateRequest{
+		UserId:   1,
+		StockId:  f.stockId,
+		Type:     "BUY",
+		Quantity: decimal.NewFromInt(100),
+		Price:    decimal.NewFromInt(500),
+	}), http.StatusBadRequest)
+}
+
+func TestStockBuyFillsInChargesFromRates(t *testing.T) {
+	f := newChargesFixture(t)
+
+	var result domain.ClientStockBuyResponse
+	res := f.usecase.StockBuy(domain.ClientStockBuyRequest{
+		UserId:       1,
+		AccountId:    f.accountId,
+		StockId:      f.stockId,
+		Date:         date(2024, time.January, 10),
+		Quantity:     decimal.NewFromInt(100),
+		AveragePrice: decimal.NewFromInt(500),
+	})
+	if err := json.Unmarshal(expectSuccess(t, res).Data, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Charges == nil {
+		t.Fatal("charges = nil, want the charges worked out from the rates")
+	}
+	assertDecimal(t, "response total", result.Charges.Total, "77.02")
+
+	transactions := f.accountTransactions(t)
+	assertDecimal(t, "transaction fee", transactions[0].Fee, "77.02")
+	stored, err := f.repo.GetTransactionChargesByTransactionIds(context.Background(), []int{transactions[0].Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 {
+		t.Fatalf("stored charges = %+v, want one row", stored)
+	}
+	assertDecimal(t, "stored stamp duty", stored[0].StampDuty, "7.5")
+
+	// A fee given alone is kept as it is and not itemised.
+	expectSuccess(t, f.usecase.StockBuy(domain.ClientStockBuyRequest{
+		UserId:       1,
+		AccountId:    f.accountId,
+		StockId:      f.stockId,
+		Date:         date(2024, time.January, 11),
+		Quantity:     decimal.NewFromInt(10),
+		AveragePrice: decimal.NewFromInt(500),
+		FeeAmount:    decimal.NewFromInt(12),
+	}))
+	transactions = f.accountTransactions(t)
+	assertDecimal(t, "given fee", transactions[1].Fee, "12")
+	stored, err = f.repo.GetTransactionChargesByTransactionIds(context.Background(), []int{transactions[1].Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 0 {
+		t.Fatalf("stored charges = %+v, want none for a fee given alone", stored)
+	}
+}
+
+func TestStockBuyItemisedChargesMustMatchFee(t *testing.T) {
+	f := newChargesFixture(t)
+	request := domain.ClientStockBuyRequest{
+		UserId:       1,
+		AccountId:    f.accountId,
+		StockId:      f.stockId,
+		Date:         date(2024, time.January, 10),
+		Quantity:     decimal.NewFromInt(10),
+		AveragePrice: decimal.NewFromInt(100),
+		FeeAmount:    decimal.NewFromInt(20),
+		Charges:      &domain.ClientTradeCharges{Brokerage: decimal.NewFromInt(10), Stt: decimal.NewFromInt(5)},
+	}
+	expectStatus(t, f.usecase.StockBuy(request), http.StatusBadRequest)
+
+	request.FeeAmount = decimal.NewFromInt(15)
+	expectSuccess(t, f.usecase.StockBuy(request))
+	transaction := f.accountTransactions(t)[0]
+	assertDecimal(t, "fee", transaction.Fee, "15")
+
+	// Editing the fee leaves the itemised charges behind.
+	fee := decimal.NewFromInt(18)
+	expectSuccess(t, f.usecase.TransactionUpdate(domain.ClientTransactionUpdateRequest{
+		UserId:        1,
+		AccountId:     f.accountId,
+		TransactionId: transaction.Id,
+		FeeAmount:     &fee,
+	}))
+	stored, err := f.repo.GetTransactionChargesByTransactionIds(context.Background(), []int{transaction.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 0 {
+		t.Fatalf("stored charges = %+v, want none after the fee was edited", stored)
+	}
+}
+
+func TestStockSplitSharesFeeAcrossLots(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	f.buy(t, 20, 100, date(2024, time.February, 1))
+	expectSuccess(t, f.usecase.StockSplit(domain.ClientStockSplitRequest{
+		UserId:    1,
+		AccountId: f.accountId,
+		StockId:   f.stockId,
+		Quantity:  decimal.NewFromInt(30),
+		FeeAmount: decimal.NewFromInt(10),
+	}))
+
+	var fees []string
+	for _, inventory := range f.inventories(t, f.stockId) {
+		ledgers, err := f.repo.GetInventoryLedgersByInventoryId(context.Background(), inventory.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, ledger := range ledgers {
+			if ledger.Type == domain.SPLIT {
+				fees = append(fees, ledger.Fee.String())
+			}
+		}
+	}
+	if len(fees) != 2 || fees[0] != "3.33" || fees[1] != "6.67" {
+		t.Fatalf("split fees = %v, want [3.33 6.67]", fees)
+	}
+}
+
+func TestStockChargesPerFinancialYear(t *testing.T) {
+	f := newChargesFixture(t)
+	// Itemised from the rates in 2023-24.
+	expectSuccess(t, f.usecase.StockBuy(domain.ClientStockBuyRequest{
+		UserId:       1,
+		AccountId:    f.accountId,
+		StockId:      f.stockId,
+		Date:         date(2024, time.January, 10),
+		Quantity:     decimal.NewFromInt(100),
+		AveragePrice: decimal.NewFromInt(500),
+	}))
+	// A fee given alone in 2024-25.
+	expectSuccess(t, f.usecase.StockSell(domain.ClientStockSellRequest{
+		UserId:       1,
+		AccountId:    f.accountId,
+		StockId:      f.stockId,
+		Date:         date(2024, time.May, 1),
+		Quantity:     decimal.NewFromInt(10),
+		AveragePrice: decimal.NewFromInt(550),
+		FeeAmount:    decimal.NewFromInt(10),
+	}))
+	// A voided buy is left out.
+	expectSuccess(t, f.usecase.StockBuy(domain.ClientStockBuyRequest{
+		UserId:       1,
+		AccountId:    f.accountId,
+		StockId:      f.otherId,
+		Date:         date(2024, time.June, 1),
+		Quantity:     decimal.NewFromInt(1),
+		AveragePrice: decimal.NewFromInt(100),
+		FeeAmount:    decimal.NewFromInt(5),
+	}))
+	transactions := f.accountTransactions(t)
+	expectSuccess(t, f.void(transactions[len(transactions)-1].Id, false))
+
+	result := f.charges(t, 0)
+	if len(result.Years) != 2 || result.Years[0].FinancialYear != "2023-24" || result.Years[1].FinancialYear != "2024-25" {
+		t.Fatalf("years = %+v, want 2023-24 and 2024-25", result.Years)
+	}
+	assertDecimal(t, "2023-24 stt", result.Years[0].Stt, "50")
+	assertDecimal(t, "2023-24 total", result.Years[0].Total, "77.02")
+	assertDecimal(t, "2024-25 unitemised", result.Years[1].Unitemised, "10")
+	assertDecimal(t, "2024-25 total", result.Years[1].Total, "10")
+	if result.Totals.Transactions != 2 {
+		t.Errorf("transactions = %d, want 2", result.Totals.Transactions)
+	}
+	assertDecimal(t, "total brokerage", result.Totals.Brokerage, "15")
+	assertDecimal(t, "total", result.Totals.Total, "87.02")
+
+	single := f.charges(t, 2024)
+	if len(single.Years) != 1 || single.Years[0].FinancialYear != "2024-25" {
+		t.Fatalf("years = %+v, want only 2024-25", single.Years)
+	}
+	assertDecimal(t, "single year total", single.Totals.Total, "10")
+
+	expectStatus(t, f.usecase.StockCharges(domain.ClientStockChargesRequest{UserId: 2, AccountId: f.accountId}), http.StatusBadRequest)
+}
@@ -0,0 +1,184 @@
+package stock
+
+import (
+	"assetio/internal/adapters/handler/response"
+	"assetio/internal/constant"
+	"assetio/internal/domain"
+	"assetio/internal/port"
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// StockConsistency scans the inventories, ledgers and transactions of an account, or of every account, for
+// rows that disagree with each other.
+//
+// Parameters:
+//   - request: domain.ClientStockConsistencyRequest - the account to check, or zero for all accounts.
+//
+// Returns:
+//   - domain.Response - a report listing every broken invariant; an empty list means the data is consistent.
+func (s *stockUsecase) StockConsistency(request domain.ClientStockConsistencyRequest) domain.Response {
+	ctx := context.Background()
+	res := response.New()
+
+	resData, err := Check(ctx, s.mysql, request)
+	if err != nil {
+		s.logger.Errorw(ctx, "Check failed",
+			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+			constant.ERROR_MESSAGE, err.Error(),
+			constant.REQUEST, request,
+		)
+		res.SetStatus(http.StatusInternalServerError)
+		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+		return res
+	}
+
+	res.SetData(resData)
+	return res
+}
+
+// Check runs the consistency checks in a single transaction of repo, so every row is read from the same
+// snapshot. It is shared by StockConsistency and the check command.
+func Check(ctx context.Context, repo port.RepositoryStore, request domain.ClientStockConsistencyRequest) (domain.ClientStockConsistencyResponse, error) {
+	var resData domain.ClientStockConsistencyResponse
+	err := repo.WithTx(ctx, func(repo port.RepositoryStore) error {
+		var err error
+		resData, err = check(ctx, repo, request)
+		return err
+	})
+	return resData, err
+}
+
+// check verifies that:
+//   - replaying each inventory's ledger reproduces its stored totals,
+//   - every ledger entry is linked to an existing transaction,
+//   - the ledger entries of each transaction add up to its quantity,
+//   - no inventory, ledger entry or transaction has a negative quantity,
+//   - no inventory or ledger entry carries a value on zero quantity.
+func check(ctx context.Context, repo port.RepositoryStore, request domain.ClientStockConsistencyRequest) (domain.ClientStockConsistencyResponse, error) {
+	resData := domain.ClientStockConsistencyResponse{
+		AccountId: request.AccountId,
+		Issues:    []domain.ClientStockConsistencyIssue{},
+	}
+	report := func(issue domain.ClientStockConsistencyIssue) {
+		resData.Issues = append(resData.Issues, issue)
+	}
+
+	inventories, err := repo.GetInventoriesByAccountIdOrSecurityId(ctx, request.AccountId, 0)
+	if err != nil {
+		return resData, err
+	}
+
+	inventoriesById := make(map[int]domain.Inventories, len(inventories))
+	for _, inventory := range inventories {
+		inventoriesById[inventory.Id] = inventory
+		resData.InventoriesChecked++
+
+		issue := domain.ClientStockConsistencyIssue{
+			AccountId:   inventory.AccountId,
+			StockId:     inventory.SecurityId,
+			InventoryId: inventory.Id,
+		}
+
+		if inventory.AvailableQuantity.IsNegative() {
+			issue.Type = domain.CONSISTENCY_NEGATIVE_QUANTITY
+			issue.Message = fmt.Sprintf("inventory quantity is %s", inventory.AvailableQuantity)
+			report(issue)
+		}
+		if inventory.AvailableQuantity.IsZero() && !inventory.TotalValue.IsZero() {
+			issue.Type = domain.CONSISTENCY_ZERO_QUANTITY_VALUE
+			issue.Message = fmt.Sprintf("inventory holds a value of %s on zero quantity", inventory.TotalValue)
+			report(issue)
+		}
+
+		ledgers, err := repo.GetInventoryLedgersByInventoryId(ctx, inventory.Id)
+		if err != nil {
+			return resData, err
+		}
+		resData.LedgersChecked += len(ledgers)
+
+		for _, ledger := range ledgers {
+			ledgerIssue := issue
+			ledgerIssue.LedgerId = ledger.Id
+			if ledger.Quantity.IsNegative() {
+				ledgerIssue.Type = domain.CONSISTENCY_NEGATIVE_QUANTITY
+				ledgerIssue.Message = fmt.Sprintf("%s ledger quantity is %s", ledger.Type, ledger.Quantity)
+				report(ledgerIssue)
+			}
+			if ledger.Quantity.IsZero() && !ledger.TotalValue.IsZero() {
+				ledgerIssue.Type = domain.CONSISTENCY_ZERO_QUANTITY_VALUE
+				ledgerIssue.Message = fmt.Sprintf("%s ledger holds a value of %s on zero quantity", ledger.Type, ledger.TotalValue)
+				report(ledgerIssue)
+			}
+		}
+
+		rebuilt, err := replayLedgers(ledgers)
+		if err != nil {
+			issue.Type = domain.CONSISTENCY_LEDGER_UNREPLAYABLE
+			issue.Message = err.Error()
+			report(issue)
+			continue
+		}
+		if !rebuilt.Quantity.Equal(inventory.AvailableQuantity) || !rebuilt.AveragePrice.Equal(inventory.AveragePrice) || !rebuilt.TotalValue.Equal(inventory.TotalValue) {
+			issue.Type = domain.CONSISTENCY_INVENTORY_MISMATCH
+			issue.Message = fmt.Sprintf("stored quantity %s, average price %s, total value %s; ledger gives %s, %s, %s",
+				inventory.AvailableQuantity, inventory.AveragePrice, inventory.TotalValue,
+				rebuilt.Quantity, rebuilt.AveragePrice, rebuilt.TotalValue)
+			report(issue)
+		}
+	}
+
+	unlinked, err := repo.GetUnlinkedInventoryLedgers(ctx, request.AccountId)
+	if err != nil {
+		return resData, err
+	}
+	for _, ledger := range unlinked {
+		inventory := inventoriesById[ledger.InventoryId]
+		issue := domain.ClientStockConsistencyIssue{
+			Type:          domain.CONSISTENCY_LEDGER_UNLINKED,
+			AccountId:     inventory.AccountId,
+			StockId:       inventory.SecurityId,
+			InventoryId:   ledger.InventoryId,
+			LedgerId:      ledger.Id,
+			TransactionId: ledger.TransactionId,
+			Message:       fmt.Sprintf("%s ledger has no transaction", ledger.Type),
+		}
+		if ledger.TransactionId != 0 {
+			issue.Message = fmt.Sprintf("%s ledger refers to missing transaction %d", ledger.Type, ledger.TransactionId)
+		}
+		report(issue)
+	}
+
+	transactions, err := repo.GetTransactionLedgerTotals(ctx, request.AccountId)
+	if err != nil {
+		return resData, err
+	}
+	for _, transaction := range transactions {
+		// Rights entitlements and their renunciation move no shares, so they have no ledger entries to check.
+		if transaction.Type == domain.RIGHTS_ENTITLEMENT || transaction.Type == domain.RIGHTS_RENOUNCE {
+			continue
+		}
+		resData.TransactionsChecked++
+
+		issue := domain.ClientStockConsistencyIssue{
+			AccountId:     transaction.AccountId,
+			StockId:       transaction.SecurityId,
+			TransactionId: transaction.Id,
+		}
+		if transaction.Quantity.IsNegative() {
+			issue.Type = domain.CONSISTENCY_NEGATIVE_QUANTITY
+			issue.Message = fmt.Sprintf("%s transaction quantity is %s", transaction.Type, transaction.Quantity)
+			report(issue)
+		}
+		if !transaction.LedgerQuantity.Equal(transaction.Quantity) {
+			issue.Type = domain.CONSISTENCY_TRANSACTION_MISMATCH
+			issue.Message = fmt.Sprintf("%s transaction quantity is %s but its %d ledger entries add up to %s",
+				transaction.Type, transaction.Quantity, transaction.LedgerCount, transaction.LedgerQuantity)
+			report(issue)
+		}
+	}
+
+	resData.Consistent = len(resData.Issues) == 0
+	return resData, nil
+}
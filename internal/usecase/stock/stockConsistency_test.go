@@ -0,0 +1,105 @@
+package stock
+
+import (
+	"assetio/internal/domain"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func consistencyReport(t *testing.T, res domain.Response) domain.ClientStockConsistencyResponse {
+	t.Helper()
+	var report domain.ClientStockConsistencyResponse
+	if err := json.Unmarshal(expectSuccess(t, res).Data, &report); err != nil {
+		t.Fatalf("decode consistency response: %v", err)
+	}
+	return report
+}
+
+func TestStockConsistencyCleanAccount(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	f.buy(t, 10, 300, date(2024, time.February, 1))
+	expectSuccess(t, f.usecase.StockSell(f.sell(12)))
+	expectSuccess(t, f.usecase.StockSplit(domain.ClientStockSplitRequest{
+		UserId:    1,
+		AccountId: f.accountId,
+		StockId:   f.stockId,
+		Quantity:  decimal.NewFromInt(8),
+	}))
+	expectSuccess(t, f.usecase.StockMerge(domain.ClientStockMergeRequest{
+		UserId:        1,
+		AccountId:     f.accountId,
+		ParentStockId: f.stockId,
+		NewStockId:    f.otherId,
+		Quantity:      decimal.NewFromInt(4),
+		Date:          date(2024, time.March, 1),
+	}))
+
+	report := consistencyReport(t, f.usecase.StockConsistency(domain.ClientStockConsistencyRequest{AccountId: f.accountId}))
+	if !report.Consistent || len(report.Issues) != 0 {
+		t.Fatalf("report = %+v, want no issues", report)
+	}
+	if report.InventoriesChecked != 3 || report.TransactionsChecked != 6 {
+		t.Fatalf("report checked %d inventories and %d transactions, want 3 and 6", report.InventoriesChecked, report.TransactionsChecked)
+	}
+}
+
+func TestStockConsistencyReportsBrokenInvariants(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	inventory := f.inventories(t, f.stockId)[0]
+
+	// A sale whose ledger never got its transaction id, and whose inventory update went missing.
+	_, err := f.repo.InsertInventoryLedger(ctx, domain.InventoryLedger{
+		InventoryId: inventory.Id,
+		Type:        domain.SELL,
+		Quantity:    decimal.NewFromInt(10),
+		TotalValue:  decimal.NewFromInt(2000),
+		Date:        time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A transaction recorded without any ledger entries.
+	_, err = f.repo.InsertTransaction(ctx, domain.Transactions{AccountId: f.accountId, SecurityId: f.stockId, Type: domain.SELL, Quantity: decimal.NewFromInt(10)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An inventory emptied without clearing its value.
+	empty, err := f.repo.InsertInventoryData(ctx, domain.Inventories{AccountId: f.accountId, SecurityId: f.otherId})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.repo.UpdateInventoryDetailsById(ctx, empty.Id, 0, decimal.Zero, decimal.Zero, decimal.NewFromInt(50)); err != nil {
+		t.Fatal(err)
+	}
+
+	report := consistencyReport(t, f.usecase.StockConsistency(domain.ClientStockConsistencyRequest{}))
+	if report.Consistent {
+		t.Fatal("report is consistent, want issues")
+	}
+
+	found := map[domain.ConsistencyIssueType]int{}
+	for _, issue := range report.Issues {
+		found[issue.Type]++
+	}
+	want := map[domain.ConsistencyIssueType]int{
+		domain.CONSISTENCY_INVENTORY_MISMATCH:   2,
+		domain.CONSISTENCY_LEDGER_UNLINKED:      1,
+		domain.CONSISTENCY_TRANSACTION_MISMATCH: 1,
+		domain.CONSISTENCY_ZERO_QUANTITY_VALUE:  1,
+	}
+	for issueType, count := range want {
+		if found[issueType] != count {
+			t.Errorf("%s reported %d times, want %d: %+v", issueType, found[issueType], count, report.Issues)
+		}
+	}
+	if len(report.Issues) != 5 {
+		t.Errorf("got %d issues, want 5: %+v", len(report.Issues), report.Issues)
+	}
+}
@@ -0,0 +1,40 @@
+package stock
+
+import (
+	"assetio/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestStockDemergeApportionsCost(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+
+	expectSuccess(t, f.usecase.StockDemerge(domain.ClientStockDemergeRequest{
+		UserId:           1,
+		AccountId:        f.accountId,
+		ParentStockId:    f.stockId,
+		NewStockId:       f.otherId,
+		Quantity:         decimal.NewFromInt(5),
+		Date:             date(2024, time.March, 1),
+		ListingPrice:     decimal.NewFromInt(40),
+		ParentStockPrice: decimal.NewFromInt(200),
+	}))
+
+	// The new stock lists at 20% of the parent price, so each new share carries 20% of the
+	// parent's per-share cost: 5 shares at 20 move 100 of cost out of the parent.
+	parent := f.inventories(t, f.stockId)
+	assertDecimal(t, "parent quantity", parent[0].AvailableQuantity, "10")
+	assertDecimal(t, "parent total value", parent[0].TotalValue, "900")
+	assertDecimal(t, "parent average price", parent[0].AveragePrice, "90")
+
+	demerged := f.inventories(t, f.otherId)
+	if len(demerged) != 1 {
+		t.Fatalf("got %d demerged inventories, want 1", len(demerged))
+	}
+	assertDecimal(t, "demerged quantity", demerged[0].AvailableQuantity, "5")
+	assertDecimal(t, "demerged total value", demerged[0].TotalValue, "100")
+	assertDecimal(t, "demerged average price", demerged[0].AveragePrice, "20")
+}
@@ -39,6 +39,7 @@ func (s *stockUsecase) StockDividendAdd(request domain.ClientStockDividendAddReq
 		res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "incorrect stock")
 		return res
 	}
+	request.AmountPerQuantity = domain.RoundPrice(request.AmountPerQuantity)
 	date := time.Now()
 
 	if request.Date != "" {
@@ -61,7 +62,7 @@ func (s *stockUsecase) StockDividendAdd(request domain.ClientStockDividendAddReq
 		return res
 	}
 
-	if availableQuanity <= 0 {
+	if !availableQuanity.IsPositive() {
 		res.SetStatus(http.StatusConflict)
 		res.SetError(constant.ERROR_CODE_DATA_EXISTS, "no stocks available to add dividend")
 		return res
@@ -74,7 +75,7 @@ func (s *stockUsecase) StockDividendAdd(request domain.ClientStockDividendAddReq
 		Type:         domain.DIVIDEND,
 		Quantity:     availableQuanity,
 		AveragePrice: request.AmountPerQuantity,
-		TotalValue:   availableQuanity * request.AmountPerQuantity,
+		TotalValue:   domain.RoundValue(availableQuanity.Mul(request.AmountPerQuantity)),
 		Date:         date,
 	})
 
@@ -150,7 +151,7 @@ func (s *stockUsecase) StockDividends(request domain.ClientStockDividendsRequest
 
 	for _, transactionData := range transactionsData {
 		resData = append(resData, domain.ClientStockDividendsResponse{
-			Quantity: int(transactionData.Quantity),
+			Quantity: int(transactionData.Quantity.IntPart()),
 			Amount:   transactionData.Price,
 			Date:     transactionData.Date.Format("02-01-2006"),
 		})
@@ -0,0 +1,213 @@
+package stock
+
+import (
+	"assetio/internal/adapters/handler/response"
+	"assetio/internal/constant"
+	"assetio/internal/domain"
+	"assetio/internal/port"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// StockHoldings reconstructs what an account held at the end of a past day by replaying the ledger of every
+// inventory up to that day, and values each holding at that day's close where the marketer knows one. Voids and
+// edits correct the record rather than happen on a date, so a transaction voided later is left out and an edited one
+// counts as edited.
+//
+// Parameters:
+//   - request: domain.ClientStockHoldingsRequest - contains the account, the day and, optionally, the stock.
+//
+// Returns:
+//   - domain.Response - contains the holdings per stock with their lots, or an error if the request is invalid or a
+//     ledger cannot be replayed.
+func (s *stockUsecase) StockHoldings(request domain.ClientStockHoldingsRequest) domain.Response {
+	ctx := context.Background()
+	res := response.New()
+
+	day, err := time.Parse(constant.DATE_LAYOUT, request.Date)
+	if err != nil {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "invalid date")
+		return res
+	}
+	// The holdings include every entry recorded on the day itself.
+	end := day.Add(24 * time.Hour)
+
+	account, err := s.mysql.GetAccountDataByIdAndUserId(ctx, request.AccountId, request.UserId)
+	if err != nil {
+		s.logger.Errorw(ctx, "GetAccountDataByIdAndUserId failed",
+			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+			constant.ERROR_MESSAGE, err.Error(),
+			constant.REQUEST, request,
+		)
+		res.SetStatus(http.StatusInternalServerError)
+		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+		return res
+	}
+	if account.Id == 0 {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "incorrect account id")
+		return res
+	}
+
+	inventories, err := s.mysql.GetInventoriesByAccountIdOrSecurityId(ctx, request.AccountId, request.StockId)
+	if err != nil {
+		s.logger.Errorw(ctx, "GetInventoriesByAccountIdOrSecurityId failed",
+			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+			constant.ERROR_MESSAGE, err.Error(),
+			constant.REQUEST, request,
+		)
+		res.SetStatus(http.StatusInternalServerError)
+		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+		return res
+	}
+
+	resData := domain.ClientStockHoldingsResponse{
+		Date:   day.Format("02-01-2006"),
+		Totals: domain.ClientStockValue{Priced: true},
+		Stocks: []domain.ClientStockHoldingsStock{},
+	}
+	stocks := map[int]int{}
+	for _, inventory := range inventories {
+		ledgers, err := s.mysql.GetInventoryLedgersByInventoryId(ctx, inventory.Id)
+		if err != nil {
+			s.logger.Errorw(ctx, "GetInventoryLedgersByInventoryId failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return res
+		}
+
+		held, err := replayLedgers(ledgersUntil(ledgers, end))
+		if err != nil {
+			s.logger.Warnw(ctx, "replay failed",
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusUnprocessableEntity)
+			res.SetError(constant.ERROR_CODE_DATA_INVALID, fmt.Errorf("inventory %d: %w", inventory.Id, err).Error())
+			return res
+		}
+		if !held.Quantity.IsPositive() {
+			continue
+		}
+
+		index, ok := stocks[inventory.SecurityId]
+		if !ok {
+			security, err := s.mysql.GetSecurityDataById(ctx, inventory.SecurityId)
+			if err != nil {
+				s.logger.Errorw(ctx, "GetSecurityDataById failed",
+					constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+					constant.ERROR_MESSAGE, err.Error(),
+					constant.REQUEST, request,
+				)
+				res.SetStatus(http.StatusInternalServerError)
+				res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+				return res
+			}
+			index = len(resData.Stocks)
+			stocks[inventory.SecurityId] = index
+			resData.Stocks = append(resData.Stocks, domain.ClientStockHoldingsStock{
+				StockId:     security.Id,
+				StockSymbol: security.Symbol,
+				StockName:   security.Name,
+				Lots:        []domain.ClientStockHoldingsLot{},
+			})
+		}
+
+		stock := &resData.Stocks[index]
+		stock.Lots = append(stock.Lots, domain.ClientStockHoldingsLot{
+			InventoryId:  inventory.Id,
+			Date:         inventory.Date.Format("02-01-2006"),
+			Quantity:     held.Quantity,
+			AveragePrice: held.AveragePrice,
+			TotalValue:   held.TotalValue,
+		})
+		stock.Quantity = stock.Quantity.Add(held.Quantity)
+		stock.InvestedValue = stock.InvestedValue.Add(held.TotalValue)
+	}
+
+	// Value each holding at the close of the day, or at cost when the close is not known.
+	for i := range resData.Stocks {
+		stock := &resData.Stocks[i]
+		stock.AveragePrice = domain.AveragePrice(stock.InvestedValue, stock.Quantity)
+		stock.CurrentValue = stock.InvestedValue
+
+		closePrice, err := s.marketer.QueryClose(stock.StockSymbol, "NSE", day)
+		stock.Priced = err == nil && closePrice > 0
+		if stock.Priced {
+			stock.ClosePrice = domain.RoundPrice(decimal.NewFromFloat(closePrice))
+			stock.CurrentValue = domain.RoundValue(stock.Quantity.Mul(stock.ClosePrice))
+		}
+		completeValue(&stock.ClientStockValue)
+
+		resData.Totals.Priced = resData.Totals.Priced && stock.Priced
+		resData.Totals.InvestedValue = resData.Totals.InvestedValue.Add(stock.InvestedValue)
+		resData.Totals.CurrentValue = resData.Totals.CurrentValue.Add(stock.CurrentValue)
+	}
+	completeValue(&resData.Totals)
+
+	res.SetData(resData)
+	return res
+}
+
+// lotHolding is a lot and what it held at the end of a day.
+type lotHolding struct {
+	inventory domain.Inventories
+	held      domain.ClientStockRebuildTotals
+}
+
+// lotsHeldOn replays the ledger of every lot of a stock in an account up to end and returns the lots that held
+// shares then, oldest first, and the quantity they held together.
+func lotsHeldOn(ctx context.Context, repo port.RepositoryStore, accountId, securityId int, end time.Time) ([]lotHolding, decimal.Decimal, error) {
+	inventories, err := repo.GetInventoriesByAccountIdOrSecurityId(ctx, accountId, securityId)
+	if err != nil {
+		return nil, decimal.Zero, err
+	}
+
+	var lots []lotHolding
+	var total decimal.Decimal
+	for _, inventory := range inventories {
+		ledgers, err := repo.GetInventoryLedgersByInventoryId(ctx, inventory.Id)
+		if err != nil {
+			return nil, decimal.Zero, err
+		}
+		held, err := replayLedgers(ledgersUntil(ledgers, end))
+		if err != nil {
+			return nil, decimal.Zero, fmt.Errorf("inventory %d: %w", inventory.Id, err)
+		}
+		if !held.Quantity.IsPositive() {
+			continue
+		}
+		lots = append(lots, lotHolding{inventory: inventory, held: held})
+		total = total.Add(held.Quantity)
+	}
+
+	sort.SliceStable(lots, func(i, j int) bool {
+		if !lots[i].inventory.Date.Equal(lots[j].inventory.Date) {
+			return lots[i].inventory.Date.Before(lots[j].inventory.Date)
+		}
+		return lots[i].inventory.Id < lots[j].inventory.Id
+	})
+	return lots, total, nil
+}
+
+// ledgersUntil keeps the ledger entries dated before end, and every VOID entry whatever its date, so a voided
+// transaction is left out however late it was voided.
+func ledgersUntil(ledgers []domain.InventoryLedgers, end time.Time) []domain.InventoryLedgers {
+	var kept []domain.InventoryLedgers
+	for _, ledger := range ledgers {
+		if ledger.Type == domain.VOID || ledger.Date.Before(end) {
+			kept = append(kept, ledger)
+		}
+	}
+	return kept
+}
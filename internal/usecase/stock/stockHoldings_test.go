@@ -0,0 +1,118 @@
+package stock
+
+import (
+	"assetio/internal/domain"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// holdings reconstructs the holdings of the fixture account at the end of day and decodes them.
+func (f *fixture) holdings(t *testing.T, day string) domain.ClientStockHoldingsResponse {
+	t.Helper()
+	var result domain.ClientStockHoldingsResponse
+	res := f.usecase.StockHoldings(domain.ClientStockHoldingsRequest{UserId: 1, AccountId: f.accountId, Date: day})
+	if err := json.Unmarshal(expectSuccess(t, res).Data, &result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestStockHoldingsReplaysLedgersToTheDay(t *testing.T) {
+	f := newFixture(t)
+	f.usecase = New(nopLogger{}, f.repo, quoteMarketer{"PARENT": {120, 0}}, nil)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	f.buy(t, 5, 200, date(2024, time.February, 1))
+	expectSuccess(t, f.usecase.StockSell(domain.ClientStockSellRequest{
+		UserId:       1,
+		AccountId:    f.accountId,
+		StockId:      f.stockId,
+		Date:         date(2024, time.March, 1),
+		Quantity:     decimal.NewFromInt(12),
+		AveragePrice: decimal.NewFromInt(250),
+	}))
+
+	if before := f.holdings(t, date(2023, time.December, 31)); len(before.Stocks) != 0 {
+		t.Fatalf("stocks before the first buy = %+v, want none", before.Stocks)
+	}
+
+	january := f.holdings(t, date(2024, time.January, 1))
+	if len(january.Stocks) != 1 || len(january.Stocks[0].Lots) != 1 {
+		t.Fatalf("stocks on the first buy = %+v, want the one lot bought that day", january.Stocks)
+	}
+	stock := january.Stocks[0]
+	assertDecimal(t, "january quantity", stock.Quantity, "10")
+	assertDecimal(t, "january invested", stock.InvestedValue, "1000")
+	assertDecimal(t, "january close", stock.ClosePrice, "120")
+	assertDecimal(t, "january current", stock.CurrentValue, "1200")
+	assertDecimal(t, "january gain", stock.UnrealizedGain, "200")
+	assertDecimal(t, "january gain percent", stock.UnrealizedGainPercent, "20")
+
+	february := f.holdings(t, date(2024, time.February, 29))
+	stock = february.Stocks[0]
+	assertDecimal(t, "february quantity", stock.Quantity, "15")
+	assertDecimal(t, "february invested", stock.InvestedValue, "2000")
+	assertDecimal(t, "february average", stock.AveragePrice, "133.3333")
+
+	// The sell emptied the first lot and took two from the second.
+	march := f.holdings(t, date(2024, time.March, 1))
+	stock = march.Stocks[0]
+	if len(stock.Lots) != 1 {
+		t.Fatalf("lots after the sell = %+v, want only the second lot", stock.Lots)
+	}
+	assertDecimal(t, "march lot quantity", stock.Lots[0].Quantity, "3")
+	assertDecimal(t, "march lot value", stock.Lots[0].TotalValue, "600")
+	if stock.Lots[0].Date != "01-02-2024" {
+		t.Errorf("march lot date = %s, want 01-02-2024", stock.Lots[0].Date)
+	}
+	assertDecimal(t, "march total invested", march.Totals.InvestedValue, "600")
+	assertDecimal(t, "march total current", march.Totals.CurrentValue, "360")
+
+	// A past day has no day's change to report, so none is given.
+	res := f.usecase.StockHoldings(domain.ClientStockHoldingsRequest{UserId: 1, AccountId: f.accountId, Date: date(2024, time.March, 1)})
+	if data := string(expectSuccess(t, res).Data); strings.Contains(data, "day_change") {
+		t.Fatalf("holdings = %s, want no day change", data)
+	}
+}
+
+func TestStockHoldingsLeavesOutVoidedAndUnpriced(t *testing.T) {
+	f := newFixture(t)
+	f.usecase = New(nopLogger{}, f.repo, quoteMarketer{"PARENT": {120, 0}}, nil)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	expectSuccess(t, f.usecase.StockBuy(domain.ClientStockBuyRequest{
+		UserId:       1,
+		AccountId:    f.accountId,
+		StockId:      f.otherId,
+		Date:         date(2024, time.January, 1),
+		Quantity:     decimal.NewFromInt(4),
+		AveragePrice: decimal.NewFromInt(50),
+	}))
+	f.buy(t, 5, 200, date(2024, time.January, 10))
+
+	// Voiding the second buy months later still removes it from January.
+	buys := f.transactionIds(t, domain.BUY)
+	expectSuccess(t, f.void(buys[len(buys)-1], false))
+
+	result := f.holdings(t, date(2024, time.January, 31))
+	stocks := map[string]domain.ClientStockHoldingsStock{}
+	for _, stock := range result.Stocks {
+		stocks[stock.StockSymbol] = stock
+	}
+	assertDecimal(t, "parent quantity", stocks["PARENT"].Quantity, "10")
+
+	// CHILD has no close, so it is carried at cost and the totals are not priced.
+	child := stocks["CHILD"]
+	if child.Priced || result.Totals.Priced {
+		t.Fatalf("child = %+v, totals = %+v, want both unpriced", child, result.Totals)
+	}
+	assertDecimal(t, "child current", child.CurrentValue, "200")
+	assertDecimal(t, "total invested", result.Totals.InvestedValue, "1200")
+	assertDecimal(t, "total current", result.Totals.CurrentValue, "1400")
+
+	expectStatus(t, f.usecase.StockHoldings(domain.ClientStockHoldingsRequest{UserId: 2, AccountId: f.accountId, Date: date(2024, time.January, 31)}), http.StatusBadRequest)
+	expectStatus(t, f.usecase.StockHoldings(domain.ClientStockHoldingsRequest{UserId: 1, AccountId: f.accountId, Date: "2024-01-31"}), http.StatusBadRequest)
+}
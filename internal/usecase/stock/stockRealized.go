@@ -0,0 +1,223 @@
+package stock
+
+import (
+	"assetio/internal/adapters/handler/response"
+	"assetio/internal/constant"
+	"assetio/internal/domain"
+	"assetio/internal/port"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// StockRealized reports the profit and loss realized by the sells of an account, lot by lot, with totals per stock
+// and for the account.
+//
+// Parameters:
+//   - request: domain.ClientStockRealizedRequest - contains the account and, optionally, the stock and the
+//     range of sell dates to report.
+//
+// Returns:
+//   - domain.Response - contains the realized profit and loss, or an error if the request is invalid.
+func (s *stockUsecase) StockRealized(request domain.ClientStockRealizedRequest) domain.Response {
+	ctx := context.Background()
+	res := response.New()
+
+	var from, to time.Time
+	if request.From != "" {
+		parsedDate, err := time.Parse(constant.DATE_LAYOUT, request.From)
+		if err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "invalid from date")
+			return res
+		}
+		from = parsedDate
+	}
+	if request.To != "" {
+		parsedDate, err := time.Parse(constant.DATE_LAYOUT, request.To)
+		if err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "invalid to date")
+			return res
+		}
+		// The range includes every sell made on the last day.
+		to = parsedDate.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	account, err := s.mysql.GetAccountDataByIdAndUserId(ctx, request.AccountId, request.UserId)
+	if err != nil {
+		s.logger.Errorw(ctx, "GetAccountDataByIdAndUserId failed",
+			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+			constant.ERROR_MESSAGE, err.Error(),
+			constant.REQUEST, request,
+		)
+		res.SetStatus(http.StatusInternalServerError)
+		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+		return res
+	}
+	if account.Id == 0 {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "incorrect account id")
+		return res
+	}
+
+	realizedGains, err := s.mysql.GetRealizedGains(ctx, request.AccountId, request.StockId, from, to)
+	if err != nil {
+		s.logger.Errorw(ctx, "GetRealizedGains failed",
+			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+			constant.ERROR_MESSAGE, err.Error(),
+			constant.REQUEST, request,
+		)
+		res.SetStatus(http.StatusInternalServerError)
+		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+		return res
+	}
+
+	resData := domain.ClientStockRealizedResponse{Stocks: []domain.ClientStockRealizedStock{}}
+	positions := map[int]int{}
+	for _, realizedGain := range realizedGains {
+		position, ok := positions[realizedGain.SecurityId]
+		if !ok {
+			securityData, err := s.mysql.GetSecurityDataById(ctx, realizedGain.SecurityId)
+			if err != nil {
+				s.logger.Errorw(ctx, "GetSecurityDataById failed",
+					constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+					constant.ERROR_MESSAGE, err.Error(),
+					constant.REQUEST, request,
+				)
+				res.SetStatus(http.StatusInternalServerError)
+				res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+				return res
+			}
+
+			position = len(resData.Stocks)
+			positions[realizedGain.SecurityId] = position
+			resData.Stocks = append(resData.Stocks, domain.ClientStockRealizedStock{
+				StockId:     realizedGain.SecurityId,
+				StockSymbol: securityData.Symbol,
+				StockName:   securityData.Name,
+				Lots:        []domain.ClientStockRealizedLot{},
+			})
+		}
+
+		stock := &resData.Stocks[position]
+		stock.Lots = append(stock.Lots, domain.ClientStockRealizedLot{
+			TransactionId:   realizedGain.TransactionId,
+			InventoryId:     realizedGain.InventoryId,
+			Date:            realizedGain.Date.Format("02-01-2006"),
+			AcquisitionDate: realizedGain.AcquisitionDate.Format("02-01-2006"),
+			Quantity:        realizedGain.Quantity,
+			CostPrice:       realizedGain.CostPrice,
+			CostValue:       realizedGain.CostValue,
+			SalePrice:       realizedGain.SalePrice,
+			Proceeds:        realizedGain.Proceeds,
+			Fee:             realizedGain.Fee,
+			Gain:            realizedGain.Gain,
+		})
+		addRealized(&stock.Totals, realizedGain)
+		addRealized(&resData.Totals, realizedGain)
+	}
+
+	res.SetData(resData)
+	return res
+}
+
+// addRealized adds one realized gain record to totals.
+func addRealized(totals *domain.ClientStockRealizedTotals, realizedGain domain.RealizedGains) {
+	totals.Quantity = totals.Quantity.Add(realizedGain.Quantity)
+	totals.CostValue = totals.CostValue.Add(realizedGain.CostValue)
+	totals.Proceeds = totals.Proceeds.Add(realizedGain.Proceeds)
+	totals.Fee = totals.Fee.Add(realizedGain.Fee)
+	totals.Gain = totals.Gain.Add(realizedGain.Gain)
+}
+
+// realizeInventory rewrites the realized gain records of an inventory from its ledger. Every sell consumes the lot at
+// the average price held just before it, and is charged its share of the sell fee by quantity together with the
+// share of the lot's purchase fees carried by the shares it sold. Entries of voided transactions are skipped by the
+// replay, so they realize nothing.
+func realizeInventory(ctx context.Context, repo port.RepositoryStore, inventoryId int) error {
+	inventory, err := repo.GetInventoryDataById(ctx, inventoryId)
+	if err != nil {
+		return err
+	}
+	ledgers, err := repo.GetInventoryLedgersByInventoryId(ctx, inventoryId)
+	if err != nil {
+		return err
+	}
+
+	// A sell ledger entry of an older release carries the fee of the whole sell, so the fee is apportioned from the
+	// transaction.
+	sells := map[int]domain.Transactions{}
+	for _, ledger := range ledgers {
+		if ledger.Type != domain.SELL || ledger.TransactionId == 0 {
+			continue
+		}
+		if _, ok := sells[ledger.TransactionId]; ok {
+			continue
+		}
+		transaction, err := repo.GetTransactionDataById(ctx, ledger.TransactionId)
+		if err != nil {
+			return err
+		}
+		sells[ledger.TransactionId] = transaction
+	}
+
+	var realizedGains []domain.RealizedGains
+	var purchaseFees decimal.Decimal
+	_, err = replay(ledgers, func(ledger domain.InventoryLedgers, held domain.ClientStockRebuildTotals) {
+		switch ledger.Type {
+		case domain.BUY, domain.RIGHTS_SUBSCRIBE, domain.RIGHTS_CALL:
+			purchaseFees = purchaseFees.Add(ledger.Fee)
+		case domain.MERGER_TRANSFER:
+			if held.Quantity.IsPositive() {
+				purchaseFees = purchaseFees.Sub(domain.RoundValue(purchaseFees.Mul(ledger.Quantity).Div(held.Quantity)))
+			}
+		case domain.SELL:
+			var purchaseFee decimal.Decimal
+			if held.Quantity.IsPositive() {
+				purchaseFee = domain.RoundValue(purchaseFees.Mul(ledger.Quantity).Div(held.Quantity))
+			}
+			purchaseFees = purchaseFees.Sub(purchaseFee)
+
+			sellFee := ledger.Fee
+			if transaction, ok := sells[ledger.TransactionId]; ok && transaction.Quantity.IsPositive() {
+				sellFee = domain.RoundValue(transaction.Fee.Mul(ledger.Quantity).Div(transaction.Quantity))
+			}
+
+			costValue := domain.RoundValue(ledger.Quantity.Mul(held.AveragePrice))
+			fee := purchaseFee.Add(sellFee)
+			realizedGains = append(realizedGains, domain.RealizedGains{
+				AccountId:       inventory.AccountId,
+				SecurityId:      inventory.SecurityId,
+				InventoryId:     inventory.Id,
+				LedgerId:        ledger.Id,
+				TransactionId:   ledger.TransactionId,
+				Quantity:        ledger.Quantity,
+				AcquisitionDate: inventory.Date,
+				CostPrice:       held.AveragePrice,
+				CostValue:       costValue,
+				SalePrice:       ledger.Price,
+				Proceeds:        ledger.TotalValue,
+				Fee:             fee,
+				Gain:            ledger.TotalValue.Sub(costValue).Sub(fee),
+				Date:            ledger.Date,
+			})
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("inventory %d: %w", inventoryId, err)
+	}
+
+	if err := repo.DeleteRealizedGainsByInventoryId(ctx, inventoryId); err != nil {
+		return err
+	}
+	for _, realizedGain := range realizedGains {
+		if _, err := repo.InsertRealizedGain(ctx, realizedGain); err != nil {
+			return err
+		}
+	}
+	return nil
+}
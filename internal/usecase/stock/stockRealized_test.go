@@ -0,0 +1,97 @@
+package stock
+
+import (
+	"assetio/internal/domain"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func (f *fixture) realized(t *testing.T) domain.ClientStockRealizedResponse {
+	t.Helper()
+	var result domain.ClientStockRealizedResponse
+	res := f.usecase.StockRealized(domain.ClientStockRealizedRequest{UserId: 1, AccountId: f.accountId})
+	if err := json.Unmarshal(expectSuccess(t, res).Data, &result); err != nil {
+		t.Fatalf("decode realized response: %v", err)
+	}
+	return result
+}
+
+func TestStockRealizedChargesFeesPerLot(t *testing.T) {
+	f := newFixture(t)
+	expectSuccess(t, f.usecase.StockBuy(domain.ClientStockBuyRequest{
+		UserId:       1,
+		AccountId:    f.accountId,
+		StockId:      f.stockId,
+		Date:         date(2024, time.January, 1),
+		Quantity:     decimal.NewFromInt(10),
+		AveragePrice: decimal.NewFromInt(100),
+		FeeAmount:    decimal.NewFromInt(10),
+	}))
+	f.buy(t, 10, 300, date(2024, time.February, 1))
+	sell := f.sell(12)
+	sell.FeeAmount = decimal.NewFromInt(12)
+	expectSuccess(t, f.usecase.StockSell(sell))
+
+	// The first lot carries its whole purchase fee and ten twelfths of the sell fee.
+	result := f.realized(t)
+	if len(result.Stocks) != 1 || len(result.Stocks[0].Lots) != 2 {
+		t.Fatalf("realized = %+v, want one stock sold from two lots", result)
+	}
+	first, second := result.Stocks[0].Lots[0], result.Stocks[0].Lots[1]
+	assertDecimal(t, "first lot cost", first.CostValue, "1000")
+	assertDecimal(t, "first lot fee", first.Fee, "20")
+	assertDecimal(t, "first lot gain", first.Gain, "1980")
+	if first.AcquisitionDate != "01-01-2024" || first.Date != "01-03-2024" {
+		t.Fatalf("first lot dates = %s, %s", first.AcquisitionDate, first.Date)
+	}
+	assertDecimal(t, "second lot cost", second.CostValue, "600")
+	assertDecimal(t, "second lot fee", second.Fee, "2")
+	assertDecimal(t, "second lot gain", second.Gain, "-2")
+	assertDecimal(t, "total quantity", result.Totals.Quantity, "12")
+	assertDecimal(t, "total gain", result.Totals.Gain, "1978")
+
+	// Correcting the purchase price revalues the sale of the first lot.
+	buy := f.transactionIds(t, domain.BUY)[0]
+	updateResult(t, f.update(domain.ClientTransactionUpdateRequest{TransactionId: buy, AveragePrice: decimalPtr(200)}))
+	assertDecimal(t, "total gain after update", f.realized(t).Totals.Gain, "978")
+
+	// A voided sale realizes nothing.
+	expectSuccess(t, f.usecase.TransactionVoid(domain.ClientTransactionVoidRequest{
+		UserId:        1,
+		AccountId:     f.accountId,
+		TransactionId: f.transactionIds(t, domain.SELL)[0],
+	}))
+	if result := f.realized(t); len(result.Stocks) != 0 || !result.Totals.Gain.IsZero() {
+		t.Fatalf("realized after void = %+v", result)
+	}
+}
+
+func TestStockRealizedFiltersBySellDate(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	expectSuccess(t, f.usecase.StockSell(f.sell(4)))
+
+	request := domain.ClientStockRealizedRequest{UserId: 1, AccountId: f.accountId, From: date(2024, time.March, 1), To: date(2024, time.March, 1)}
+	var result domain.ClientStockRealizedResponse
+	if err := json.Unmarshal(expectSuccess(t, f.usecase.StockRealized(request)).Data, &result); err != nil {
+		t.Fatal(err)
+	}
+	assertDecimal(t, "gain on the day", result.Totals.Gain, "800")
+
+	request.From = date(2024, time.March, 2)
+	request.To = ""
+	if err := json.Unmarshal(expectSuccess(t, f.usecase.StockRealized(request)).Data, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Stocks) != 0 {
+		t.Fatalf("realized after the sell date = %+v", result.Stocks)
+	}
+
+	// Another user's account is refused.
+	request.UserId = 2
+	expectStatus(t, f.usecase.StockRealized(request), http.StatusBadRequest)
+}
@@ -0,0 +1,232 @@
+package stock
+
+import (
+	"assetio/internal/adapters/handler/response"
+	"assetio/internal/constant"
+	"assetio/internal/domain"
+	"assetio/internal/port"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrLedgerReplay is returned when an inventory's ledger cannot be replayed: it holds an entry type the
+// replay does not know, or it sells more than the inventory held at that point.
+var ErrLedgerReplay = errors.New("ledger cannot be replayed")
+
+// StockRebuild recomputes the running totals of inventories from their ledger entries.
+//
+// Parameters:
+//   - request: domain.ClientStockRebuildRequest - selects the inventories by account, stock or both,
+//     and whether the rebuilt totals are written back or only reported.
+//
+// Returns:
+//   - domain.Response - lists every inventory whose stored totals differ from the replayed ones,
+//     or an error when a ledger cannot be replayed.
+func (s *stockUsecase) StockRebuild(request domain.ClientStockRebuildRequest) domain.Response {
+	ctx := context.Background()
+	res := response.New()
+
+	var resData domain.ClientStockRebuildResponse
+
+	// Read and write in one unit of work so every inventory is compared and updated against the same snapshot.
+	err := s.withTx(ctx, func(repo port.RepositoryStore) error {
+		var err error
+		resData, err = rebuild(ctx, repo, request)
+		return err
+	})
+	if errors.Is(err, ErrLedgerReplay) {
+		s.logger.Warnw(ctx, "rebuild failed",
+			constant.ERROR_MESSAGE, err.Error(),
+			constant.REQUEST, request,
+		)
+		res.SetStatus(http.StatusUnprocessableEntity)
+		res.SetError(constant.ERROR_CODE_DATA_INVALID, err.Error())
+		return res
+	}
+	if err != nil {
+		return s.txFailed(ctx, res, err, request)
+	}
+
+	res.SetData(resData)
+	return res
+}
+
+// Rebuild runs the same rebuild as StockRebuild in a single transaction of repo. It is used by the rebuild
+// command, which talks to the store directly rather than through the HTTP use cases.
+func Rebuild(ctx context.Context, repo port.RepositoryStore, request domain.ClientStockRebuildRequest) (domain.ClientStockRebuildResponse, error) {
+	var resData domain.ClientStockRebuildResponse
+	err := repo.WithTx(ctx, func(repo port.RepositoryStore) error {
+		var err error
+		resData, err = rebuild(ctx, repo, request)
+		return err
+	})
+	return resData, err
+}
+
+// rebuild replays the ledger of every selected inventory and compares the result with the stored totals.
+// When request.Apply is set the differing inventories are updated, guarded by their version like any other write.
+func rebuild(ctx context.Context, repo port.RepositoryStore, request domain.ClientStockRebuildRequest) (domain.ClientStockRebuildResponse, error) {
+	resData := domain.ClientStockRebuildResponse{
+		Applied:     request.Apply,
+		Inventories: []domain.ClientStockRebuildInventory{},
+	}
+
+	inventories, err := repo.GetInventoriesByAccountIdOrSecurityId(ctx, request.AccountId, request.StockId)
+	if err != nil {
+		return resData, err
+	}
+
+	for _, inventory := range inventories {
+		ledgers, err := repo.GetInventoryLedgersByInventoryId(ctx, inventory.Id)
+		if err != nil {
+			return resData, err
+		}
+
+		rebuilt, err := replayLedgers(ledgers)
+		if err != nil {
+			return resData, fmt.Errorf("inventory %d: %w", inventory.Id, err)
+		}
+		resData.Checked++
+
+		// Applying also rewrites the realized gains, which fills them in for sells recorded before they were kept.
+		if request.Apply {
+			if err := realizeInventory(ctx, repo, inventory.Id); err != nil {
+				return resData, err
+			}
+		}
+
+		stored := domain.ClientStockRebuildTotals{
+			Quantity:     inventory.AvailableQuantity,
+			AveragePrice: inventory.AveragePrice,
+			TotalValue:   inventory.TotalValue,
+		}
+		if stored.Quantity.Equal(rebuilt.Quantity) && stored.AveragePrice.Equal(rebuilt.AveragePrice) && stored.TotalValue.Equal(rebuilt.TotalValue) {
+			continue
+		}
+
+		resData.Inventories = append(resData.Inventories, domain.ClientStockRebuildInventory{
+			InventoryId: inventory.Id,
+			AccountId:   inventory.AccountId,
+			StockId:     inventory.SecurityId,
+			Stored:      stored,
+			Rebuilt:     rebuilt,
+		})
+
+		if request.Apply {
+			err = repo.UpdateInventoryDetailsById(ctx, inventory.Id, inventory.Version, rebuilt.Quantity, rebuilt.AveragePrice, rebuilt.TotalValue)
+			if err != nil {
+				return resData, err
+			}
+		}
+	}
+
+	return resData, nil
+}
+
+// settleInventory replays the ledger of an inventory after an entry was recorded on a past date, writes the totals
+// back when they changed, guarded by the version like any other write, and rewrites the realized gains of the sells
+// that follow the entry.
+func settleInventory(ctx context.Context, repo port.RepositoryStore, inventoryId int) error {
+	inventory, err := repo.GetInventoryDataById(ctx, inventoryId)
+	if err != nil {
+		return err
+	}
+	ledgers, err := repo.GetInventoryLedgersByInventoryId(ctx, inventoryId)
+	if err != nil {
+		return err
+	}
+
+	replayed, err := replayLedgers(ledgers)
+	if err != nil {
+		return fmt.Errorf("inventory %d: %w", inventoryId, err)
+	}
+	if err := realizeInventory(ctx, repo, inventoryId); err != nil {
+		return err
+	}
+
+	if inventory.AvailableQuantity.Equal(replayed.Quantity) && inventory.AveragePrice.Equal(replayed.AveragePrice) && inventory.TotalValue.Equal(replayed.TotalValue) {
+		return nil
+	}
+	return repo.UpdateInventoryDetailsById(ctx, inventoryId, inventory.Version, replayed.Quantity, replayed.AveragePrice, replayed.TotalValue)
+}
+
+// replayLedgers applies ledger entries to an empty inventory in date order, earliest id first within a date,
+// with the same arithmetic the use cases use when they record each entry:
+//   - BUY, SPLIT, BONUS, MERGER and DEMERGER add their quantity and value,
+//   - SELL removes its quantity and values the remainder at the unchanged average price,
+//   - MERGER_TRANSFER removes the quantity and value moved to the merged stock,
+//   - DEMERGER_TRANSFER removes the value moved to the demerged stock and keeps the quantity.
+//
+// A VOID entry cancels its transaction: every entry of that transaction is skipped, so the totals are exactly
+// those the inventory would have had if the transaction had never been recorded.
+func replayLedgers(ledgers []domain.InventoryLedgers) (domain.ClientStockRebuildTotals, error) {
+	return replay(ledgers, nil)
+}
+
+// replay is replayLedgers calling visit, when set, with every entry it applies and the totals held just before it.
+func replay(ledgers []domain.InventoryLedgers, visit func(ledger domain.InventoryLedgers, held domain.ClientStockRebuildTotals)) (domain.ClientStockRebuildTotals, error) {
+	voided := map[int]bool{}
+	for _, ledger := range ledgers {
+		if ledger.Type == domain.VOID {
+			voided[ledger.TransactionId] = true
+		}
+	}
+
+	ordered := make([]domain.InventoryLedgers, 0, len(ledgers))
+	for _, ledger := range ledgers {
+		if ledger.Type != domain.VOID && !voided[ledger.TransactionId] {
+			ordered = append(ordered, ledger)
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if !ordered[i].Date.Equal(ordered[j].Date) {
+			return ordered[i].Date.Before(ordered[j].Date)
+		}
+		return ordered[i].Id < ordered[j].Id
+	})
+
+	var quantity, averagePrice, totalValue decimal.Decimal
+	for _, ledger := range ordered {
+		if visit != nil {
+			visit(ledger, domain.ClientStockRebuildTotals{Quantity: quantity, AveragePrice: averagePrice, TotalValue: totalValue})
+		}
+
+		switch ledger.Type {
+		case domain.BUY, domain.SPLIT, domain.BONUS, domain.MERGER, domain.DEMERGER, domain.RIGHTS_SUBSCRIBE:
+			quantity = quantity.Add(ledger.Quantity)
+			totalValue = totalValue.Add(ledger.TotalValue)
+			averagePrice = domain.AveragePrice(totalValue, quantity)
+		case domain.SELL:
+			quantity = quantity.Sub(ledger.Quantity)
+			totalValue = domain.RoundValue(quantity.Mul(averagePrice))
+		case domain.MERGER_TRANSFER:
+			quantity = quantity.Sub(ledger.Quantity)
+			totalValue = totalValue.Sub(ledger.TotalValue)
+			averagePrice = domain.AveragePrice(totalValue, quantity)
+		case domain.DEMERGER_TRANSFER:
+			totalValue = totalValue.Sub(ledger.TotalValue)
+			averagePrice = domain.AveragePrice(totalValue, quantity)
+		case domain.RIGHTS_CALL:
+			// A call on partly paid shares adds to their cost; its quantity is the shares it was paid on.
+			totalValue = totalValue.Add(ledger.TotalValue)
+			averagePrice = domain.AveragePrice(totalValue, quantity)
+		default:
+			return domain.ClientStockRebuildTotals{}, fmt.Errorf("%w: ledger %d has type %s", ErrLedgerReplay, ledger.Id, ledger.Type)
+		}
+
+		if quantity.IsNegative() {
+			return domain.ClientStockRebuildTotals{}, fmt.Errorf("%w: ledger %d leaves a quantity of %s", ErrLedgerReplay, ledger.Id, quantity)
+		}
+	}
+
+	return domain.ClientStockRebuildTotals{
+		Quantity:     quantity,
+		AveragePrice: averagePrice,
+		TotalValue:   totalValue,
+	}, nil
+}
@@ -0,0 +1,106 @@
+package stock
+
+import (
+	"assetio/internal/domain"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func rebuildResult(t *testing.T, res domain.Response) domain.ClientStockRebuildResponse {
+	t.Helper()
+	var result domain.ClientStockRebuildResponse
+	if err := json.Unmarshal(expectSuccess(t, res).Data, &result); err != nil {
+		t.Fatalf("decode rebuild response: %v", err)
+	}
+	return result
+}
+
+func TestStockRebuildMatchesRecordedTotals(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	f.buy(t, 20, 160, date(2024, time.February, 1))
+	expectSuccess(t, f.usecase.StockSell(f.sell(12)))
+	expectSuccess(t, f.usecase.StockBonus(domain.ClientStockBonusRequest{
+		UserId:    1,
+		AccountId: f.accountId,
+		StockId:   f.stockId,
+		Quantity:  decimal.NewFromInt(7),
+	}))
+	expectSuccess(t, f.usecase.StockDemerge(domain.ClientStockDemergeRequest{
+		UserId:           1,
+		AccountId:        f.accountId,
+		ParentStockId:    f.stockId,
+		NewStockId:       f.otherId,
+		Quantity:         decimal.NewFromInt(5),
+		Date:             date(2024, time.April, 1),
+		ListingPrice:     decimal.NewFromInt(30),
+		ParentStockPrice: decimal.NewFromInt(210),
+	}))
+
+	// Replaying the ledgers written by the use cases reproduces their running totals exactly.
+	result := rebuildResult(t, f.usecase.StockRebuild(domain.ClientStockRebuildRequest{AccountId: f.accountId}))
+	if result.Checked != 4 || len(result.Inventories) != 0 {
+		t.Fatalf("rebuild = %+v, want 4 inventories checked and none changed", result)
+	}
+}
+
+func TestStockRebuildDryRunAndApply(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	expectSuccess(t, f.usecase.StockSell(f.sell(4)))
+
+	// Corrupt the running totals behind the ledger's back.
+	ctx := context.Background()
+	inventory := f.inventories(t, f.stockId)[0]
+	if err := f.repo.UpdateInventoryDetailsById(ctx, inventory.Id, inventory.Version, decimal.NewFromInt(9), decimal.NewFromInt(100), decimal.NewFromInt(900)); err != nil {
+		t.Fatal(err)
+	}
+
+	request := domain.ClientStockRebuildRequest{StockId: f.stockId}
+	result := rebuildResult(t, f.usecase.StockRebuild(request))
+	if result.Applied || len(result.Inventories) != 1 {
+		t.Fatalf("dry run = %+v, want one unapplied difference", result)
+	}
+	assertDecimal(t, "stored quantity", result.Inventories[0].Stored.Quantity, "9")
+	assertDecimal(t, "rebuilt quantity", result.Inventories[0].Rebuilt.Quantity, "6")
+	assertDecimal(t, "rebuilt total value", result.Inventories[0].Rebuilt.TotalValue, "600")
+	assertDecimal(t, "quantity after dry run", f.inventories(t, f.stockId)[0].AvailableQuantity, "9")
+
+	request.Apply = true
+	if result := rebuildResult(t, f.usecase.StockRebuild(request)); !result.Applied || len(result.Inventories) != 1 {
+		t.Fatalf("apply = %+v, want one applied difference", result)
+	}
+	inventory = f.inventories(t, f.stockId)[0]
+	assertDecimal(t, "quantity", inventory.AvailableQuantity, "6")
+	assertDecimal(t, "average price", inventory.AveragePrice, "100")
+	assertDecimal(t, "total value", inventory.TotalValue, "600")
+
+	if result := rebuildResult(t, f.usecase.StockRebuild(request)); len(result.Inventories) != 0 {
+		t.Fatalf("second apply = %+v, want nothing left to change", result)
+	}
+}
+
+func TestStockRebuildRejectsUnreplayableLedger(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	inventory := f.inventories(t, f.stockId)[0]
+
+	// A sale dated before the purchase would take the inventory below zero.
+	_, err := f.repo.InsertInventoryLedger(context.Background(), domain.InventoryLedger{
+		InventoryId: inventory.Id,
+		Type:        domain.SELL,
+		Quantity:    decimal.NewFromInt(4),
+		Date:        time.Date(2023, time.December, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectStatus(t, f.usecase.StockRebuild(domain.ClientStockRebuildRequest{AccountId: f.accountId, Apply: true}), http.StatusUnprocessableEntity)
+	assertDecimal(t, "quantity", f.inventories(t, f.stockId)[0].AvailableQuantity, "10")
+}
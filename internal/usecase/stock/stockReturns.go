@@ -0,0 +1,347 @@
+package stock
+
+import (
+	"assetio/internal/adapters/handler/response"
+	"assetio/internal/constant"
+	"assetio/internal/domain"
+	"context"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// cashFlow is money paid into a holding, negative, or taken out of it, positive, on a date. Notional flows move cost
+// between securities on a merger or demerger; they count for a single stock and are left out for the account.
+type cashFlow struct {
+	date     time.Time
+	amount   decimal.Decimal
+	notional bool
+}
+
+// returnsSeries gathers the cash flows and the current value of one stock, one account or the whole report.
+type returnsSeries struct {
+	flows        []cashFlow
+	currentValue decimal.Decimal
+	priced       bool
+}
+
+// include adds the cash flows and value of part, leaving out its notional flows.
+func (r *returnsSeries) include(part returnsSeries) {
+	for _, flow := range part.flows {
+		if !flow.notional {
+			r.flows = append(r.flows, flow)
+		}
+	}
+	r.currentValue = r.currentValue.Add(part.currentValue)
+	r.priced = r.priced && part.priced
+}
+
+// StockReturns computes the money-weighted return (XIRR) and the compound annual growth rate (CAGR) of a user's
+// stocks from their buy, sell and dividend cash flows and the current market value, per stock, per account and over
+// every reported account. Splits and bonuses move no cash and are skipped; mergers and demergers move cost between
+// stocks without cash, so they count for each stock and cancel out for the account.
+//
+// Parameters:
+//   - request: domain.ClientStockReturnsRequest - contains the user and, optionally, the account and the stock to
+//     report.
+//
+// Returns:
+//   - domain.Response - contains the returns, or an error if the request is invalid.
+func (s *stockUsecase) StockReturns(request domain.ClientStockReturnsRequest) domain.Response {
+	ctx := context.Background()
+	res := response.New()
+
+	var accounts []domain.Accounts
+	if request.AccountId != 0 {
+		account, err := s.mysql.GetAccountDataByIdAndUserId(ctx, request.AccountId, request.UserId)
+		if err != nil {
+			s.logger.Errorw(ctx, "GetAccountDataByIdAndUserId failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return res
+		}
+		if account.Id == 0 {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "incorrect account id")
+			return res
+		}
+		accounts = append(accounts, account)
+	} else {
+		var err error
+		accounts, err = s.mysql.GetAccountsData(ctx, request.UserId)
+		if err != nil {
+			s.logger.Errorw(ctx, "GetAccountsData failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return res
+		}
+	}
+
+	now := time.Now().UTC()
+	resData := domain.ClientStockReturnsResponse{
+		Date:     now.Format("02-01-2006"),
+		Accounts: []domain.ClientStockReturnsAccount{},
+	}
+	total := returnsSeries{priced: true}
+	securities := map[int]domain.Securities{}
+
+	for _, account := range accounts {
+		transactions, err := s.mysql.GetTransactionsByAccountId(ctx, account.Id)
+		if err != nil {
+			s.logger.Errorw(ctx, "GetTransactionsByAccountId failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return res
+		}
+		holdings, err := s.mysql.GetInvertriesSummaryByAccountIdAndSecurityType(ctx, account.Id, constant.SECURITY_TYPE_STOCK)
+		if err != nil {
+			s.logger.Errorw(ctx, "GetInvertriesSummaryByAccountIdAndSecurityType failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return res
+		}
+
+		// Collect the cash flows of every stock, in the order the stocks were first traded.
+		stocks := map[int]*returnsSeries{}
+		var order []int
+		series := func(securityId int) *returnsSeries {
+			if _, ok := stocks[securityId]; !ok {
+				stocks[securityId] = &returnsSeries{priced: true}
+				order = append(order, securityId)
+			}
+			return stocks[securityId]
+		}
+		for _, transaction := range transactions {
+			if request.StockId != 0 && transaction.SecurityId != request.StockId {
+				continue
+			}
+			if flow, ok := transactionFlow(transaction); ok {
+				stock := series(transaction.SecurityId)
+				stock.flows = append(stock.flows, flow)
+			}
+		}
+
+		// Value what is still held at the market price, or at cost without a quote.
+		for _, holding := range holdings {
+			if request.StockId != 0 && holding.SecurityId != request.StockId {
+				continue
+			}
+			stock := series(holding.SecurityId)
+			stock.currentValue = holding.TotalValue
+			markerData, err := s.marketer.Query(holding.SecuritySymbol, "NSE")
+			stock.priced = err == nil
+			if stock.priced {
+				stock.currentValue = domain.RoundValue(holding.AvailableQuantity.Mul(decimal.NewFromFloat(markerData.GetMarketPrice())))
+			}
+		}
+
+		accountData := domain.ClientStockReturnsAccount{
+			AccountId:   account.Id,
+			AccountName: account.Name,
+			Stocks:      []domain.ClientStockReturnsStock{},
+		}
+		accountSeries := returnsSeries{priced: true}
+		for _, securityId := range order {
+			security, ok := securities[securityId]
+			if !ok {
+				security, err = s.mysql.GetSecurityDataById(ctx, securityId)
+				if err != nil {
+					s.logger.Errorw(ctx, "GetSecurityDataById failed",
+						constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+						constant.ERROR_MESSAGE, err.Error(),
+						constant.REQUEST, request,
+					)
+					res.SetStatus(http.StatusInternalServerError)
+					res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+					return res
+				}
+				securities[securityId] = security
+			}
+
+			accountData.Stocks = append(accountData.Stocks, domain.ClientStockReturnsStock{
+				StockId:            securityId,
+				StockSymbol:        security.Symbol,
+				StockName:          security.Name,
+				ClientStockReturns: computeReturns(*stocks[securityId], now),
+			})
+			accountSeries.include(*stocks[securityId])
+		}
+		accountData.ClientStockReturns = computeReturns(accountSeries, now)
+		resData.Accounts = append(resData.Accounts, accountData)
+		total.include(accountSeries)
+	}
+	resData.Totals = computeReturns(total, now)
+
+	res.SetData(resData)
+	return res
+}
+
+// transactionFlow returns the cash flow of a transaction. Buys and the subscriptions and calls of a rights issue pay
+// in their value and fee, sells, buybacks, dividends and renounced rights pay out their value less the fee, and
+// mergers and demergers move the recorded cost as a notional flow: into the stock that receives it and out of the
+// stock that gives it up. Voided transactions, splits, bonuses and rights entitlements move nothing.
+func transactionFlow(transaction domain.Transactions) (cashFlow, bool) {
+	if transaction.State == domain.TRANSACTION_STATE_VOIDED {
+		return cashFlow{}, false
+	}
+
+	flow := cashFlow{date: transaction.Date}
+	switch transaction.Type {
+	case domain.BUY, domain.RIGHTS_SUBSCRIBE, domain.RIGHTS_CALL:
+		flow.amount = transaction.TotalValue.Add(transaction.Fee).Neg()
+	case domain.SELL, domain.BUYBACK, domain.DIVIDEND, domain.RIGHTS_RENOUNCE:
+		flow.amount = transaction.TotalValue.Sub(transaction.Fee)
+	case domain.MERGER, domain.DEMERGER:
+		flow.amount = transaction.TotalValue.Neg()
+		flow.notional = true
+	case domain.MERGER_TRANSFER, domain.DEMERGER_TRANSFER:
+		flow.amount = transaction.TotalValue
+		flow.notional = true
+	default:
+		return cashFlow{}, false
+	}
+	return flow, true
+}
+
+// computeReturns sums the cash flows of a series and solves its XIRR and CAGR, valuing what is held on now.
+func computeReturns(series returnsSeries, now time.Time) domain.ClientStockReturns {
+	returns := domain.ClientStockReturns{
+		Priced:       series.priced,
+		CurrentValue: series.currentValue,
+	}
+	if len(series.flows) == 0 {
+		return returns
+	}
+
+	start := series.flows[0].date
+	for _, flow := range series.flows {
+		if flow.date.Before(start) {
+			start = flow.date
+		}
+		if flow.amount.IsNegative() {
+			returns.Invested = returns.Invested.Sub(flow.amount)
+		} else {
+			returns.Received = returns.Received.Add(flow.amount)
+		}
+	}
+	returns.StartDate = start.Format("02-01-2006")
+	returns.Gain = returns.Received.Add(returns.CurrentValue).Sub(returns.Invested)
+
+	flows := series.flows
+	if series.currentValue.IsPositive() {
+		flows = append(append([]cashFlow{}, flows...), cashFlow{date: now, amount: series.currentValue})
+	}
+	if rate, ok := xirr(flows); ok {
+		returns.XIRR = annualPercent(rate)
+	}
+
+	// CAGR grows what was invested into what came back and what is held, over the years since the first flow.
+	years := now.Sub(start).Hours() / 24 / 365
+	if returns.Invested.IsPositive() && years > 0 {
+		growth := returns.Received.Add(returns.CurrentValue).Div(returns.Invested).InexactFloat64()
+		returns.CAGR = annualPercent(math.Pow(growth, 1/years) - 1)
+	}
+	return returns
+}
+
+// annualPercent converts a rate to a percentage rounded to domain.PERCENT_SCALE places.
+func annualPercent(rate float64) *decimal.Decimal {
+	if math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return nil
+	}
+	percent := decimal.NewFromFloat(rate * 100).Round(domain.PERCENT_SCALE)
+	return &percent
+}
+
+// xirr solves for the annual rate at which the cash flows discount to zero, by Newton's method from 10% and by
+// bisection when Newton's method does not converge. It reports false when there is no such rate, as when the flows
+// never both pay in and take out.
+func xirr(flows []cashFlow) (float64, bool) {
+	if len(flows) < 2 {
+		return 0, false
+	}
+
+	start := flows[0].date
+	for _, flow := range flows {
+		if flow.date.Before(start) {
+			start = flow.date
+		}
+	}
+	years := make([]float64, len(flows))
+	amounts := make([]float64, len(flows))
+	var scale float64
+	for i, flow := range flows {
+		years[i] = flow.date.Sub(start).Hours() / 24 / 365
+		amounts[i] = flow.amount.InexactFloat64()
+		scale += math.Abs(amounts[i])
+	}
+	tolerance := scale * 1e-9
+
+	npv := func(rate float64) (value, derivative float64) {
+		for i := range amounts {
+			discount := math.Pow(1+rate, years[i])
+			value += amounts[i] / discount
+			derivative -= years[i] * amounts[i] / (discount * (1 + rate))
+		}
+		return value, derivative
+	}
+
+	rate := 0.1
+	for i := 0; i < 100; i++ {
+		value, derivative := npv(rate)
+		if math.Abs(value) < tolerance {
+			return rate, true
+		}
+		if derivative == 0 {
+			break
+		}
+		next := rate - value/derivative
+		if next <= -1 || math.IsNaN(next) || math.IsInf(next, 0) {
+			break
+		}
+		rate = next
+	}
+
+	// Bracket a sign change between a total loss and an ever larger gain, then halve the bracket.
+	low, high := -0.999999, 1.0
+	lowValue, _ := npv(low)
+	highValue, _ := npv(high)
+	for lowValue*highValue > 0 && high < 1e6 {
+		high *= 2
+		highValue, _ = npv(high)
+	}
+	if lowValue*highValue > 0 {
+		return 0, false
+	}
+	for i := 0; i < 200; i++ {
+		mid := (low + high) / 2
+		midValue, _ := npv(mid)
+		if math.Abs(midValue) < tolerance {
+			return mid, true
+		}
+		if lowValue*midValue < 0 {
+			high = mid
+		} else {
+			low, lowValue = mid, midValue
+		}
+	}
+	return (low + high) / 2, true
+}
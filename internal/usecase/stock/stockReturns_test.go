@@ -0,0 +1,210 @@
+package stock
+
+import (
+	"assetio/internal/constant"
+	"assetio/internal/domain"
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// returns reports the returns of the user's stocks through the use case and decodes them.
+func (f *fixture) returns(t *testing.T, request domain.ClientStockReturnsRequest) domain.ClientStockReturnsResponse {
+	t.Helper()
+	var result domain.ClientStockReturnsResponse
+	if err := json.Unmarshal(expectSuccess(t, f.usecase.StockReturns(request)).Data, &result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// daysAgo is a trade date the given number of days before today.
+func daysAgo(days int) string {
+	return time.Now().UTC().AddDate(0, 0, -days).Format(constant.DATE_LAYOUT)
+}
+
+// assertPercent compares a rate solved from today's date, which moves by a fraction of a day between runs.
+func assertPercent(t *testing.T, name string, got *decimal.Decimal, want float64) {
+	t.Helper()
+	if got == nil {
+		t.Fatalf("%s = nil, want %v", name, want)
+	}
+	if math.Abs(got.InexactFloat64()-want) > 0.1 {
+		t.Errorf("%s = %s, want about %v", name, got, want)
+	}
+}
+
+func TestXirrSolvesAnnualRate(t *testing.T) {
+	start := time.Date(2023, time.January, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		flows []cashFlow
+		want  float64
+		ok    bool
+	}{
+		{
+			name: "one year gain",
+			flows: []cashFlow{
+				{date: start, amount: decimal.NewFromInt(-1000)},
+				{date: start.AddDate(0, 0, 365), amount: decimal.NewFromInt(1100)},
+			},
+			want: 0.1,
+			ok:   true,
+		},
+		{
+			name: "loss with a dividend",
+			flows: []cashFlow{
+				{date: start, amount: decimal.NewFromInt(-1000)},
+				{date: start.AddDate(0, 0, 365), amount: decimal.NewFromInt(50)},
+				{date: start.AddDate(0, 0, 730), amount: decimal.NewFromInt(700)},
+			},
+			want: -0.13797,
+			ok:   true,
+		},
+		{
+			name: "nothing taken out",
+			flows: []cashFlow{
+				{date: start, amount: decimal.NewFromInt(-1000)},
+				{date: start.AddDate(0, 0, 30), amount: decimal.NewFromInt(-500)},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rate, ok := xirr(test.flows)
+			if ok != test.ok {
+				t.Fatalf("solved = %v, want %v", ok, test.ok)
+			}
+			if ok && math.Abs(rate-test.want) > 0.0001 {
+				t.Errorf("rate = %v, want %v", rate, test.want)
+			}
+		})
+	}
+}
+
+func TestStockReturnsPerStockAndAccount(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 80, daysAgo(365))
+	expectSuccess(t, f.usecase.StockDividendAdd(domain.ClientStockDividendAddRequest{
+		UserId:            1,
+		AccountId:         f.accountId,
+		StockId:           f.stockId,
+		Date:              daysAgo(182),
+		AmountPerQuantity: decimal.NewFromInt(2),
+	}))
+
+	result := f.returns(t, domain.ClientStockReturnsRequest{UserId: 1, AccountId: f.accountId})
+	if len(result.Accounts) != 1 || len(result.Accounts[0].Stocks) != 1 {
+		t.Fatalf("accounts = %+v, want one account holding one stock", result.Accounts)
+	}
+
+	// 800 invested a year ago paid a dividend of 20 and is worth 1000 at the quoted price of 100. The dividend came
+	// back half way through the year, so the money-weighted return beats the growth rate.
+	stock := result.Accounts[0].Stocks[0]
+	if stock.StockSymbol != "PARENT" || !stock.Priced {
+		t.Fatalf("stock = %+v, want the priced PARENT holding", stock)
+	}
+	assertDecimal(t, "invested", stock.Invested, "800")
+	assertDecimal(t, "current value", stock.CurrentValue, "1000")
+	assertDecimal(t, "received", stock.Received, "20")
+	assertDecimal(t, "gain", stock.Gain, "220")
+	assertPercent(t, "cagr", stock.CAGR, 27.5)
+	if stock.XIRR == nil || !stock.XIRR.GreaterThan(*stock.CAGR) {
+		t.Errorf("xirr = %v, want above the cagr of %s", stock.XIRR, stock.CAGR)
+	}
+	assertDecimal(t, "account gain", result.Accounts[0].Gain, "220")
+	assertDecimal(t, "total gain", result.Totals.Gain, "220")
+}
+
+func TestStockReturnsMergerMovesCostWithoutCash(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 80, daysAgo(365))
+	expectSuccess(t, f.usecase.StockMerge(domain.ClientStockMergeRequest{
+		UserId:        1,
+		AccountId:     f.accountId,
+		ParentStockId: f.stockId,
+		NewStockId:    f.otherId,
+		Quantity:      decimal.NewFromInt(5),
+		Date:          daysAgo(100),
+	}))
+
+	result := f.returns(t, domain.ClientStockReturnsRequest{UserId: 1, AccountId: f.accountId})
+	stocks := map[string]domain.ClientStockReturnsStock{}
+	for _, stock := range result.Accounts[0].Stocks {
+		stocks[stock.StockSymbol] = stock
+	}
+
+	// The parent hands its cost on at cost, so it neither gains nor loses.
+	parent := stocks["PARENT"]
+	assertDecimal(t, "parent invested", parent.Invested, "800")
+	assertDecimal(t, "parent received", parent.Received, "800")
+	assertDecimal(t, "parent gain", parent.Gain, "0")
+
+	child := stocks["CHILD"]
+	assertDecimal(t, "child invested", child.Invested, "800")
+	assertDecimal(t, "child current value", child.CurrentValue, "500")
+	assertDecimal(t, "child gain", child.Gain, "-300")
+
+	// The account only paid for the original buy.
+	account := result.Accounts[0]
+	assertDecimal(t, "account invested", account.Invested, "800")
+	assertDecimal(t, "account received", account.Received, "0")
+	assertDecimal(t, "account gain", account.Gain, "-300")
+	assertPercent(t, "account cagr", account.CAGR, -37.5)
+}
+
+func TestStockReturnsAcrossAccounts(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	second, err := f.repo.InsertAccountData(ctx, domain.Accounts{UserId: 1, Name: "second", Status: constant.ACCOUNT_STATUS_ACTIVE})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := f.repo.InsertAccountData(ctx, domain.Accounts{UserId: 2, Name: "other user", Status: constant.ACCOUNT_STATUS_ACTIVE})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.buy(t, 10, 80, daysAgo(365))
+	expectSuccess(t, f.usecase.StockBuy(domain.ClientStockBuyRequest{
+		UserId:       1,
+		AccountId:    second.Id,
+		StockId:      f.otherId,
+		Date:         daysAgo(200),
+		Quantity:     decimal.NewFromInt(4),
+		AveragePrice: decimal.NewFromInt(125),
+	}))
+	expectSuccess(t, f.usecase.StockSell(domain.ClientStockSellRequest{
+		UserId:       1,
+		AccountId:    second.Id,
+		StockId:      f.otherId,
+		Date:         daysAgo(100),
+		Quantity:     decimal.NewFromInt(2),
+		AveragePrice: decimal.NewFromInt(150),
+	}))
+
+	result := f.returns(t, domain.ClientStockReturnsRequest{UserId: 1})
+	if len(result.Accounts) != 2 {
+		t.Fatalf("accounts = %+v, want the two accounts of the user", result.Accounts)
+	}
+	assertDecimal(t, "second invested", result.Accounts[1].Invested, "500")
+	assertDecimal(t, "second received", result.Accounts[1].Received, "300")
+	assertDecimal(t, "second gain", result.Accounts[1].Gain, "0")
+
+	totals := result.Totals
+	assertDecimal(t, "total invested", totals.Invested, "1300")
+	assertDecimal(t, "total received", totals.Received, "300")
+	assertDecimal(t, "total current value", totals.CurrentValue, "1200")
+	assertDecimal(t, "total gain", totals.Gain, "200")
+	if totals.XIRR == nil || totals.CAGR == nil {
+		t.Fatalf("totals = %+v, want both rates", totals)
+	}
+
+	expectStatus(t, f.usecase.StockReturns(domain.ClientStockReturnsRequest{UserId: 1, AccountId: other.Id}), http.StatusBadRequest)
+}
@@ -0,0 +1,645 @@
+package stock
+
+import (
+	"assetio/internal/adapters/handler/response"
+	"assetio/internal/constant"
+	"assetio/internal/domain"
+	"assetio/internal/port"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// StockRightsEntitle records the rights entitlements (REs) a holding brings in a rights issue. The shares held at the
+// end of the record date are eligible, and the account is entitled to the whole rights shares the ratio gives. An
+// entitlement moves no shares: it is recorded as a transaction without ledger entries, and the entitlements are then
+// renounced or subscribed.
+//
+// Parameters:
+//   - request: domain.ClientStockRightsEntitleRequest - contains the account, the stock, the record date, the ratio,
+//     the issue price and the part of it payable on application.
+//
+// Returns:
+//   - domain.Response - contains the rights issue, the eligible holding and the entitlements,
+//     or an error if the request is invalid or nothing was held.
+func (s *stockUsecase) StockRightsEntitle(request domain.ClientStockRightsEntitleRequest) domain.Response {
+	ctx := context.Background()
+	res := response.New()
+
+	if !s.ownsAccount(ctx, res, request.AccountId, request.UserId, request) {
+		return res
+	}
+
+	security, err := s.mysql.GetSecurityDataById(ctx, request.StockId)
+	if err != nil {
+		s.logger.Errorw(ctx, "GetSecurityDataById failed",
+			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+			constant.ERROR_MESSAGE, err.Error(),
+			constant.REQUEST, request,
+		)
+		res.SetStatus(http.StatusInternalServerError)
+		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+		return res
+	}
+	if security.Type != constant.SECURITY_TYPE_STOCK {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "invalid stock")
+		return res
+	}
+
+	// Apply the rounding rules to the request before any arithmetic. Shares paid in full on application are
+	// recorded with the whole issue price payable then.
+	request.IssuePrice = domain.RoundPrice(request.IssuePrice)
+	request.ApplicationPrice = domain.RoundPrice(request.ApplicationPrice)
+	if request.ApplicationPrice.IsZero() {
+		request.ApplicationPrice = request.IssuePrice
+	}
+
+	// The shares held at the end of the record date are eligible; without one the issue applies to today's holding.
+	recordDate := time.Now()
+	end := recordDate
+	if request.RecordDate != "" {
+		recordDate, err = time.Parse(constant.DATE_LAYOUT, request.RecordDate)
+		if err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "invalid record date")
+			return res
+		}
+		end = recordDate.Add(24 * time.Hour)
+	}
+
+	var resData domain.ClientStockRightsEntitleResponse
+
+	// Run every write inside one unit of work so a partial failure leaves no orphan rows behind.
+	err = s.withTx(ctx, func(repo port.RepositoryStore) error {
+		_, held, err := lotsHeldOn(ctx, repo, request.AccountId, request.StockId, end)
+		if errors.Is(err, ErrLedgerReplay) {
+			res.SetStatus(http.StatusUnprocessableEntity)
+			res.SetError(constant.ERROR_CODE_DATA_INVALID, err.Error())
+			return errTxAborted
+		}
+		if err != nil {
+			s.logger.Errorw(ctx, "lotsHeldOn failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
+
+		entitled := held.Mul(request.RatioRights).Div(request.RatioHeld)
+		resData = domain.ClientStockRightsEntitleResponse{
+			RecordDate: recordDate.Format("02-01-2006"),
+			Held:       held,
+			Entitled:   entitled.Floor(),
+			Fractional: domain.RoundQuantity(entitled.Sub(entitled.Floor())),
+		}
+		if !resData.Entitled.IsPositive() {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "the holding brings no rights entitlements")
+			return errTxAborted
+		}
+
+		transactionData, err := repo.InsertTransaction(ctx, domain.Transactions{
+			AccountId:    request.AccountId,
+			SecurityId:   security.Id,
+			Type:         domain.RIGHTS_ENTITLEMENT,
+			Quantity:     resData.Entitled,
+			AveragePrice: request.IssuePrice,
+			Date:         recordDate,
+		})
+		if err != nil {
+			s.logger.Errorw(ctx, "InsertTransaction failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
+
+		rightsIssue, err := repo.InsertRightsIssue(ctx, domain.RightsIssues{
+			AccountId:     request.AccountId,
+			SecurityId:    security.Id,
+			TransactionId: transactionData.Id,
+			RecordDate:    recordDate,
+			RatioRights:   request.RatioRights,
+			RatioHeld:     request.RatioHeld,
+			IssuePrice:    request.IssuePrice,
+			PaidPrice:     request.ApplicationPrice,
+			Entitled:      resData.Entitled,
+		})
+		if err != nil {
+			s.logger.Errorw(ctx, "InsertRightsIssue failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
+		resData.RightsIssueId = rightsIssue.Id
+
+		return nil
+	})
+	if err != nil {
+		return s.txFailed(ctx, res, err, request)
+	}
+
+	resData.Message = "rights entitlement added successfully"
+
+	res.SetData(resData)
+	return res
+}
+
+// StockRightsRenounce records the sale or renunciation of rights entitlements. Entitlements cost nothing, so the
+// proceeds less the fee are what the sale gains; they are recorded on a transaction without ledger entries and
+// realized as a gain on entitlements held from the record date.
+//
+// Parameters:
+//   - request: domain.ClientStockRightsRenounceRequest - contains the rights issue, the entitlements given up,
+//     the price they were sold at and the fee.
+//
+// Returns:
+//   - domain.Response - contains the rights issue after the renunciation,
+//     or an error if more entitlements are renounced than are unused.
+func (s *stockUsecase) StockRightsRenounce(request domain.ClientStockRightsRenounceRequest) domain.Response {
+	ctx := context.Background()
+	res := response.New()
+
+	if !s.ownsAccount(ctx, res, request.AccountId, request.UserId, request) {
+		return res
+	}
+
+	request.Quantity = domain.RoundQuantity(request.Quantity)
+	request.Price = domain.RoundPrice(request.Price)
+	request.FeeAmount = domain.RoundValue(request.FeeAmount)
+
+	date, ok := rightsDate(res, request.Date)
+	if !ok {
+		return res
+	}
+
+	var resData domain.ClientStockRightsResponse
+
+	// Run every write inside one unit of work so a partial failure leaves no orphan rows behind.
+	err := s.withTx(ctx, func(repo port.RepositoryStore) error {
+		rightsIssue, ok := s.rightsIssue(ctx, res, repo, request.RightsIssueId, request.AccountId, request)
+		if !ok {
+			return errTxAborted
+		}
+		if date.Before(rightsIssue.RecordDate) {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "date is before the record date")
+			return errTxAborted
+		}
+		if request.Quantity.GreaterThan(rightsIssue.Unused()) {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "not enough unused rights entitlements")
+			return errTxAborted
+		}
+
+		transactionData, err := repo.InsertTransaction(ctx, domain.Transactions{
+			AccountId:    request.AccountId,
+			SecurityId:   rightsIssue.SecurityId,
+			Type:         domain.RIGHTS_RENOUNCE,
+			Quantity:     request.Quantity,
+			AveragePrice: request.Price,
+			TotalValue:   domain.RoundValue(request.Quantity.Mul(request.Price)),
+			Fee:          request.FeeAmount,
+			Date:         date,
+		})
+		if err != nil {
+			s.logger.Errorw(ctx, "InsertTransaction failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
+
+		// The renunciation disposes of the entitlements; they belong to no lot.
+		_, err = repo.InsertRealizedGain(ctx, domain.RealizedGains{
+			AccountId:       request.AccountId,
+			SecurityId:      rightsIssue.SecurityId,
+			TransactionId:   transactionData.Id,
+			Quantity:        request.Quantity,
+			AcquisitionDate: rightsIssue.RecordDate,
+			SalePrice:       request.Price,
+			Proceeds:        transactionData.TotalValue,
+			Fee:             request.FeeAmount,
+			Gain:            transactionData.TotalValue.Sub(request.FeeAmount),
+			Date:            date,
+		})
+		if err != nil {
+			s.logger.Errorw(ctx, "InsertRealizedGain failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
+
+		rightsIssue.Renounced = rightsIssue.Renounced.Add(request.Quantity)
+		if !s.updateRightsIssue(ctx, res, repo, rightsIssue, request) {
+			return errTxAborted
+		}
+		resData = rightsResponse(rightsIssue)
+
+		return nil
+	})
+	if err != nil {
+		return s.txFailed(ctx, res, err, request)
+	}
+
+	resData.Message = "rights entitlement renounced successfully"
+
+	res.SetData(resData)
+	return res
+}
+
+// StockRightsSubscribe takes up rights entitlements. The rights shares form a lot of their own, acquired on
+// allotment at the price payable on application; while it is below the issue price the shares are partly paid and
+// each call paid later adds to the lot's cost. An issue is subscribed once.
+//
+// Parameters:
+//   - request: domain.ClientStockRightsSubscribeRequest - contains the rights issue, the entitlements taken up,
+//     the allotment date and the fee.
+//
+// Returns:
+//   - domain.Response - contains the rights issue and the lot of rights shares,
+//     or an error if more entitlements are subscribed than are unused.
+func (s *stockUsecase) StockRightsSubscribe(request domain.ClientStockRightsSubscribeRequest) domain.Response {
+	ctx := context.Background()
+	res := response.New()
+
+	if !s.ownsAccount(ctx, res, request.AccountId, request.UserId, request) {
+		return res
+	}
+
+	request.Quantity = domain.RoundQuantity(request.Quantity)
+	request.FeeAmount = domain.RoundValue(request.FeeAmount)
+
+	date, ok := rightsDate(res, request.Date)
+	if !ok {
+		return res
+	}
+
+	var resData domain.ClientStockRightsResponse
+
+	// Run every write inside one unit of work so a partial failure leaves no orphan rows behind.
+	err := s.withTx(ctx, func(repo port.RepositoryStore) error {
+		rightsIssue, ok := s.rightsIssue(ctx, res, repo, request.RightsIssueId, request.AccountId, request)
+		if !ok {
+			return errTxAborted
+		}
+		if date.Before(rightsIssue.RecordDate) {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "date is before the record date")
+			return errTxAborted
+		}
+		if rightsIssue.InventoryId != 0 {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "rights issue is already subscribed")
+			return errTxAborted
+		}
+		if request.Quantity.GreaterThan(rightsIssue.Unused()) {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "not enough unused rights entitlements")
+			return errTxAborted
+		}
+
+		ledger := domain.InventoryLedger{
+			Type:         domain.RIGHTS_SUBSCRIBE,
+			Quantity:     request.Quantity,
+			AveragePrice: rightsIssue.PaidPrice,
+			TotalValue:   domain.RoundValue(request.Quantity.Mul(rightsIssue.PaidPrice)),
+			Fee:          request.FeeAmount,
+			Date:         date,
+		}
+
+		transactionData, err := repo.InsertTransaction(ctx, domain.Transactions{
+			AccountId:    request.AccountId,
+			SecurityId:   rightsIssue.SecurityId,
+			Type:         ledger.Type,
+			Quantity:     ledger.Quantity,
+			AveragePrice: ledger.AveragePrice,
+			TotalValue:   ledger.TotalValue,
+			Fee:          ledger.Fee,
+			Date:         date,
+		})
+		if err != nil {
+			s.logger.Errorw(ctx, "InsertTransaction failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
+
+		// Insert the lot of rights shares, acquired on allotment.
+		inventory, err := repo.InsertInventoryData(ctx, domain.Inventories{
+			AccountId:  request.AccountId,
+			SecurityId: rightsIssue.SecurityId,
+			Date:       date,
+		})
+		if err != nil {
+			s.logger.Errorw(ctx, "InsertInventoryData failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
+
+		ledger.InventoryId = inventory.Id
+		ledger.TransactionId = transactionData.Id
+		if err := s.insertRightsLedger(ctx, res, repo, ledger, request); err != nil {
+			return err
+		}
+
+		rightsIssue.Subscribed = request.Quantity
+		rightsIssue.InventoryId = inventory.Id
+		if !s.updateRightsIssue(ctx, res, repo, rightsIssue, request) {
+			return errTxAborted
+		}
+		resData = rightsResponse(rightsIssue)
+
+		return nil
+	})
+	if err != nil {
+		return s.txFailed(ctx, res, err, request)
+	}
+
+	resData.Message = "rights shares subscribed successfully"
+
+	res.SetData(resData)
+	return res
+}
+
+// StockRightsCall records a call-money payment on the partly paid shares of a rights issue. The call is paid on the
+// rights shares still held on its date and adds to the cost of their lot; the lot's quantity and acquisition date do
+// not change. The calls on an issue cannot take the paid-up price beyond the issue price.
+//
+// Parameters:
+//   - request: domain.ClientStockRightsCallRequest - contains the rights issue, the call per share, the date it was
+//     paid on and the fee.
+//
+// Returns:
+//   - domain.Response - contains the rights issue after the call,
+//     or an error if the shares are not partly paid or none are held.
+func (s *stockUsecase) StockRightsCall(request domain.ClientStockRightsCallRequest) domain.Response {
+	ctx := context.Background()
+	res := response.New()
+
+	if !s.ownsAccount(ctx, res, request.AccountId, request.UserId, request) {
+		return res
+	}
+
+	request.CallPrice = domain.RoundPrice(request.CallPrice)
+	request.FeeAmount = domain.RoundValue(request.FeeAmount)
+
+	date, ok := rightsDate(res, request.Date)
+	if !ok {
+		return res
+	}
+
+	var resData domain.ClientStockRightsResponse
+
+	// Run every write inside one unit of work so a partial failure leaves no orphan rows behind.
+	err := s.withTx(ctx, func(repo port.RepositoryStore) error {
+		rightsIssue, ok := s.rightsIssue(ctx, res, repo, request.RightsIssueId, request.AccountId, request)
+		if !ok {
+			return errTxAborted
+		}
+		if rightsIssue.InventoryId == 0 {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "rights issue is not subscribed")
+			return errTxAborted
+		}
+		if rightsIssue.PaidPrice.Add(request.CallPrice).GreaterThan(rightsIssue.IssuePrice) {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "call exceeds the unpaid part of the issue price")
+			return errTxAborted
+		}
+
+		lots, _, err := lotsHeldOn(ctx, repo, request.AccountId, rightsIssue.SecurityId, date.Add(24*time.Hour))
+		if errors.Is(err, ErrLedgerReplay) {
+			res.SetStatus(http.StatusUnprocessableEntity)
+			res.SetError(constant.ERROR_CODE_DATA_INVALID, err.Error())
+			return errTxAborted
+		}
+		if err != nil {
+			s.logger.Errorw(ctx, "lotsHeldOn failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
+		var held decimal.Decimal
+		for _, lot := range lots {
+			if lot.inventory.Id == rightsIssue.InventoryId {
+				held = lot.held.Quantity
+			}
+		}
+		if !held.IsPositive() {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "no rights shares held on the call date")
+			return errTxAborted
+		}
+
+		ledger := domain.InventoryLedger{
+			InventoryId:  rightsIssue.InventoryId,
+			Type:         domain.RIGHTS_CALL,
+			Quantity:     held,
+			AveragePrice: request.CallPrice,
+			TotalValue:   domain.RoundValue(held.Mul(request.CallPrice)),
+			Fee:          request.FeeAmount,
+			Date:         date,
+		}
+
+		transactionData, err := repo.InsertTransaction(ctx, domain.Transactions{
+			AccountId:    request.AccountId,
+			SecurityId:   rightsIssue.SecurityId,
+			Type:         ledger.Type,
+			Quantity:     ledger.Quantity,
+			AveragePrice: ledger.AveragePrice,
+			TotalValue:   ledger.TotalValue,
+			Fee:          ledger.Fee,
+			Date:         date,
+		})
+		if err != nil {
+			s.logger.Errorw(ctx, "InsertTransaction failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
+
+		ledger.TransactionId = transactionData.Id
+		if err := s.insertRightsLedger(ctx, res, repo, ledger, request); err != nil {
+			return err
+		}
+
+		rightsIssue.PaidPrice = rightsIssue.PaidPrice.Add(request.CallPrice)
+		if !s.updateRightsIssue(ctx, res, repo, rightsIssue, request) {
+			return errTxAborted
+		}
+		resData = rightsResponse(rightsIssue)
+
+		return nil
+	})
+	if err != nil {
+		return s.txFailed(ctx, res, err, request)
+	}
+
+	resData.Message = "rights call paid successfully"
+
+	res.SetData(resData)
+	return res
+}
+
+// ownsAccount reports whether the account belongs to the user, setting the error on res when it does not.
+func (s *stockUsecase) ownsAccount(ctx context.Context, res domain.Response, accountId, userId int, request any) bool {
+	account, err := s.mysql.GetAccountDataByIdAndUserId(ctx, accountId, userId)
+	if err != nil {
+		s.logger.Errorw(ctx, "GetAccountDataByIdAndUserId failed",
+			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+			constant.ERROR_MESSAGE, err.Error(),
+			constant.REQUEST, request,
+		)
+		res.SetStatus(http.StatusInternalServerError)
+		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+		return false
+	}
+	if account.Id == 0 {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "incorrect account id")
+		return false
+	}
+	return true
+}
+
+// rightsIssue loads a rights issue of the account, setting the error on res when there is none.
+func (s *stockUsecase) rightsIssue(ctx context.Context, res domain.Response, repo port.RepositoryStore, rightsIssueId, accountId int, request any) (domain.RightsIssues, bool) {
+	rightsIssue, err := repo.GetRightsIssueByIdAndAccountId(ctx, rightsIssueId, accountId)
+	if err != nil {
+		s.logger.Errorw(ctx, "GetRightsIssueByIdAndAccountId failed",
+			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+			constant.ERROR_MESSAGE, err.Error(),
+			constant.REQUEST, request,
+		)
+		res.SetStatus(http.StatusInternalServerError)
+		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+		return rightsIssue, false
+	}
+	if rightsIssue.Id == 0 {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "incorrect rights issue id")
+		return rightsIssue, false
+	}
+	return rightsIssue, true
+}
+
+// updateRightsIssue saves the progress of a rights issue, setting the error on res when it fails.
+func (s *stockUsecase) updateRightsIssue(ctx context.Context, res domain.Response, repo port.RepositoryStore, rightsIssue domain.RightsIssues, request any) bool {
+	if err := repo.UpdateRightsIssueById(ctx, rightsIssue.Id, rightsIssue); err != nil {
+		s.logger.Errorw(ctx, "UpdateRightsIssueById failed",
+			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+			constant.ERROR_MESSAGE, err.Error(),
+			constant.REQUEST, request,
+		)
+		res.SetStatus(http.StatusInternalServerError)
+		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+		return false
+	}
+	return true
+}
+
+// insertRightsLedger adds a subscription or call to the lot of rights shares and replays the lot, so an entry dated
+// before later ones applies in its place. It sets the error on res and returns errTxAborted when it fails, or
+// domain.ErrInventoryConflict for withTx to retry.
+func (s *stockUsecase) insertRightsLedger(ctx context.Context, res domain.Response, repo port.RepositoryStore, ledger domain.InventoryLedger, request any) error {
+	if _, err := repo.InsertInventoryLedger(ctx, ledger); err != nil {
+		s.logger.Errorw(ctx, "InsertInventoryLedger failed",
+			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+			constant.ERROR_MESSAGE, err.Error(),
+			constant.REQUEST, request,
+		)
+		res.SetStatus(http.StatusInternalServerError)
+		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+		return errTxAborted
+	}
+
+	err := settleInventory(ctx, repo, ledger.InventoryId)
+	if errors.Is(err, domain.ErrInventoryConflict) {
+		// Another request changed this lot since it was read; withTx rolls back and retries.
+		return err
+	}
+	if errors.Is(err, ErrLedgerReplay) {
+		res.SetStatus(http.StatusUnprocessableEntity)
+		res.SetError(constant.ERROR_CODE_DATA_INVALID, err.Error())
+		return errTxAborted
+	}
+	if err != nil {
+		s.logger.Errorw(ctx, "settleInventory failed",
+			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+			constant.ERROR_MESSAGE, err.Error(),
+			constant.REQUEST, request,
+		)
+		res.SetStatus(http.StatusInternalServerError)
+		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+		return errTxAborted
+	}
+	return nil
+}
+
+// rightsDate parses the date of a rights step, defaulting to today, setting the error on res when it is invalid.
+func rightsDate(res domain.Response, value string) (time.Time, bool) {
+	if value == "" {
+		return time.Now(), true
+	}
+	date, err := time.Parse(constant.DATE_LAYOUT, value)
+	if err != nil {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "invalid date")
+		return date, false
+	}
+	return date, true
+}
+
+// rightsResponse reports the progress of a rights issue.
+func rightsResponse(rightsIssue domain.RightsIssues) domain.ClientStockRightsResponse {
+	return domain.ClientStockRightsResponse{
+		RightsIssueId: rightsIssue.Id,
+		InventoryId:   rightsIssue.InventoryId,
+		Entitled:      rightsIssue.Entitled,
+		Renounced:     rightsIssue.Renounced,
+		Subscribed:    rightsIssue.Subscribed,
+		Unused:        rightsIssue.Unused(),
+		IssuePrice:    rightsIssue.IssuePrice,
+		PaidPrice:     rightsIssue.PaidPrice,
+		PartlyPaid:    rightsIssue.InventoryId != 0 && rightsIssue.PaidPrice.LessThan(rightsIssue.IssuePrice),
+	}
+}
@@ -0,0 +1,191 @@
+package stock
+
+import (
+	"assetio/internal/domain"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// rightsEntitle records a rights entitlement through the use case and decodes its report.
+func (f *fixture) rightsEntitle(t *testing.T, request domain.ClientStockRightsEntitleRequest) domain.ClientStockRightsEntitleResponse {
+	t.Helper()
+	request.UserId, request.AccountId, request.StockId = 1, f.accountId, f.stockId
+	var result domain.ClientStockRightsEntitleResponse
+	if err := json.Unmarshal(expectSuccess(t, f.usecase.StockRightsEntitle(request)).Data, &result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// rights decodes the report of a renunciation, subscription or call.
+func rights(t *testing.T, res domain.Response) domain.ClientStockRightsResponse {
+	t.Helper()
+	var result domain.ClientStockRightsResponse
+	if err := json.Unmarshal(expectSuccess(t, res).Data, &result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestStockRightsRenounceAndSubscribe(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	f.buy(t, 5, 100, date(2024, time.March, 2))
+
+	// Shares bought after the record date carry no rights. 1 for 3 on 10 shares is 3 and a third entitlements.
+	entitlement := f.rightsEntitle(t, domain.ClientStockRightsEntitleRequest{
+		RecordDate:  date(2024, time.March, 1),
+		RatioRights: decimal.NewFromInt(1),
+		RatioHeld:   decimal.NewFromInt(3),
+		IssuePrice:  decimal.NewFromInt(90),
+	})
+	assertDecimal(t, "held", entitlement.Held, "10")
+	assertDecimal(t, "entitled", entitlement.Entitled, "3")
+	assertDecimal(t, "fractional", entitlement.Fractional, "0.3333")
+
+	renounce := domain.ClientStockRightsRenounceRequest{
+		UserId:        1,
+		AccountId:     f.accountId,
+		RightsIssueId: entitlement.RightsIssueId,
+		Date:          date(2024, time.March, 10),
+		Quantity:      decimal.NewFromInt(1),
+		Price:         decimal.NewFromInt(12),
+		FeeAmount:     decimal.NewFromInt(1),
+	}
+	result := rights(t, f.usecase.StockRightsRenounce(renounce))
+	assertDecimal(t, "renounced", result.Renounced, "1")
+	assertDecimal(t, "unused after renouncing", result.Unused, "2")
+
+	renounce.Quantity = decimal.NewFromInt(3)
+	expectStatus(t, f.usecase.StockRightsRenounce(renounce), http.StatusBadRequest)
+
+	// The entitlement sold cost nothing, so its proceeds less the fee are a short-term gain.
+	realized := f.realized(t)
+	if len(realized.Stocks) != 1 || len(realized.Stocks[0].Lots) != 1 {
+		t.Fatalf("realized = %+v, want the renunciation", realized)
+	}
+	assertDecimal(t, "renunciation cost", realized.Totals.CostValue, "0")
+	assertDecimal(t, "renunciation gain", realized.Totals.Gain, "11")
+	gains := capitalGainsResult(t, f.usecase.StockCapitalGains(domain.ClientStockCapitalGainsRequest{
+		UserId:        1,
+		AccountId:     f.accountId,
+		FinancialYear: 2023,
+	}))
+	if len(gains.Gains) != 1 || gains.Gains[0].Term != domain.CAPITAL_GAIN_SHORT_TERM || gains.Gains[0].AcquisitionDate != "01-03-2024" {
+		t.Fatalf("capital gains = %+v, want the renunciation short-term from the record date", gains.Gains)
+	}
+	assertDecimal(t, "short-term equity", gains.Summary.EquityShortTerm.Gain, "11")
+
+	subscribe := domain.ClientStockRightsSubscribeRequest{
+		UserId:        1,
+		AccountId:     f.accountId,
+		RightsIssueId: entitlement.RightsIssueId,
+		Date:          date(2024, time.March, 20),
+		Quantity:      decimal.NewFromInt(2),
+		FeeAmount:     decimal.NewFromInt(2),
+	}
+	result = rights(t, f.usecase.StockRightsSubscribe(subscribe))
+	if result.PartlyPaid || !result.Unused.IsZero() {
+		t.Fatalf("subscription = %+v, want the remaining entitlements taken up fully paid", result)
+	}
+	expectStatus(t, f.usecase.StockRightsSubscribe(subscribe), http.StatusBadRequest)
+
+	inventories := f.inventories(t, f.stockId)
+	if len(inventories) != 3 || inventories[2].Id != result.InventoryId {
+		t.Fatalf("inventories = %+v, want the two bought lots and the rights lot", inventories)
+	}
+	rightsLot := inventories[2]
+	if !rightsLot.Date.Equal(time.Date(2024, time.March, 20, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("rights lot date = %s, want the allotment", rightsLot.Date)
+	}
+	assertDecimal(t, "rights lot quantity", rightsLot.AvailableQuantity, "2")
+	assertDecimal(t, "rights lot total value", rightsLot.TotalValue, "180")
+
+	// The entitlement and renunciation move no shares, so the account stays consistent without ledgers for them.
+	f.expectConsistent(t)
+	if rebuilt := rebuildResult(t, f.usecase.StockRebuild(domain.ClientStockRebuildRequest{AccountId: f.accountId})); len(rebuilt.Inventories) != 0 {
+		t.Fatalf("rebuild = %+v, want nothing to change", rebuilt)
+	}
+}
+
+func TestStockRightsPartlyPaidCalls(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 20, 100, date(2024, time.January, 1))
+	entitlement := f.rightsEntitle(t, domain.ClientStockRightsEntitleRequest{
+		RecordDate:       date(2024, time.February, 1),
+		RatioRights:      decimal.NewFromInt(1),
+		RatioHeld:        decimal.NewFromInt(2),
+		IssuePrice:       decimal.NewFromInt(100),
+		ApplicationPrice: decimal.NewFromInt(25),
+	})
+	assertDecimal(t, "entitled", entitlement.Entitled, "10")
+
+	result := rights(t, f.usecase.StockRightsSubscribe(domain.ClientStockRightsSubscribeRequest{
+		UserId:        1,
+		AccountId:     f.accountId,
+		RightsIssueId: entitlement.RightsIssueId,
+		Date:          date(2024, time.February, 15),
+		Quantity:      decimal.NewFromInt(10),
+	}))
+	if !result.PartlyPaid {
+		t.Fatalf("subscription = %+v, want partly paid shares", result)
+	}
+	rightsLotId := result.InventoryId
+
+	// Partly paid shares sold before a call realize a gain on what was paid up then.
+	expectSuccess(t, f.usecase.StockSell(domain.ClientStockSellRequest{
+		UserId:       1,
+		AccountId:    f.accountId,
+		StockId:      f.stockId,
+		InventoryId:  rightsLotId,
+		Date:         date(2024, time.March, 1),
+		Quantity:     decimal.NewFromInt(4),
+		AveragePrice: decimal.NewFromInt(60),
+	}))
+	gains, err := f.repo.GetRealizedGains(context.Background(), f.accountId, f.stockId, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gains) != 1 {
+		t.Fatalf("realized gains = %+v, want the partly paid shares sold", gains)
+	}
+	assertDecimal(t, "cost of partly paid shares", gains[0].CostValue, "100")
+	assertDecimal(t, "gain on partly paid shares", gains[0].Gain, "140")
+
+	// The call is paid on the 6 shares still held and adds to their cost only.
+	call := domain.ClientStockRightsCallRequest{
+		UserId:        1,
+		AccountId:     f.accountId,
+		RightsIssueId: entitlement.RightsIssueId,
+		Date:          date(2024, time.April, 1),
+		CallPrice:     decimal.NewFromInt(50),
+	}
+	result = rights(t, f.usecase.StockRightsCall(call))
+	assertDecimal(t, "paid price after first call", result.PaidPrice, "75")
+	rightsLot := f.inventories(t, f.stockId)[1]
+	assertDecimal(t, "quantity after first call", rightsLot.AvailableQuantity, "6")
+	assertDecimal(t, "total value after first call", rightsLot.TotalValue, "450")
+	assertDecimal(t, "average price after first call", rightsLot.AveragePrice, "75")
+
+	call.CallPrice = decimal.NewFromInt(30)
+	expectStatus(t, f.usecase.StockRightsCall(call), http.StatusBadRequest)
+
+	call.Date, call.CallPrice = date(2024, time.May, 1), decimal.NewFromInt(25)
+	result = rights(t, f.usecase.StockRightsCall(call))
+	if result.PartlyPaid {
+		t.Fatalf("final call = %+v, want the shares fully paid", result)
+	}
+	rightsLot = f.inventories(t, f.stockId)[1]
+	assertDecimal(t, "total value when fully paid", rightsLot.TotalValue, "600")
+	assertDecimal(t, "average price when fully paid", rightsLot.AveragePrice, "100")
+	f.expectConsistent(t)
+
+	// The calls were paid on the shares left by the sell, so it cannot be voided under them.
+	sells := f.transactionIds(t, domain.SELL)
+	expectStatus(t, f.void(sells[0], true), http.StatusUnprocessableEntity)
+}
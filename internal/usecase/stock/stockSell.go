@@ -4,9 +4,13 @@ import (
 	"assetio/internal/adapters/handler/response"
 	"assetio/internal/constant"
 	"assetio/internal/domain"
+	"assetio/internal/port"
 	"context"
+	"errors"
 	"net/http"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 // StockSell handles the sale of stocks by validating security information,
@@ -42,6 +46,20 @@ func (s *stockUsecase) StockSell(request domain.ClientStockSellRequest) domain.R
 		return res
 	}
 
+	// Apply the rounding rules to the request before any arithmetic.
+	request.Quantity = domain.RoundQuantity(request.Quantity)
+	request.AveragePrice = domain.RoundPrice(request.AveragePrice)
+	request.FeeAmount = domain.RoundValue(request.FeeAmount)
+
+	// Settle the fee from the itemised charges or the exchange's rate table.
+	fee, charges, err := s.tradeFee(secuirity, domain.SELL, request.Quantity, request.AveragePrice, request.FeeAmount, request.Charges)
+	if err != nil {
+		res.SetStatus(http.StatusBadRequest)
+		res.SetError(constant.ERROR_CODE_REQUEST_INVALID, err.Error())
+		return res
+	}
+	request.FeeAmount = fee
+
 	var date = time.Now()
 
 	if request.Date != "" {
@@ -51,178 +69,244 @@ func (s *stockUsecase) StockSell(request domain.ClientStockSellRequest) domain.R
 		}
 	}
 
-	var inventories []domain.Inventories
+	var resData domain.ClientStockSellResponse
 
-	// Retrieve inventory based on InventoryId, or get all active inventories for the account and stock.
-	if request.InventoryId != 0 {
-		inventory, err := s.mysql.GetInventoryDataById(ctx, request.InventoryId)
-		if err != nil {
-			s.logger.Errorw(ctx, "GetInventoryDataById failed",
-				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
-				constant.ERROR_MESSAGE, err.Error(),
-				constant.REQUEST, request,
-			)
-			res.SetStatus(http.StatusInternalServerError)
-			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-			return res
-		}
+	// Run every write inside one unit of work so a partial failure leaves no orphan rows behind.
+	err = s.withTx(ctx, func(repo port.RepositoryStore) error {
+		var err error
+		var matches []lotMatch
+		resData = domain.ClientStockSellResponse{}
 
-		// Validate account and stock match for inventory and ensure sufficient quantity.
+		// Retrieve inventory based on InventoryId, or get all active inventories for the account and stock.
+		if request.InventoryId != 0 {
+			inventory, err := repo.GetInventoryDataById(ctx, request.InventoryId)
+			if err != nil {
+				s.logger.Errorw(ctx, "GetInventoryDataById failed",
+					constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+					constant.ERROR_MESSAGE, err.Error(),
+					constant.REQUEST, request,
+				)
+				res.SetStatus(http.StatusInternalServerError)
+				res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+				return errTxAborted
+			}
 
-		if request.AccountId != inventory.AccountId {
-			res.SetStatus(http.StatusBadRequest)
-			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "incorrect inventory")
-			return res
-		}
-		if secuirity.Id != inventory.SecurityId {
-			res.SetStatus(http.StatusBadRequest)
-			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "incorrect stock")
-			return res
-		}
+			// Validate account and stock match for inventory and ensure sufficient quantity.
 
-		if inventory.AvailableQuantity < request.Quantity {
-			res.SetStatus(http.StatusBadRequest)
-			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "requested stock not available to sell")
-			return res
-		}
+			if request.AccountId != inventory.AccountId {
+				res.SetStatus(http.StatusBadRequest)
+				res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "incorrect inventory")
+				return errTxAborted
+			}
+			if secuirity.Id != inventory.SecurityId {
+				res.SetStatus(http.StatusBadRequest)
+				res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "incorrect stock")
+				return errTxAborted
+			}
 
-		inventories = append(inventories, inventory)
-	} else {
-		// If no InventoryId, fetch all active inventories for this stock and account.
-		inventories, err = s.mysql.GetActiveInventoriesByAccountIdAndSecurityId(ctx, request.AccountId, request.StockId)
-		if err != nil {
-			s.logger.Errorw(ctx, "GetActiveInventoriesByAccountIdAndSecurityId failed",
-				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
-				constant.ERROR_MESSAGE, err.Error(),
-				constant.REQUEST, request,
-			)
-			res.SetStatus(http.StatusInternalServerError)
-			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-			return res
-		}
+			if inventory.AvailableQuantity.LessThan(request.Quantity) {
+				res.SetStatus(http.StatusBadRequest)
+				res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "requested stock not available to sell")
+				return errTxAborted
+			}
 
-		// Ensure available quantity across all inventories is sufficient.
-		var availabletoSell float64
-		for _, inventory := range inventories {
-			availabletoSell += inventory.AvailableQuantity
-		}
+			matches = append(matches, lotMatch{inventory: inventory, quantity: request.Quantity})
+		} else {
+			// If no InventoryId, the account's lot method chooses among its active inventories for this stock.
+			account, err := repo.GetAccountDataByIdAndUserId(ctx, request.AccountId, request.UserId)
+			if err != nil {
+				s.logger.Errorw(ctx, "GetAccountDataByIdAndUserId failed",
+					constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+					constant.ERROR_MESSAGE, err.Error(),
+					constant.REQUEST, request,
+				)
+				res.SetStatus(http.StatusInternalServerError)
+				res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+				return errTxAborted
+			}
+			if account.Id == 0 {
+				res.SetStatus(http.StatusBadRequest)
+				res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "incorrect account id")
+				return errTxAborted
+			}
+			resData.LotMethod = account.LotMethod
+			if resData.LotMethod == "" {
+				resData.LotMethod = domain.LOT_METHOD_FIFO
+			}
 
-		if availabletoSell < request.Quantity {
-			res.SetStatus(http.StatusBadRequest)
-			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "requested stock not available to sell")
-			return res
-		}
-	}
+			inventories, err := repo.GetActiveInventoriesByAccountIdAndSecurityId(ctx, request.AccountId, request.StockId)
+			if err != nil {
+				s.logger.Errorw(ctx, "GetActiveInventoriesByAccountIdAndSecurityId failed",
+					constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+					constant.ERROR_MESSAGE, err.Error(),
+					constant.REQUEST, request,
+				)
+				res.SetStatus(http.StatusInternalServerError)
+				res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+				return errTxAborted
+			}
 
-	var inventoryLedgerIds []int
-	quantity := request.Quantity
+			// Ensure available quantity across all inventories is sufficient.
+			var availabletoSell decimal.Decimal
+			for _, inventory := range inventories {
+				availabletoSell = availabletoSell.Add(inventory.AvailableQuantity)
+			}
 
-	// Process each inventory to fulfill the sale quantity.
-	for _, inventory := range inventories {
-		if quantity <= 0 {
-			break
+			if availabletoSell.LessThan(request.Quantity) {
+				res.SetStatus(http.StatusBadRequest)
+				res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "requested stock not available to sell")
+				return errTxAborted
+			}
+
+			matches = matchLots(resData.LotMethod, inventories, request.Quantity)
 		}
 
-		// Determine ledger quantity to deduct from each inventory.
-		var ledgerQuanity float64
-		if inventory.AvailableQuantity < quantity {
-			ledgerQuanity = inventory.AvailableQuantity
-		} else {
-			ledgerQuanity = quantity
+		var inventoryLedgerIds, soldInventoryIds []int
+
+		// Process each matched lot to fulfill the sale quantity. Each lot bears its share of the fee and the last
+		// lot takes whatever rounding left over.
+		remainingFee := request.FeeAmount
+		for i, match := range matches {
+			inventory := match.inventory
+			ledgerQuanity := match.quantity
+			fee := remainingFee
+			if i < len(matches)-1 {
+				fee = shareFee(request.FeeAmount, ledgerQuanity, request.Quantity)
+			}
+			remainingFee = remainingFee.Sub(fee)
+
+			// Record ledger entry for sell transaction.
+			inventoryLedgerData, err := repo.InsertInventoryLedger(ctx, domain.InventoryLedger{
+				InventoryId:  inventory.Id,
+				Type:         domain.SELL,
+				Quantity:     ledgerQuanity,
+				AveragePrice: request.AveragePrice,
+				Fee:          fee,
+				TotalValue:   domain.RoundValue(ledgerQuanity.Mul(request.AveragePrice)),
+				Date:         date,
+			})
+
+			if err != nil {
+				s.logger.Errorw(ctx, "InsertInventoryLedger failed",
+					constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+					constant.ERROR_MESSAGE, err.Error(),
+					constant.REQUEST, request,
+				)
+				res.SetStatus(http.StatusInternalServerError)
+				res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+				return errTxAborted
+			}
+
+			inventoryLedgerIds = append(inventoryLedgerIds, inventoryLedgerData.Id)
+			soldInventoryIds = append(soldInventoryIds, inventory.Id)
+
+			// Update inventory data with reduced quantity and recalculated total value. The update is
+			// conditional on the version read above, so a lot sold by a concurrent request is never oversold.
+			inventory.AvailableQuantity = inventory.AvailableQuantity.Sub(ledgerQuanity)
+			inventory.TotalValue = domain.RoundValue(inventory.AvailableQuantity.Mul(inventory.AveragePrice))
+			err = repo.UpdateInventoryDetailsById(ctx, inventory.Id, inventory.Version, inventory.AvailableQuantity, inventory.AveragePrice, inventory.TotalValue)
+			if errors.Is(err, domain.ErrInventoryConflict) {
+				// Another request changed this lot since it was read; withTx rolls back and retries.
+				return err
+			}
+			if err != nil {
+				s.logger.Errorw(ctx, "UpdateInventoryDataById failed",
+					constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+					constant.ERROR_MESSAGE, err.Error(),
+					constant.REQUEST, request,
+				)
+				res.SetStatus(http.StatusInternalServerError)
+				res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+				return errTxAborted
+			}
+
+			resData.Lots = append(resData.Lots, domain.ClientStockSellLot{
+				InventoryId:  inventory.Id,
+				Date:         inventory.Date.Format("02-01-2006"),
+				Quantity:     ledgerQuanity,
+				AveragePrice: inventory.AveragePrice,
+				CostValue:    domain.RoundValue(ledgerQuanity.Mul(inventory.AveragePrice)),
+			})
 		}
 
-		// Record ledger entry for sell transaction.
-		inventoryLedgerData, err := s.mysql.InsertInventoryLedger(ctx, domain.InventoryLedger{
-			InventoryId:  inventory.Id,
+		// Insert transaction record for the sell operation.
+		transactionData, err := repo.InsertTransaction(ctx, domain.Transactions{
+			AccountId:    request.AccountId,
+			SecurityId:   secuirity.Id,
 			Type:         domain.SELL,
-			Quantity:     ledgerQuanity,
+			Quantity:     request.Quantity,
 			AveragePrice: request.AveragePrice,
 			Fee:          request.FeeAmount,
-			TotalValue:   ledgerQuanity * request.AveragePrice,
+			TotalValue:   domain.RoundValue(request.Quantity.Mul(request.AveragePrice)),
 			Date:         date,
 		})
 
 		if err != nil {
-			s.logger.Errorw(ctx, "InsertInventoryLedger failed",
+			s.logger.Errorw(ctx, "InsertTransaction failed",
 				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
 				constant.ERROR_MESSAGE, err.Error(),
 				constant.REQUEST, request,
 			)
 			res.SetStatus(http.StatusInternalServerError)
 			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-			return res
+			return errTxAborted
 		}
 
-		inventoryLedgerIds = append(inventoryLedgerIds, inventoryLedgerData.Id)
-		inventory, err := s.mysql.GetInventoryDataById(ctx, inventory.Id)
+		// Update ledger entries with the transaction ID for tracking purposes.
+		err = repo.UpdateInventoryLedgerTransactionIdByIds(ctx, inventoryLedgerIds, transactionData.Id)
 		if err != nil {
-			s.logger.Errorw(ctx, "InsertInventoryLedger failed",
+			s.logger.Errorw(ctx, "UpdateInventoryLedgerTransactionIdById failed",
 				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
 				constant.ERROR_MESSAGE, err.Error(),
 				constant.REQUEST, request,
 			)
 			res.SetStatus(http.StatusInternalServerError)
 			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-			return res
+			return errTxAborted
 		}
-		// Update inventory data with reduced quantity and recalculated total value.
-		inventory.AvailableQuantity -= ledgerQuanity
-		inventory.TotalValue = inventory.AvailableQuantity * inventory.AveragePrice
-		err = s.mysql.UpdateInventoryDetailsById(ctx, inventory.Id, inventory.AvailableQuantity, inventory.AveragePrice, inventory.TotalValue)
+
+		// Record the itemised charges of the fee.
+		err = insertCharges(ctx, repo, transactionData.Id, charges)
 		if err != nil {
-			s.logger.Errorw(ctx, "UpdateInventoryDataById failed",
+			s.logger.Errorw(ctx, "InsertTransactionCharges failed",
 				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
 				constant.ERROR_MESSAGE, err.Error(),
 				constant.REQUEST, request,
 			)
 			res.SetStatus(http.StatusInternalServerError)
 			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-			return res
+			return errTxAborted
 		}
 
-		quantity -= ledgerQuanity
-	}
+		// Record the cost basis and the gain realized on every lot the sale consumed.
+		for _, inventoryId := range soldInventoryIds {
+			err = realizeInventory(ctx, repo, inventoryId)
+			if errors.Is(err, ErrLedgerReplay) {
+				res.SetStatus(http.StatusUnprocessableEntity)
+				res.SetError(constant.ERROR_CODE_DATA_INVALID, err.Error())
+				return errTxAborted
+			}
+			if err != nil {
+				s.logger.Errorw(ctx, "realizeInventory failed",
+					constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+					constant.ERROR_MESSAGE, err.Error(),
+					constant.REQUEST, request,
+				)
+				res.SetStatus(http.StatusInternalServerError)
+				res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+				return errTxAborted
+			}
+		}
 
-	// Insert transaction record for the sell operation.
-	transactionData, err := s.mysql.InsertTransaction(ctx, domain.Transactions{
-		AccountId:    request.AccountId,
-		SecurityId:   secuirity.Id,
-		Type:         domain.SELL,
-		Quantity:     request.Quantity,
-		AveragePrice: request.AveragePrice,
-		Fee:          request.FeeAmount,
-		TotalValue:   request.Quantity * request.AveragePrice,
-		Date:         date,
+		return nil
 	})
-
-	if err != nil {
-		s.logger.Errorw(ctx, "InsertTransaction failed",
-			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
-			constant.ERROR_MESSAGE, err.Error(),
-			constant.REQUEST, request,
-		)
-		res.SetStatus(http.StatusInternalServerError)
-		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-		return res
-	}
-
-	// Update ledger entries with the transaction ID for tracking purposes.
-	err = s.mysql.UpdateInventoryLedgerTransactionIdByIds(ctx, inventoryLedgerIds, transactionData.Id)
 	if err != nil {
-		s.logger.Errorw(ctx, "UpdateInventoryLedgerTransactionIdById failed",
-			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
-			constant.ERROR_MESSAGE, err.Error(),
-			constant.REQUEST, request,
-		)
-		res.SetStatus(http.StatusInternalServerError)
-		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-		return res
+		return s.txFailed(ctx, res, err, request)
 	}
 
 	// Set success response message.
-	resData := domain.ClientStockSellResponse{
-		Message: "stock sell successfully",
-	}
+	resData.Message = "stock sell successfully"
+	resData.Charges = charges
 
 	res.SetData(resData)
 	return res
@@ -4,21 +4,27 @@ import (
 	"assetio/internal/adapters/handler/response"
 	"assetio/internal/constant"
 	"assetio/internal/domain"
+	"assetio/internal/port"
 	"context"
+	"errors"
 	"net/http"
+	"sort"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
-// StockSplit processes a stock split for a client by validating security information,
-// managing inventory records, recording ledger entries, and updating transaction details.
+// StockSplit processes a stock split for a client. The lots held at the end of the record date are eligible; a
+// ratio split entitles the account to the whole shares its holding becomes, and the new shares are recorded on each
+// lot on the record date, so every lot keeps its cost and its average price falls in proportion.
 //
 // Parameters:
-//   - request: domain.ClientStockSplitRequest - contains details of the stock purchase request,
-//     including stock ID, account ID, quantity, price, and fees.
+//   - request: domain.ClientStockSplitRequest - contains details of the stock split request,
+//     including stock ID, account ID, the ratio or quantity, the record date and the fee.
 //
 // Returns:
-//   - domain.Response - contains the outcome of the stock purchase request,
-//     either confirming success or detailing any encountered error.
+//   - domain.Response - contains the holding on the record date and the new shares per lot,
+//     or an error if the request is invalid or nothing was held.
 func (s *stockUsecase) StockSplit(request domain.ClientStockSplitRequest) domain.Response {
 	// Create a new background context to manage the request lifecycle.
 	ctx := context.Background()
@@ -46,91 +52,256 @@ func (s *stockUsecase) StockSplit(request domain.ClientStockSplitRequest) domain
 		return res
 	}
 
-	inventories, err := s.mysql.GetActiveInventoriesByAccountIdAndSecurityId(ctx, request.AccountId, request.StockId)
-	if err != nil {
-		s.logger.Errorw(ctx, "GetActiveInventoriesByAccountIdAndSecurityId failed",
-			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
-			constant.ERROR_MESSAGE, err.Error(),
-			constant.REQUEST, request,
-		)
-		res.SetStatus(http.StatusInternalServerError)
-		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-		return res
-	}
+	// Apply the rounding rules to the request before any arithmetic.
+	request.Quantity = domain.RoundQuantity(request.Quantity)
+	request.FeeAmount = domain.RoundValue(request.FeeAmount)
+	ratioFrom, ratioTo := splitRatio(request)
 
-	// Insert transaction record for buy operation.
-	transactionData, err := s.mysql.InsertTransaction(ctx, domain.Transactions{
-		AccountId:  request.AccountId,
-		SecurityId: secuirity.Id,
-		Type:       domain.SPLIT,
-		Quantity:   request.Quantity,
-		Fee:        request.FeeAmount,
-		Date:       time.Now(),
-	})
-
-	if err != nil {
-		s.logger.Errorw(ctx, "InsertTransaction failed",
-			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
-			constant.ERROR_MESSAGE, err.Error(),
-			constant.REQUEST, request,
-		)
-		res.SetStatus(http.StatusInternalServerError)
-		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-		return res
+	// The lots held at the end of the record date are eligible; without one the split applies to today's holding.
+	date := time.Now()
+	end := date
+	if request.Date != "" {
+		date, err = time.Parse(constant.DATE_LAYOUT, request.Date)
+		if err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "invalid date")
+			return res
+		}
+		end = date.Add(24 * time.Hour)
 	}
 
-	var availableQuantities float64
-	for _, inventory := range inventories {
-		availableQuantities += inventory.AvailableQuantity
-	}
+	var resData domain.ClientStockSplitResponse
 
-	newlyAddedRatio := float64(request.Quantity) / availableQuantities
-
-	for _, inventory := range inventories {
-		newStockForInv := newlyAddedRatio * inventory.AvailableQuantity
-		inventoryLedgerData, err := s.mysql.InsertInventoryLedger(ctx, domain.InventoryLedger{
-			InventoryId:   inventory.Id,
-			TransactionId: transactionData.Id,
-			Type:          domain.SPLIT,
-			Quantity:      newStockForInv,
-			Fee:           request.FeeAmount,
-			Date:          time.Now(),
-		})
+	// Run every write inside one unit of work so a partial failure leaves no orphan rows behind.
+	err = s.withTx(ctx, func(repo port.RepositoryStore) error {
+		account, err := repo.GetAccountDataByIdAndUserId(ctx, request.AccountId, request.UserId)
+		if err != nil {
+			s.logger.Errorw(ctx, "GetAccountDataByIdAndUserId failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
+		if account.Id == 0 {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "incorrect account id")
+			return errTxAborted
+		}
 
+		lots, held, err := lotsHeldOn(ctx, repo, request.AccountId, request.StockId, end)
+		if errors.Is(err, ErrLedgerReplay) {
+			res.SetStatus(http.StatusUnprocessableEntity)
+			res.SetError(constant.ERROR_CODE_DATA_INVALID, err.Error())
+			return errTxAborted
+		}
 		if err != nil {
-			s.logger.Errorw(ctx, "InsertInventoryLedger failed",
+			s.logger.Errorw(ctx, "lotsHeldOn failed",
 				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
 				constant.ERROR_MESSAGE, err.Error(),
 				constant.REQUEST, request,
 			)
 			res.SetStatus(http.StatusInternalServerError)
 			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-			return res
+			return errTxAborted
+		}
+		if held.IsZero() {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "no stock available for split")
+			return errTxAborted
 		}
 
-		inventory.AvailableQuantity += inventoryLedgerData.Quantity
-		inventory.TotalValue += inventoryLedgerData.TotalValue
-		inventory.AveragePrice = inventory.TotalValue / inventory.AvailableQuantity
+		resData = domain.ClientStockSplitResponse{
+			Date: date.Format("02-01-2006"),
+			Held: held,
+			Lots: []domain.ClientStockSplitLot{},
+		}
+		var shares []decimal.Decimal
+		if ratioFrom.IsPositive() {
+			shares, resData.Fractional = splitWholeShares(lots, held, ratioFrom, ratioTo)
+		} else {
+			shares = splitShares(lots, held, request.Quantity)
+		}
+		for _, share := range shares {
+			resData.Quantity = resData.Quantity.Add(share)
+		}
+		if !resData.Quantity.IsPositive() {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "the split gives no new shares")
+			return errTxAborted
+		}
+
+		// Insert transaction record for the split.
+		transactionData, err := repo.InsertTransaction(ctx, domain.Transactions{
+			AccountId:  request.AccountId,
+			SecurityId: secuirity.Id,
+			Type:       domain.SPLIT,
+			Quantity:   resData.Quantity,
+			Fee:        request.FeeAmount,
+			RatioFrom:  ratioFrom,
+			RatioTo:    ratioTo,
+			Date:       date,
+		})
 
-		// Update inventory data in database.
-		err = s.mysql.UpdateInventoryDetailsById(ctx, inventory.Id, inventory.AvailableQuantity, inventory.AveragePrice, inventory.TotalValue)
 		if err != nil {
-			s.logger.Errorw(ctx, "UpdateInventoryDataById failed",
+			s.logger.Errorw(ctx, "InsertTransaction failed",
 				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
 				constant.ERROR_MESSAGE, err.Error(),
 				constant.REQUEST, request,
 			)
 			res.SetStatus(http.StatusInternalServerError)
 			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-			return res
+			return errTxAborted
+		}
+
+		// Each lot records its new shares and its share of the fee by holding, rounded; the last lot takes whatever
+		// rounding left over so the lots add up to exactly the fee.
+		remainingFee := request.FeeAmount
+		for i, lot := range lots {
+			fee := remainingFee
+			if i < len(lots)-1 {
+				fee = shareFee(request.FeeAmount, lot.held.Quantity, held)
+			}
+			remainingFee = remainingFee.Sub(fee)
+
+			if shares[i].IsPositive() || fee.IsPositive() {
+				_, err = repo.InsertInventoryLedger(ctx, domain.InventoryLedger{
+					InventoryId:   lot.inventory.Id,
+					TransactionId: transactionData.Id,
+					Type:          domain.SPLIT,
+					Quantity:      shares[i],
+					Fee:           fee,
+					Date:          date,
+				})
+				if err != nil {
+					s.logger.Errorw(ctx, "InsertInventoryLedger failed",
+						constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+						constant.ERROR_MESSAGE, err.Error(),
+						constant.REQUEST, request,
+					)
+					res.SetStatus(http.StatusInternalServerError)
+					res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+					return errTxAborted
+				}
+			}
+
+			// The lot keeps its cost, so its average price falls in proportion to its new quantity. A lot recorded
+			// before any later entries is replayed so they apply after the split.
+			err = settleInventory(ctx, repo, lot.inventory.Id)
+			if errors.Is(err, domain.ErrInventoryConflict) {
+				// Another request changed this lot since it was read; withTx rolls back and retries.
+				return err
+			}
+			if errors.Is(err, ErrLedgerReplay) {
+				res.SetStatus(http.StatusUnprocessableEntity)
+				res.SetError(constant.ERROR_CODE_DATA_INVALID, err.Error())
+				return errTxAborted
+			}
+			if err != nil {
+				s.logger.Errorw(ctx, "settleInventory failed",
+					constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+					constant.ERROR_MESSAGE, err.Error(),
+					constant.REQUEST, request,
+				)
+				res.SetStatus(http.StatusInternalServerError)
+				res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+				return errTxAborted
+			}
+
+			quantity := lot.held.Quantity.Add(shares[i])
+			resData.Lots = append(resData.Lots, domain.ClientStockSplitLot{
+				InventoryId:  lot.inventory.Id,
+				Date:         lot.inventory.Date.Format("02-01-2006"),
+				Held:         lot.held.Quantity,
+				Quantity:     shares[i],
+				AveragePrice: domain.AveragePrice(lot.held.TotalValue, quantity),
+			})
 		}
+
+		return nil
+	})
+	if err != nil {
+		return s.txFailed(ctx, res, err, request)
 	}
 
 	// Set success response message.
-	resData := domain.ClientStockSplitResponse{
-		Message: "stock split successfully",
-	}
+	resData.Message = "stock split successfully"
 
 	res.SetData(resData)
 	return res
 }
+
+// splitRatio returns the ratio of a split as the shares held to the shares they become. A face value of 10 split to
+// 2 makes every share 5, so face values give the ratio the other way round. Both are zero when the request gives
+// neither and so asks for a quantity of new shares.
+func splitRatio(request domain.ClientStockSplitRequest) (decimal.Decimal, decimal.Decimal) {
+	if request.RatioFrom.IsPositive() && request.RatioTo.IsPositive() {
+		return request.RatioFrom, request.RatioTo
+	}
+	if request.FaceValueFrom.IsPositive() && request.FaceValueTo.IsPositive() {
+		return request.FaceValueTo, request.FaceValueFrom
+	}
+	return decimal.Zero, decimal.Zero
+}
+
+// splitShares shares quantity new shares over the lots pro rata: every lot gets its rounded share and the last lot
+// takes whatever rounding left over.
+func splitShares(lots []lotHolding, held, quantity decimal.Decimal) []decimal.Decimal {
+	shares := make([]decimal.Decimal, len(lots))
+	remaining := quantity
+	for i, lot := range lots {
+		shares[i] = remaining
+		if i < len(lots)-1 {
+			shares[i] = domain.RoundQuantity(quantity.Mul(lot.held.Quantity).Div(held))
+		}
+		remaining = remaining.Sub(shares[i])
+	}
+	return shares
+}
+
+// splitWholeShares works out the new shares of a ratio split. The account is entitled to the whole shares its
+// holding becomes; the fraction beyond them is returned rather than added. Every lot gets the whole shares its own
+// holding becomes, and the shares the lots' fractions add up to go one each to the lots with the largest fractions,
+// oldest first on a tie, so each lot holds whole shares.
+func splitWholeShares(lots []lotHolding, held, ratioFrom, ratioTo decimal.Decimal) ([]decimal.Decimal, decimal.Decimal) {
+	entitled := held.Mul(ratioTo).Div(ratioFrom)
+	whole := entitled.Floor()
+	fractional := domain.RoundQuantity(entitled.Sub(whole))
+
+	shares := make([]decimal.Decimal, len(lots))
+	fractions := make([]decimal.Decimal, len(lots))
+	left := whole.Sub(held)
+	for i, lot := range lots {
+		lotEntitled := lot.held.Quantity.Mul(ratioTo).Div(ratioFrom)
+		shares[i] = lotEntitled.Floor().Sub(lot.held.Quantity)
+		fractions[i] = lotEntitled.Sub(lotEntitled.Floor())
+		left = left.Sub(shares[i])
+	}
+
+	order := make([]int, len(lots))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return fractions[order[a]].GreaterThan(fractions[order[b]])
+	})
+	one := decimal.NewFromInt(1)
+	for _, i := range order {
+		if left.LessThan(one) {
+			break
+		}
+		shares[i] = shares[i].Add(one)
+		left = left.Sub(one)
+	}
+	// A holding with fractional shares leaves part of a share over, which the last lot takes.
+	if len(lots) > 0 && left.IsPositive() {
+		shares[len(lots)-1] = shares[len(lots)-1].Add(left)
+	}
+
+	for i := range shares {
+		shares[i] = domain.RoundQuantity(shares[i])
+	}
+	return shares, fractional
+}
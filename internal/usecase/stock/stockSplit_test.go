@@ -0,0 +1,237 @@
+package stock
+
+import (
+	"assetio/internal/domain"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestStockSplitSpreadsNewSharesAcrossLots(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	f.buy(t, 30, 200, date(2024, time.February, 1))
+
+	expectSuccess(t, f.usecase.StockSplit(domain.ClientStockSplitRequest{
+		UserId:    1,
+		AccountId: f.accountId,
+		StockId:   f.stockId,
+		Quantity:  decimal.NewFromInt(40),
+	}))
+
+	// A 1:2 split doubles every lot and halves its average price without changing its cost.
+	inventories := f.inventories(t, f.stockId)
+	assertDecimal(t, "first lot quantity", inventories[0].AvailableQuantity, "20")
+	assertDecimal(t, "first lot average price", inventories[0].AveragePrice, "50")
+	assertDecimal(t, "first lot total value", inventories[0].TotalValue, "1000")
+	assertDecimal(t, "second lot quantity", inventories[1].AvailableQuantity, "60")
+	assertDecimal(t, "second lot average price", inventories[1].AveragePrice, "100")
+	assertDecimal(t, "second lot total value", inventories[1].TotalValue, "6000")
+
+	// Another user's account is refused.
+	expectStatus(t, f.usecase.StockSplit(domain.ClientStockSplitRequest{
+		UserId:    2,
+		AccountId: f.accountId,
+		StockId:   f.stockId,
+		Quantity:  decimal.NewFromInt(80),
+	}), http.StatusBadRequest)
+}
+
+func TestStockBonusAddsZeroCostLot(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	f.buy(t, 20, 160, date(2024, time.February, 1))
+
+	expectSuccess(t, f.usecase.StockBonus(domain.ClientStockBonusRequest{
+		UserId:    1,
+		AccountId: f.accountId,
+		StockId:   f.stockId,
+		Quantity:  decimal.NewFromInt(15),
+	}))
+
+	// The bonus shares form a lot of their own at no cost; the lots they were issued on are left as they were.
+	inventories := f.inventories(t, f.stockId)
+	if len(inventories) != 3 {
+		t.Fatalf("inventories = %+v, want the two bought lots and the bonus lot", inventories)
+	}
+	assertDecimal(t, "first lot quantity", inventories[0].AvailableQuantity, "10")
+	assertDecimal(t, "second lot average price", inventories[1].AveragePrice, "160")
+	assertDecimal(t, "bonus lot quantity", inventories[2].AvailableQuantity, "15")
+	assertDecimal(t, "bonus lot total value", inventories[2].TotalValue, "0")
+
+	// Another user's account is refused.
+	expectStatus(t, f.usecase.StockBonus(domain.ClientStockBonusRequest{
+		UserId:    2,
+		AccountId: f.accountId,
+		StockId:   f.stockId,
+		Quantity:  decimal.NewFromInt(15),
+	}), http.StatusBadRequest)
+	if len(f.inventories(t, f.stockId)) != 3 {
+		t.Fatal("bonus for another user's account added a lot")
+	}
+}
+
+func TestStockSplitRollsBackOnFailure(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	inventoryId := f.inventories(t, f.stockId)[0].Id
+
+	usecase := New(nopLogger{}, failingRepo{RepositoryStore: f.repo, failOn: "InsertTransaction"}, stubMarketer{}, nil)
+	expectStatus(t, usecase.StockSplit(domain.ClientStockSplitRequest{
+		UserId:    1,
+		AccountId: f.accountId,
+		StockId:   f.stockId,
+		Quantity:  decimal.NewFromInt(10),
+	}), http.StatusInternalServerError)
+
+	ledgers, err := f.repo.GetInventoryLedgersByInventoryId(context.Background(), inventoryId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ledgers) != 1 {
+		t.Fatalf("split ledger survived the rollback: %+v", ledgers)
+	}
+}
+
+func TestStockSplitAllocatesExactQuantity(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 1, 100, date(2024, time.January, 1))
+	f.buy(t, 1, 100, date(2024, time.February, 1))
+	f.buy(t, 1, 100, date(2024, time.March, 1))
+
+	expectSuccess(t, f.usecase.StockSplit(domain.ClientStockSplitRequest{
+		UserId:    1,
+		AccountId: f.accountId,
+		StockId:   f.stockId,
+		Quantity:  decimal.NewFromInt(1),
+	}))
+
+	// A third of a share per lot does not divide evenly; the last lot absorbs the rounding so the
+	// holding grows by exactly the requested quantity.
+	inventories := f.inventories(t, f.stockId)
+	assertDecimal(t, "first lot quantity", inventories[0].AvailableQuantity, "1.3333")
+	assertDecimal(t, "second lot quantity", inventories[1].AvailableQuantity, "1.3333")
+	assertDecimal(t, "third lot quantity", inventories[2].AvailableQuantity, "1.3334")
+
+	total := decimal.Zero
+	for _, inventory := range inventories {
+		total = total.Add(inventory.AvailableQuantity)
+	}
+	assertDecimal(t, "total quantity", total, "4")
+	assertDecimal(t, "first lot average price", inventories[0].AveragePrice, "75.0019")
+}
+
+// split records a split through the use case and decodes its report.
+func (f *fixture) split(t *testing.T, request domain.ClientStockSplitRequest) domain.ClientStockSplitResponse {
+	t.Helper()
+	request.UserId, request.AccountId, request.StockId = 1, f.accountId, f.stockId
+	var result domain.ClientStockSplitResponse
+	if err := json.Unmarshal(expectSuccess(t, f.usecase.StockSplit(request)).Data, &result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestStockSplitRatioOnRecordDate(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	f.buy(t, 3, 200, date(2024, time.March, 1))
+
+	// Only the lot held on the record date is split; the lot bought after it is not.
+	result := f.split(t, domain.ClientStockSplitRequest{
+		Date:      date(2024, time.February, 1),
+		RatioFrom: decimal.NewFromInt(1),
+		RatioTo:   decimal.NewFromInt(5),
+	})
+	assertDecimal(t, "held", result.Held, "10")
+	assertDecimal(t, "new shares", result.Quantity, "40")
+	assertDecimal(t, "fractional", result.Fractional, "0")
+	if result.Date != "01-02-2024" || len(result.Lots) != 1 {
+		t.Fatalf("result = %+v, want one lot split on 01-02-2024", result)
+	}
+	assertDecimal(t, "lot average price", result.Lots[0].AveragePrice, "20")
+
+	inventories := f.inventories(t, f.stockId)
+	assertDecimal(t, "first lot quantity", inventories[0].AvailableQuantity, "50")
+	assertDecimal(t, "first lot total value", inventories[0].TotalValue, "1000")
+	assertDecimal(t, "second lot quantity", inventories[1].AvailableQuantity, "3")
+
+	transactions := f.accountTransactions(t)
+	split := transactions[len(transactions)-2]
+	if split.Type != domain.SPLIT || !split.Date.Equal(time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("transactions = %+v, want the split dated on the record date between the buys", transactions)
+	}
+}
+
+func TestStockSplitDropsFractionalEntitlement(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 3, 100, date(2024, time.January, 1))
+	f.buy(t, 3, 100, date(2024, time.February, 1))
+	f.buy(t, 3, 100, date(2024, time.March, 1))
+
+	// 9 shares at 2:3 become 13.5; the account gets 13 and half a share is settled in cash. Each lot's 4.5 gives
+	// it one whole share, and the share the halves add up to goes to the oldest lot.
+	result := f.split(t, domain.ClientStockSplitRequest{
+		Date:      date(2024, time.April, 1),
+		RatioFrom: decimal.NewFromInt(2),
+		RatioTo:   decimal.NewFromInt(3),
+	})
+	assertDecimal(t, "new shares", result.Quantity, "4")
+	assertDecimal(t, "fractional", result.Fractional, "0.5")
+
+	inventories := f.inventories(t, f.stockId)
+	assertDecimal(t, "first lot quantity", inventories[0].AvailableQuantity, "5")
+	assertDecimal(t, "second lot quantity", inventories[1].AvailableQuantity, "4")
+	assertDecimal(t, "third lot quantity", inventories[2].AvailableQuantity, "4")
+	assertDecimal(t, "first lot average price", inventories[0].AveragePrice, "60")
+}
+
+func TestStockSplitByFaceValueBeforeLaterSell(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	expectSuccess(t, f.usecase.StockSell(domain.ClientStockSellRequest{
+		UserId:       1,
+		AccountId:    f.accountId,
+		StockId:      f.stockId,
+		Date:         date(2024, time.March, 1),
+		Quantity:     decimal.NewFromInt(5),
+		AveragePrice: decimal.NewFromInt(60),
+	}))
+
+	// A face value of 10 split to 5 doubles the shares. Recorded late, the split still applies before the sell, so
+	// the sell took 5 of the 20 shares at the split cost of 50 each.
+	result := f.split(t, domain.ClientStockSplitRequest{
+		Date:          date(2024, time.February, 1),
+		FaceValueFrom: decimal.NewFromInt(10),
+		FaceValueTo:   decimal.NewFromInt(5),
+	})
+	assertDecimal(t, "held", result.Held, "10")
+	assertDecimal(t, "new shares", result.Quantity, "10")
+
+	inventory := f.inventories(t, f.stockId)[0]
+	assertDecimal(t, "quantity", inventory.AvailableQuantity, "15")
+	assertDecimal(t, "average price", inventory.AveragePrice, "50")
+	assertDecimal(t, "total value", inventory.TotalValue, "750")
+
+	gains, err := f.repo.GetRealizedGains(context.Background(), f.accountId, f.stockId, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gains) != 1 {
+		t.Fatalf("realized gains = %+v, want the one sell", gains)
+	}
+	assertDecimal(t, "sell cost", gains[0].CostValue, "250")
+
+	expectStatus(t, f.usecase.StockSplit(domain.ClientStockSplitRequest{
+		UserId:    1,
+		AccountId: f.accountId,
+		StockId:   f.stockId,
+		Date:      date(2023, time.December, 1),
+		RatioFrom: decimal.NewFromInt(1),
+		RatioTo:   decimal.NewFromInt(2),
+	}), http.StatusBadRequest)
+}
@@ -5,9 +5,10 @@ import (
 	"assetio/internal/constant"
 	"assetio/internal/domain"
 	"context"
-	"fmt"
 	"net/http"
 	"sync"
+
+	"github.com/shopspring/decimal"
 )
 
 // StockSummary generates a summary of the client's stock holdings based on their account ID and security type.
@@ -16,7 +17,8 @@ import (
 //   - request: domain.ClientStockSummaryRequest - Contains the account ID and security type for which to fetch the stock summary.
 //
 // Returns:
-//   - domain.Response - Includes a list of stock summary details such as stock ID, symbol, exchange, name, quantity, and amount.
+//   - domain.Response - Includes a list of stock summary details such as stock ID, symbol, exchange, name, quantity, and amount,
+//     each valued at the market price with its unrealized gain, day change and weight in the portfolio.
 //     Returns an error message if any issue is encountered during data retrieval.
 func (s *stockUsecase) StockSummary(request domain.ClientStockSummaryRequest) domain.Response {
 	// Create a new context for managing request lifecycle.
@@ -25,6 +27,43 @@ func (s *stockUsecase) StockSummary(request domain.ClientStockSummaryRequest) do
 	// Initialize a response object to store the result of the request.
 	res := response.New()
 
+	resData, _, ok := s.summarizeStocks(ctx, res, request)
+	if !ok {
+		return res
+	}
+
+	// Set the formatted stock summary data in the response to be returned to the client.
+	res.SetData(resData)
+	return res
+}
+
+// StockSummaryTotals values the client's whole stock portfolio at the market price: what it cost, what it is worth,
+// the unrealized gain and the day's change. They are the totals of the holdings StockSummary lists.
+//
+// Parameters:
+//   - request: domain.ClientStockSummaryRequest - Contains the account ID for which to total the portfolio.
+//
+// Returns:
+//   - domain.Response - Includes the totals of the portfolio, priced only when every holding has a quote.
+//     Returns an error message if any issue is encountered during data retrieval.
+func (s *stockUsecase) StockSummaryTotals(request domain.ClientStockSummaryRequest) domain.Response {
+	ctx := context.Background()
+	res := response.New()
+
+	_, totals, ok := s.summarizeStocks(ctx, res, request)
+	if !ok {
+		return res
+	}
+
+	res.SetData(totals)
+	return res
+}
+
+// summarizeStocks values every stock held by the account at the market price, weighs each by its share of the
+// portfolio and returns them with the totals of the portfolio. On failure it sets the error on res and returns false.
+func (s *stockUsecase) summarizeStocks(ctx context.Context, res domain.Response, request domain.ClientStockSummaryRequest) ([]domain.ClientStockSummaryResponse, domain.ClientStockValuation, bool) {
+	totals := domain.ClientStockValuation{ClientStockValue: domain.ClientStockValue{Priced: true}}
+
 	// Fetch inventory data for the specified account ID and security type (e.g., stocks).
 	// If the retrieval fails, log the error and return an internal server error.
 	inventoriesData, err := s.mysql.GetInvertriesSummaryByAccountIdAndSecurityType(ctx, request.AccountId, constant.SECURITY_TYPE_STOCK)
@@ -39,44 +78,56 @@ func (s *stockUsecase) StockSummary(request domain.ClientStockSummaryRequest) do
 		// Set the response status to HTTP 500 and provide a generic error message to the client.
 		res.SetStatus(http.StatusInternalServerError)
 		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-		return res
+		return nil, totals, false
 	}
 
-	// Prepare a slice to accumulate the stock summary data to be sent in the response.
-	var resData []domain.ClientStockSummaryResponse
+	// Prepare a slice to accumulate the stock summary data to be sent in the response, one slot per holding so the
+	// quotes can be fetched concurrently.
+	stocks := make([]domain.ClientStockSummaryResponse, len(inventoriesData))
 
 	var wg sync.WaitGroup
 
 	// Iterate over each inventory record and transform it into a stock summary format.
-	for _, inventoryData := range inventoriesData {
+	for i, inventoryData := range inventoriesData {
 		wg.Add(1)
-		go func(inventoryData domain.InventorySummary) {
+		go func(i int, inventoryData domain.InventorySummary) {
+			defer wg.Done()
 			metaData := domain.ClientStockSummaryResponse{
 				StockId:       inventoryData.SecurityId,
 				StockSymbol:   inventoryData.SecuritySymbol,
 				StockExchange: inventoryData.SecurityExchange,
 				StockName:     inventoryData.SecurityName,
-				Quantity:      int(inventoryData.AvailableQuantity),
+				Quantity:      int(inventoryData.AvailableQuantity.IntPart()),
 				Amount:        inventoryData.TotalValue,
 			}
 
 			markerData, err := s.marketer.Query(inventoryData.SecuritySymbol, "NSE")
 			if err == nil {
-				metaData.MarketPrice = markerData.GetMarketPrice()
-				metaData.MarketChange = markerData.GetMarketChange()
-				metaData.MarketChangePercent = markerData.GetMarketChangePercent()
+				metaData.MarketPrice = decimal.NewFromFloat(markerData.GetMarketPrice())
+				metaData.MarketChange = decimal.NewFromFloat(markerData.GetMarketChange())
+				metaData.MarketChangePercent = decimal.NewFromFloat(markerData.GetMarketChangePercent())
 			}
+			metaData.ClientStockValuation = valueHolding(inventoryData.AvailableQuantity, inventoryData.TotalValue, metaData.MarketPrice, metaData.MarketChange, err == nil)
 
-			resData = append(resData, metaData)
-			wg.Done()
-		}(inventoryData)
+			stocks[i] = metaData
+		}(i, inventoryData)
 
 	}
 	wg.Wait()
 
-	// Set the formatted stock summary data in the response to be returned to the client.
-	res.SetData(resData)
-	return res
+	// Add up the portfolio and weigh every holding by its share of the current value.
+	for _, stock := range stocks {
+		totals.Priced = totals.Priced && stock.Priced
+		totals.InvestedValue = totals.InvestedValue.Add(stock.InvestedValue)
+		totals.CurrentValue = totals.CurrentValue.Add(stock.CurrentValue)
+		totals.DayChange = totals.DayChange.Add(stock.DayChange)
+	}
+	completeValuation(&totals)
+	for i := range stocks {
+		stocks[i].Weight = domain.Percent(stocks[i].CurrentValue, totals.CurrentValue)
+	}
+
+	return stocks, totals, true
 }
 
 // StockInventories retrieves the inventory details of a client's specific stock holdings.
@@ -135,12 +186,13 @@ func (s *stockUsecase) StockInventories(request domain.ClientStockInventoriesReq
 		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
 		return res
 	}
-	var marketPrice, marketChange, marketChangePercent float64
+	var marketPrice, marketChange, marketChangePercent decimal.Decimal
 	markerData, err := s.marketer.Query(secuirityData.Symbol, "NSE")
-	if err == nil {
-		marketPrice = markerData.GetMarketPrice()
-		marketChange = markerData.GetMarketChange()
-		marketChangePercent = markerData.GetMarketChangePercent()
+	priced := err == nil
+	if priced {
+		marketPrice = decimal.NewFromFloat(markerData.GetMarketPrice())
+		marketChange = decimal.NewFromFloat(markerData.GetMarketChange())
+		marketChangePercent = decimal.NewFromFloat(markerData.GetMarketChangePercent())
 	}
 
 	// Initialize a slice to store the inventory details for the response.
@@ -149,13 +201,14 @@ func (s *stockUsecase) StockInventories(request domain.ClientStockInventoriesReq
 	// Process each inventory record and transform it into a client-specific response format.
 	for _, inventoryData := range inventoriesData {
 		resData = append(resData, domain.ClientStockInventoriesResponse{
-			InventoryId:         inventoryData.Id,
-			Amount:              (inventoryData.TotalValue / inventoryData.AvailableQuantity),
-			Quantity:            inventoryData.AvailableQuantity,
-			MarketPrice:         marketPrice,
-			MarketChange:        marketChange,
-			MarketChangePercent: marketChangePercent,
-			Date:                inventoryData.Date.Format("02-01-2006"),
+			InventoryId:          inventoryData.Id,
+			Amount:               domain.AveragePrice(inventoryData.TotalValue, inventoryData.AvailableQuantity),
+			Quantity:             inventoryData.AvailableQuantity,
+			MarketPrice:          marketPrice,
+			MarketChange:         marketChange,
+			MarketChangePercent:  marketChangePercent,
+			Date:                 inventoryData.Date.Format("02-01-2006"),
+			ClientStockValuation: valueHolding(inventoryData.AvailableQuantity, inventoryData.TotalValue, marketPrice, marketChange, priced),
 		})
 	}
 
@@ -164,6 +217,35 @@ func (s *stockUsecase) StockInventories(request domain.ClientStockInventoriesReq
 	return res
 }
 
+// valueHolding values quantity shares that cost invested at the market price, with the day's change. Without a quote
+// the holding is carried at cost.
+func valueHolding(quantity, invested, marketPrice, marketChange decimal.Decimal, priced bool) domain.ClientStockValuation {
+	valuation := domain.ClientStockValuation{ClientStockValue: domain.ClientStockValue{
+		Priced:        priced,
+		InvestedValue: invested,
+		CurrentValue:  invested,
+	}}
+	if priced {
+		valuation.CurrentValue = domain.RoundValue(quantity.Mul(marketPrice))
+		valuation.DayChange = domain.RoundValue(quantity.Mul(marketChange))
+	}
+	completeValuation(&valuation)
+	return valuation
+}
+
+// completeValuation derives the unrealized gain and the percentages from the invested and current values and the
+// day's change. The day's change is a percentage of the value at the previous close.
+func completeValuation(valuation *domain.ClientStockValuation) {
+	completeValue(&valuation.ClientStockValue)
+	valuation.DayChangePercent = domain.Percent(valuation.DayChange, valuation.CurrentValue.Sub(valuation.DayChange))
+}
+
+// completeValue derives the unrealized gain and its percentage from the invested and current values.
+func completeValue(value *domain.ClientStockValue) {
+	value.UnrealizedGain = value.CurrentValue.Sub(value.InvestedValue)
+	value.UnrealizedGainPercent = domain.Percent(value.UnrealizedGain, value.InvestedValue)
+}
+
 // StockInventoryLedgers retrieves the inventory ledger details for a specific client's stock inventory.
 //
 // Parameters:
@@ -245,15 +327,14 @@ func (s *stockUsecase) StockInventoryLedgers(request domain.ClientStockInventory
 	// Initialize a slice to store the ledger details for the response.
 	var resData []domain.ClientStockInventoryLedgersResponse
 
-	fmt.Println("ledgersData", ledgersData)
 	// Process each transaction ledger record and format it for the response.
 	for _, ledgerData := range ledgersData {
-		if ledgerData.Quantity > 0 {
+		if ledgerData.Quantity.IsPositive() {
 			resData = append(resData, domain.ClientStockInventoryLedgersResponse{
 
 				LedgerId: ledgerData.Id,
 				Type:     string(ledgerData.Type),
-				Amount:   (ledgerData.TotalValue / ledgerData.Quantity),
+				Amount:   domain.AveragePrice(ledgerData.TotalValue, ledgerData.Quantity),
 				Quantity: ledgerData.Quantity,
 				Date:     ledgerData.Date.Format("02-01-2006"),
 			})
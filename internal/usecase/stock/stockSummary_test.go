@@ -0,0 +1,121 @@
+package stock
+
+import (
+	"assetio/internal/domain"
+	"assetio/internal/port"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// quoteMarketer quotes a price and a day's change per symbol and has no quote for any other symbol.
+type quoteMarketer map[string][2]float64
+
+type quoteMarketerData [2]float64
+
+func (m quoteMarketer) Query(symbol, exchange string) (port.MarketerData, error) {
+	quote, ok := m[symbol]
+	if !ok {
+		return nil, errors.New("no quote")
+	}
+	return quoteMarketerData(quote), nil
+}
+
+func (m quoteMarketer) QueryClose(symbol, exchange string, date time.Time) (float64, error) {
+	quote, ok := m[symbol]
+	if !ok {
+		return 0, errors.New("no quote")
+	}
+	return quote[0], nil
+}
+
+func (d quoteMarketerData) GetMarketPrice() float64         { return d[0] }
+func (d quoteMarketerData) GetMarketChange() float64        { return d[1] }
+func (d quoteMarketerData) GetMarketChangePercent() float64 { return d[1] * 100 / (d[0] - d[1]) }
+
+func TestStockSummaryValuesHoldingsAndPortfolio(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 80, date(2024, time.January, 1))
+	expectSuccess(t, f.usecase.StockBuy(domain.ClientStockBuyRequest{
+		UserId:       1,
+		AccountId:    f.accountId,
+		StockId:      f.otherId,
+		Date:         date(2024, time.January, 1),
+		Quantity:     decimal.NewFromInt(5),
+		AveragePrice: decimal.NewFromInt(150),
+	}))
+	usecase := New(nopLogger{}, f.repo, quoteMarketer{"PARENT": {100, 4}, "CHILD": {100, -5}}, nil)
+
+	// The summary stays a list of holdings.
+	request := domain.ClientStockSummaryRequest{UserId: 1, AccountId: f.accountId}
+	var result []domain.ClientStockSummaryResponse
+	if err := json.Unmarshal(expectSuccess(t, usecase.StockSummary(request)).Data, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("stocks = %+v, want two holdings", result)
+	}
+	stocks := map[string]domain.ClientStockSummaryResponse{}
+	for _, stock := range result {
+		stocks[stock.StockSymbol] = stock
+	}
+
+	parent := stocks["PARENT"]
+	assertDecimal(t, "parent current value", parent.CurrentValue, "1000")
+	assertDecimal(t, "parent gain", parent.UnrealizedGain, "200")
+	assertDecimal(t, "parent gain percent", parent.UnrealizedGainPercent, "25")
+	assertDecimal(t, "parent day change", parent.DayChange, "40")
+	assertDecimal(t, "parent weight", parent.Weight, "66.67")
+
+	child := stocks["CHILD"]
+	assertDecimal(t, "child gain", child.UnrealizedGain, "-250")
+	assertDecimal(t, "child gain percent", child.UnrealizedGainPercent, "-33.33")
+	assertDecimal(t, "child weight", child.Weight, "33.33")
+
+	// The day's change is measured against the previous close of 1515.
+	var totals domain.ClientStockValuation
+	if err := json.Unmarshal(expectSuccess(t, usecase.StockSummaryTotals(request)).Data, &totals); err != nil {
+		t.Fatal(err)
+	}
+	if !totals.Priced {
+		t.Fatal("totals not priced although every holding has a quote")
+	}
+	assertDecimal(t, "invested", totals.InvestedValue, "1550")
+	assertDecimal(t, "current", totals.CurrentValue, "1500")
+	assertDecimal(t, "gain", totals.UnrealizedGain, "-50")
+	assertDecimal(t, "gain percent", totals.UnrealizedGainPercent, "-3.23")
+	assertDecimal(t, "day change", totals.DayChange, "15")
+	assertDecimal(t, "day change percent", totals.DayChangePercent, "1.01")
+}
+
+func TestStockSummaryCarriesUnquotedHoldingsAtCost(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 80, date(2024, time.January, 1))
+	usecase := New(nopLogger{}, f.repo, quoteMarketer{}, nil)
+
+	request := domain.ClientStockSummaryRequest{UserId: 1, AccountId: f.accountId}
+	var result []domain.ClientStockSummaryResponse
+	if err := json.Unmarshal(expectSuccess(t, usecase.StockSummary(request)).Data, &result); err != nil {
+		t.Fatal(err)
+	}
+	var totals domain.ClientStockValuation
+	if err := json.Unmarshal(expectSuccess(t, usecase.StockSummaryTotals(request)).Data, &totals); err != nil {
+		t.Fatal(err)
+	}
+	if totals.Priced || result[0].Priced {
+		t.Fatalf("unquoted holding reported as priced: %+v, totals %+v", result, totals)
+	}
+	assertDecimal(t, "current value", result[0].CurrentValue, "800")
+	assertDecimal(t, "gain", result[0].UnrealizedGain, "0")
+
+	var lots []domain.ClientStockInventoriesResponse
+	res := New(nopLogger{}, f.repo, quoteMarketer{"PARENT": {90, 0}}, nil).StockInventories(domain.ClientStockInventoriesRequest{UserId: 1, AccountId: f.accountId, StockId: f.stockId})
+	if err := json.Unmarshal(expectSuccess(t, res).Data, &lots); err != nil {
+		t.Fatal(err)
+	}
+	assertDecimal(t, "lot invested", lots[0].InvestedValue, "800")
+	assertDecimal(t, "lot gain percent", lots[0].UnrealizedGainPercent, "12.5")
+}
@@ -1,20 +1,72 @@
 package stock
 
 import (
+	"assetio/internal/constant"
 	"assetio/internal/domain"
 	"assetio/internal/port"
+	"context"
+	"errors"
+	"net/http"
 )
 
+// errTxAborted is returned from a unit-of-work closure after the response has already been
+// populated with the failure, so the caller only needs to roll back and return it.
+var errTxAborted = errors.New("transaction aborted")
+
+// maxTxAttempts bounds how often a unit of work is re-run after losing an inventory update to a concurrent request.
+const maxTxAttempts = 3
+
 type stockUsecase struct {
-	logger   port.Logger
-	mysql    port.RepositoryStore
-	marketer port.Marketer
+	logger      port.Logger
+	mysql       port.RepositoryStore
+	marketer    port.Marketer
+	chargeRates map[string]domain.ChargeRates // Rate tables keyed by lower case exchange name, e.g. "nse"
 }
 
-func New(loggerIns port.Logger, mysqlIns port.RepositoryStore, marketerIns port.Marketer) domain.StockSvr {
+func New(loggerIns port.Logger, mysqlIns port.RepositoryStore, marketerIns port.Marketer, chargeRates map[string]domain.ChargeRates) domain.StockSvr {
 	return &stockUsecase{
-		mysql:    mysqlIns,
-		logger:   loggerIns,
-		marketer: marketerIns,
+		mysql:       mysqlIns,
+		logger:      loggerIns,
+		marketer:    marketerIns,
+		chargeRates: chargeRates,
+	}
+}
+
+// withTx runs fn in a repository transaction. When an inventory row was changed by a concurrent request the
+// whole transaction is rolled back and run again, so fn re-reads the current state before it re-checks it.
+func (s *stockUsecase) withTx(ctx context.Context, fn func(repo port.RepositoryStore) error) error {
+	var err error
+	for attempt := 0; attempt < maxTxAttempts; attempt++ {
+		err = s.mysql.WithTx(ctx, fn)
+		if !errors.Is(err, domain.ErrInventoryConflict) {
+			return err
+		}
+	}
+	return err
+}
+
+// txFailed reports a unit-of-work error on the response. Errors raised by the closure have already
+// been logged and set on the response (errTxAborted); anything else came from begin/commit itself.
+func (s *stockUsecase) txFailed(ctx context.Context, res domain.Response, err error, request any) domain.Response {
+	if errors.Is(err, domain.ErrInventoryConflict) {
+		// Every retry lost the race; the client can safely send the same request again.
+		s.logger.Warnw(ctx, "WithTx conflict",
+			constant.ERROR_MESSAGE, err.Error(),
+			constant.REQUEST, request,
+		)
+		res.SetStatus(http.StatusConflict)
+		res.SetError(constant.ERROR_CODE_CONFLICT, "inventory was updated by another request, please retry")
+		return res
+	}
+
+	if err != errTxAborted {
+		s.logger.Errorw(ctx, "WithTx failed",
+			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+			constant.ERROR_MESSAGE, err.Error(),
+			constant.REQUEST, request,
+		)
+		res.SetStatus(http.StatusInternalServerError)
+		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
 	}
+	return res
 }
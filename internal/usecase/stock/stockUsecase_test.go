@@ -0,0 +1,296 @@
+package stock
+
+import (
+	"assetio/internal/adapters/repository/memory"
+	"assetio/internal/constant"
+	"assetio/internal/domain"
+	"assetio/internal/port"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// nopLogger discards every log line written by the use cases under test.
+type nopLogger struct{}
+
+func (nopLogger) Debug(ctx context.Context, messages ...any)                   {}
+func (nopLogger) Info(ctx context.Context, messages ...any)                    {}
+func (nopLogger) Warn(ctx context.Context, messages ...any)                    {}
+func (nopLogger) Error(ctx context.Context, messages ...any)                   {}
+func (nopLogger) Fatal(ctx context.Context, messages ...any)                   {}
+func (nopLogger) Debugf(ctx context.Context, template string, args ...any)     {}
+func (nopLogger) Infof(ctx context.Context, template string, args ...any)      {}
+func (nopLogger) Warnf(ctx context.Context, template string, args ...any)      {}
+func (nopLogger) Errorf(ctx context.Context, template string, args ...any)     {}
+func (nopLogger) Fatalf(ctx context.Context, template string, args ...any)     {}
+func (nopLogger) Debugw(ctx context.Context, msg string, keysAndValues ...any) {}
+func (nopLogger) Infow(ctx context.Context, msg string, keysAndValues ...any)  {}
+func (nopLogger) Warnw(ctx context.Context, msg string, keysAndValues ...any)  {}
+func (nopLogger) Errorw(ctx context.Context, msg string, keysAndValues ...any) {}
+func (nopLogger) Fatalw(ctx context.Context, msg string, keysAndValues ...any) {}
+func (nopLogger) Sync(ctx context.Context) error                               { return nil }
+
+// stubMarketer answers every quote with a fixed price.
+type stubMarketer struct {
+	price float64
+}
+
+type stubMarketerData struct {
+	price float64
+}
+
+func (m stubMarketer) Query(symbol, exchange string) (port.MarketerData, error) {
+	return stubMarketerData{price: m.price}, nil
+}
+
+func (m stubMarketer) QueryClose(symbol, exchange string, date time.Time) (float64, error) {
+	return m.price, nil
+}
+
+func (d stubMarketerData) GetMarketPrice() float64         { return d.price }
+func (d stubMarketerData) GetMarketChange() float64        { return 0 }
+func (d stubMarketerData) GetMarketChangePercent() float64 { return 0 }
+
+// failingRepo wraps a repository and fails the named method when it runs inside WithTx.
+type failingRepo struct {
+	port.RepositoryStore
+	failOn string
+}
+
+var errInjected = errors.New("injected failure")
+
+func (f failingRepo) WithTx(ctx context.Context, fn func(repo port.RepositoryStore) error) error {
+	return f.RepositoryStore.WithTx(ctx, func(repo port.RepositoryStore) error {
+		return fn(failingTxRepo{RepositoryStore: repo, failOn: f.failOn})
+	})
+}
+
+type failingTxRepo struct {
+	port.RepositoryStore
+	failOn string
+}
+
+func (f failingTxRepo) UpdateInventoryLedgerTransactionIdByIds(ctx context.Context, ledgerIds []int, transactionId int) error {
+	if f.failOn == "UpdateInventoryLedgerTransactionIdByIds" {
+		return errInjected
+	}
+	return f.RepositoryStore.UpdateInventoryLedgerTransactionIdByIds(ctx, ledgerIds, transactionId)
+}
+
+func (f failingTxRepo) InsertTransaction(ctx context.Context, transactionData domain.Transactions) (domain.Transactions, error) {
+	if f.failOn == "InsertTransaction" {
+		return transactionData, errInjected
+	}
+	return f.RepositoryStore.InsertTransaction(ctx, transactionData)
+}
+
+// conflictingRepo wraps a repository and makes the next conflicts inventory updates inside WithTx lose
+// the race to a simulated concurrent request.
+type conflictingRepo struct {
+	port.RepositoryStore
+	conflicts *int
+}
+
+func (c conflictingRepo) WithTx(ctx context.Context, fn func(repo port.RepositoryStore) error) error {
+	return c.RepositoryStore.WithTx(ctx, func(repo port.RepositoryStore) error {
+		return fn(conflictingTxRepo{RepositoryStore: repo, conflicts: c.conflicts})
+	})
+}
+
+type conflictingTxRepo struct {
+	port.RepositoryStore
+	conflicts *int
+}
+
+func (c conflictingTxRepo) UpdateInventoryDetailsById(ctx context.Context, inventoryId, version int, availableQuantity, averagePrice, totalValue decimal.Decimal) error {
+	if *c.conflicts > 0 {
+		*c.conflicts--
+		return domain.ErrInventoryConflict
+	}
+	return c.RepositoryStore.UpdateInventoryDetailsById(ctx, inventoryId, version, availableQuantity, averagePrice, totalValue)
+}
+
+// fixture is an in-memory store with one account and two stocks, plus the use case under test.
+type fixture struct {
+	repo      port.RepositoryStore
+	usecase   domain.StockSvr
+	accountId int
+	stockId   int
+	otherId   int
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	ctx := context.Background()
+	repo := memory.New()
+
+	account, err := repo.InsertAccountData(ctx, domain.Accounts{UserId: 1, Name: "primary", Status: constant.ACCOUNT_STATUS_ACTIVE})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stock, err := repo.InsertSecurityData(ctx, domain.Securities{Type: constant.SECURITY_TYPE_STOCK, Exchange: constant.EXCHANGE_TYPE_NSE, Symbol: "PARENT", Name: "Parent Ltd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := repo.InsertSecurityData(ctx, domain.Securities{Type: constant.SECURITY_TYPE_STOCK, Exchange: constant.EXCHANGE_TYPE_NSE, Symbol: "CHILD", Name: "Child Ltd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &fixture{
+		repo:      repo,
+		usecase:   New(nopLogger{}, repo, stubMarketer{price: 100}, nil),
+		accountId: account.Id,
+		stockId:   stock.Id,
+		otherId:   other.Id,
+	}
+}
+
+// buy records a purchase through the use case and fails the test when it is rejected.
+func (f *fixture) buy(t *testing.T, quantity, price float64, date string) {
+	t.Helper()
+	expectSuccess(t, f.usecase.StockBuy(domain.ClientStockBuyRequest{
+		UserId:       1,
+		AccountId:    f.accountId,
+		StockId:      f.stockId,
+		Date:         date,
+		Quantity:     decimal.NewFromFloat(quantity),
+		AveragePrice: decimal.NewFromFloat(price),
+	}))
+}
+
+// inventories returns the open inventories of the account for a stock, oldest first.
+func (f *fixture) inventories(t *testing.T, stockId int) []domain.Inventories {
+	t.Helper()
+	inventories, err := f.repo.GetActiveInventoriesByAccountIdAndSecurityId(context.Background(), f.accountId, stockId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return inventories
+}
+
+type decodedResponse struct {
+	Status  int             `json:"status"`
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data"`
+}
+
+func decode(t *testing.T, res domain.Response) decodedResponse {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	res.Send(recorder)
+
+	var decoded decodedResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &decoded); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return decoded
+}
+
+func expectSuccess(t *testing.T, res domain.Response) decodedResponse {
+	t.Helper()
+	decoded := decode(t, res)
+	if !decoded.Success {
+		t.Fatalf("request failed with status %d", decoded.Status)
+	}
+	return decoded
+}
+
+func expectStatus(t *testing.T, res domain.Response, status int) {
+	t.Helper()
+	if decoded := decode(t, res); decoded.Status != status {
+		t.Fatalf("status = %d, want %d", decoded.Status, status)
+	}
+}
+
+// assertDecimal compares exactly: stored values are rounded, so there is no tolerance to allow for.
+func assertDecimal(t *testing.T, name string, got decimal.Decimal, want string) {
+	t.Helper()
+	if !got.Equal(decimal.RequireFromString(want)) {
+		t.Errorf("%s = %s, want %s", name, got, want)
+	}
+}
+
+func date(year int, month time.Month, day int) string {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Format(constant.DATE_LAYOUT)
+}
+
+// sell is the request used by the concurrency tests: sell quantity of the fixture stock.
+func (f *fixture) sell(quantity float64) domain.ClientStockSellRequest {
+	return domain.ClientStockSellRequest{
+		UserId:       1,
+		AccountId:    f.accountId,
+		StockId:      f.stockId,
+		Date:         date(2024, time.March, 1),
+		Quantity:     decimal.NewFromFloat(quantity),
+		AveragePrice: decimal.NewFromInt(300),
+	}
+}
+
+func TestWithTxRetriesInventoryConflict(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+
+	conflicts := maxTxAttempts - 1
+	usecase := New(nopLogger{}, conflictingRepo{RepositoryStore: f.repo, conflicts: &conflicts}, stubMarketer{}, nil)
+	expectSuccess(t, usecase.StockSell(f.sell(4)))
+
+	inventories := f.inventories(t, f.stockId)
+	if len(inventories) != 1 {
+		t.Fatalf("got %d open inventories, want 1", len(inventories))
+	}
+	assertDecimal(t, "quantity", inventories[0].AvailableQuantity, "6")
+
+	// Only the attempt that committed left a ledger entry behind.
+	ledgers, err := f.repo.GetInventoryLedgersByInventoryId(context.Background(), inventories[0].Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ledgers) != 2 {
+		t.Fatalf("got %d ledgers, want the buy and one sell", len(ledgers))
+	}
+}
+
+func TestWithTxReportsPersistentConflict(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+
+	conflicts := maxTxAttempts
+	usecase := New(nopLogger{}, conflictingRepo{RepositoryStore: f.repo, conflicts: &conflicts}, stubMarketer{}, nil)
+	expectStatus(t, usecase.StockSell(f.sell(4)), http.StatusConflict)
+
+	assertDecimal(t, "quantity", f.inventories(t, f.stockId)[0].AvailableQuantity, "10")
+}
+
+func TestConcurrentSellsNeverOversell(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sold := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if decode(t, f.usecase.StockSell(f.sell(3))).Success {
+				mu.Lock()
+				sold++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if sold != 3 {
+		t.Fatalf("%d sells of 3 succeeded against 10 shares, want 3", sold)
+	}
+	assertDecimal(t, "quantity", f.inventories(t, f.stockId)[0].AvailableQuantity, "1")
+}
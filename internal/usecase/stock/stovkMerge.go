@@ -4,9 +4,13 @@ import (
 	"assetio/internal/adapters/handler/response"
 	"assetio/internal/constant"
 	"assetio/internal/domain"
+	"assetio/internal/port"
 	"context"
+	"errors"
 	"net/http"
 	"time"
+
+	"github.com/shopspring/decimal"
 )
 
 func (s *stockUsecase) StockMerge(request domain.ClientStockMergeRequest) domain.Response {
@@ -56,6 +60,9 @@ func (s *stockUsecase) StockMerge(request domain.ClientStockMergeRequest) domain
 		return res
 	}
 
+	// Apply the rounding rules to the request before any arithmetic.
+	request.Quantity = domain.RoundQuantity(request.Quantity)
+
 	var date = time.Now()
 
 	if request.Date != "" {
@@ -65,196 +72,213 @@ func (s *stockUsecase) StockMerge(request domain.ClientStockMergeRequest) domain
 		}
 	}
 
-	inventories, err := s.mysql.GetActiveInventoriesByAccountIdAndSecurityId(ctx, request.AccountId, request.ParentStockId)
-	if err != nil {
-		s.logger.Errorw(ctx, "GetActiveInventoriesByAccountIdAndSecurityId failed",
-			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
-			constant.ERROR_MESSAGE, err.Error(),
-			constant.REQUEST, request,
-		)
-		res.SetStatus(http.StatusInternalServerError)
-		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-		return res
-	}
-
-	var availableQuantities float64
-	var totalAmount float64
-	var inventoryLedgerIds []int
+	// Run every write inside one unit of work so a partial failure leaves no orphan rows behind.
+	err = s.withTx(ctx, func(repo port.RepositoryStore) error {
+		inventories, err := repo.GetActiveInventoriesByAccountIdAndSecurityId(ctx, request.AccountId, request.ParentStockId)
+		if err != nil {
+			s.logger.Errorw(ctx, "GetActiveInventoriesByAccountIdAndSecurityId failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
 
-	for _, inventory := range inventories {
-		availableQuantities += inventory.AvailableQuantity
-		totalAmount += inventory.TotalValue
-	}
+		var availableQuantities decimal.Decimal
+		var totalAmount decimal.Decimal
+		var inventoryLedgerIds []int
 
-	averagePrice := totalAmount / availableQuantities
+		for _, inventory := range inventories {
+			availableQuantities = availableQuantities.Add(inventory.AvailableQuantity)
+			totalAmount = totalAmount.Add(inventory.TotalValue)
+		}
 
-	for _, inventory := range inventories {
+		averagePrice := domain.AveragePrice(totalAmount, availableQuantities)
+
+		for _, inventory := range inventories {
+
+			inventoryLedgerData, err := repo.InsertInventoryLedger(ctx, domain.InventoryLedger{
+				InventoryId:  inventory.Id,
+				Type:         domain.MERGER_TRANSFER,
+				Quantity:     inventory.AvailableQuantity,
+				AveragePrice: inventory.AveragePrice,
+				TotalValue:   inventory.TotalValue,
+				Date:         time.Now(),
+			})
+
+			if err != nil {
+				s.logger.Errorw(ctx, "InsertInventoryLedger failed",
+					constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+					constant.ERROR_MESSAGE, err.Error(),
+					constant.REQUEST, request,
+				)
+				res.SetStatus(http.StatusInternalServerError)
+				res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+				return errTxAborted
+			}
+
+			inventoryLedgerIds = append(inventoryLedgerIds, inventoryLedgerData.Id)
+			inventory.AvailableQuantity = inventory.AvailableQuantity.Sub(inventoryLedgerData.Quantity)
+			inventory.TotalValue = inventory.TotalValue.Sub(inventoryLedgerData.TotalValue)
+			// The whole lot moves to the new stock, so the emptied lot has no average price.
+			inventory.AveragePrice = decimal.Zero
+
+			// Update inventory data in database.
+			err = repo.UpdateInventoryDetailsById(ctx, inventory.Id, inventory.Version, inventory.AvailableQuantity, inventory.AveragePrice, inventory.TotalValue)
+			if errors.Is(err, domain.ErrInventoryConflict) {
+				// Another request changed this lot since it was read; withTx rolls back and retries.
+				return err
+			}
+			if err != nil {
+				s.logger.Errorw(ctx, "UpdateInventoryDataById failed",
+					constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+					constant.ERROR_MESSAGE, err.Error(),
+					constant.REQUEST, request,
+				)
+				res.SetStatus(http.StatusInternalServerError)
+				res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+				return errTxAborted
+			}
+		}
 
-		inventoryLedgerData, err := s.mysql.InsertInventoryLedger(ctx, domain.InventoryLedger{
-			InventoryId:  inventory.Id,
+		// Insert transaction record for buy operation.
+		transactionData, err := repo.InsertTransaction(ctx, domain.Transactions{
+			AccountId:    request.AccountId,
+			SecurityId:   parrentSecuirity.Id,
 			Type:         domain.MERGER_TRANSFER,
-			Quantity:     inventory.AvailableQuantity,
-			AveragePrice: inventory.AveragePrice,
-			TotalValue:   inventory.TotalValue,
+			AveragePrice: averagePrice,
+			Quantity:     availableQuantities,
+			TotalValue:   totalAmount,
 			Date:         time.Now(),
 		})
 
 		if err != nil {
-			s.logger.Errorw(ctx, "InsertInventoryLedger failed",
+			s.logger.Errorw(ctx, "InsertTransaction failed",
 				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
 				constant.ERROR_MESSAGE, err.Error(),
 				constant.REQUEST, request,
 			)
 			res.SetStatus(http.StatusInternalServerError)
 			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-			return res
+			return errTxAborted
 		}
 
-		inventoryLedgerIds = append(inventoryLedgerIds, inventoryLedgerData.Id)
-		inventory.AvailableQuantity -= inventoryLedgerData.Quantity
-		inventory.TotalValue -= inventoryLedgerData.TotalValue
-		inventory.AveragePrice = inventory.TotalValue / inventory.AvailableQuantity
-
-		// Update inventory data in database.
-		err = s.mysql.UpdateInventoryDetailsById(ctx, inventory.Id, inventory.AvailableQuantity, inventory.AveragePrice, inventory.TotalValue)
+		// Update ledger entries with the transaction ID for tracking purposes.
+		err = repo.UpdateInventoryLedgerTransactionIdByIds(ctx, inventoryLedgerIds, transactionData.Id)
 		if err != nil {
-			s.logger.Errorw(ctx, "UpdateInventoryDataById failed",
+			s.logger.Errorw(ctx, "UpdateInventoryLedgerTransactionIdById failed",
 				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
 				constant.ERROR_MESSAGE, err.Error(),
 				constant.REQUEST, request,
 			)
 			res.SetStatus(http.StatusInternalServerError)
 			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-			return res
+			return errTxAborted
 		}
-	}
 
-	// Insert transaction record for buy operation.
-	transactionData, err := s.mysql.InsertTransaction(ctx, domain.Transactions{
-		AccountId:    request.AccountId,
-		SecurityId:   parrentSecuirity.Id,
-		Type:         domain.MERGER_TRANSFER,
-		AveragePrice: averagePrice,
-		Quantity:     availableQuantities,
-		TotalValue:   averagePrice * availableQuantities,
-		Date:         time.Now(),
-	})
+		// Insert new inventory .
+		inventory, err := repo.InsertInventoryData(ctx, domain.Inventories{
+			AccountId:  request.AccountId,
+			SecurityId: newSecuirity.Id,
+			Date:       date,
+		})
 
-	if err != nil {
-		s.logger.Errorw(ctx, "InsertTransaction failed",
-			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
-			constant.ERROR_MESSAGE, err.Error(),
-			constant.REQUEST, request,
-		)
-		res.SetStatus(http.StatusInternalServerError)
-		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-		return res
-	}
+		if err != nil {
+			s.logger.Errorw(ctx, "InsertInventoryData failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			// Set HTTP status to 500 and include an internal server error message in the response.
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
 
-	// Update ledger entries with the transaction ID for tracking purposes.
-	err = s.mysql.UpdateInventoryLedgerTransactionIdByIds(ctx, inventoryLedgerIds, transactionData.Id)
-	if err != nil {
-		s.logger.Errorw(ctx, "UpdateInventoryLedgerTransactionIdById failed",
-			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
-			constant.ERROR_MESSAGE, err.Error(),
-			constant.REQUEST, request,
-		)
-		res.SetStatus(http.StatusInternalServerError)
-		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-		return res
-	}
+		// Record ledger entry for buy transaction.
+		inventoryLedgerData, err := repo.InsertInventoryLedger(ctx, domain.InventoryLedger{
+			InventoryId:  inventory.Id,
+			Type:         domain.MERGER,
+			Quantity:     request.Quantity,
+			AveragePrice: domain.AveragePrice(totalAmount, request.Quantity),
+			TotalValue:   totalAmount,
+			Date:         date,
+		})
 
-	// Insert new inventory .
-	inventory, err := s.mysql.InsertInventoryData(ctx, domain.Inventories{
-		AccountId:  request.AccountId,
-		SecurityId: newSecuirity.Id,
-		Date:       date,
-	})
+		if err != nil {
+			s.logger.Errorw(ctx, "InsertInventoryLedger failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
 
-	if err != nil {
-		s.logger.Errorw(ctx, "InsertInventoryData failed",
-			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
-			constant.ERROR_MESSAGE, err.Error(),
-			constant.REQUEST, request,
-		)
-		// Set HTTP status to 500 and include an internal server error message in the response.
-		res.SetStatus(http.StatusInternalServerError)
-		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-		return res
-	}
+		// Update inventory with new quantity, value, and average price.
+		inventory.AvailableQuantity = inventory.AvailableQuantity.Add(inventoryLedgerData.Quantity)
+		inventory.TotalValue = inventory.TotalValue.Add(inventoryLedgerData.TotalValue)
+		inventory.AveragePrice = domain.AveragePrice(inventory.TotalValue, inventory.AvailableQuantity)
 
-	// Record ledger entry for buy transaction.
-	inventoryLedgerData, err := s.mysql.InsertInventoryLedger(ctx, domain.InventoryLedger{
-		InventoryId:  inventory.Id,
-		Type:         domain.MERGER,
-		Quantity:     request.Quantity,
-		AveragePrice: totalAmount / request.Quantity,
-		TotalValue:   totalAmount,
-		Date:         date,
-	})
+		// Update inventory data in database.
+		err = repo.UpdateInventoryDetailsById(ctx, inventory.Id, inventory.Version, inventory.AvailableQuantity, inventory.AveragePrice, inventory.TotalValue)
+		if errors.Is(err, domain.ErrInventoryConflict) {
+			// Another request changed this lot since it was read; withTx rolls back and retries.
+			return err
+		}
+		if err != nil {
+			s.logger.Errorw(ctx, "UpdateInventoryDataById failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
 
-	if err != nil {
-		s.logger.Errorw(ctx, "InsertInventoryLedger failed",
-			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
-			constant.ERROR_MESSAGE, err.Error(),
-			constant.REQUEST, request,
-		)
-		res.SetStatus(http.StatusInternalServerError)
-		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-		return res
-	}
+		// Insert transaction record for buy operation.
+		transactionData, err = repo.InsertTransaction(ctx, domain.Transactions{
+			AccountId:    request.AccountId,
+			SecurityId:   newSecuirity.Id,
+			Type:         domain.MERGER,
+			Quantity:     request.Quantity,
+			AveragePrice: domain.AveragePrice(totalAmount, request.Quantity),
+			TotalValue:   totalAmount,
+			Date:         date,
+		})
 
-	// Update inventory with new quantity, value, and average price.
-	inventory.AvailableQuantity += inventoryLedgerData.Quantity
-	inventory.TotalValue += inventoryLedgerData.TotalValue
-	inventory.AveragePrice = inventory.TotalValue / inventory.AvailableQuantity
+		if err != nil {
+			s.logger.Errorw(ctx, "InsertTransaction failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
 
-	// Update inventory data in database.
-	err = s.mysql.UpdateInventoryDetailsById(ctx, inventory.Id, inventory.AvailableQuantity, inventory.AveragePrice, inventory.TotalValue)
-	if err != nil {
-		s.logger.Errorw(ctx, "UpdateInventoryDataById failed",
-			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
-			constant.ERROR_MESSAGE, err.Error(),
-			constant.REQUEST, request,
-		)
-		res.SetStatus(http.StatusInternalServerError)
-		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-		return res
-	}
+		// Link ledger entry to transaction by updating ledger with transaction ID.
+		err = repo.UpdateInventoryLedgerTransactionIdById(ctx, inventoryLedgerData.Id, transactionData.Id)
+		if err != nil {
+			s.logger.Errorw(ctx, "UpdateInventoryLedgerTransactionIdById failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
 
-	// Insert transaction record for buy operation.
-	transactionData, err = s.mysql.InsertTransaction(ctx, domain.Transactions{
-		AccountId:    request.AccountId,
-		SecurityId:   newSecuirity.Id,
-		Type:         domain.MERGER,
-		Quantity:     request.Quantity,
-		AveragePrice: totalAmount / request.Quantity,
-		TotalValue:   totalAmount,
-		Date:         date,
+		return nil
 	})
-
 	if err != nil {
-		s.logger.Errorw(ctx, "InsertTransaction failed",
-			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
-			constant.ERROR_MESSAGE, err.Error(),
-			constant.REQUEST, request,
-		)
-		res.SetStatus(http.StatusInternalServerError)
-		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-		return res
-	}
-
-	// Link ledger entry to transaction by updating ledger with transaction ID.
-	err = s.mysql.UpdateInventoryLedgerTransactionIdById(ctx, inventoryLedgerData.Id, transactionData.Id)
-	if err != nil {
-		s.logger.Errorw(ctx, "UpdateInventoryLedgerTransactionIdById failed",
-			constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
-			constant.ERROR_MESSAGE, err.Error(),
-			constant.REQUEST, request,
-		)
-		res.SetStatus(http.StatusInternalServerError)
-		res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
-		return res
+		return s.txFailed(ctx, res, err, request)
 	}
 
 	// Set success response message.
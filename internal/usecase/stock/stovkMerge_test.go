@@ -0,0 +1,36 @@
+package stock
+
+import (
+	"assetio/internal/domain"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestStockMergeMovesCostToNewStock(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	f.buy(t, 10, 300, date(2024, time.February, 1))
+
+	expectSuccess(t, f.usecase.StockMerge(domain.ClientStockMergeRequest{
+		UserId:        1,
+		AccountId:     f.accountId,
+		ParentStockId: f.stockId,
+		NewStockId:    f.otherId,
+		Quantity:      decimal.NewFromInt(5),
+		Date:          date(2024, time.March, 1),
+	}))
+
+	if parent := f.inventories(t, f.stockId); len(parent) != 0 {
+		t.Fatalf("parent stock still has open inventories: %+v", parent)
+	}
+
+	merged := f.inventories(t, f.otherId)
+	if len(merged) != 1 {
+		t.Fatalf("got %d merged inventories, want 1", len(merged))
+	}
+	assertDecimal(t, "merged quantity", merged[0].AvailableQuantity, "5")
+	assertDecimal(t, "merged total value", merged[0].TotalValue, "4000")
+	assertDecimal(t, "merged average price", merged[0].AveragePrice, "800")
+}
@@ -0,0 +1,763 @@
+package stock
+
+import (
+	"assetio/internal/adapters/handler/response"
+	"assetio/internal/constant"
+	"assetio/internal/domain"
+	"assetio/internal/port"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// errUpdateBlocked is returned when an edit cannot be carried through the transactions recorded after it.
+var errUpdateBlocked = errors.New("transaction cannot be updated")
+
+// TransactionUpdate edits the quantity, price, fee or date of a recorded buy, sell or dividend and recomputes
+// everything of the same account and stock recorded from the earlier of its old and new dates on:
+//   - sells are matched to the lots again under the account's lot method, as StockSell matches them,
+//   - ratio splits are worked out again from their ratio as StockSplit works them out, whole shares only,
+//   - ratio bonuses are worked out again on the holding at the start of their ex-date, whole shares only, as
+//     StockBonus works them out,
+//   - splits and bonuses given as a quantity keep their ratio to the holding and are shared over the lots again,
+//   - dividends are paid on the holding on their date,
+//   - every inventory whose ledger changed is replayed.
+//
+// Mergers and demergers are not recomputed, so an edit that reaches one is refused.
+//
+// Parameters:
+//   - request: domain.ClientTransactionUpdateRequest - contains the account, the transaction to edit and the
+//     values to change.
+//
+// Returns:
+//   - domain.Response - lists the transactions, ledger entries and inventories that changed, or an error
+//     when the edit cannot be applied.
+func (s *stockUsecase) TransactionUpdate(request domain.ClientTransactionUpdateRequest) domain.Response {
+	ctx := context.Background()
+	res := response.New()
+
+	var date time.Time
+	if request.Date != "" {
+		parsedDate, err := time.Parse(constant.DATE_LAYOUT, request.Date)
+		if err != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "invalid date")
+			return res
+		}
+		date = parsedDate
+	}
+
+	var resData domain.ClientTransactionUpdateResponse
+
+	// Rewrite the transaction and everything after it in one unit of work.
+	err := s.withTx(ctx, func(repo port.RepositoryStore) error {
+		// Validate the account belongs to the user.
+		account, err := repo.GetAccountDataByIdAndUserId(ctx, request.AccountId, request.UserId)
+		if err != nil {
+			s.logger.Errorw(ctx, "GetAccountDataByIdAndUserId failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
+		if account.Id == 0 {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "incorrect account id")
+			return errTxAborted
+		}
+
+		transaction, err := repo.GetTransactionDataById(ctx, request.TransactionId)
+		if err != nil {
+			s.logger.Errorw(ctx, "GetTransactionDataById failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
+
+		// Validate the transaction belongs to the account and can be edited.
+		if transaction.Id == 0 || transaction.AccountId != request.AccountId {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "invalid transaction id")
+			return errTxAborted
+		}
+		if transaction.State == domain.TRANSACTION_STATE_VOIDED {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "transaction is voided")
+			return errTxAborted
+		}
+		if transaction.Type != domain.BUY && transaction.Type != domain.SELL && transaction.Type != domain.DIVIDEND {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "only buy, sell and dividend transactions can be updated")
+			return errTxAborted
+		}
+		if transaction.Type == domain.DIVIDEND && request.Quantity != nil {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "dividend quantity follows the holding on its date")
+			return errTxAborted
+		}
+
+		// Apply the rounding rules to the new values, keeping the recorded ones that are not edited.
+		edited := transaction
+		if request.Quantity != nil {
+			edited.Quantity = domain.RoundQuantity(*request.Quantity)
+		}
+		if request.AveragePrice != nil {
+			edited.AveragePrice = domain.RoundPrice(*request.AveragePrice)
+		}
+		if request.FeeAmount != nil {
+			edited.Fee = domain.RoundValue(*request.FeeAmount)
+		}
+		if request.Date != "" {
+			edited.Date = date
+		}
+		edited.TotalValue = domain.RoundValue(edited.Quantity.Mul(edited.AveragePrice))
+
+		resData, err = updateTransaction(ctx, repo, transaction, edited, account.LotMethod)
+		if errors.Is(err, domain.ErrInventoryConflict) {
+			// Another request changed one of the lots since it was read; withTx rolls back and retries.
+			return err
+		}
+		if errors.Is(err, errUpdateBlocked) || errors.Is(err, ErrLedgerReplay) {
+			res.SetStatus(http.StatusUnprocessableEntity)
+			res.SetError(constant.ERROR_CODE_DATA_INVALID, err.Error())
+			return errTxAborted
+		}
+		if err != nil {
+			s.logger.Errorw(ctx, "updateTransaction failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
+
+		// An edited fee no longer adds up to its itemised charges, so it stands unitemised.
+		if !edited.Fee.Equal(transaction.Fee) {
+			err = repo.DeleteTransactionChargesByTransactionId(ctx, transaction.Id)
+			if err != nil {
+				s.logger.Errorw(ctx, "DeleteTransactionChargesByTransactionId failed",
+					constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+					constant.ERROR_MESSAGE, err.Error(),
+					constant.REQUEST, request,
+				)
+				res.SetStatus(http.StatusInternalServerError)
+				res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+				return errTxAborted
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return s.txFailed(ctx, res, err, request)
+	}
+
+	// Set success response message.
+	resData.Message = "transaction updated successfully"
+
+	res.SetData(resData)
+	return res
+}
+
+// updateTransaction writes edited over original and recomputes the transactions, ledger entries and inventories
+// of the same account and stock that follow it, matching sells to lots under method, and returns what changed.
+func updateTransaction(ctx context.Context, repo port.RepositoryStore, original, edited domain.Transactions, method domain.LotMethod) (domain.ClientTransactionUpdateResponse, error) {
+	resData := domain.ClientTransactionUpdateResponse{
+		Transactions: []domain.ClientTransactionUpdateTransaction{},
+		Ledgers:      []domain.ClientTransactionUpdateLedger{},
+		Inventories:  []domain.ClientTransactionUpdateInventory{},
+	}
+
+	transactions, err := repo.GetTransactionsByAccountIdAndSecurityId(ctx, original.AccountId, original.SecurityId)
+	if err != nil {
+		return resData, err
+	}
+
+	// Everything from the earlier of the two dates on may change. A dividend changes no lot, so editing one
+	// only recomputes the dividend itself.
+	cutoff := original.Date
+	if edited.Date.Before(cutoff) {
+		cutoff = edited.Date
+	}
+	var recorded, dividends []domain.Transactions
+	for _, transaction := range transactions {
+		if transaction.State == domain.TRANSACTION_STATE_VOIDED || transaction.Date.Before(cutoff) {
+			continue
+		}
+		if transaction.Type == domain.DIVIDEND {
+			if original.Type != domain.DIVIDEND || transaction.Id == original.Id {
+				dividends = append(dividends, transaction)
+			}
+			continue
+		}
+		if original.Type != domain.DIVIDEND {
+			recorded = append(recorded, transaction)
+		}
+	}
+
+	if original.Type != domain.DIVIDEND {
+		changes, err := planLots(ctx, repo, recorded, edited, method)
+		if err != nil {
+			return resData, err
+		}
+		err = applyLots(ctx, repo, changes, original, edited, &resData)
+		if err != nil {
+			return resData, err
+		}
+	}
+
+	// Dividends are paid on the holding on their date, read back after the lots were rewritten.
+	for _, dividend := range dividends {
+		updated := dividend
+		if dividend.Id == edited.Id {
+			updated = edited
+		}
+		quantity, err := repo.GetInventoryAvailableQuanitityBySecurityIdAndDate(ctx, updated.AccountId, updated.SecurityId, updated.Date)
+		if err != nil {
+			return resData, err
+		}
+		updated.Quantity = quantity
+		updated.TotalValue = domain.RoundValue(quantity.Mul(updated.AveragePrice))
+
+		if err := updateTransactionValues(ctx, repo, dividend, updated, &resData); err != nil {
+			return resData, err
+		}
+	}
+
+	return resData, nil
+}
+
+// lotChange is a transaction recorded on the lots with the values and ledger entries it has after an edit.
+type lotChange struct {
+	before   domain.Transactions
+	after    domain.Transactions
+	recorded []domain.InventoryLedger // Ledger entries as stored
+	entries  []domain.InventoryLedger // Ledger entries as recomputed; an entry with a zero id is new
+}
+
+// planLots recomputes the ledger entries of the recorded transactions, oldest first, with edited in place of
+// the transaction it replaces and sells matched to lots under method. Nothing is written.
+func planLots(ctx context.Context, repo port.RepositoryStore, recorded []domain.Transactions, edited domain.Transactions, method domain.LotMethod) ([]lotChange, error) {
+	changes := make([]lotChange, 0, len(recorded))
+	window := map[int]bool{}
+	for _, transaction := range recorded {
+		switch transaction.Type {
+		case domain.MERGER, domain.MERGER_TRANSFER, domain.DEMERGER, domain.DEMERGER_TRANSFER:
+			return nil, fmt.Errorf("%w: %s transaction %d is recorded after it", errUpdateBlocked, transaction.Type, transaction.Id)
+		}
+
+		ledgers, err := repo.GetInventoryLedgersByTransactionId(ctx, transaction.Id)
+		if err != nil {
+			return nil, err
+		}
+		change := lotChange{before: transaction, after: transaction, recorded: ledgers}
+		if transaction.Id == edited.Id {
+			change.after = edited
+		}
+		changes = append(changes, change)
+		window[transaction.Id] = true
+	}
+
+	// Quantity of every lot from the entries the edit leaves alone.
+	inventories, err := repo.GetInventoriesByAccountIdOrSecurityId(ctx, edited.AccountId, edited.SecurityId)
+	if err != nil {
+		return nil, err
+	}
+	lots := map[int]decimal.Decimal{}
+	openedBy := map[int]int{}
+	inventoryIds := make([]int, 0, len(inventories))
+	for i, inventory := range inventories {
+		inventoryIds = append(inventoryIds, inventory.Id)
+
+		entries, err := repo.GetInventoryLedgersByInventoryId(ctx, inventory.Id)
+		if err != nil {
+			return nil, err
+		}
+		openedBy[inventory.Id] = firstTransactionId(entries)
+		// A lot is dated by its purchase, so matching and sharing see an edited purchase date.
+		if edited.Type == domain.BUY && openedBy[inventory.Id] == edited.Id {
+			inventories[i].Date = edited.Date
+		}
+		voided := map[int]bool{}
+		for _, entry := range entries {
+			if entry.Type == domain.VOID {
+				voided[entry.TransactionId] = true
+			}
+		}
+		for _, entry := range entries {
+			if entry.Type == domain.VOID || voided[entry.TransactionId] || window[entry.TransactionId] {
+				continue
+			}
+			lots[inventory.Id] = lots[inventory.Id].Add(quantityChange(entry.Type, entry.Quantity))
+		}
+	}
+	sort.Ints(inventoryIds)
+
+	// Holding where the recomputed transactions begin, and just before each split as recorded, so a split given as
+	// a quantity keeps its ratio.
+	start := holding(lots)
+	cutoff := edited.Date
+	held := start
+	heldBefore := map[int]decimal.Decimal{}
+	for _, change := range changes {
+		if change.before.Date.Before(cutoff) {
+			cutoff = change.before.Date
+		}
+		if change.before.Type == domain.SPLIT {
+			heldBefore[change.before.Id] = held
+		}
+		for _, ledger := range change.recorded {
+			held = held.Add(quantityChange(ledger.Type, ledger.Quantity))
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if !changes[i].after.Date.Equal(changes[j].after.Date) {
+			return changes[i].after.Date.Before(changes[j].after.Date)
+		}
+		return changes[i].after.Id < changes[j].after.Id
+	})
+
+	for i := range changes {
+		change := &changes[i]
+		switch change.after.Type {
+		case domain.BUY:
+			if len(change.recorded) != 1 {
+				return nil, fmt.Errorf("%w: buy %d has %d ledger entries", errUpdateBlocked, change.after.Id, len(change.recorded))
+			}
+			entry := change.recorded[0]
+			entry.Quantity = change.after.Quantity
+			entry.AveragePrice = change.after.AveragePrice
+			entry.TotalValue = change.after.TotalValue
+			entry.Fee = change.after.Fee
+			entry.Date = change.after.Date
+			change.entries = []domain.InventoryLedger{entry}
+		case domain.SELL, domain.BUYBACK:
+			change.entries, err = allocateSell(change.after, change.recorded, lots, inventories, method)
+			if err != nil {
+				return nil, err
+			}
+		case domain.SPLIT:
+			holdings, held := heldLots(inventories, lots)
+			if !held.IsPositive() {
+				return nil, fmt.Errorf("%w: %s %d would apply to no shares", errUpdateBlocked, change.after.Type, change.after.Id)
+			}
+			var shares []decimal.Decimal
+			if change.after.RatioFrom.IsPositive() {
+				shares, _ = splitWholeShares(holdings, held, change.after.RatioFrom, change.after.RatioTo)
+			} else {
+				quantity := change.after.Quantity
+				if before := heldBefore[change.after.Id]; before.IsPositive() && !before.Equal(held) {
+					quantity = domain.RoundQuantity(quantity.Mul(held).Div(before))
+				}
+				shares = splitShares(holdings, held, quantity)
+			}
+			change.after.Quantity = decimal.Zero
+			for _, share := range shares {
+				change.after.Quantity = change.after.Quantity.Add(share)
+			}
+			if !change.after.Quantity.IsPositive() {
+				return nil, fmt.Errorf("%w: %s %d would give no new shares", errUpdateBlocked, change.after.Type, change.after.Id)
+			}
+			change.entries = splitEntries(change.after, change.recorded, holdings, held, shares)
+		case domain.BONUS:
+			// A bonus is issued in whole shares on the holding at the start of its ex-date, as StockBonus works
+			// it out. One whose ex-date falls before every change keeps its quantity.
+			exDate := change.after.Date
+			if change.after.ExDate != nil {
+				exDate = *change.after.ExDate
+			}
+			if !exDate.Before(cutoff) {
+				heldEx := heldBeforeDate(start, changes[:i], exDate, true)
+				if change.after.RatioFrom.IsPositive() {
+					change.after.Quantity = heldEx.Mul(change.after.RatioTo).Div(change.after.RatioFrom).Floor()
+				} else if before := heldBeforeDate(start, changes, exDate, false); before.IsPositive() && !before.Equal(heldEx) {
+					change.after.Quantity = change.after.Quantity.Mul(heldEx).Div(before).Floor()
+				}
+			}
+			if !change.after.Quantity.IsPositive() {
+				return nil, fmt.Errorf("%w: %s %d would give no new shares", errUpdateBlocked, change.after.Type, change.after.Id)
+			}
+			// A bonus issued as a lot of its own keeps it.
+			if len(change.recorded) == 1 && openedBy[change.recorded[0].InventoryId] == change.after.Id {
+				entry := change.recorded[0]
+				entry.Quantity = change.after.Quantity
+				entry.Fee = change.after.Fee
+				change.entries = []domain.InventoryLedger{entry}
+				break
+			}
+			held := holding(lots)
+			if !held.IsPositive() {
+				return nil, fmt.Errorf("%w: %s %d would apply to no shares", errUpdateBlocked, change.after.Type, change.after.Id)
+			}
+			change.entries = distributeShares(change.after, change.recorded, lots, inventoryIds, held)
+		case domain.RIGHTS_ENTITLEMENT, domain.RIGHTS_RENOUNCE, domain.RIGHTS_SUBSCRIBE, domain.RIGHTS_CALL:
+			// The entitlements were fixed on the record date and the rights shares are a lot of their own, so the
+			// steps of a rights issue are kept as recorded.
+			change.entries = change.recorded
+		default:
+			return nil, fmt.Errorf("%w: %s transaction %d is recorded after it", errUpdateBlocked, change.after.Type, change.after.Id)
+		}
+
+		for _, entry := range change.entries {
+			lots[entry.InventoryId] = lots[entry.InventoryId].Add(quantityChange(entry.Type, entry.Quantity))
+		}
+	}
+
+	return changes, nil
+}
+
+// allocateSell matches a sell to the lots held under the account's lot method, as StockSell does. Each lot bears its
+// share of the fee and the last takes whatever rounding left over. Entries already recorded against a lot keep their
+// ids.
+func allocateSell(transaction domain.Transactions, recorded []domain.InventoryLedger, lots map[int]decimal.Decimal, inventories []domain.Inventories, method domain.LotMethod) ([]domain.InventoryLedger, error) {
+	byInventory := map[int]domain.InventoryLedger{}
+	for _, ledger := range recorded {
+		if _, ok := byInventory[ledger.InventoryId]; !ok {
+			byInventory[ledger.InventoryId] = ledger
+		}
+	}
+
+	// The lots as they stand just before the sell.
+	var held decimal.Decimal
+	open := make([]domain.Inventories, 0, len(inventories))
+	for _, inventory := range inventories {
+		inventory.AvailableQuantity = lots[inventory.Id]
+		held = held.Add(inventory.AvailableQuantity)
+		open = append(open, inventory)
+	}
+	if held.LessThan(transaction.Quantity) {
+		return nil, fmt.Errorf("%w: sell %d of %s on %s is %s more than was held", errUpdateBlocked, transaction.Id, transaction.Quantity, transaction.Date.Format(constant.DATE_LAYOUT), transaction.Quantity.Sub(held))
+	}
+
+	matches := matchLots(method, open, transaction.Quantity)
+	entries := make([]domain.InventoryLedger, 0, len(matches))
+	remainingFee := transaction.Fee
+	for i, match := range matches {
+		fee := remainingFee
+		if i < len(matches)-1 {
+			fee = shareFee(transaction.Fee, match.quantity, transaction.Quantity)
+		}
+		remainingFee = remainingFee.Sub(fee)
+
+		entry, ok := byInventory[match.inventory.Id]
+		if !ok {
+			entry = domain.InventoryLedger{InventoryId: match.inventory.Id, TransactionId: transaction.Id, Type: domain.SELL}
+		}
+		entry.Quantity = match.quantity
+		entry.AveragePrice = transaction.AveragePrice
+		entry.TotalValue = domain.RoundValue(match.quantity.Mul(transaction.AveragePrice))
+		entry.Fee = fee
+		entry.Date = transaction.Date
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// splitEntries records the new shares of a split on the lots held with their share of the fee by holding, as
+// StockSplit does; the last lot takes whatever rounding left over. Entries already recorded against a lot keep their
+// ids.
+func splitEntries(transaction domain.Transactions, recorded []domain.InventoryLedger, holdings []lotHolding, held decimal.Decimal, shares []decimal.Decimal) []domain.InventoryLedger {
+	byInventory := map[int]domain.InventoryLedger{}
+	for _, ledger := range recorded {
+		byInventory[ledger.InventoryId] = ledger
+	}
+
+	entries := make([]domain.InventoryLedger, 0, len(holdings))
+	remainingFee := transaction.Fee
+	for i, lot := range holdings {
+		fee := remainingFee
+		if i < len(holdings)-1 {
+			fee = shareFee(transaction.Fee, lot.held.Quantity, held)
+		}
+		remainingFee = remainingFee.Sub(fee)
+		if !shares[i].IsPositive() && !fee.IsPositive() {
+			continue
+		}
+
+		entry, ok := byInventory[lot.inventory.Id]
+		if !ok {
+			entry = domain.InventoryLedger{InventoryId: lot.inventory.Id, TransactionId: transaction.Id, Type: transaction.Type}
+		}
+		entry.Quantity = shares[i]
+		entry.Fee = fee
+		entry.Date = transaction.Date
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// distributeShares shares the quantity of a bonus over the lots held pro rata: every lot gets its rounded share of
+// the quantity and the fee and the last lot takes whatever rounding left over. Entries already recorded against a
+// lot keep their date.
+func distributeShares(transaction domain.Transactions, recorded []domain.InventoryLedger, lots map[int]decimal.Decimal, inventoryIds []int, held decimal.Decimal) []domain.InventoryLedger {
+	byInventory := map[int]domain.InventoryLedger{}
+	for _, ledger := range recorded {
+		byInventory[ledger.InventoryId] = ledger
+	}
+
+	var open []int
+	for _, inventoryId := range inventoryIds {
+		if lots[inventoryId].IsPositive() {
+			open = append(open, inventoryId)
+		}
+	}
+
+	entries := make([]domain.InventoryLedger, 0, len(open))
+	remaining := transaction.Quantity
+	remainingFee := transaction.Fee
+	for i, inventoryId := range open {
+		share := remaining
+		fee := remainingFee
+		if i < len(open)-1 {
+			share = domain.RoundQuantity(transaction.Quantity.Mul(lots[inventoryId]).Div(held))
+			fee = shareFee(transaction.Fee, lots[inventoryId], held)
+		}
+		remaining = remaining.Sub(share)
+		remainingFee = remainingFee.Sub(fee)
+
+		entry, ok := byInventory[inventoryId]
+		if !ok {
+			entry = domain.InventoryLedger{InventoryId: inventoryId, TransactionId: transaction.Id, Type: transaction.Type, Date: transaction.Date}
+		}
+		entry.Quantity = share
+		entry.Fee = fee
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// applyLots writes the recomputed transactions and ledger entries and replays every inventory whose ledger changed,
+// realized gains included.
+func applyLots(ctx context.Context, repo port.RepositoryStore, changes []lotChange, original, edited domain.Transactions, resData *domain.ClientTransactionUpdateResponse) error {
+	touched := map[int]bool{}
+	for _, change := range changes {
+		if err := updateTransactionValues(ctx, repo, change.before, change.after, resData); err != nil {
+			return err
+		}
+
+		kept := map[int]bool{}
+		for _, entry := range change.entries {
+			if entry.Id == 0 {
+				inserted, err := repo.InsertInventoryLedger(ctx, entry)
+				if err != nil {
+					return err
+				}
+				resData.Ledgers = append(resData.Ledgers, domain.ClientTransactionUpdateLedger{
+					LedgerId:      inserted.Id,
+					InventoryId:   entry.InventoryId,
+					TransactionId: entry.TransactionId,
+					Type:          entry.Type,
+					Change:        domain.LEDGER_INSERTED,
+					QuantityAfter: entry.Quantity,
+				})
+				touched[entry.InventoryId] = true
+				continue
+			}
+
+			kept[entry.Id] = true
+			for _, ledger := range change.recorded {
+				if ledger.Id != entry.Id || sameLedgerValues(ledger, entry) {
+					continue
+				}
+				if err := repo.UpdateInventoryLedgerById(ctx, entry.Id, entry); err != nil {
+					return err
+				}
+				resData.Ledgers = append(resData.Ledgers, domain.ClientTransactionUpdateLedger{
+					LedgerId:       entry.Id,
+					InventoryId:    entry.InventoryId,
+					TransactionId:  entry.TransactionId,
+					Type:           entry.Type,
+					Change:         domain.LEDGER_UPDATED,
+					QuantityBefore: ledger.Quantity,
+					QuantityAfter:  entry.Quantity,
+				})
+				touched[entry.InventoryId] = true
+			}
+		}
+
+		for _, ledger := range change.recorded {
+			if kept[ledger.Id] {
+				continue
+			}
+			if err := repo.DeleteInventoryLedgerById(ctx, ledger.Id); err != nil {
+				return err
+			}
+			resData.Ledgers = append(resData.Ledgers, domain.ClientTransactionUpdateLedger{
+				LedgerId:       ledger.Id,
+				InventoryId:    ledger.InventoryId,
+				TransactionId:  ledger.TransactionId,
+				Type:           ledger.Type,
+				Change:         domain.LEDGER_DELETED,
+				QuantityBefore: ledger.Quantity,
+			})
+			touched[ledger.InventoryId] = true
+		}
+	}
+
+	// A lot is dated by its purchase.
+	if edited.Type == domain.BUY && !edited.Date.Equal(original.Date) {
+		for _, change := range changes {
+			if change.after.Id == edited.Id {
+				if err := repo.UpdateInventoryDateById(ctx, change.entries[0].InventoryId, edited.Date); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	inventoryIds := make([]int, 0, len(touched))
+	for inventoryId := range touched {
+		inventoryIds = append(inventoryIds, inventoryId)
+	}
+	sort.Ints(inventoryIds)
+
+	for _, inventoryId := range inventoryIds {
+		inventory, err := repo.GetInventoryDataById(ctx, inventoryId)
+		if err != nil {
+			return err
+		}
+		ledgers, err := repo.GetInventoryLedgersByInventoryId(ctx, inventoryId)
+		if err != nil {
+			return err
+		}
+
+		replayed, err := replayLedgers(ledgers)
+		if err != nil {
+			return fmt.Errorf("inventory %d: %w", inventoryId, err)
+		}
+
+		// The cost basis of later sells follows the corrected lot even when its totals end up unchanged.
+		if err := realizeInventory(ctx, repo, inventoryId); err != nil {
+			return err
+		}
+
+		stored := domain.ClientStockRebuildTotals{
+			Quantity:     inventory.AvailableQuantity,
+			AveragePrice: inventory.AveragePrice,
+			TotalValue:   inventory.TotalValue,
+		}
+		if stored.Quantity.Equal(replayed.Quantity) && stored.AveragePrice.Equal(replayed.AveragePrice) && stored.TotalValue.Equal(replayed.TotalValue) {
+			continue
+		}
+
+		err = repo.UpdateInventoryDetailsById(ctx, inventoryId, inventory.Version, replayed.Quantity, replayed.AveragePrice, replayed.TotalValue)
+		if err != nil {
+			return err
+		}
+		resData.Inventories = append(resData.Inventories, domain.ClientTransactionUpdateInventory{
+			InventoryId: inventoryId,
+			Before:      stored,
+			After:       replayed,
+		})
+	}
+	return nil
+}
+
+// updateTransactionValues writes after over before when any of their values differ and records the change.
+func updateTransactionValues(ctx context.Context, repo port.RepositoryStore, before, after domain.Transactions, resData *domain.ClientTransactionUpdateResponse) error {
+	if before.Quantity.Equal(after.Quantity) && before.AveragePrice.Equal(after.AveragePrice) && before.TotalValue.Equal(after.TotalValue) &&
+		before.Fee.Equal(after.Fee) && before.Date.Equal(after.Date) {
+		return nil
+	}
+
+	if err := repo.UpdateTransactionById(ctx, after.Id, after); err != nil {
+		return err
+	}
+	resData.Transactions = append(resData.Transactions, domain.ClientTransactionUpdateTransaction{
+		TransactionId: after.Id,
+		Type:          after.Type,
+		Before:        transactionUpdateValues(before),
+		After:         transactionUpdateValues(after),
+	})
+	return nil
+}
+
+func transactionUpdateValues(transaction domain.Transactions) domain.ClientTransactionUpdateValues {
+	return domain.ClientTransactionUpdateValues{
+		Quantity:     transaction.Quantity,
+		AveragePrice: transaction.AveragePrice,
+		TotalValue:   transaction.TotalValue,
+		Fee:          transaction.Fee,
+		Date:         transaction.Date.Format("02-01-2006"),
+	}
+}
+
+// sameLedgerValues reports whether two versions of a ledger entry hold the same values.
+func sameLedgerValues(a, b domain.InventoryLedger) bool {
+	return a.Quantity.Equal(b.Quantity) && a.AveragePrice.Equal(b.AveragePrice) && a.TotalValue.Equal(b.TotalValue) &&
+		a.Fee.Equal(b.Fee) && a.Date.Equal(b.Date)
+}
+
+// quantityChange is the change an entry makes to the quantity of its inventory, as replayLedgers applies it.
+func quantityChange(transactionType domain.TransactionType, quantity decimal.Decimal) decimal.Decimal {
+	switch transactionType {
+	case domain.BUY, domain.SPLIT, domain.BONUS, domain.MERGER, domain.DEMERGER, domain.RIGHTS_SUBSCRIBE:
+		return quantity
+	case domain.SELL, domain.MERGER_TRANSFER:
+		return quantity.Neg()
+	}
+	return decimal.Zero
+}
+
+// heldLots returns the lots that hold shares, oldest first as lotsHeldOn orders them, and the quantity they hold
+// together.
+func heldLots(inventories []domain.Inventories, lots map[int]decimal.Decimal) ([]lotHolding, decimal.Decimal) {
+	var holdings []lotHolding
+	var held decimal.Decimal
+	for _, inventory := range inventories {
+		if !lots[inventory.Id].IsPositive() {
+			continue
+		}
+		holdings = append(holdings, lotHolding{inventory: inventory, held: domain.ClientStockRebuildTotals{Quantity: lots[inventory.Id]}})
+		held = held.Add(lots[inventory.Id])
+	}
+	sort.SliceStable(holdings, func(i, j int) bool {
+		if !holdings[i].inventory.Date.Equal(holdings[j].inventory.Date) {
+			return holdings[i].inventory.Date.Before(holdings[j].inventory.Date)
+		}
+		return holdings[i].inventory.Id < holdings[j].inventory.Id
+	})
+	return holdings, held
+}
+
+// heldBeforeDate is the holding at the start of date: start, the holding where changes begin, with the entries of
+// the changes dated before it, as recomputed or as recorded.
+func heldBeforeDate(start decimal.Decimal, changes []lotChange, date time.Time, recomputed bool) decimal.Decimal {
+	held := start
+	for _, change := range changes {
+		transaction, entries := change.before, change.recorded
+		if recomputed {
+			transaction, entries = change.after, change.entries
+		}
+		if !transaction.Date.Before(date) {
+			continue
+		}
+		for _, entry := range entries {
+			held = held.Add(quantityChange(entry.Type, entry.Quantity))
+		}
+	}
+	return held
+}
+
+// holding sums the quantity of every lot.
+func holding(lots map[int]decimal.Decimal) decimal.Decimal {
+	var total decimal.Decimal
+	for _, quantity := range lots {
+		total = total.Add(quantity)
+	}
+	return total
+}
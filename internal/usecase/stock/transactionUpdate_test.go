@@ -0,0 +1,261 @@
+package stock
+
+import (
+	"assetio/internal/domain"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func (f *fixture) update(request domain.ClientTransactionUpdateRequest) domain.Response {
+	request.UserId = 1
+	request.AccountId = f.accountId
+	return f.usecase.TransactionUpdate(request)
+}
+
+func updateResult(t *testing.T, res domain.Response) domain.ClientTransactionUpdateResponse {
+	t.Helper()
+	var result domain.ClientTransactionUpdateResponse
+	if err := json.Unmarshal(expectSuccess(t, res).Data, &result); err != nil {
+		t.Fatalf("decode update response: %v", err)
+	}
+	return result
+}
+
+func decimalPtr(value int64) *decimal.Decimal {
+	d := decimal.NewFromInt(value)
+	return &d
+}
+
+func TestTransactionUpdateBuyPriceRevaluesLot(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	f.buy(t, 10, 300, date(2024, time.February, 1))
+	expectSuccess(t, f.usecase.StockSell(f.sell(5)))
+
+	buy := f.transactionIds(t, domain.BUY)[0]
+
+	// Only the owner of the account can edit its transactions.
+	expectStatus(t, f.usecase.TransactionUpdate(domain.ClientTransactionUpdateRequest{
+		UserId:        2,
+		AccountId:     f.accountId,
+		TransactionId: buy,
+		AveragePrice:  decimalPtr(200),
+	}), http.StatusBadRequest)
+
+	result := updateResult(t, f.update(domain.ClientTransactionUpdateRequest{TransactionId: buy, AveragePrice: decimalPtr(200)}))
+	if len(result.Transactions) != 1 || result.Transactions[0].TransactionId != buy || !result.Transactions[0].After.TotalValue.Equal(decimal.NewFromInt(2000)) {
+		t.Fatalf("transactions = %+v, want only the buy revalued", result.Transactions)
+	}
+	if len(result.Ledgers) != 1 || result.Ledgers[0].Change != domain.LEDGER_UPDATED || len(result.Inventories) != 1 {
+		t.Fatalf("ledgers = %+v, inventories = %+v", result.Ledgers, result.Inventories)
+	}
+
+	// The sale keeps its lot; what is left of the lot is valued at the corrected price.
+	inventories := f.inventories(t, f.stockId)
+	assertDecimal(t, "first lot quantity", inventories[0].AvailableQuantity, "5")
+	assertDecimal(t, "first lot average", inventories[0].AveragePrice, "200")
+	assertDecimal(t, "first lot value", inventories[0].TotalValue, "1000")
+	assertDecimal(t, "second lot quantity", inventories[1].AvailableQuantity, "10")
+	f.expectConsistent(t)
+}
+
+func TestTransactionUpdateBuyQuantityReallocatesSellAndDividend(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	f.buy(t, 10, 300, date(2024, time.February, 1))
+	expectSuccess(t, f.usecase.StockSell(f.sell(12)))
+	expectSuccess(t, f.usecase.StockDividendAdd(domain.ClientStockDividendAddRequest{
+		UserId:            1,
+		AccountId:         f.accountId,
+		StockId:           f.stockId,
+		AmountPerQuantity: decimal.NewFromInt(5),
+		Date:              date(2024, time.April, 1),
+	}))
+
+	// With 15 shares in the first lot the sale no longer reaches the second one.
+	buy := f.transactionIds(t, domain.BUY)[0]
+	result := updateResult(t, f.update(domain.ClientTransactionUpdateRequest{TransactionId: buy, Quantity: decimalPtr(15)}))
+
+	changes := map[domain.LedgerChange]int{}
+	for _, ledger := range result.Ledgers {
+		changes[ledger.Change]++
+	}
+	if changes[domain.LEDGER_UPDATED] != 2 || changes[domain.LEDGER_DELETED] != 1 || changes[domain.LEDGER_INSERTED] != 0 {
+		t.Fatalf("ledgers = %+v, want the buy and first sale entry updated and the second sale entry deleted", result.Ledgers)
+	}
+
+	inventories := f.inventories(t, f.stockId)
+	if len(inventories) != 2 {
+		t.Fatalf("got %d open inventories, want 2", len(inventories))
+	}
+	assertDecimal(t, "first lot quantity", inventories[0].AvailableQuantity, "3")
+	assertDecimal(t, "first lot value", inventories[0].TotalValue, "300")
+	assertDecimal(t, "second lot quantity", inventories[1].AvailableQuantity, "10")
+
+	// The dividend is paid on the 13 shares now held on its date.
+	var dividend domain.ClientTransactionUpdateTransaction
+	for _, transaction := range result.Transactions {
+		if transaction.Type == domain.DIVIDEND {
+			dividend = transaction
+		}
+	}
+	assertDecimal(t, "dividend quantity before", dividend.Before.Quantity, "8")
+	assertDecimal(t, "dividend quantity", dividend.After.Quantity, "13")
+	assertDecimal(t, "dividend value", dividend.After.TotalValue, "65")
+	f.expectConsistent(t)
+
+	// Moving the dividend before the first purchase leaves nothing to pay on.
+	result = updateResult(t, f.update(domain.ClientTransactionUpdateRequest{TransactionId: dividend.TransactionId, Date: date(2023, time.December, 1)}))
+	if len(result.Transactions) != 1 || !result.Transactions[0].After.Quantity.IsZero() || len(result.Ledgers) != 0 {
+		t.Fatalf("dividend update = %+v", result)
+	}
+	expectStatus(t, f.update(domain.ClientTransactionUpdateRequest{TransactionId: dividend.TransactionId, Quantity: decimalPtr(1)}), http.StatusBadRequest)
+}
+
+func TestTransactionUpdateKeepsSplitRatio(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2020, time.January, 1))
+	expectSuccess(t, f.usecase.StockSplit(domain.ClientStockSplitRequest{
+		UserId:    1,
+		AccountId: f.accountId,
+		StockId:   f.stockId,
+		Quantity:  decimal.NewFromInt(10),
+	}))
+
+	// The split doubled the holding, so it still doubles the corrected one.
+	buy := f.transactionIds(t, domain.BUY)[0]
+	result := updateResult(t, f.update(domain.ClientTransactionUpdateRequest{TransactionId: buy, Quantity: decimalPtr(20)}))
+	if len(result.Transactions) != 2 || !result.Transactions[1].After.Quantity.Equal(decimal.NewFromInt(20)) {
+		t.Fatalf("transactions = %+v, want the buy and the rescaled split", result.Transactions)
+	}
+
+	inventories := f.inventories(t, f.stockId)
+	assertDecimal(t, "lot quantity", inventories[0].AvailableQuantity, "40")
+	assertDecimal(t, "lot average", inventories[0].AveragePrice, "50")
+	f.expectConsistent(t)
+
+	rebuilt := rebuildResult(t, f.usecase.StockRebuild(domain.ClientStockRebuildRequest{AccountId: f.accountId}))
+	if len(rebuilt.Inventories) != 0 {
+		t.Fatalf("rebuild after update found differences: %+v", rebuilt.Inventories)
+	}
+}
+
+func TestTransactionUpdateRefusesOversoldLots(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	expectSuccess(t, f.usecase.StockSell(f.sell(8)))
+	buy := f.transactionIds(t, domain.BUY)[0]
+
+	expectStatus(t, f.update(domain.ClientTransactionUpdateRequest{TransactionId: buy, Quantity: decimalPtr(5)}), http.StatusUnprocessableEntity)
+	expectStatus(t, f.update(domain.ClientTransactionUpdateRequest{TransactionId: buy, Date: date(2024, time.April, 1)}), http.StatusUnprocessableEntity)
+
+	// Nothing was written by the refused edits.
+	inventories := f.inventories(t, f.stockId)
+	assertDecimal(t, "lot quantity", inventories[0].AvailableQuantity, "2")
+	if !inventories[0].Date.Equal(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("lot date = %v", inventories[0].Date)
+	}
+
+	// Moving the purchase earlier is fine and redates the lot.
+	updateResult(t, f.update(domain.ClientTransactionUpdateRequest{TransactionId: buy, Date: date(2023, time.June, 1)}))
+	if got := f.inventories(t, f.stockId)[0].Date; !got.Equal(time.Date(2023, time.June, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("lot date after update = %v", got)
+	}
+	f.expectConsistent(t)
+}
+
+func TestTransactionUpdateSellFollowsLotMethod(t *testing.T) {
+	f := newFixture(t)
+	if err := f.repo.UpdateAccountData(context.Background(), f.accountId, 1, domain.Accounts{LotMethod: domain.LOT_METHOD_LIFO}); err != nil {
+		t.Fatal(err)
+	}
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	f.buy(t, 10, 200, date(2024, time.January, 15))
+	f.buy(t, 10, 300, date(2024, time.February, 1))
+	expectSuccess(t, f.usecase.StockSell(f.sell(5)))
+
+	// The larger sale takes the newest lots first, as it would have when sold, leaving the oldest lot whole.
+	sell := f.transactionIds(t, domain.SELL)[0]
+	updateResult(t, f.update(domain.ClientTransactionUpdateRequest{TransactionId: sell, Quantity: decimalPtr(15)}))
+	inventories := f.inventories(t, f.stockId)
+	if len(inventories) != 2 {
+		t.Fatalf("inventories = %+v, want the oldest lot and what is left of the middle one", inventories)
+	}
+	assertDecimal(t, "oldest lot quantity", inventories[0].AvailableQuantity, "10")
+	assertDecimal(t, "middle lot quantity", inventories[1].AvailableQuantity, "5")
+	assertDecimal(t, "middle lot average", inventories[1].AveragePrice, "200")
+	f.expectConsistent(t)
+}
+
+func TestTransactionUpdateWorksOutRatioSplitAgain(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	f.buy(t, 5, 100, date(2024, time.January, 15))
+	f.split(t, domain.ClientStockSplitRequest{
+		Date:      date(2024, time.February, 1),
+		RatioFrom: decimal.NewFromInt(1),
+		RatioTo:   decimal.NewFromInt(3),
+	})
+
+	// 15.5 shares split 1:3 become 46.5; the account is entitled to 46 whole shares, each lot holding whole shares,
+	// as a split recorded on the corrected holding would give.
+	buy := f.transactionIds(t, domain.BUY)[0]
+	quantity := decimal.RequireFromString("10.5")
+	result := updateResult(t, f.update(domain.ClientTransactionUpdateRequest{TransactionId: buy, Quantity: &quantity}))
+	split := f.transactionIds(t, domain.SPLIT)[0]
+	for _, transaction := range result.Transactions {
+		if transaction.TransactionId == split {
+			assertDecimal(t, "split quantity", transaction.After.Quantity, "30.5")
+		}
+	}
+
+	inventories := f.inventories(t, f.stockId)
+	assertDecimal(t, "first lot quantity", inventories[0].AvailableQuantity, "31")
+	assertDecimal(t, "second lot quantity", inventories[1].AvailableQuantity, "15")
+	f.expectConsistent(t)
+
+	rebuilt := rebuildResult(t, f.usecase.StockRebuild(domain.ClientStockRebuildRequest{AccountId: f.accountId}))
+	if len(rebuilt.Inventories) != 0 {
+		t.Fatalf("rebuild after update found differences: %+v", rebuilt.Inventories)
+	}
+}
+
+func TestTransactionUpdateWorksOutRatioBonusAgain(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	f.buy(t, 5, 100, date(2024, time.March, 1))
+	f.bonus(t, domain.ClientStockBonusRequest{
+		ExDate:        date(2024, time.February, 15),
+		AllotmentDate: date(2024, time.March, 10),
+		RatioBonus:    decimal.NewFromInt(1),
+		RatioHeld:     decimal.NewFromInt(3),
+	})
+
+	// The bonus is issued on the 13 shares held at the start of the ex-date, not on the 18 held on the allotment
+	// date: 1 for 3 is 4 and a third, of which the account gets 4 whole shares.
+	buy := f.transactionIds(t, domain.BUY)[0]
+	quantity := decimal.NewFromInt(13)
+	result := updateResult(t, f.update(domain.ClientTransactionUpdateRequest{TransactionId: buy, Quantity: &quantity}))
+	bonus := f.transactionIds(t, domain.BONUS)[0]
+	found := false
+	for _, transaction := range result.Transactions {
+		if transaction.TransactionId == bonus {
+			found = true
+			assertDecimal(t, "bonus quantity", transaction.After.Quantity, "4")
+		}
+	}
+	if !found {
+		t.Fatalf("update = %+v, want the bonus worked out again", result.Transactions)
+	}
+	f.expectConsistent(t)
+
+	rebuilt := rebuildResult(t, f.usecase.StockRebuild(domain.ClientStockRebuildRequest{AccountId: f.accountId}))
+	if len(rebuilt.Inventories) != 0 {
+		t.Fatalf("rebuild after update found differences: %+v", rebuilt.Inventories)
+	}
+}
@@ -0,0 +1,305 @@
+package stock
+
+import (
+	"assetio/internal/adapters/handler/response"
+	"assetio/internal/constant"
+	"assetio/internal/domain"
+	"assetio/internal/port"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// errVoidBlocked is returned when a transaction to void is followed by one that cannot be voided with it.
+var errVoidBlocked = errors.New("transaction cannot be voided")
+
+// TransactionVoid voids a recorded buy, sell or buyback. The transaction is marked voided, a VOID ledger entry is
+// written against every entry it recorded, and each inventory it touched is replayed without it.
+//
+// Later transactions on the same lots that were recorded against the quantity it changed depend on it: sells of
+// bought shares, splits and bonuses. They are voided with it when request.Cascade is set; otherwise the request
+// is refused. Mergers and demergers cannot be voided, so a transaction they depend on cannot be voided either.
+//
+// Parameters:
+//   - request: domain.ClientTransactionVoidRequest - contains the account, the transaction to void and
+//     whether its dependent transactions are voided too.
+//
+// Returns:
+//   - domain.Response - lists the voided transactions, or an error when the transaction cannot be voided.
+func (s *stockUsecase) TransactionVoid(request domain.ClientTransactionVoidRequest) domain.Response {
+	ctx := context.Background()
+	res := response.New()
+
+	var voided []int
+	now := time.Now()
+
+	// Void every transaction and restore every inventory in one unit of work.
+	err := s.withTx(ctx, func(repo port.RepositoryStore) error {
+		// Validate the account belongs to the user.
+		account, err := repo.GetAccountDataByIdAndUserId(ctx, request.AccountId, request.UserId)
+		if err != nil {
+			s.logger.Errorw(ctx, "GetAccountDataByIdAndUserId failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
+		if account.Id == 0 {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "incorrect account id")
+			return errTxAborted
+		}
+
+		transaction, err := repo.GetTransactionDataById(ctx, request.TransactionId)
+		if err != nil {
+			s.logger.Errorw(ctx, "GetTransactionDataById failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
+
+		// Validate the transaction belongs to the account and can be voided.
+		if transaction.Id == 0 || transaction.AccountId != request.AccountId {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "invalid transaction id")
+			return errTxAborted
+		}
+		if transaction.State == domain.TRANSACTION_STATE_VOIDED {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "transaction already voided")
+			return errTxAborted
+		}
+		if transaction.Type != domain.BUY && transaction.Type != domain.SELL && transaction.Type != domain.BUYBACK {
+			res.SetStatus(http.StatusBadRequest)
+			res.SetError(constant.ERROR_CODE_REQUEST_INVALID, "only buy, sell and buyback transactions can be voided")
+			return errTxAborted
+		}
+
+		plan, err := planVoid(ctx, repo, transaction.Id)
+		if errors.Is(err, errVoidBlocked) {
+			res.SetStatus(http.StatusUnprocessableEntity)
+			res.SetError(constant.ERROR_CODE_DATA_INVALID, err.Error())
+			return errTxAborted
+		}
+		if err != nil {
+			s.logger.Errorw(ctx, "planVoid failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
+
+		// Dependent transactions are only voided when the client asked for it.
+		if len(plan.transactions) > 1 && !request.Cascade {
+			res.SetStatus(http.StatusUnprocessableEntity)
+			res.SetError(constant.ERROR_CODE_DATA_INVALID, fmt.Sprintf("transactions %v depend on this transaction; set cascade to void them too", plan.transactions[1:]))
+			return errTxAborted
+		}
+
+		err = applyVoid(ctx, repo, plan, now)
+		if errors.Is(err, domain.ErrInventoryConflict) {
+			// Another request changed one of the lots since it was read; withTx rolls back and retries.
+			return err
+		}
+		if errors.Is(err, ErrLedgerReplay) {
+			res.SetStatus(http.StatusUnprocessableEntity)
+			res.SetError(constant.ERROR_CODE_DATA_INVALID, err.Error())
+			return errTxAborted
+		}
+		if err != nil {
+			s.logger.Errorw(ctx, "applyVoid failed",
+				constant.ERROR_TYPE, constant.ERROR_TYPE_DBEXECUTION,
+				constant.ERROR_MESSAGE, err.Error(),
+				constant.REQUEST, request,
+			)
+			res.SetStatus(http.StatusInternalServerError)
+			res.SetError(constant.ERROR_CODE_INTERNAL_SERVER, "internal server error")
+			return errTxAborted
+		}
+
+		voided = plan.transactions
+		return nil
+	})
+	if err != nil {
+		return s.txFailed(ctx, res, err, request)
+	}
+
+	// Set success response message.
+	resData := domain.ClientTransactionVoidResponse{
+		Message:      "transaction voided successfully",
+		Transactions: voided,
+	}
+
+	res.SetData(resData)
+	return res
+}
+
+// voidPlan is everything one void writes: the transactions to void, their ledger entries and the inventories to restore.
+type voidPlan struct {
+	transactions []int                            // The requested transaction first, then its dependents as found
+	ledgers      map[int][]domain.InventoryLedger // Ledger entries of each transaction
+	inventories  []domain.Inventories             // Inventories touched, read before anything is written
+}
+
+// planVoid collects transactionId and, transitively, every transaction that depends on it.
+func planVoid(ctx context.Context, repo port.RepositoryStore, transactionId int) (voidPlan, error) {
+	plan := voidPlan{ledgers: map[int][]domain.InventoryLedger{}}
+	inventoryLedgers := map[int][]domain.InventoryLedgers{}
+	queued := map[int]bool{transactionId: true}
+
+	for queue := []int{transactionId}; len(queue) > 0; queue = queue[1:] {
+		current := queue[0]
+		plan.transactions = append(plan.transactions, current)
+
+		ledgers, err := repo.GetInventoryLedgersByTransactionId(ctx, current)
+		if err != nil {
+			return plan, err
+		}
+		plan.ledgers[current] = ledgers
+
+		for _, ledger := range ledgers {
+			entries, ok := inventoryLedgers[ledger.InventoryId]
+			if !ok {
+				// The version read here guards the update in applyVoid against concurrent writes to the lot.
+				inventory, err := repo.GetInventoryDataById(ctx, ledger.InventoryId)
+				if err != nil {
+					return plan, err
+				}
+				entries, err = repo.GetInventoryLedgersByInventoryId(ctx, ledger.InventoryId)
+				if err != nil {
+					return plan, err
+				}
+				inventoryLedgers[ledger.InventoryId] = entries
+				plan.inventories = append(plan.inventories, inventory)
+			}
+
+			for _, dependent := range dependents(ledger, entries) {
+				if queued[dependent.TransactionId] {
+					continue
+				}
+				switch {
+				case dependent.TransactionId == 0:
+					return plan, fmt.Errorf("%w: ledger %d depends on transaction %d but belongs to no transaction", errVoidBlocked, dependent.Id, current)
+				case dependent.Type == domain.MERGER_TRANSFER || dependent.Type == domain.DEMERGER_TRANSFER || dependent.Type == domain.RIGHTS_CALL:
+					return plan, fmt.Errorf("%w: transaction %d depends on transaction %d and %s cannot be voided", errVoidBlocked, dependent.TransactionId, current, dependent.Type)
+				}
+				queued[dependent.TransactionId] = true
+				queue = append(queue, dependent.TransactionId)
+			}
+
+			// A bonus issued as a lot of its own depends on the shares added to the stock before it, in any lot.
+			switch ledger.Type {
+			case domain.BUY, domain.SPLIT, domain.BONUS, domain.MERGER, domain.DEMERGER:
+				bonuses, err := bonusDependents(ctx, repo, ledger)
+				if err != nil {
+					return plan, err
+				}
+				for _, bonusId := range bonuses {
+					if !queued[bonusId] {
+						queued[bonusId] = true
+						queue = append(queue, bonusId)
+					}
+				}
+			}
+		}
+	}
+	return plan, nil
+}
+
+// dependents returns the entries of an inventory that were recorded after ledger against the quantity it changed.
+// Corporate actions depend on every earlier entry; a sell depends only on entries that added the shares it sold.
+// Entries of voided transactions are left out.
+func dependents(ledger domain.InventoryLedger, entries []domain.InventoryLedgers) []domain.InventoryLedgers {
+	voided := map[int]bool{}
+	for _, entry := range entries {
+		if entry.Type == domain.VOID {
+			voided[entry.TransactionId] = true
+		}
+	}
+
+	var addsQuantity bool
+	switch ledger.Type {
+	case domain.BUY, domain.SPLIT, domain.BONUS, domain.MERGER, domain.DEMERGER, domain.RIGHTS_SUBSCRIBE:
+		addsQuantity = true
+	}
+
+	var found []domain.InventoryLedgers
+	for _, entry := range entries {
+		if entry.TransactionId == ledger.TransactionId || entry.Type == domain.VOID || voided[entry.TransactionId] {
+			continue
+		}
+		if entry.Date.Before(ledger.Date) || (entry.Date.Equal(ledger.Date) && entry.Id < ledger.Id) {
+			continue
+		}
+
+		switch entry.Type {
+		case domain.SPLIT, domain.BONUS, domain.MERGER_TRANSFER, domain.DEMERGER_TRANSFER, domain.RIGHTS_CALL:
+			found = append(found, entry)
+		case domain.SELL:
+			if addsQuantity {
+				found = append(found, entry)
+			}
+		}
+	}
+	return found
+}
+
+// applyVoid writes the VOID entries, marks the transactions voided and replays every touched inventory, realized
+// gains included.
+func applyVoid(ctx context.Context, repo port.RepositoryStore, plan voidPlan, date time.Time) error {
+	for _, transactionId := range plan.transactions {
+		for _, ledger := range plan.ledgers[transactionId] {
+			_, err := repo.InsertInventoryLedger(ctx, domain.InventoryLedger{
+				InventoryId:   ledger.InventoryId,
+				TransactionId: transactionId,
+				Type:          domain.VOID,
+				Quantity:      ledger.Quantity,
+				AveragePrice:  ledger.AveragePrice,
+				TotalValue:    ledger.TotalValue,
+				Fee:           ledger.Fee,
+				Date:          date,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := repo.UpdateTransactionStateById(ctx, transactionId, domain.TRANSACTION_STATE_VOIDED); err != nil {
+			return err
+		}
+	}
+
+	for _, inventory := range plan.inventories {
+		ledgers, err := repo.GetInventoryLedgersByInventoryId(ctx, inventory.Id)
+		if err != nil {
+			return err
+		}
+
+		restored, err := replayLedgers(ledgers)
+		if err != nil {
+			return fmt.Errorf("inventory %d: %w", inventory.Id, err)
+		}
+
+		err = repo.UpdateInventoryDetailsById(ctx, inventory.Id, inventory.Version, restored.Quantity, restored.AveragePrice, restored.TotalValue)
+		if err != nil {
+			return err
+		}
+
+		if err := realizeInventory(ctx, repo, inventory.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package stock
+
+import (
+	"assetio/internal/domain"
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// transactionIds returns the ids of the account's transactions of a type, oldest first.
+func (f *fixture) transactionIds(t *testing.T, transactionType domain.TransactionType) []int {
+	t.Helper()
+	totals, err := f.repo.GetTransactionLedgerTotals(context.Background(), f.accountId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int
+	for _, total := range totals {
+		if total.Type == transactionType {
+			ids = append(ids, total.Id)
+		}
+	}
+	return ids
+}
+
+func (f *fixture) void(transactionId int, cascade bool) domain.Response {
+	return f.usecase.TransactionVoid(domain.ClientTransactionVoidRequest{
+		UserId:        1,
+		AccountId:     f.accountId,
+		TransactionId: transactionId,
+		Cascade:       cascade,
+	})
+}
+
+func voidResult(t *testing.T, res domain.Response) domain.ClientTransactionVoidResponse {
+	t.Helper()
+	var result domain.ClientTransactionVoidResponse
+	if err := json.Unmarshal(expectSuccess(t, res).Data, &result); err != nil {
+		t.Fatalf("decode void response: %v", err)
+	}
+	return result
+}
+
+// expectConsistent fails the test when the account no longer passes the consistency check.
+func (f *fixture) expectConsistent(t *testing.T) {
+	t.Helper()
+	report := consistencyReport(t, f.usecase.StockConsistency(domain.ClientStockConsistencyRequest{AccountId: f.accountId}))
+	if !report.Consistent {
+		t.Fatalf("account is inconsistent after void: %+v", report.Issues)
+	}
+}
+
+func TestTransactionVoidSellRestoresLots(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	f.buy(t, 10, 300, date(2024, time.February, 1))
+	expectSuccess(t, f.usecase.StockSell(f.sell(12)))
+
+	sell := f.transactionIds(t, domain.SELL)[0]
+
+	// Only the owner of the account can void its transactions.
+	expectStatus(t, f.usecase.TransactionVoid(domain.ClientTransactionVoidRequest{
+		UserId:        2,
+		AccountId:     f.accountId,
+		TransactionId: sell,
+	}), http.StatusBadRequest)
+
+	result := voidResult(t, f.void(sell, false))
+	if len(result.Transactions) != 1 || result.Transactions[0] != sell {
+		t.Fatalf("voided %v, want only the sell", result.Transactions)
+	}
+
+	inventories := f.inventories(t, f.stockId)
+	if len(inventories) != 2 {
+		t.Fatalf("got %d open inventories, want both lots back", len(inventories))
+	}
+	assertDecimal(t, "first lot quantity", inventories[0].AvailableQuantity, "10")
+	assertDecimal(t, "first lot value", inventories[0].TotalValue, "1000")
+	assertDecimal(t, "second lot quantity", inventories[1].AvailableQuantity, "10")
+	assertDecimal(t, "second lot average", inventories[1].AveragePrice, "300")
+
+	// The voided sale no longer counts towards the holding on a later date.
+	available, err := f.repo.GetInventoryAvailableQuanitityBySecurityIdAndDate(context.Background(), f.accountId, f.stockId, time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDecimal(t, "available quantity", available, "20")
+
+	f.expectConsistent(t)
+	expectStatus(t, f.void(sell, false), http.StatusBadRequest)
+}
+
+func TestTransactionVoidBuyNeedsCascadeForLaterSell(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	f.buy(t, 10, 300, date(2024, time.February, 1))
+	expectSuccess(t, f.usecase.StockSell(f.sell(12)))
+	buys := f.transactionIds(t, domain.BUY)
+
+	// The first lot is sold out, so voiding its purchase needs the sale voided too.
+	expectStatus(t, f.void(buys[0], false), http.StatusUnprocessableEntity)
+	assertDecimal(t, "second lot quantity", f.inventories(t, f.stockId)[0].AvailableQuantity, "8")
+
+	result := voidResult(t, f.void(buys[0], true))
+	if len(result.Transactions) != 2 || result.Transactions[0] != buys[0] || result.Transactions[1] != f.transactionIds(t, domain.SELL)[0] {
+		t.Fatalf("voided %v, want the buy then the sell", result.Transactions)
+	}
+
+	inventories := f.inventories(t, f.stockId)
+	if len(inventories) != 1 {
+		t.Fatalf("open inventories = %+v, want only the second lot", inventories)
+	}
+	assertDecimal(t, "second lot quantity", inventories[0].AvailableQuantity, "10")
+	assertDecimal(t, "second lot value", inventories[0].TotalValue, "3000")
+	f.expectConsistent(t)
+}
+
+func TestTransactionVoidCascadesThroughSplit(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2020, time.January, 1))
+	f.buy(t, 10, 300, date(2020, time.February, 1))
+	expectSuccess(t, f.usecase.StockSplit(domain.ClientStockSplitRequest{
+		UserId:    1,
+		AccountId: f.accountId,
+		StockId:   f.stockId,
+		Quantity:  decimal.NewFromInt(20),
+	}))
+	buys := f.transactionIds(t, domain.BUY)
+
+	expectStatus(t, f.void(buys[1], false), http.StatusUnprocessableEntity)
+	result := voidResult(t, f.void(buys[1], true))
+	if len(result.Transactions) != 2 {
+		t.Fatalf("voided %v, want the buy and the split", result.Transactions)
+	}
+
+	// The split is voided on every lot, so the untouched first lot is back to its purchase.
+	inventories := f.inventories(t, f.stockId)
+	if len(inventories) != 1 {
+		t.Fatalf("got %d open inventories, want 1", len(inventories))
+	}
+	assertDecimal(t, "first lot quantity", inventories[0].AvailableQuantity, "10")
+	assertDecimal(t, "first lot average", inventories[0].AveragePrice, "100")
+	f.expectConsistent(t)
+
+	rebuilt := rebuildResult(t, f.usecase.StockRebuild(domain.ClientStockRebuildRequest{AccountId: f.accountId}))
+	if len(rebuilt.Inventories) != 0 {
+		t.Fatalf("rebuild after void found differences: %+v", rebuilt.Inventories)
+	}
+}
+
+func TestTransactionVoidRefusesMergedLots(t *testing.T) {
+	f := newFixture(t)
+	f.buy(t, 10, 100, date(2024, time.January, 1))
+	expectSuccess(t, f.usecase.StockMerge(domain.ClientStockMergeRequest{
+		UserId:        1,
+		AccountId:     f.accountId,
+		ParentStockId: f.stockId,
+		NewStockId:    f.otherId,
+		Quantity:      decimal.NewFromInt(5),
+		Date:          date(2024, time.March, 1),
+	}))
+
+	expectStatus(t, f.void(f.transactionIds(t, domain.BUY)[0], true), http.StatusUnprocessableEntity)
+	expectStatus(t, f.void(f.transactionIds(t, domain.MERGER)[0], true), http.StatusBadRequest)
+	f.expectConsistent(t)
+}